@@ -5,20 +5,39 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strings"
 
 	gogithub "github.com/google/go-github/v68/github"
 
-	dyffdiff "github.com/nathantilsley/chart-val/internal/diff/adapters/dyff_diff"
+	celpolicy "github.com/nathantilsley/chart-val/internal/diff/adapters/cel_policy"
+	chartdeps "github.com/nathantilsley/chart-val/internal/diff/adapters/chart_deps"
+	chartversion "github.com/nathantilsley/chart-val/internal/diff/adapters/chart_version"
 	argoenv "github.com/nathantilsley/chart-val/internal/diff/adapters/environment_config/argo"
 	fsenv "github.com/nathantilsley/chart-val/internal/diff/adapters/environment_config/filesystem"
 	githubin "github.com/nathantilsley/chart-val/internal/diff/adapters/github_in"
 	githubout "github.com/nathantilsley/chart-val/internal/diff/adapters/github_out"
 	helmcli "github.com/nathantilsley/chart-val/internal/diff/adapters/helm_cli"
+	helmlint "github.com/nathantilsley/chart-val/internal/diff/adapters/helm_lint"
+	helmsdk "github.com/nathantilsley/chart-val/internal/diff/adapters/helm_sdk"
+	ignorerules "github.com/nathantilsley/chart-val/internal/diff/adapters/ignore_rules"
+	jsonout "github.com/nathantilsley/chart-val/internal/diff/adapters/json_out"
+	junitout "github.com/nathantilsley/chart-val/internal/diff/adapters/junit_out"
+	kubedeprecations "github.com/nathantilsley/chart-val/internal/diff/adapters/kube_deprecations"
+	kubeschema "github.com/nathantilsley/chart-val/internal/diff/adapters/kube_schema"
 	linediff "github.com/nathantilsley/chart-val/internal/diff/adapters/line_diff"
+	multiout "github.com/nathantilsley/chart-val/internal/diff/adapters/multi_out"
+	outputdir "github.com/nathantilsley/chart-val/internal/diff/adapters/output_dir"
 	prfiles "github.com/nathantilsley/chart-val/internal/diff/adapters/pr_files"
+	reportstore "github.com/nathantilsley/chart-val/internal/diff/adapters/report_store"
+	sarifout "github.com/nathantilsley/chart-val/internal/diff/adapters/sarif_out"
+	secretredact "github.com/nathantilsley/chart-val/internal/diff/adapters/secret_redact"
 	sourcectrl "github.com/nathantilsley/chart-val/internal/diff/adapters/source_ctrl"
+	valuessurface "github.com/nathantilsley/chart-val/internal/diff/adapters/values_surface"
+	webhookout "github.com/nathantilsley/chart-val/internal/diff/adapters/webhook_out"
+	yamldiff "github.com/nathantilsley/chart-val/internal/diff/adapters/yaml_diff"
 	"github.com/nathantilsley/chart-val/internal/diff/app"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
 	"github.com/nathantilsley/chart-val/internal/diff/ports"
 	"github.com/nathantilsley/chart-val/internal/platform/config"
 	ghclient "github.com/nathantilsley/chart-val/internal/platform/github"
@@ -33,6 +52,7 @@ type Container struct {
 	GitHubClient   *gogithub.Client
 	DiffService    ports.DiffUseCase
 	WebhookHandler *githubin.WebhookHandler
+	ReportHandler  http.Handler // nil unless REPORT_URL is set
 	ReadyCheck     func() bool
 }
 
@@ -54,18 +74,115 @@ func NewContainer(
 
 	// Adapters
 	sourceCtrl := sourcectrl.New(githubClient)
-	helmRenderer, err := helmcli.New()
+	helmRenderer, err := newRenderer(cfg, log)
 	if err != nil {
-		return nil, fmt.Errorf("creating helm adapter: %w", err)
+		return nil, err
+	}
+	depResolver, err := chartdeps.New(cfg.ChartDepsCacheDir, log)
+	if err != nil {
+		return nil, fmt.Errorf("creating dependency resolver: %w", err)
+	}
+	templates, err := githubout.LoadTemplates(cfg.TemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading report templates: %w", err)
+	}
+	githubReporter := githubout.New(
+		githubClient, cfg.AppName, cfg.AppURL, cfg.CommentCollapse, templates, cfg.TemplateRepoDir,
+	)
+	reporter, err := newReporter(cfg, log, githubReporter)
+	if err != nil {
+		return nil, err
 	}
-	reporter := githubout.New(githubClient, cfg.AppName, cfg.AppURL)
 	changedCharts := prfiles.New(githubClient, log, cfg.ChartDir)
-	semanticDiff := dyffdiff.New()
+	semanticDiff := yamldiff.New()
 	unifiedDiff := linediff.New()
+	redactor, err := secretredact.New(cfg.RedactHMACKey, cfg.SensitiveKeyPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("creating secret redactor: %w", err)
+	}
+	ignoreFilter, err := newIgnoreFilter(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	// Optionally validate rendered manifests against Kubernetes schemas
+	var validator ports.ManifestValidatorPort
+	if cfg.SchemaValidation {
+		log.Info("schema validation enabled", "crdSchemaDir", cfg.CRDSchemaDir, "kubeSchemaDir", cfg.KubeSchemaDir)
+		schemaValidator, err := kubeschema.New(kubeschema.Options{
+			CRDDir:          cfg.CRDSchemaDir,
+			SchemaDir:       cfg.KubeSchemaDir,
+			KubeVersion:     cfg.KubeVersion,
+			EnvKubeVersions: cfg.EnvKubeVersions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating schema validator: %w", err)
+		}
+		validator = schemaValidator
+	}
+
+	// Optionally report deprecated and removed API versions
+	var deprecations ports.DeprecationCheckerPort
+	if cfg.DeprecationCheck {
+		checker, err := kubedeprecations.New(cfg.KubeVersion, cfg.EnvKubeVersions)
+		if err != nil {
+			return nil, fmt.Errorf("creating deprecation checker: %w", err)
+		}
+		deprecations = checker
+	}
+
+	policies, err := celpolicy.New(cfg.PolicyDir)
+	if err != nil {
+		return nil, fmt.Errorf("creating policy evaluator: %w", err)
+	}
+
+	// Optionally require chart changes to bump the Chart.yaml version. The env
+	// and policies directories are chart-val's own, not part of the release.
+	var chartVersion ports.ChartVersionPort
+	if cfg.VersionCheck {
+		chartVersion = chartversion.New(cfg.EnvDir, app.ChartPolicyDir)
+	}
+
+	// Optionally lint charts and check each environment's values against values.schema.json
+	var linter ports.ChartLinterPort
+	if cfg.ChartLint {
+		linter = helmlint.New()
+	}
+
+	// Optionally report breaking values changes, the environments still setting removed keys, and unused values
+	var valuesSurface ports.ValuesSurfacePort
+	if cfg.ValuesCheck {
+		valuesSurface = valuessurface.New()
+	}
+
+	// Optionally keep full diffs so reports cut to fit GitHub's size limits can link to them
+	var reports ports.ReportStorePort
+	var reportHandler http.Handler
+	if cfg.ReportURL != "" {
+		log.Info("full diff reports enabled", "url", cfg.ReportURL, "dir", cfg.ReportDir)
+		store, err := reportstore.New(cfg.ReportDir, cfg.ReportURL, cfg.ReportRetention)
+		if err != nil {
+			return nil, fmt.Errorf("creating report store: %w", err)
+		}
+		reports = store
+		reportHandler = store
+	}
+
+	// Optionally write results as JSON, SARIF or JUnit for CI and dashboards
+	formatters, outputs, err := newOutputs(cfg, log)
+	if err != nil {
+		return nil, err
+	}
 
 	// Environment config adapters (both discover where charts are deployed)
 	// Filesystem adapter - discovers from chart's env/ folder
-	filesystemEnvConfig := fsenv.New(sourceCtrl, cfg.ChartDir, cfg.EnvDir, cfg.ValuesFileSuffix)
+	filesystemEnvConfig := fsenv.New(
+		sourceCtrl,
+		cfg.ChartDir,
+		cfg.EnvDir,
+		cfg.ValuesFileSuffix,
+		cfg.RenderFileSuffix,
+	)
 
 	// Default readiness: always ready (no argo repo to wait for)
 	readyCheck := func() bool { return true }
@@ -96,21 +213,37 @@ func NewContainer(
 
 	// Domain service (handles composite strategy: Argo → Filesystem → Base chart)
 	metricPrefix := strings.ReplaceAll(cfg.AppName, "-", "_")
-	diffService := app.NewDiffService(
-		sourceCtrl,
-		changedCharts,
-		argoEnvConfig,       // nil if not configured
-		filesystemEnvConfig, // always present - discovers from chart's env/ folder
-		helmRenderer,
-		reporter,
-		semanticDiff,
-		unifiedDiff,
-		log,
-		tel.Meter,
-		tel.Tracer,
-		cfg.ChartDir,
-		metricPrefix,
-	)
+	diffService := app.NewDiffService(app.Deps{
+		SourceControl: sourceCtrl,
+		ChangedCharts: changedCharts,
+		ArgoEnvConfig: argoEnvConfig,       // nil if not configured
+		FSEnvConfig:   filesystemEnvConfig, // always present - discovers from chart's env/ folder
+		Renderer:      helmRenderer,
+		DepResolver:   depResolver,
+		Reporter:      reporter,
+		SemanticDiff:  semanticDiff,
+		UnifiedDiff:   unifiedDiff,
+		Redactor:      redactor,
+		Validator:     validator, // nil if SCHEMA_VALIDATION=false
+		Linter:        linter,    // nil if CHART_LINT=false
+		Deprecations:  deprecations,
+		Policies:      policies,
+		ChartVersion:  chartVersion,  // nil if VERSION_CHECK=false
+		ValuesSurface: valuesSurface, // nil if VALUES_CHECK=false
+		IgnoreFilter:  ignoreFilter,
+		Reports:       reports,    // nil if REPORT_URL is not set
+		Formatters:    formatters, // empty if OUTPUT_FORMATS is not set
+		Outputs:       outputs,    // nil if OUTPUT_FORMATS is not set
+		Logger:        log,
+		Meter:         tel.Meter,
+		Tracer:        tel.Tracer,
+	}, app.Options{
+		ChartDir:     cfg.ChartDir,
+		CommentMode:  domain.CommentMode(cfg.CommentMode),
+		MetricPrefix: metricPrefix,
+
+		FailOnStaleOverrides: cfg.FailOnStaleOverrides,
+	})
 
 	// Webhook handler
 	webhookHandler := githubin.NewWebhookHandler(
@@ -125,6 +258,106 @@ func NewContainer(
 		GitHubClient:   githubClient,
 		DiffService:    diffService,
 		WebhookHandler: webhookHandler,
+		ReportHandler:  reportHandler,
 		ReadyCheck:     readyCheck,
 	}, nil
 }
+
+// newRenderer selects the RendererPort implementation configured via RENDERER.
+func newRenderer(cfg config.Config, log *slog.Logger) (ports.RendererPort, error) {
+	if cfg.Renderer == config.RendererHelmSDK {
+		log.Info("using in-process helm sdk renderer")
+		return helmsdk.New(log), nil
+	}
+
+	renderer, err := helmcli.New()
+	if err != nil {
+		return nil, fmt.Errorf("creating helm adapter: %w", err)
+	}
+	return renderer, nil
+}
+
+// newReporter reports to GitHub and, with NOTIFY_WEBHOOK_URL set, also
+// notifies the webhook of the changes its filter selects. A failing webhook
+// never holds up the GitHub reports.
+func newReporter(cfg config.Config, log *slog.Logger, github ports.ReportingPort) (ports.ReportingPort, error) {
+	if cfg.NotifyWebhookURL == "" {
+		return github, nil
+	}
+
+	minRisk := domain.RiskLow
+	switch cfg.NotifyMinRisk {
+	case config.NotifyRiskMedium:
+		minRisk = domain.RiskMedium
+	case config.NotifyRiskHigh:
+		minRisk = domain.RiskHigh
+	}
+	filter := webhookout.Filter{Charts: cfg.NotifyCharts, Environments: cfg.NotifyEnvironments, MinRisk: minRisk}
+
+	// The URL is a secret for most chat webhooks, so it isn't logged
+	log.Info("webhook notifications enabled",
+		"charts", cfg.NotifyCharts,
+		"environments", cfg.NotifyEnvironments,
+		"minRisk", cfg.NotifyMinRisk,
+	)
+	notifier, err := webhookout.New(cfg.NotifyWebhookURL, cfg.AppName, filter, log)
+	if err != nil {
+		return nil, fmt.Errorf("creating webhook notifier: %w", err)
+	}
+	return multiout.New(github, []multiout.Sink{{Name: "webhook", Reporter: notifier}}, log), nil
+}
+
+// newIgnoreFilter builds the diff ignore rules from the built-in defaults and
+// IGNORE_RULES_FILE, to which each target repo's IGNORE_RULES_REPO_FILE is
+// added. With no rules the filter leaves manifests untouched.
+func newIgnoreFilter(cfg config.Config, log *slog.Logger) (ports.ManifestFilterPort, error) {
+	var rules []domain.IgnoreRule
+	if cfg.IgnoreDefaultRules {
+		rules = append(rules, domain.DefaultIgnoreRules()...)
+	}
+	if cfg.IgnoreRulesFile != "" {
+		fileRules, err := ignorerules.ReadRules(cfg.IgnoreRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, fileRules...)
+	}
+
+	log.Info("diff ignore rules loaded",
+		"count", len(rules),
+		"file", cfg.IgnoreRulesFile,
+		"repoFile", cfg.IgnoreRulesRepoFile,
+	)
+	filter, err := ignorerules.New(rules, cfg.IgnoreRulesRepoFile)
+	if err != nil {
+		return nil, fmt.Errorf("creating ignore rules: %w", err)
+	}
+	return filter, nil
+}
+
+// newOutputs builds a formatter for each of OUTPUT_FORMATS and the store
+// writing their outputs. With no formats there is nothing to write.
+func newOutputs(cfg config.Config, log *slog.Logger) ([]ports.ReportFormatterPort, ports.OutputStorePort, error) {
+	if len(cfg.OutputFormats) == 0 {
+		return nil, nil, nil
+	}
+
+	formatters := make([]ports.ReportFormatterPort, 0, len(cfg.OutputFormats))
+	for _, format := range cfg.OutputFormats {
+		switch format {
+		case config.OutputFormatJSON:
+			formatters = append(formatters, jsonout.New())
+		case config.OutputFormatSARIF:
+			formatters = append(formatters, sarifout.New(cfg.AppName, cfg.AppURL))
+		case config.OutputFormatJUnit:
+			formatters = append(formatters, junitout.New(cfg.AppName))
+		}
+	}
+
+	log.Info("machine-readable outputs enabled", "formats", cfg.OutputFormats, "dir", cfg.OutputDir)
+	store, err := outputdir.New(cfg.OutputDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating output store: %w", err)
+	}
+	return formatters, store, nil
+}
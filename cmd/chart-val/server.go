@@ -26,6 +26,9 @@ func NewServer(container *Container) *Server {
 
 	// Routes (otelhttp creates an inbound span for each webhook request)
 	mux.Handle("POST /webhook", otelhttp.NewHandler(container.WebhookHandler, "POST /webhook"))
+	if container.ReportHandler != nil {
+		mux.Handle("GET /reports/{id}", container.ReportHandler)
+	}
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		//nolint:errcheck // Health check response, error not actionable
@@ -0,0 +1,269 @@
+// Package celpolicy evaluates policy-as-code rules, written as CEL
+// expressions, against rendered manifests.
+package celpolicy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"sigs.k8s.io/yaml"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// costLimit bounds the work a single evaluation may do, so a careless
+// comprehension over a large resource can't stall a diff.
+const costLimit = 1_000_000
+
+// Adapter implements ports.PolicyPort. Each policy is a CEL expression that
+// is evaluated once per resource it applies to, with the resource as
+// `object` and the environment name as `environment`; the resource passes
+// if the expression returns true. Evaluation errors, such as a missing field
+// that the expression doesn't guard with has(), count as failures.
+type Adapter struct {
+	dir string // Policy directory applied to every chart, "" for none
+	env *cel.Env
+
+	mu       sync.Mutex
+	programs map[string]cel.Program // Compiled expressions, by source
+}
+
+// New creates a policy evaluator that applies the policy files under dir
+// (e.g. a folder of the Argo CD apps repo clone) to every chart. dir is read
+// on every LoadPolicies call, so policy changes are picked up as it syncs.
+func New(dir string) (*Adapter, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("environment", cel.StringType),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating CEL environment: %w", err)
+	}
+	return &Adapter{dir: dir, env: env, programs: make(map[string]cel.Program)}, nil
+}
+
+// LoadPolicies reads the policies under the adapter's directory and under
+// chartPolicyDir. Missing directories hold no policies.
+func (a *Adapter) LoadPolicies(chartPolicyDir string) ([]domain.Policy, error) {
+	var policies []domain.Policy
+	for _, dir := range []string{a.dir, chartPolicyDir} {
+		if dir == "" {
+			continue
+		}
+		loaded, err := a.loadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, loaded...)
+	}
+	return policies, nil
+}
+
+// loadDir reads every YAML file under dir, of the form:
+//
+//	policies:
+//	  - name: no-latest-tag
+//	    message: images must be pinned to a version
+//	    kinds: [Deployment, StatefulSet, DaemonSet]
+//	    expression: object.spec.template.spec.containers.all(c, !c.image.endsWith(":latest"))
+//	    severity: warning
+//	    environments:
+//	      prod: error
+func (a *Adapter) loadDir(dir string) ([]domain.Policy, error) {
+	var policies []domain.Policy
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+		loaded, err := a.loadFile(path)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, loaded...)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading policies from %s: %w", dir, err)
+	}
+	return policies, nil
+}
+
+func (a *Adapter) loadFile(file string) ([]domain.Policy, error) {
+	data, err := os.ReadFile(file) //nolint:gosec // G304: path is within a policy directory
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Policies []struct {
+			Name         string                     `json:"name"`
+			Message      string                     `json:"message"`
+			Kinds        []string                   `json:"kinds"`
+			Expression   string                     `json:"expression"`
+			Severity     domain.Severity            `json:"severity"`
+			Environments map[string]domain.Severity `json:"environments"`
+		} `json:"policies"`
+	}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+
+	policies := make([]domain.Policy, 0, len(parsed.Policies))
+	for i, p := range parsed.Policies {
+		policy := domain.Policy(p)
+		if err := a.check(policy); err != nil {
+			return nil, fmt.Errorf("%s: policy %d: %w", file, i+1, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// check validates a policy and compiles its expression.
+func (a *Adapter) check(p domain.Policy) error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if p.Expression == "" {
+		return fmt.Errorf("%s: expression is required", p.Name)
+	}
+	for _, s := range append([]domain.Severity{p.Severity}, slices.Collect(maps.Values(p.Environments))...) {
+		switch s {
+		case "", domain.SeverityError, domain.SeverityWarning, domain.SeverityOff:
+		default:
+			return fmt.Errorf("%s: invalid severity %q (want error, warning or off)", p.Name, s)
+		}
+	}
+	if _, err := a.program(p.Expression); err != nil {
+		return fmt.Errorf("%s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Evaluate checks every resource in manifest against the policies that are
+// on in envName. Documents that don't parse are skipped; they fail schema
+// validation instead.
+func (a *Adapter) Evaluate(policies []domain.Policy, manifest []byte, envName string) []domain.PolicyViolation {
+	objects := parseObjects(manifest)
+
+	var violations []domain.PolicyViolation
+	for _, p := range policies {
+		severity := p.SeverityFor(envName)
+		if severity == domain.SeverityOff {
+			continue
+		}
+		prg, err := a.program(p.Expression)
+		if err != nil {
+			violations = append(violations, domain.PolicyViolation{
+				Policy: p.Name, Message: fmt.Sprintf("invalid policy: %s", err), Severity: severity,
+			})
+			continue
+		}
+
+		for _, obj := range objects {
+			if !p.AppliesTo(obj.kind) {
+				continue
+			}
+			message, ok := evaluate(prg, p, obj.object, envName)
+			if ok {
+				continue
+			}
+			violations = append(violations, domain.PolicyViolation{
+				Policy:   p.Name,
+				Resource: obj.id,
+				Message:  message,
+				Severity: severity,
+				Template: obj.template,
+			})
+		}
+	}
+	return violations
+}
+
+// evaluate runs a policy against one resource, returning whether it passed
+// and, if not, why.
+func evaluate(prg cel.Program, p domain.Policy, object map[string]any, envName string) (string, bool) {
+	out, _, err := prg.Eval(map[string]any{"object": object, "environment": envName})
+	if err != nil {
+		return fmt.Sprintf("evaluation failed: %s", err), false
+	}
+	if pass, ok := out.Value().(bool); ok && pass {
+		return "", true
+	}
+	if p.Message != "" {
+		return p.Message, false
+	}
+	return "failed " + p.Expression, false
+}
+
+// program returns the compiled program for a CEL expression, compiling and
+// caching it on first use.
+func (a *Adapter) program(expression string) (cel.Program, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if prg, ok := a.programs[expression]; ok {
+		return prg, nil
+	}
+
+	ast, issues := a.env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("compiling expression: %w", issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must return a bool, not %s", ast.OutputType())
+	}
+	prg, err := a.env.Program(ast, cel.CostLimit(costLimit))
+	if err != nil {
+		return nil, fmt.Errorf("building program: %w", err)
+	}
+	a.programs[expression] = prg
+	return prg, nil
+}
+
+type resource struct {
+	id       string // apiVersion/kind[/namespace]/name
+	kind     string
+	template string // Template that rendered it, from its "# Source:" comment
+	object   map[string]any
+}
+
+// parseObjects returns the resources in a multi-document manifest.
+func parseObjects(manifest []byte) []resource {
+	var objects []resource
+	for _, doc := range yamldoc.Split(manifest) {
+		var obj map[string]any
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj == nil {
+			continue
+		}
+		metadata, _ := obj["metadata"].(map[string]any)
+		rc := domain.ResourceChange{
+			APIVersion: stringAt(obj, "apiVersion"),
+			Kind:       stringAt(obj, "kind"),
+			Namespace:  stringAt(metadata, "namespace"),
+			Name:       stringAt(metadata, "name"),
+		}
+		objects = append(objects, resource{id: rc.ID(), kind: rc.Kind, template: yamldoc.Source(doc), object: obj})
+	}
+	return objects
+}
+
+func stringAt(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
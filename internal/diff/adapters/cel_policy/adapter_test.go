@@ -0,0 +1,234 @@
+package celpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const manifest = `# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  namespace: prod
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      containers:
+        - name: my-app
+          image: my-app:latest
+        - name: sidecar
+          image: envoy:1.30
+          resources:
+            limits: {cpu: 100m}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+var (
+	noLatest = domain.Policy{
+		Name:       "no-latest-tag",
+		Message:    "images must be pinned to a version",
+		Kinds:      []string{"Deployment"},
+		Expression: `object.spec.template.spec.containers.all(c, !c.image.endsWith(":latest"))`,
+	}
+	limits = domain.Policy{
+		Name:       "resource-limits",
+		Kinds:      []string{"Deployment"},
+		Expression: `object.spec.template.spec.containers.all(c, has(c.resources) && has(c.resources.limits))`,
+		Severity:   domain.SeverityWarning,
+	}
+	noHostNetwork = domain.Policy{
+		Name:         "no-host-network",
+		Message:      "hostNetwork is not allowed in prod",
+		Kinds:        []string{"Deployment"},
+		Expression:   `!object.spec.template.spec.hostNetwork`,
+		Severity:     domain.SeverityOff,
+		Environments: map[string]domain.Severity{"prod": domain.SeverityError},
+	}
+	named = domain.Policy{
+		Name:       "named",
+		Expression: `environment == "dev" || object.metadata.name.startsWith("my-")`,
+	}
+)
+
+func TestAdapter_Evaluate(t *testing.T) {
+	const deployment = "apps/v1/Deployment/prod/my-app"
+	const template = "my-app/templates/deployment.yaml"
+
+	tests := []struct {
+		name     string
+		policies []domain.Policy
+		env      string
+		want     []domain.PolicyViolation
+	}{
+		{
+			name:     "failing policies with their severities",
+			policies: []domain.Policy{noLatest, limits},
+			env:      "dev",
+			want: []domain.PolicyViolation{
+				{
+					Policy:   "no-latest-tag",
+					Resource: deployment,
+					Message:  "images must be pinned to a version",
+					Severity: domain.SeverityError,
+					Template: template,
+				},
+				{
+					Policy:   "resource-limits",
+					Resource: deployment,
+					Message:  "failed " + limits.Expression,
+					Severity: domain.SeverityWarning,
+					Template: template,
+				},
+			},
+		},
+		{
+			name:     "policies that are off in an environment are skipped",
+			policies: []domain.Policy{noHostNetwork},
+			env:      "dev",
+		},
+		{
+			name:     "per-environment severity",
+			policies: []domain.Policy{noHostNetwork},
+			env:      "prod",
+			want: []domain.PolicyViolation{{
+				Policy:   "no-host-network",
+				Resource: deployment,
+				Message:  "hostNetwork is not allowed in prod",
+				Severity: domain.SeverityError,
+				Template: template,
+			}},
+		},
+		{
+			name:     "policies without kinds apply to every resource",
+			policies: []domain.Policy{named},
+			env:      "prod",
+			want: []domain.PolicyViolation{{
+				Policy:   "named",
+				Resource: "v1/Service/web",
+				Message:  "failed " + named.Expression,
+				Severity: domain.SeverityError,
+			}},
+		},
+	}
+
+	adapter, err := New("")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adapter.Evaluate(tt.policies, []byte(manifest), tt.env)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdapter_Evaluate_ErrorsFail(t *testing.T) {
+	adapter, err := New("")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	unguarded := domain.Policy{
+		Name:       "unguarded",
+		Kinds:      []string{"Service"},
+		Expression: `object.spec.type != "NodePort"`,
+	}
+
+	got := adapter.Evaluate([]domain.Policy{unguarded}, []byte(manifest), "dev")
+	if len(got) != 1 || !strings.HasPrefix(got[0].Message, "evaluation failed: ") {
+		t.Errorf("Evaluate() = %+v, want one evaluation failure", got)
+	}
+}
+
+func TestAdapter_LoadPolicies(t *testing.T) {
+	orgDir := t.TempDir()
+	chartDir := t.TempDir()
+	writeFile(t, filepath.Join(orgDir, "images.yaml"), `policies:
+  - name: no-latest-tag
+    message: images must be pinned to a version
+    kinds: [Deployment]
+    expression: object.spec.template.spec.containers.all(c, !c.image.endsWith(":latest"))
+`)
+	writeFile(t, filepath.Join(chartDir, "nested", "network.yml"), `policies:
+  - name: no-host-network
+    message: hostNetwork is not allowed in prod
+    kinds: [Deployment]
+    expression: "!object.spec.template.spec.hostNetwork"
+    severity: "off"
+    environments:
+      prod: error
+`)
+	writeFile(t, filepath.Join(chartDir, "README.md"), "not a policy")
+
+	adapter, err := New(orgDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got, err := adapter.LoadPolicies(chartDir)
+	if err != nil {
+		t.Fatalf("LoadPolicies() error = %v", err)
+	}
+	want := []domain.Policy{noLatest, noHostNetwork}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadPolicies() = %+v, want %+v", got, want)
+	}
+
+	if got, err := adapter.LoadPolicies(filepath.Join(chartDir, "missing")); err != nil || len(got) != 1 {
+		t.Errorf("LoadPolicies() with missing chart dir = %+v, %v, want org policies only", got, err)
+	}
+}
+
+func TestAdapter_LoadPolicies_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"missing name", "policies:\n  - expression: \"true\"\n"},
+		{"missing expression", "policies:\n  - name: p\n"},
+		{"bad severity", "policies:\n  - name: p\n    expression: \"true\"\n    severity: fatal\n"},
+		{
+			"bad environment severity",
+			"policies:\n  - name: p\n    expression: \"true\"\n    environments: {prod: high}\n",
+		},
+		{"syntax error", "policies:\n  - name: p\n    expression: object.spec.(\n"},
+		{"non-bool expression", "policies:\n  - name: p\n    expression: \"'yes'\"\n"},
+		{"invalid YAML", "policies: [unclosed\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "policy.yaml"), tt.content)
+			adapter, err := New("")
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if _, err := adapter.LoadPolicies(dir); err == nil {
+				t.Error("LoadPolicies() expected error, got nil")
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,425 @@
+// Package chartdeps resolves Helm chart dependencies declared in Chart.yaml
+// into the chart's charts/ directory so the chart can be rendered.
+package chartdeps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// Adapter implements ports.DependencyResolverPort. It supports local
+// (file://), HTTP chart repository and OCI registry dependencies, pinning
+// versions from Chart.lock when present. Downloaded archives are kept in a
+// content-addressed cache shared by base/head renders and across PRs.
+type Adapter struct {
+	cache      *archiveCache
+	httpClient *http.Client
+	registry   *registry.Client
+	logger     *slog.Logger
+}
+
+// New creates a new dependency resolver that caches archives under cacheDir.
+func New(cacheDir string, logger *slog.Logger) (*Adapter, error) {
+	registryClient, err := registry.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating registry client: %w", err)
+	}
+	return &Adapter{
+		cache:      newArchiveCache(cacheDir),
+		httpClient: http.DefaultClient,
+		registry:   registryClient,
+		logger:     logger,
+	}, nil
+}
+
+// dependency is a single entry from Chart.yaml (or requirements.yaml for
+// apiVersion v1 charts) and their lock files.
+type dependency struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	Repository string `yaml:"repository"`
+}
+
+type dependencyFile struct {
+	Dependencies []dependency `yaml:"dependencies"`
+}
+
+// ResolveDependencies fetches every dependency of the chart in chartDir that
+// is not already vendored under charts/. Local (file://) dependencies must lie
+// inside repoDir, the repository checkout holding the chart. It is a no-op
+// for charts without dependencies.
+func (a *Adapter) ResolveDependencies(ctx context.Context, chartDir, repoDir string) error {
+	return a.resolveAll(ctx, chartDir, chartDir, repoDir)
+}
+
+// resolveAll resolves the dependencies of the chart in chartDir, whose
+// relative file:// paths point from srcDir. The two differ for a local
+// dependency copied into its parent's charts/.
+func (a *Adapter) resolveAll(ctx context.Context, chartDir, srcDir, repoDir string) error {
+	deps, err := readDependencies(chartDir, "Chart.yaml", "requirements.yaml")
+	if err != nil {
+		return err
+	}
+	if len(deps) == 0 {
+		return nil
+	}
+
+	locked, err := readDependencies(chartDir, "Chart.lock", "requirements.lock")
+	if err != nil {
+		return err
+	}
+	lockedVersions := make(map[string]string, len(locked))
+	for _, l := range locked {
+		lockedVersions[l.Name+"|"+l.Repository] = l.Version
+	}
+
+	chartsDir := filepath.Join(chartDir, "charts")
+	for _, dep := range deps {
+		if isVendored(chartsDir, dep.Name) {
+			a.logger.Debug("dependency already vendored", "chartDir", chartDir, "dependency", dep.Name)
+			continue
+		}
+
+		version := dep.Version
+		if v, ok := lockedVersions[dep.Name+"|"+dep.Repository]; ok {
+			version = v
+		}
+
+		a.logger.Info("resolving chart dependency",
+			"chartDir", chartDir,
+			"dependency", dep.Name,
+			"version", version,
+			"repository", dep.Repository,
+		)
+		if err := a.resolve(ctx, srcDir, repoDir, chartsDir, dep, version); err != nil {
+			return fmt.Errorf("resolving dependency %s: %w", dep.Name, err)
+		}
+	}
+	return nil
+}
+
+func (a *Adapter) resolve(
+	ctx context.Context,
+	srcDir, repoDir, chartsDir string,
+	dep dependency,
+	version string,
+) error {
+	var (
+		data     []byte
+		resolved string
+		err      error
+	)
+
+	switch {
+	case strings.HasPrefix(dep.Repository, "file://"):
+		return a.resolveLocal(ctx, srcDir, repoDir, chartsDir, dep)
+	case strings.HasPrefix(dep.Repository, "oci://"):
+		data, resolved, err = a.fetchOCI(dep, version)
+	case strings.HasPrefix(dep.Repository, "http://"), strings.HasPrefix(dep.Repository, "https://"):
+		data, resolved, err = a.fetchHTTP(ctx, dep, version)
+	case dep.Repository == "":
+		return errors.New("no repository set and chart is not vendored under charts/")
+	default:
+		return fmt.Errorf("unsupported repository %q: repository aliases are not supported, use a URL", dep.Repository)
+	}
+	if err != nil {
+		return err
+	}
+
+	//nolint:gosec // G301: charts/ lives inside our own temp checkout
+	if err := os.MkdirAll(chartsDir, 0o755); err != nil {
+		return fmt.Errorf("creating charts directory: %w", err)
+	}
+	target := filepath.Join(chartsDir, fmt.Sprintf("%s-%s.tgz", dep.Name, resolved))
+	if err := os.WriteFile(target, data, 0o600); err != nil {
+		return fmt.Errorf("writing dependency archive: %w", err)
+	}
+	return nil
+}
+
+// resolveLocal copies a file:// dependency into charts/ and resolves its own
+// dependencies in turn. The path is relative to srcDir, the chart's directory
+// in the repo checkout, and may not leave repoDir.
+func (a *Adapter) resolveLocal(ctx context.Context, srcDir, repoDir, chartsDir string, dep dependency) error {
+	path := strings.TrimPrefix(dep.Repository, "file://")
+	if filepath.IsAbs(path) {
+		return fmt.Errorf("local dependency %s: absolute paths are not allowed", dep.Repository)
+	}
+	src := filepath.Join(srcDir, path)
+	if rel, err := filepath.Rel(repoDir, src); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("local dependency %s: path escapes the repository", dep.Repository)
+	}
+	if _, err := os.Stat(filepath.Join(src, "Chart.yaml")); err != nil {
+		return fmt.Errorf("local dependency %s: %w", dep.Repository, err)
+	}
+
+	dst := filepath.Join(chartsDir, dep.Name)
+	if err := copyDir(src, dst); err != nil {
+		return fmt.Errorf("copying local dependency: %w", err)
+	}
+	return a.resolveAll(ctx, dst, src, repoDir)
+}
+
+// repoIndex is the subset of a Helm repository index.yaml that we need.
+type repoIndex struct {
+	Entries map[string][]indexEntry `yaml:"entries"`
+}
+
+type indexEntry struct {
+	Version string   `yaml:"version"`
+	URLs    []string `yaml:"urls"`
+	Digest  string   `yaml:"digest"`
+}
+
+func (a *Adapter) fetchHTTP(ctx context.Context, dep dependency, constraint string) ([]byte, string, error) {
+	repoURL := strings.TrimSuffix(dep.Repository, "/")
+
+	// Exact versions are immutable, so a previously recorded digest lets us
+	// skip fetching the index entirely.
+	if isExactVersion(constraint) {
+		if data, ok := a.cache.get(a.cache.lookupRef(refKey(repoURL, dep.Name, constraint))); ok {
+			return data, constraint, nil
+		}
+	}
+
+	indexData, err := a.download(ctx, repoURL+"/index.yaml")
+	if err != nil {
+		return nil, "", fmt.Errorf("fetching repository index: %w", err)
+	}
+	var index repoIndex
+	if err := yaml.Unmarshal(indexData, &index); err != nil {
+		return nil, "", fmt.Errorf("parsing repository index: %w", err)
+	}
+
+	entries := index.Entries[dep.Name]
+	versions := make([]string, 0, len(entries))
+	for _, e := range entries {
+		versions = append(versions, e.Version)
+	}
+	version, err := selectVersion(versions, constraint)
+	if err != nil {
+		return nil, "", err
+	}
+	var entry indexEntry
+	for _, e := range entries {
+		if e.Version == version {
+			entry = e
+			break
+		}
+	}
+
+	key := refKey(repoURL, dep.Name, version)
+	if data, ok := a.cache.get(entry.Digest); ok {
+		return data, version, nil
+	}
+	if data, ok := a.cache.get(a.cache.lookupRef(key)); ok {
+		return data, version, nil
+	}
+
+	if len(entry.URLs) == 0 {
+		return nil, "", fmt.Errorf("no download URL for %s %s", dep.Name, version)
+	}
+	archiveURL, err := resolveURL(repoURL, entry.URLs[0])
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := a.download(ctx, archiveURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("downloading chart archive: %w", err)
+	}
+	if entry.Digest != "" && digestOf(data) != entry.Digest {
+		return nil, "", fmt.Errorf("digest mismatch for %s %s", dep.Name, version)
+	}
+
+	if err := a.store(key, data); err != nil {
+		return nil, "", err
+	}
+	return data, version, nil
+}
+
+func (a *Adapter) fetchOCI(dep dependency, constraint string) ([]byte, string, error) {
+	repo := strings.TrimPrefix(strings.TrimSuffix(dep.Repository, "/"), "oci://") + "/" + dep.Name
+
+	version := constraint
+	if !isExactVersion(constraint) {
+		tags, err := a.registry.Tags(repo)
+		if err != nil {
+			return nil, "", fmt.Errorf("listing tags for %s: %w", repo, err)
+		}
+		version, err = selectVersion(tags, constraint)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	key := refKey("oci://"+repo, dep.Name, version)
+	if data, ok := a.cache.get(a.cache.lookupRef(key)); ok {
+		return data, version, nil
+	}
+
+	// OCI tags cannot contain "+", Helm substitutes "_" for build metadata.
+	result, err := a.registry.Pull(repo + ":" + strings.ReplaceAll(version, "+", "_"))
+	if err != nil {
+		return nil, "", fmt.Errorf("pulling %s: %w", repo, err)
+	}
+
+	if err := a.store(key, result.Chart.Data); err != nil {
+		return nil, "", err
+	}
+	return result.Chart.Data, version, nil
+}
+
+func (a *Adapter) store(key string, data []byte) error {
+	digest, err := a.cache.put(data)
+	if err != nil {
+		return err
+	}
+	if err := a.cache.recordRef(key, digest); err != nil {
+		a.logger.Warn("failed to record dependency digest", "key", key, "error", err)
+	}
+	return nil
+}
+
+func (a *Adapter) download(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			a.logger.Warn("failed to close response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status fetching %s: %d", rawURL, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// readDependencies returns the dependencies from the first of files that exists.
+func readDependencies(chartDir string, files ...string) ([]dependency, error) {
+	for _, name := range files {
+		//nolint:gosec // G304: chartDir is a temp checkout we created
+		data, err := os.ReadFile(filepath.Join(chartDir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		var f dependencyFile
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+		if len(f.Dependencies) > 0 {
+			return f.Dependencies, nil
+		}
+	}
+	return nil, nil
+}
+
+// isVendored reports whether charts/ already holds the dependency, either
+// unpacked or as an archive.
+func isVendored(chartsDir, name string) bool {
+	if info, err := os.Stat(filepath.Join(chartsDir, name)); err == nil && info.IsDir() {
+		return true
+	}
+	matches, err := filepath.Glob(filepath.Join(chartsDir, name+"-[0-9v]*.tgz"))
+	return err == nil && len(matches) > 0
+}
+
+func isExactVersion(v string) bool {
+	_, err := semver.StrictNewVersion(strings.TrimPrefix(v, "v"))
+	return err == nil
+}
+
+// selectVersion returns the highest version satisfying constraint.
+// An empty constraint matches any stable version.
+func selectVersion(versions []string, constraint string) (string, error) {
+	if constraint == "" {
+		constraint = "*"
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	var best *semver.Version
+	var bestRaw string
+	for _, raw := range versions {
+		v, err := semver.NewVersion(raw)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best, bestRaw = v, raw
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no version matching %q", constraint)
+	}
+	return bestRaw, nil
+}
+
+func refKey(repo, name, version string) string {
+	return repo + "|" + name + "|" + version
+}
+
+// resolveURL resolves a (possibly relative) index URL against the repo URL.
+func resolveURL(repoURL, ref string) (string, error) {
+	base, err := url.Parse(repoURL + "/")
+	if err != nil {
+		return "", fmt.Errorf("parsing repository URL: %w", err)
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("parsing chart URL: %w", err)
+	}
+	return base.ResolveReference(u).String(), nil
+}
+
+//nolint:gosec // G301,G304: copying between directories inside our temp checkout
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o600)
+	})
+}
@@ -0,0 +1,399 @@
+package chartdeps
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// chartRepo is a stand-in for an HTTP Helm chart repository serving a
+// single chart name at one or more versions.
+type chartRepo struct {
+	server    *httptest.Server
+	archives  map[string][]byte // version -> .tgz
+	downloads atomic.Int32      // archive requests served
+	badDigest bool              // advertise a wrong digest in index.yaml
+}
+
+func newChartRepo(t *testing.T, name string, versions ...string) *chartRepo {
+	t.Helper()
+	repo := &chartRepo{archives: make(map[string][]byte)}
+	for _, v := range versions {
+		repo.archives[v] = buildArchive(t, name, v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "apiVersion: v1\nentries:\n  %s:\n", name)
+		for _, v := range versions {
+			digest := digestOf(repo.archives[v])
+			if repo.badDigest {
+				digest = strings.Repeat("0", len(digest))
+			}
+			fmt.Fprintf(&b, "  - version: %s\n    digest: %s\n    urls:\n    - charts/%s-%s.tgz\n", v, digest, name, v)
+		}
+		_, _ = w.Write([]byte(b.String()))
+	})
+	mux.HandleFunc("/charts/", func(w http.ResponseWriter, r *http.Request) {
+		file := strings.TrimPrefix(r.URL.Path, "/charts/")
+		version := strings.TrimSuffix(strings.TrimPrefix(file, name+"-"), ".tgz")
+		data, ok := repo.archives[version]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		repo.downloads.Add(1)
+		_, _ = w.Write(data)
+	})
+	repo.server = httptest.NewServer(mux)
+	t.Cleanup(repo.server.Close)
+	return repo
+}
+
+// buildArchive packages a minimal chart the way `helm package` would.
+func buildArchive(t *testing.T, name, version string) []byte {
+	t.Helper()
+	files := map[string]string{
+		name + "/Chart.yaml": fmt.Sprintf("apiVersion: v2\nname: %s\nversion: %s\n", name, version),
+		name + "/templates/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " +
+			name + "\n",
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for path, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: path, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("writing tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("writing tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("closing tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("closing gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// writeChart creates a chart directory under root with the given files.
+func writeChart(t *testing.T, root, dir string, files map[string]string) string {
+	t.Helper()
+	chartDir := filepath.Join(root, dir)
+	for name, content := range files {
+		path := filepath.Join(chartDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+	}
+	return chartDir
+}
+
+func newTestAdapter(t *testing.T, cacheDir string) *Adapter {
+	t.Helper()
+	adapter, err := New(cacheDir, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return adapter
+}
+
+// resolveInParent resolves the dependencies of the chart in chartDir, taking
+// its parent directory as the repository checkout.
+func resolveInParent(adapter *Adapter, chartDir string) error {
+	return adapter.ResolveDependencies(context.Background(), chartDir, filepath.Dir(chartDir))
+}
+
+func chartYAML(repoURL, version string) string {
+	return fmt.Sprintf(`apiVersion: v2
+name: app
+version: 1.0.0
+dependencies:
+  - name: redis
+    version: %q
+    repository: %q
+`, version, repoURL)
+}
+
+func assertArchive(t *testing.T, chartDir, file string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(filepath.Join(chartDir, "charts", file))
+	if err != nil {
+		t.Fatalf("expected %s to be written: %v", file, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s content does not match the repository archive", file)
+	}
+}
+
+func TestResolveDependencies_HTTPRepoSelectsHighestMatch(t *testing.T) {
+	repo := newChartRepo(t, "redis", "1.2.0", "1.4.1", "2.0.0")
+	chartDir := writeChart(t, t.TempDir(), "app", map[string]string{
+		"Chart.yaml": chartYAML(repo.server.URL, "^1.2.0"),
+	})
+
+	if err := resolveInParent(newTestAdapter(t, t.TempDir()), chartDir); err != nil {
+		t.Fatalf("ResolveDependencies failed: %v", err)
+	}
+
+	assertArchive(t, chartDir, "redis-1.4.1.tgz", repo.archives["1.4.1"])
+}
+
+func TestResolveDependencies_ChartLockPinsVersion(t *testing.T) {
+	repo := newChartRepo(t, "redis", "1.2.0", "1.4.1")
+	chartDir := writeChart(t, t.TempDir(), "app", map[string]string{
+		"Chart.yaml": chartYAML(repo.server.URL, "^1.2.0"),
+		"Chart.lock": fmt.Sprintf(
+			"dependencies:\n- name: redis\n  repository: %s\n  version: 1.2.0\n",
+			repo.server.URL,
+		),
+	})
+
+	if err := resolveInParent(newTestAdapter(t, t.TempDir()), chartDir); err != nil {
+		t.Fatalf("ResolveDependencies failed: %v", err)
+	}
+
+	assertArchive(t, chartDir, "redis-1.2.0.tgz", repo.archives["1.2.0"])
+	if _, err := os.Stat(filepath.Join(chartDir, "charts", "redis-1.4.1.tgz")); err == nil {
+		t.Error("expected locked version only, but 1.4.1 was also written")
+	}
+}
+
+func TestResolveDependencies_CacheSharedAcrossCheckouts(t *testing.T) {
+	repo := newChartRepo(t, "redis", "1.4.1")
+	cacheDir := t.TempDir()
+	root := t.TempDir()
+	baseDir := writeChart(t, root, "base", map[string]string{"Chart.yaml": chartYAML(repo.server.URL, "1.4.1")})
+	headDir := writeChart(t, root, "head", map[string]string{"Chart.yaml": chartYAML(repo.server.URL, "1.4.1")})
+
+	// Separate adapters model separate process runs sharing one cache dir.
+	ctx := context.Background()
+	if err := newTestAdapter(t, cacheDir).ResolveDependencies(ctx, baseDir, root); err != nil {
+		t.Fatalf("resolving base: %v", err)
+	}
+	if err := newTestAdapter(t, cacheDir).ResolveDependencies(ctx, headDir, root); err != nil {
+		t.Fatalf("resolving head: %v", err)
+	}
+
+	if got := repo.downloads.Load(); got != 1 {
+		t.Errorf("expected archive to be downloaded once, got %d downloads", got)
+	}
+	assertArchive(t, headDir, "redis-1.4.1.tgz", repo.archives["1.4.1"])
+
+	digest := digestOf(repo.archives["1.4.1"])
+	if _, err := os.Stat(filepath.Join(cacheDir, "sha256", digest+".tgz")); err != nil {
+		t.Errorf("expected archive stored by digest in cache: %v", err)
+	}
+}
+
+func TestResolveDependencies_DigestMismatch(t *testing.T) {
+	repo := newChartRepo(t, "redis", "1.4.1")
+	repo.badDigest = true
+	chartDir := writeChart(t, t.TempDir(), "app", map[string]string{
+		"Chart.yaml": chartYAML(repo.server.URL, "1.4.1"),
+	})
+
+	err := resolveInParent(newTestAdapter(t, t.TempDir()), chartDir)
+	if err == nil {
+		t.Fatal("expected digest mismatch error, got nil")
+	}
+	if !strings.Contains(err.Error(), "digest mismatch") {
+		t.Errorf("error %q should mention digest mismatch", err.Error())
+	}
+}
+
+func TestResolveDependencies_LocalFileDependency(t *testing.T) {
+	root := t.TempDir()
+	writeChart(t, root, "charts/common", map[string]string{
+		"Chart.yaml":             "apiVersion: v2\nname: common\nversion: 0.1.0\n",
+		"templates/_helpers.tpl": `{{- define "common.name" -}}common{{- end -}}`,
+	})
+	chartDir := writeChart(t, root, "charts/app", map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: app
+version: 1.0.0
+dependencies:
+  - name: common
+    version: 0.1.0
+    repository: file://../common
+`,
+	})
+
+	if err := newTestAdapter(t, t.TempDir()).ResolveDependencies(context.Background(), chartDir, root); err != nil {
+		t.Fatalf("ResolveDependencies failed: %v", err)
+	}
+
+	for _, file := range []string{"Chart.yaml", "templates/_helpers.tpl"} {
+		if _, err := os.Stat(filepath.Join(chartDir, "charts", "common", file)); err != nil {
+			t.Errorf("expected local dependency file %s to be copied: %v", file, err)
+		}
+	}
+}
+
+func TestResolveDependencies_NestedLocalFileDependency(t *testing.T) {
+	// app -> libs/common -> ../base: base's path is relative to common's
+	// source directory, not to the copy under app/charts/.
+	root := t.TempDir()
+	writeChart(t, root, "base", map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: base\nversion: 0.1.0\n",
+	})
+	writeChart(t, root, "libs/common", map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: common
+version: 0.1.0
+dependencies:
+  - name: base
+    version: 0.1.0
+    repository: file://../../base
+`,
+	})
+	chartDir := writeChart(t, root, "charts/app", map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: app
+version: 1.0.0
+dependencies:
+  - name: common
+    version: 0.1.0
+    repository: file://../../libs/common
+`,
+	})
+
+	if err := newTestAdapter(t, t.TempDir()).ResolveDependencies(context.Background(), chartDir, root); err != nil {
+		t.Fatalf("ResolveDependencies failed: %v", err)
+	}
+
+	nested := filepath.Join(chartDir, "charts", "common", "charts", "base", "Chart.yaml")
+	if _, err := os.Stat(nested); err != nil {
+		t.Errorf("expected nested local dependency to be copied: %v", err)
+	}
+}
+
+func TestResolveDependencies_LocalFileDependencyOutsideRepo(t *testing.T) {
+	// The checkout is dir/repo; dir/secret is a chart next to it.
+	dir := t.TempDir()
+	root := filepath.Join(dir, "repo")
+	secretDir := writeChart(t, dir, "secret", map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: secret\nversion: 0.1.0\n",
+	})
+
+	tests := []struct {
+		name       string
+		repository string
+		wantErr    string
+	}{
+		{name: "absolute path", repository: "file://" + secretDir, wantErr: "absolute paths are not allowed"},
+		{name: "escapes checkout", repository: "file://../../secret", wantErr: "escapes the repository"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartDir := writeChart(t, root, "app", map[string]string{
+				"Chart.yaml": fmt.Sprintf(`apiVersion: v2
+name: app
+version: 1.0.0
+dependencies:
+  - name: secret
+    version: 0.1.0
+    repository: %s
+`, tt.repository),
+			})
+
+			err := newTestAdapter(t, t.TempDir()).ResolveDependencies(context.Background(), chartDir, root)
+			if err == nil {
+				t.Fatal("expected error for a dependency outside the repository, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q should contain %q", err.Error(), tt.wantErr)
+			}
+			if _, err := os.Stat(filepath.Join(chartDir, "charts", "secret")); err == nil {
+				t.Error("expected nothing copied from outside the repository")
+			}
+		})
+	}
+}
+
+func TestResolveDependencies_SkipsVendored(t *testing.T) {
+	chartDir := writeChart(t, t.TempDir(), "app", map[string]string{
+		// An unreachable repository proves nothing is fetched.
+		"Chart.yaml":                chartYAML("http://127.0.0.1:0", "1.4.1"),
+		"charts/redis-1.4.1.tgz":    "vendored",
+		"templates/deployment.yaml": "",
+	})
+
+	if err := resolveInParent(newTestAdapter(t, t.TempDir()), chartDir); err != nil {
+		t.Fatalf("ResolveDependencies failed: %v", err)
+	}
+}
+
+func TestResolveDependencies_NoDependencies(t *testing.T) {
+	chartDir := writeChart(t, t.TempDir(), "app", map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: app\nversion: 1.0.0\n",
+	})
+
+	if err := resolveInParent(newTestAdapter(t, t.TempDir()), chartDir); err != nil {
+		t.Fatalf("ResolveDependencies failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, "charts")); err == nil {
+		t.Error("expected no charts/ directory for a chart without dependencies")
+	}
+}
+
+func TestResolveDependencies_RepositoryAliasUnsupported(t *testing.T) {
+	chartDir := writeChart(t, t.TempDir(), "app", map[string]string{
+		"Chart.yaml": chartYAML("@bitnami", "1.4.1"),
+	})
+
+	err := resolveInParent(newTestAdapter(t, t.TempDir()), chartDir)
+	if err == nil {
+		t.Fatal("expected error for repository alias, got nil")
+	}
+	if !strings.Contains(err.Error(), "aliases are not supported") {
+		t.Errorf("error %q should explain aliases are unsupported", err.Error())
+	}
+}
+
+func TestSelectVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		versions   []string
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{name: "exact", versions: []string{"1.0.0", "1.1.0"}, constraint: "1.0.0", want: "1.0.0"},
+		{name: "caret range", versions: []string{"1.0.0", "1.9.3", "2.0.0"}, constraint: "^1.0.0", want: "1.9.3"},
+		{name: "empty means latest stable", versions: []string{"1.0.0", "2.0.0-rc.1"}, constraint: "", want: "1.0.0"},
+		{name: "v prefix", versions: []string{"v1.0.0", "v1.2.0"}, constraint: "~1.0", want: "v1.0.0"},
+		{name: "no match", versions: []string{"1.0.0"}, constraint: ">=2.0.0", wantErr: true},
+		{name: "invalid constraint", versions: []string{"1.0.0"}, constraint: "not-a-version", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectVersion(tt.versions, tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("selectVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package chartdeps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveCache is a content-addressed on-disk store of chart archives.
+// Archives live at {dir}/sha256/{digest}.tgz; a small refs/ index maps
+// immutable coordinates (repo, name, version) to digests so repeat
+// lookups skip the network entirely. The cache is safe to share between
+// concurrent renders and across PRs: writes go through a temp file and
+// an atomic rename.
+type archiveCache struct {
+	dir string
+}
+
+func newArchiveCache(dir string) *archiveCache {
+	return &archiveCache{dir: dir}
+}
+
+// digestOf returns the hex-encoded sha256 of data.
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *archiveCache) archivePath(digest string) string {
+	return filepath.Join(c.dir, "sha256", digest+".tgz")
+}
+
+func (c *archiveCache) refPath(key string) string {
+	return filepath.Join(c.dir, "refs", digestOf([]byte(key)))
+}
+
+// get returns the cached archive for digest, or false if it is not cached.
+func (c *archiveCache) get(digest string) ([]byte, bool) {
+	if digest == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.archivePath(digest))
+	if err != nil {
+		return nil, false
+	}
+	// Guard against truncated or tampered entries.
+	if digestOf(data) != digest {
+		return nil, false
+	}
+	return data, true
+}
+
+// put stores data under its own digest and returns that digest.
+func (c *archiveCache) put(data []byte) (string, error) {
+	digest := digestOf(data)
+	if err := writeFileAtomic(c.archivePath(digest), data); err != nil {
+		return "", fmt.Errorf("caching archive: %w", err)
+	}
+	return digest, nil
+}
+
+// lookupRef returns the digest previously recorded for key.
+func (c *archiveCache) lookupRef(key string) string {
+	data, err := os.ReadFile(c.refPath(key))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// recordRef remembers that key resolved to digest.
+func (c *archiveCache) recordRef(key, digest string) error {
+	return writeFileAtomic(c.refPath(key), []byte(digest+"\n"))
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	//nolint:gosec // G301: cache directory is shared by the service user only
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,294 @@
+// Package chartversion checks that a chart's Chart.yaml version follows SemVer
+// and is bumped enough for what changed in the chart, so the release pipeline
+// doesn't reject it later.
+package chartversion
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/ignore"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// maxListedFiles caps how many changed files a reason names.
+const maxListedFiles = 3
+
+// Adapter implements ports.ChartVersionPort. Any change to the files that go
+// into the released chart needs a patch bump; new values in
+// values.schema.json need a minor bump, and breaking schema changes a major
+// one (a minor one before 1.0.0, where SemVer allows breaking changes).
+type Adapter struct {
+	excludeDirs []string // Chart subdirectories that aren't part of the release
+}
+
+// New creates a version checker that ignores changes under excludeDirs,
+// chart subdirectories such as env/ that live next to the chart but describe
+// its deployments rather than the chart itself.
+func New(excludeDirs ...string) *Adapter {
+	return &Adapter{excludeDirs: excludeDirs}
+}
+
+// CheckVersion compares the version in headDir's Chart.yaml with the one in
+// baseDir's (baseDir is "" for a new chart, whose version only has to be
+// valid) and with the changes between the two checkouts.
+func (a *Adapter) CheckVersion(baseDir, headDir string) (domain.VersionCheck, error) {
+	headChart, err := readChart(headDir)
+	if err != nil {
+		return domain.VersionCheck{}, fmt.Errorf("head: %w", err)
+	}
+	check := domain.VersionCheck{HeadVersion: headChart.version}
+	var baseChart chartFile
+	if baseDir != "" {
+		if baseChart, err = readChart(baseDir); err != nil {
+			return domain.VersionCheck{}, fmt.Errorf("base: %w", err)
+		}
+		check.BaseVersion = baseChart.version
+	}
+
+	head, ok := parseVersion(check.HeadVersion)
+	if !ok {
+		check.Problem = fmt.Sprintf("version %q in Chart.yaml is not valid SemVer (MAJOR.MINOR.PATCH)",
+			check.HeadVersion)
+		return check, nil
+	}
+	if baseDir == "" {
+		return check, nil
+	}
+
+	check.Required, check.Reasons, err = a.requiredBump(baseDir, headDir, baseChart, headChart)
+	if err != nil {
+		return domain.VersionCheck{}, err
+	}
+
+	// A base version that isn't SemVer predates the check; there's nothing
+	// to measure the bump against.
+	base, ok := parseVersion(check.BaseVersion)
+	if !ok {
+		return check, nil
+	}
+	check.Bump, check.Problem = versionBump(base, head)
+	if check.Problem == "" {
+		if check.Required == domain.BumpMajor && base.Major() == 0 {
+			check.Required = domain.BumpMinor
+		}
+		check.Problem = domain.CheckVersionBump(
+			check.BaseVersion, check.HeadVersion, check.Bump, check.Required, check.Reasons)
+	}
+	return check, nil
+}
+
+// parseVersion parses a strict SemVer version, as Helm requires for charts.
+func parseVersion(v string) (*semver.Version, bool) {
+	parsed, err := semver.StrictNewVersion(v)
+	return parsed, err == nil
+}
+
+// versionBump classifies the increment from base to head, returning a problem
+// if head is lower than base or doesn't reset the parts below the one it
+// increments, as SemVer requires.
+func versionBump(base, head *semver.Version) (domain.VersionBump, string) {
+	var bump domain.VersionBump
+	var expected *semver.Version
+	switch {
+	case head.LessThan(base):
+		return domain.BumpNone, fmt.Sprintf("version goes backwards from %s to %s", base, head)
+	case head.Equal(base):
+		return domain.BumpNone, ""
+	case head.Major() != base.Major():
+		bump, expected = domain.BumpMajor, semver.New(head.Major(), 0, 0, "", "")
+	case head.Minor() != base.Minor():
+		bump, expected = domain.BumpMinor, semver.New(head.Major(), head.Minor(), 0, "", "")
+	case head.Patch() != base.Patch():
+		return domain.BumpPatch, ""
+	default:
+		return domain.BumpPrerelease, ""
+	}
+
+	if head.Minor() != expected.Minor() || head.Patch() != expected.Patch() {
+		return bump, fmt.Sprintf(
+			"%s → %s doesn't follow SemVer: a %s bump resets the lower versions to 0 (expected %s)",
+			base, head, bump, expected)
+	}
+	return bump, ""
+}
+
+// requiredBump returns the smallest bump the changes between the base and
+// head checkouts warrant, and why.
+func (a *Adapter) requiredBump(
+	baseDir, headDir string,
+	baseChart, headChart chartFile,
+) (domain.VersionBump, []string, error) {
+	changed, err := a.changedFiles(baseDir, headDir)
+	if err != nil {
+		return domain.BumpNone, nil, err
+	}
+	if !reflect.DeepEqual(baseChart.metadata, headChart.metadata) {
+		changed = append(changed, "Chart.yaml")
+		slices.Sort(changed)
+	}
+	if len(changed) == 0 {
+		return domain.BumpNone, nil, nil
+	}
+
+	if slices.Contains(changed, schemaFile) {
+		bump, reasons, err := compareSchemaFiles(baseDir, headDir)
+		if err != nil {
+			return domain.BumpNone, nil, err
+		}
+		if bump > domain.BumpPatch {
+			return bump, reasons, nil
+		}
+	}
+	return domain.BumpPatch, []string{describeChangedFiles(changed)}, nil
+}
+
+// changedFiles returns the chart files, relative to the chart directory, that
+// differ between the base and head checkouts. Chart.yaml is compared
+// separately, since its version is expected to change.
+func (a *Adapter) changedFiles(baseDir, headDir string) ([]string, error) {
+	rules, err := ignoreRules(headDir)
+	if err != nil {
+		return nil, err
+	}
+	baseFiles, err := a.listFiles(baseDir, rules)
+	if err != nil {
+		return nil, fmt.Errorf("base: %w", err)
+	}
+	headFiles, err := a.listFiles(headDir, rules)
+	if err != nil {
+		return nil, fmt.Errorf("head: %w", err)
+	}
+
+	var changed []string
+	for rel := range headFiles {
+		if _, ok := baseFiles[rel]; !ok {
+			changed = append(changed, rel)
+		}
+	}
+	for rel := range baseFiles {
+		if _, ok := headFiles[rel]; !ok {
+			changed = append(changed, rel)
+			continue
+		}
+		same, err := sameContent(filepath.Join(baseDir, rel), filepath.Join(headDir, rel))
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			changed = append(changed, rel)
+		}
+	}
+	slices.Sort(changed)
+	return changed, nil
+}
+
+// listFiles returns the set of files in the chart at dir that go into its
+// release, relative to dir and slash-separated.
+func (a *Adapter) listFiles(dir string, rules *ignore.Rules) (map[string]struct{}, error) {
+	files := make(map[string]struct{})
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if (d.IsDir() && slices.Contains(a.excludeDirs, rel)) || rules.Ignore(rel, info) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && rel != "Chart.yaml" {
+			files[rel] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing chart files: %w", err)
+	}
+	return files, nil
+}
+
+// ignoreRules reads the chart's .helmignore, whose files are left out of the
+// release. Returns helm's defaults when the chart has none.
+func ignoreRules(chartDir string) (*ignore.Rules, error) {
+	rules, err := ignore.ParseFile(filepath.Join(chartDir, ignore.HelmIgnore))
+	if errors.Is(err, fs.ErrNotExist) {
+		rules = ignore.Empty()
+	} else if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ignore.HelmIgnore, err)
+	}
+	rules.AddDefaults()
+	return rules, nil
+}
+
+func sameContent(basePath, headPath string) (bool, error) {
+	base, err := os.ReadFile(basePath) //nolint:gosec // G304: path is within our own temp checkout
+	if err != nil {
+		return false, fmt.Errorf("reading chart file: %w", err)
+	}
+	head, err := os.ReadFile(headPath) //nolint:gosec // G304: path is within our own temp checkout
+	if err != nil {
+		return false, fmt.Errorf("reading chart file: %w", err)
+	}
+	return bytes.Equal(base, head), nil
+}
+
+// describeChangedFiles names the first few changed files, e.g.
+// "templates/deployment.yaml, values.yaml and 2 more changed".
+func describeChangedFiles(files []string) string {
+	if len(files) <= maxListedFiles {
+		return strings.Join(files, ", ") + " changed"
+	}
+	return fmt.Sprintf("%s and %d more changed",
+		strings.Join(files[:maxListedFiles], ", "), len(files)-maxListedFiles)
+}
+
+// chartFile is a parsed Chart.yaml.
+type chartFile struct {
+	version  string         // Kept verbatim, so "1.0" isn't read as a number
+	metadata map[string]any // Every field but version
+}
+
+// readChart parses the Chart.yaml in chartDir.
+func readChart(chartDir string) (chartFile, error) {
+	//nolint:gosec // G304: chartDir is our own temp checkout
+	data, err := os.ReadFile(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		return chartFile{}, fmt.Errorf("reading Chart.yaml: %w", err)
+	}
+	var version struct {
+		Version string `yaml:"version"`
+	}
+	var metadata map[string]any
+	if err := yaml.Unmarshal(data, &version); err != nil {
+		return chartFile{}, fmt.Errorf("parsing Chart.yaml: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return chartFile{}, fmt.Errorf("parsing Chart.yaml: %w", err)
+	}
+	delete(metadata, "version")
+	return chartFile{version: version.Version, metadata: metadata}, nil
+}
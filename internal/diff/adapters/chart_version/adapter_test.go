@@ -0,0 +1,372 @@
+package chartversion
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const (
+	deployment = "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\n"
+	schemaV1   = `{
+  "type": "object",
+  "properties": {
+    "image": {
+      "type": "object",
+      "properties": {"repository": {"type": "string"}, "tag": {"type": "string"}}
+    },
+    "replicas": {"type": "integer"},
+    "logLevel": {"type": "string", "enum": ["debug", "info"]}
+  }
+}`
+)
+
+// writeChart creates a chart directory with a Chart.yaml at version plus
+// files (path relative to the chart -> content).
+func writeChart(t *testing.T, version string, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	all := map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: " + version + "\n",
+		"values.yaml":               "replicas: 1\n",
+		"templates/deployment.yaml": deployment,
+	}
+	for path, content := range files {
+		all[path] = content
+	}
+	for path, content := range all {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestAdapter_CheckVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		baseVersion  string
+		baseFiles    map[string]string
+		headVersion  string
+		headFiles    map[string]string
+		wantBump     domain.VersionBump
+		wantRequired domain.VersionBump
+		wantProblem  string // substring; "" for none
+	}{
+		{
+			name:        "nothing changed",
+			baseVersion: "1.2.3",
+			headVersion: "1.2.3",
+		},
+		{
+			name:         "template changed without a bump",
+			baseVersion:  "1.2.3",
+			headVersion:  "1.2.3",
+			headFiles:    map[string]string{"templates/deployment.yaml": deployment + "  labels: {}\n"},
+			wantRequired: domain.BumpPatch,
+			wantProblem: "chart content changed but version 1.2.3 was not bumped; a patch bump is needed: " +
+				"templates/deployment.yaml changed",
+		},
+		{
+			name:         "default values changed with a patch bump",
+			baseVersion:  "1.2.3",
+			headVersion:  "1.2.4",
+			headFiles:    map[string]string{"values.yaml": "replicas: 2\n"},
+			wantBump:     domain.BumpPatch,
+			wantRequired: domain.BumpPatch,
+		},
+		{
+			name:         "template added and removed",
+			baseVersion:  "1.2.3",
+			baseFiles:    map[string]string{"templates/service.yaml": "kind: Service\n"},
+			headVersion:  "1.2.3",
+			headFiles:    map[string]string{"templates/ingress.yaml": "kind: Ingress\n"},
+			wantRequired: domain.BumpPatch,
+			wantProblem:  "templates/ingress.yaml, templates/service.yaml changed",
+		},
+		{
+			name:         "many files changed",
+			baseVersion:  "1.2.3",
+			headVersion:  "1.2.3",
+			headFiles:    map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c", "d.txt": "d"},
+			wantRequired: domain.BumpPatch,
+			wantProblem:  "a.txt, b.txt, c.txt and 1 more changed",
+		},
+		{
+			name:        "Chart.yaml metadata changed",
+			baseVersion: "1.2.3",
+			headVersion: "1.2.3",
+			headFiles: map[string]string{
+				"Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.2.3\nappVersion: 2\n",
+			},
+			wantRequired: domain.BumpPatch,
+			wantProblem:  "Chart.yaml changed",
+		},
+		{
+			name:        "environment overrides aren't part of the chart",
+			baseVersion: "1.2.3",
+			baseFiles:   map[string]string{"env/prod-values.yaml": "replicas: 3\n"},
+			headVersion: "1.2.3",
+			headFiles: map[string]string{
+				"env/prod-values.yaml": "replicas: 5\n",
+				"env/dev-values.yaml":  "replicas: 1\n",
+			},
+		},
+		{
+			name:        "helmignored files aren't part of the chart",
+			baseVersion: "1.2.3",
+			baseFiles:   map[string]string{".helmignore": "*.md\n", "README.md": "old"},
+			headVersion: "1.2.3",
+			headFiles:   map[string]string{".helmignore": "*.md\n", "README.md": "new"},
+		},
+		{
+			name:        "schema property removed with a patch bump",
+			baseVersion: "1.2.3",
+			baseFiles:   map[string]string{schemaFile: schemaV1},
+			headVersion: "1.2.4",
+			headFiles: map[string]string{
+				schemaFile: strings.Replace(schemaV1, `, "tag": {"type": "string"}`, "", 1),
+			},
+			wantBump:     domain.BumpPatch,
+			wantRequired: domain.BumpMajor,
+			wantProblem: "1.2.3 → 1.2.4 is a patch bump, but the changes need a major bump: " +
+				"values.schema.json: image.tag removed",
+		},
+		{
+			name:         "schema breaking change with a major bump",
+			baseVersion:  "1.2.3",
+			baseFiles:    map[string]string{schemaFile: schemaV1},
+			headVersion:  "2.0.0",
+			headFiles:    map[string]string{schemaFile: strings.Replace(schemaV1, `"debug", `, "", 1)},
+			wantBump:     domain.BumpMajor,
+			wantRequired: domain.BumpMajor,
+		},
+		{
+			name:         "schema breaking change before 1.0.0 needs a minor bump",
+			baseVersion:  "0.4.1",
+			baseFiles:    map[string]string{schemaFile: schemaV1},
+			headVersion:  "0.5.0",
+			headFiles:    map[string]string{schemaFile: strings.Replace(schemaV1, `"integer"`, `"string"`, 1)},
+			wantBump:     domain.BumpMinor,
+			wantRequired: domain.BumpMinor,
+		},
+		{
+			name:        "schema property added with a patch bump",
+			baseVersion: "1.2.3",
+			baseFiles:   map[string]string{schemaFile: schemaV1},
+			headVersion: "1.2.4",
+			headFiles: map[string]string{
+				schemaFile: strings.Replace(schemaV1, `"replicas"`, `"port": {}, "replicas"`, 1),
+			},
+			wantBump:     domain.BumpPatch,
+			wantRequired: domain.BumpMinor,
+			wantProblem:  "need a minor bump: values.schema.json: port added",
+		},
+		{
+			name:        "schema description changed",
+			baseVersion: "1.2.3",
+			baseFiles:   map[string]string{schemaFile: schemaV1},
+			headVersion: "1.2.4",
+			headFiles: map[string]string{
+				schemaFile: strings.Replace(schemaV1, `"type": "object",`, `"title": "x",`, 1),
+			},
+			wantBump:     domain.BumpPatch,
+			wantRequired: domain.BumpPatch,
+		},
+		{
+			name:        "head version isn't SemVer",
+			baseVersion: "1.2.3",
+			headVersion: "1.3",
+			wantProblem: `version "1.3" in Chart.yaml is not valid SemVer`,
+		},
+		{
+			name:        "version goes backwards",
+			baseVersion: "1.2.3",
+			headVersion: "1.2.2",
+			wantProblem: "version goes backwards from 1.2.3 to 1.2.2",
+		},
+		{
+			name:        "minor bump that doesn't reset the patch version",
+			baseVersion: "1.2.3",
+			headVersion: "1.3.1",
+			wantBump:    domain.BumpMinor,
+			wantProblem: "a minor bump resets the lower versions to 0 (expected 1.3.0)",
+		},
+		{
+			name:         "pre-release bump",
+			baseVersion:  "2.0.0-rc.1",
+			headVersion:  "2.0.0-rc.2",
+			headFiles:    map[string]string{"values.yaml": "replicas: 2\n"},
+			wantBump:     domain.BumpPrerelease,
+			wantRequired: domain.BumpPatch,
+		},
+	}
+
+	a := New("env", "policies")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseDir := writeChart(t, tt.baseVersion, tt.baseFiles)
+			headDir := writeChart(t, tt.headVersion, tt.headFiles)
+
+			got, err := a.CheckVersion(baseDir, headDir)
+			if err != nil {
+				t.Fatalf("CheckVersion() error = %v", err)
+			}
+			if got.BaseVersion != tt.baseVersion || got.HeadVersion != tt.headVersion {
+				t.Errorf("versions = %q → %q, want %q → %q",
+					got.BaseVersion, got.HeadVersion, tt.baseVersion, tt.headVersion)
+			}
+			if got.Bump != tt.wantBump {
+				t.Errorf("Bump = %s, want %s", got.Bump, tt.wantBump)
+			}
+			if got.Required != tt.wantRequired {
+				t.Errorf("Required = %s, want %s (reasons: %v)", got.Required, tt.wantRequired, got.Reasons)
+			}
+			if tt.wantProblem == "" && got.Failed() {
+				t.Errorf("Problem = %q, want none", got.Problem)
+			}
+			if !strings.Contains(got.Problem, tt.wantProblem) {
+				t.Errorf("Problem = %q, want it to contain %q", got.Problem, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestAdapter_CheckVersion_NewChart(t *testing.T) {
+	a := New()
+	got, err := a.CheckVersion("", writeChart(t, "0.1.0", nil))
+	if err != nil {
+		t.Fatalf("CheckVersion() error = %v", err)
+	}
+	want := domain.VersionCheck{HeadVersion: "0.1.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckVersion() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAdapter_CheckVersion_UnreadableChart(t *testing.T) {
+	if _, err := New().CheckVersion("", t.TempDir()); err == nil {
+		t.Error("CheckVersion() expected an error for a checkout without Chart.yaml")
+	}
+}
+
+func TestCompareSchemaFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string // "" for no schema
+		head        string // "" for no schema
+		wantBump    domain.VersionBump
+		wantReasons []string
+	}{
+		{
+			name:     "schema removed",
+			base:     schemaV1,
+			wantBump: domain.BumpPatch,
+		},
+		{
+			name:        "schema added",
+			head:        `{"properties": {"replicas": {"type": "integer"}}}`,
+			wantBump:    domain.BumpMinor,
+			wantReasons: []string{"values.schema.json: replicas added"},
+		},
+		{
+			name:        "schema added with required values",
+			head:        `{"required": ["replicas"], "properties": {"replicas": {"type": "integer"}}}`,
+			wantBump:    domain.BumpMajor,
+			wantReasons: []string{"values.schema.json: replicas is now required"},
+		},
+		{
+			name:     "new property with its own required fields",
+			base:     `{"properties": {}}`,
+			head:     `{"properties": {"ingress": {"required": ["host"], "properties": {"host": {}}}}}`,
+			wantBump: domain.BumpMinor,
+			wantReasons: []string{
+				"values.schema.json: ingress added",
+			},
+		},
+		{
+			name:     "type narrowed and widened",
+			base:     `{"properties": {"a": {"type": ["string", "integer"]}, "b": {"type": "integer"}, "c": {}}}`,
+			head:     `{"properties": {"a": {"type": "string"}, "b": {"type": "number"}, "c": {"type": "boolean"}}}`,
+			wantBump: domain.BumpMajor,
+			wantReasons: []string{
+				"values.schema.json: a no longer accepts type integer",
+				"values.schema.json: c now must be boolean",
+			},
+		},
+		{
+			name:     "enum values removed, added and introduced",
+			base:     `{"properties": {"a": {"enum": ["x", "y"]}, "b": {"type": "string"}}}`,
+			head:     `{"properties": {"a": {"enum": ["x", "z"]}, "b": {"type": "string", "enum": ["on"]}}}`,
+			wantBump: domain.BumpMajor,
+			wantReasons: []string{
+				"values.schema.json: a no longer allows y",
+				"values.schema.json: b is now limited to a set of values",
+			},
+		},
+		{
+			name:        "enum value added",
+			base:        `{"properties": {"a": {"enum": ["x"]}}}`,
+			head:        `{"properties": {"a": {"enum": ["x", "y"]}}}`,
+			wantBump:    domain.BumpMinor,
+			wantReasons: []string{"values.schema.json: a now allows y"},
+		},
+		{
+			name:        "undeclared values rejected",
+			base:        `{"properties": {"env": {"type": "object"}}}`,
+			head:        `{"properties": {"env": {"type": "object", "additionalProperties": false}}}`,
+			wantBump:    domain.BumpMajor,
+			wantReasons: []string{"values.schema.json: env no longer allows undeclared values"},
+		},
+		{
+			name:        "array items",
+			base:        `{"properties": {"hosts": {"items": {"properties": {"name": {}, "port": {}}}}}}`,
+			head:        `{"properties": {"hosts": {"items": {"properties": {"name": {}}}}}}`,
+			wantBump:    domain.BumpMajor,
+			wantReasons: []string{"values.schema.json: hosts[].port removed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseDir, headDir := t.TempDir(), t.TempDir()
+			for dir, schema := range map[string]string{baseDir: tt.base, headDir: tt.head} {
+				if schema == "" {
+					continue
+				}
+				if err := os.WriteFile(filepath.Join(dir, schemaFile), []byte(schema), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			bump, reasons, err := compareSchemaFiles(baseDir, headDir)
+			if err != nil {
+				t.Fatalf("compareSchemaFiles() error = %v", err)
+			}
+			if bump != tt.wantBump {
+				t.Errorf("bump = %s, want %s", bump, tt.wantBump)
+			}
+			if !reflect.DeepEqual(reasons, tt.wantReasons) {
+				t.Errorf("reasons = %q, want %q", reasons, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestCompareSchemaFiles_Invalid(t *testing.T) {
+	headDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(headDir, schemaFile), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := compareSchemaFiles(t.TempDir(), headDir); err == nil {
+		t.Error("compareSchemaFiles() expected an error for an invalid schema")
+	}
+}
@@ -0,0 +1,225 @@
+package chartversion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// schemaFile is the JSON Schema Helm validates a chart's values against.
+const schemaFile = "values.schema.json"
+
+// compareSchemaFiles returns the bump the change to values.schema.json
+// between the base and head checkouts warrants: major if values that were
+// valid may now be rejected, minor if values were added, patch otherwise.
+// A schema that was removed accepts any values, so it needs only a patch.
+func compareSchemaFiles(baseDir, headDir string) (domain.VersionBump, []string, error) {
+	base, _, err := readSchema(baseDir)
+	if err != nil {
+		return domain.BumpNone, nil, fmt.Errorf("base: %w", err)
+	}
+	head, found, err := readSchema(headDir)
+	if err != nil {
+		return domain.BumpNone, nil, fmt.Errorf("head: %w", err)
+	}
+	if !found {
+		return domain.BumpPatch, nil, nil
+	}
+
+	// A new schema is compared with an empty one, which accepts any values
+	var d schemaDiff
+	d.compare(base, head, "")
+	switch {
+	case len(d.breaking) > 0:
+		return domain.BumpMajor, d.breaking, nil
+	case len(d.added) > 0:
+		return domain.BumpMinor, d.added, nil
+	default:
+		return domain.BumpPatch, nil, nil
+	}
+}
+
+// readSchema parses the values schema in chartDir. Returns an empty schema
+// and false if the chart has none.
+func readSchema(chartDir string) (map[string]any, bool, error) {
+	//nolint:gosec // G304: chartDir is our own temp checkout
+	data, err := os.ReadFile(filepath.Join(chartDir, schemaFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]any{}, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("reading %s: %w", schemaFile, err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, false, fmt.Errorf("parsing %s: %w", schemaFile, err)
+	}
+	return schema, true, nil
+}
+
+// schemaDiff collects the differences between two values schemas that
+// matter to chart users, as reasons for the bump they need.
+type schemaDiff struct {
+	breaking []string // Values that were valid may now be rejected
+	added    []string // New values are accepted
+}
+
+// compare walks base and head side by side from the schema node at path
+// (dot-separated; "" for the root). Properties only head has are new values
+// and aren't walked: nothing could have used them before.
+func (d *schemaDiff) compare(base, head map[string]any, path string) {
+	d.compareRequired(base, head, path)
+	if path != "" {
+		d.compareTypes(base, head, path) // Values are always an object
+	}
+	d.compareEnum(base, head, path)
+
+	if additionalAllowed(base) && !additionalAllowed(head) {
+		d.breaking = append(d.breaking, schemaReason(path, "no longer allows undeclared values"))
+	}
+	d.compareProperties(base, head, path)
+
+	baseItems, baseOK := mapField(base, "items")
+	headItems, headOK := mapField(head, "items")
+	if baseOK && headOK {
+		d.compare(baseItems, headItems, path+"[]")
+	}
+}
+
+// compareProperties flags properties head removes, records those it adds,
+// and walks those both have.
+func (d *schemaDiff) compareProperties(base, head map[string]any, path string) {
+	baseProps, _ := mapField(base, "properties")
+	headProps, _ := mapField(head, "properties")
+	for _, name := range sortedKeys(baseProps) {
+		if _, ok := headProps[name]; !ok {
+			d.breaking = append(d.breaking, schemaReason(join(path, name), "removed"))
+		}
+	}
+	for _, name := range sortedKeys(headProps) {
+		if _, ok := baseProps[name]; !ok {
+			d.added = append(d.added, schemaReason(join(path, name), "added"))
+			continue
+		}
+		baseProp, baseOK := baseProps[name].(map[string]any)
+		headProp, headOK := headProps[name].(map[string]any)
+		if baseOK && headOK {
+			d.compare(baseProp, headProp, join(path, name))
+		}
+	}
+}
+
+// compareRequired flags properties head requires that base didn't.
+func (d *schemaDiff) compareRequired(base, head map[string]any, path string) {
+	baseRequired := stringList(base, "required")
+	for _, name := range stringList(head, "required") {
+		if !slices.Contains(baseRequired, name) {
+			d.breaking = append(d.breaking, schemaReason(join(path, name), "is now required"))
+		}
+	}
+}
+
+// compareTypes flags types head no longer accepts. An integer is also a
+// number, so widening one to the other is fine.
+func (d *schemaDiff) compareTypes(base, head map[string]any, path string) {
+	headTypes := stringList(head, "type")
+	if len(headTypes) == 0 {
+		return
+	}
+	baseTypes := stringList(base, "type")
+	if len(baseTypes) == 0 {
+		d.breaking = append(d.breaking, schemaReason(path, "now must be "+strings.Join(headTypes, " or ")))
+		return
+	}
+	for _, t := range baseTypes {
+		if !slices.Contains(headTypes, t) && (t != "integer" || !slices.Contains(headTypes, "number")) {
+			d.breaking = append(d.breaking, schemaReason(path, "no longer accepts type "+t))
+		}
+	}
+}
+
+// compareEnum flags allowed values head drops, and records those it adds.
+func (d *schemaDiff) compareEnum(base, head map[string]any, path string) {
+	headEnum, ok := head["enum"].([]any)
+	if !ok {
+		return
+	}
+	baseEnum, hadEnum := base["enum"].([]any)
+	if !hadEnum {
+		d.breaking = append(d.breaking, schemaReason(path, "is now limited to a set of values"))
+		return
+	}
+	for _, v := range baseEnum {
+		if !slices.ContainsFunc(headEnum, func(h any) bool { return fmt.Sprint(h) == fmt.Sprint(v) }) {
+			d.breaking = append(d.breaking, schemaReason(path, fmt.Sprintf("no longer allows %v", v)))
+		}
+	}
+	for _, v := range headEnum {
+		if !slices.ContainsFunc(baseEnum, func(b any) bool { return fmt.Sprint(b) == fmt.Sprint(v) }) {
+			d.added = append(d.added, schemaReason(path, fmt.Sprintf("now allows %v", v)))
+		}
+	}
+}
+
+// schemaReason formats a reason for the schema node at path, e.g.
+// "values.schema.json: image.tag removed".
+func schemaReason(path, what string) string {
+	if path == "" {
+		path = "values"
+	}
+	return fmt.Sprintf("%s: %s %s", schemaFile, path, what)
+}
+
+// additionalAllowed reports whether a schema node accepts properties it
+// doesn't declare; JSON Schema's default is yes.
+func additionalAllowed(node map[string]any) bool {
+	allowed, ok := node["additionalProperties"].(bool)
+	return !ok || allowed
+}
+
+func mapField(node map[string]any, key string) (map[string]any, bool) {
+	m, ok := node[key].(map[string]any)
+	return m, ok
+}
+
+// stringList reads a keyword that is a string or a list of strings, such as
+// "type" or "required".
+func stringList(node map[string]any, key string) []string {
+	switch v := node[key].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -17,14 +17,20 @@ import (
 	"github.com/nathantilsley/chart-val/internal/platform/gitrepo"
 )
 
-// ErrNotAnApplication is returned when a manifest is not an Argo Application.
-var ErrNotAnApplication = errors.New("not an Application")
+var (
+	// ErrNotAnApplication is returned when a manifest is not an Argo Application.
+	ErrNotAnApplication = errors.New("not an Application")
+	// ErrNotAnApplicationSet is returned when a manifest is not an Argo ApplicationSet.
+	ErrNotAnApplicationSet = errors.New("not an ApplicationSet")
+)
 
 // Adapter implements ports.EnvironmentConfigPort by reading Argo CD Application
 // manifests from a locally cloned Git repository. It scans the entire repo
 // for Application files and extracts environment names from directory paths.
+// ApplicationSets are expanded into the Applications they generate.
 type Adapter struct {
 	repoPath      string // Local filesystem path of the cloned repo
+	repoURL       string // URL of the cloned repo, which git generators must read
 	folderPattern string // Folder structure pattern (e.g., "{chartName}/{envName}")
 	chartDir      string // Top-level chart directory (e.g., "charts")
 
@@ -40,6 +46,39 @@ type AppData struct {
 	Environment string   // Extracted from file path (e.g., "dev", "staging", "prod")
 	ValueFiles  []string // From spec.source.helm.valueFiles
 	RepoURL     string   // From spec.source.repoURL
+	ReleaseName string   // From spec.source.helm.releaseName, defaulting to metadata.name
+	Namespace   string   // From spec.destination.namespace
+	KubeVersion string   // From spec.source.helm.kubeVersion, else the destination cluster's Secret
+	APIVersions []string // From spec.source.helm.apiVersions
+	Values      string   // From spec.source.helm.valuesObject, or values when unset
+
+	// From spec.source.helm.parameters and fileParameters
+	Parameters []domain.HelmParameter
+
+	// From spec.sources entries with a ref, for "$ref/..." value files
+	ValuesRefs []domain.ValuesRef
+
+	DestinationServer string // From spec.destination.server
+	DestinationName   string // From spec.destination.name
+}
+
+// argoHelmSource is spec.source.helm of an Argo CD Application.
+type argoHelmSource struct {
+	ValueFiles   []string       `yaml:"valueFiles"`
+	ReleaseName  string         `yaml:"releaseName"`
+	KubeVersion  string         `yaml:"kubeVersion"`
+	APIVersions  []string       `yaml:"apiVersions"`
+	Values       string         `yaml:"values"`
+	ValuesObject map[string]any `yaml:"valuesObject"`
+	Parameters   []struct {
+		Name        string `yaml:"name"`
+		Value       string `yaml:"value"`
+		ForceString bool   `yaml:"forceString"`
+	} `yaml:"parameters"`
+	FileParameters []struct {
+		Name string `yaml:"name"`
+		Path string `yaml:"path"`
+	} `yaml:"fileParameters"`
 }
 
 // New creates a new Argo apps adapter. It registers an OnSync callback with
@@ -56,6 +95,7 @@ func New(
 
 	a := &Adapter{
 		repoPath:      repo.Path(),
+		repoURL:       repo.URL(),
 		folderPattern: folderPattern,
 		chartDir:      chartDir,
 		index:         make(map[string][]AppData),
@@ -74,6 +114,7 @@ func New(
 // rebuildIndex scans the entire repo for Application manifests and builds an index.
 func (a *Adapter) rebuildIndex() error {
 	index := make(map[string][]AppData)
+	var clusters []clusterData
 	appCount := 0
 
 	// Walk the entire repository looking for YAML files
@@ -92,10 +133,14 @@ func (a *Adapter) rebuildIndex() error {
 			return nil
 		}
 
-		// Process the YAML file as a potential Argo Application
-		app, shouldIndex := a.processApplicationFile(path)
-		if shouldIndex {
-			index[app.ChartName] = append(index[app.ChartName], *app)
+		if cluster, ok := parseClusterSecret(path); ok {
+			clusters = append(clusters, cluster)
+			return nil
+		}
+
+		// Process the YAML file as a potential Argo Application or ApplicationSet
+		for _, app := range a.processApplicationFile(path) {
+			index[app.ChartName] = append(index[app.ChartName], app)
 			appCount++
 		}
 
@@ -105,12 +150,13 @@ func (a *Adapter) rebuildIndex() error {
 	if err != nil {
 		return err
 	}
+	applyClusterVersions(index, clusters)
 
 	a.mu.Lock()
 	a.index = index
 	a.mu.Unlock()
 
-	a.logger.Info("index rebuilt", "totalApps", appCount, "uniqueCharts", len(index))
+	a.logger.Info("index rebuilt", "totalApps", appCount, "uniqueCharts", len(index), "clusters", len(clusters))
 
 	return nil
 }
@@ -134,37 +180,67 @@ func isYAMLFile(path string) bool {
 	return ext == ".yaml" || ext == ".yml"
 }
 
-// processApplicationFile attempts to parse and index an Argo Application manifest.
-// Returns the parsed app and whether it should be indexed.
-func (a *Adapter) processApplicationFile(path string) (*AppData, bool) {
+// processApplicationFile attempts to parse an Argo Application manifest, or
+// expand an ApplicationSet. Returns the apps that should be indexed.
+func (a *Adapter) processApplicationFile(path string) []AppData {
 	// Try to parse as Argo Application
 	app, err := a.parseArgoApp(path)
 	if errors.Is(err, ErrNotAnApplication) {
-		// Not an Application manifest - skip silently
-		return nil, false
+		// Not an Application manifest - it may still be an ApplicationSet
+		return a.processApplicationSetFile(path)
 	}
 	if err != nil {
 		// Invalid YAML or other error - log and skip
 		a.logger.Warn("failed to parse file as argo application", "path", path, "error", err)
-		return nil, false
+		return nil
 	}
 
 	// Extract chart name and environment from folder structure
 	chartName, env, err := a.extractFromFolderStructure(path)
 	if err != nil {
 		a.logger.Warn("failed to extract chart/env from path", "path", path, "error", err)
-		return nil, false
+		return nil
 	}
 
 	app.ChartName = chartName
 	app.Environment = env
 
-	return app, true
+	return []AppData{*app}
+}
+
+// argoSource is a single entry of spec.source or spec.sources.
+type argoSource struct {
+	RepoURL        string         `yaml:"repoURL"`
+	Path           string         `yaml:"path"`  // For Git-based charts
+	Chart          string         `yaml:"chart"` // For OCI charts
+	TargetRevision string         `yaml:"targetRevision"`
+	Ref            string         `yaml:"ref"` // Multi-source: name for $ref value files
+	Helm           argoHelmSource `yaml:"helm"`
+}
+
+// applicationManifest is the subset of an Argo CD Application we read. It is
+// also the shape of an ApplicationSet's spec.template.
+type applicationManifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Source      argoSource   `yaml:"source"`
+		Sources     []argoSource `yaml:"sources"`
+		Destination struct {
+			Server    string `yaml:"server"`
+			Name      string `yaml:"name"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"destination"`
+	} `yaml:"spec"`
 }
 
 // parseArgoApp parses an Argo CD Application manifest from a file.
 // Returns minimal data needed for chart validation.
-// Supports both OCI charts (spec.source.chart) and Git-based charts (spec.source.path).
+// Supports both OCI charts (spec.source.chart) and Git-based charts (spec.source.path),
+// as well as multi-source Applications (spec.sources) with $ref value files.
 func (a *Adapter) parseArgoApp(path string) (*AppData, error) {
 	//nolint:gosec // G304: path is from filepath.Walk, not user input
 	data, err := os.ReadFile(path)
@@ -172,21 +248,7 @@ func (a *Adapter) parseArgoApp(path string) (*AppData, error) {
 		return nil, err
 	}
 
-	var manifest struct {
-		APIVersion string `yaml:"apiVersion"`
-		Kind       string `yaml:"kind"`
-		Spec       struct {
-			Source struct {
-				RepoURL string `yaml:"repoURL"`
-				Path    string `yaml:"path"`  // For Git-based charts
-				Chart   string `yaml:"chart"` // For OCI charts
-				Helm    struct {
-					ValueFiles []string `yaml:"valueFiles"`
-				} `yaml:"helm"`
-			} `yaml:"source"`
-		} `yaml:"spec"`
-	}
-
+	var manifest applicationManifest
 	if err := yaml.Unmarshal(data, &manifest); err != nil {
 		return nil, err
 	}
@@ -196,27 +258,116 @@ func (a *Adapter) parseArgoApp(path string) (*AppData, error) {
 		return nil, ErrNotAnApplication
 	}
 
-	if manifest.Spec.Source.RepoURL == "" {
-		return nil, errors.New("missing required field: repoURL")
+	return manifest.appData()
+}
+
+// appData extracts the fields needed for chart validation.
+func (m *applicationManifest) appData() (*AppData, error) {
+	source, refs, err := chartSource(m.Spec.Source, m.Spec.Sources)
+	if err != nil {
+		return nil, err
 	}
 
 	// Determine chart name/path: prefer OCI chart, fall back to path
-	chartIdentifier := manifest.Spec.Source.Chart
+	chartIdentifier := source.Chart
 	if chartIdentifier == "" {
-		chartIdentifier = manifest.Spec.Source.Path
+		chartIdentifier = source.Path
 	}
 
-	if chartIdentifier == "" {
-		return nil, errors.New("missing both spec.source.chart and spec.source.path")
+	// Argo CD uses the Application name as the release name unless overridden
+	helm := source.Helm
+	releaseName := helm.ReleaseName
+	if releaseName == "" {
+		releaseName = m.Metadata.Name
+	}
+
+	inlineValues, err := helm.inlineValues()
+	if err != nil {
+		return nil, err
 	}
 
 	return &AppData{
-		ChartPath:  chartIdentifier, // Will be parsed as ChartName during indexing
-		ValueFiles: manifest.Spec.Source.Helm.ValueFiles,
-		RepoURL:    manifest.Spec.Source.RepoURL,
+		ChartPath:   chartIdentifier, // Will be parsed as ChartName during indexing
+		ValueFiles:  helm.ValueFiles,
+		RepoURL:     source.RepoURL,
+		ReleaseName: releaseName,
+		Namespace:   m.Spec.Destination.Namespace,
+		KubeVersion: helm.KubeVersion,
+		APIVersions: helm.APIVersions,
+		Values:      inlineValues,
+		Parameters:  helm.parameters(),
+		ValuesRefs:  refs,
+
+		DestinationServer: m.Spec.Destination.Server,
+		DestinationName:   m.Spec.Destination.Name,
 	}, nil
 }
 
+// chartSource picks the source that holds the chart. For multi-source
+// Applications that is the first source with a chart or path, preferring
+// sources without a ref; sources with a ref are returned as ValuesRefs for
+// resolving $ref value files.
+func chartSource(single argoSource, multi []argoSource) (argoSource, []domain.ValuesRef, error) {
+	if len(multi) == 0 {
+		if single.RepoURL == "" {
+			return argoSource{}, nil, errors.New("missing required field: repoURL")
+		}
+		if single.Chart == "" && single.Path == "" {
+			return argoSource{}, nil, errors.New("missing both spec.source.chart and spec.source.path")
+		}
+		return single, nil, nil
+	}
+
+	var (
+		chart *argoSource
+		refs  []domain.ValuesRef
+	)
+	for i, src := range multi {
+		if src.RepoURL == "" {
+			return argoSource{}, nil, fmt.Errorf("spec.sources[%d]: missing required field: repoURL", i)
+		}
+		if src.Ref != "" {
+			refs = append(refs, domain.ValuesRef{Name: src.Ref, RepoURL: src.RepoURL, Revision: src.TargetRevision})
+		}
+		isChart := src.Chart != "" || src.Path != ""
+		if isChart && (chart == nil || chart.Ref != "" && src.Ref == "") {
+			chart = &multi[i]
+		}
+	}
+	if chart == nil {
+		return argoSource{}, nil, errors.New("no entry in spec.sources has a chart or path")
+	}
+	return *chart, refs, nil
+}
+
+// inlineValues returns the inline values YAML. Like Argo CD, valuesObject
+// takes precedence over the values string when both are set.
+func (h argoHelmSource) inlineValues() (string, error) {
+	if len(h.ValuesObject) == 0 {
+		return h.Values, nil
+	}
+	data, err := yaml.Marshal(h.ValuesObject)
+	if err != nil {
+		return "", fmt.Errorf("encoding valuesObject: %w", err)
+	}
+	return string(data), nil
+}
+
+// parameters flattens parameters and fileParameters into --set style overrides.
+func (h argoHelmSource) parameters() []domain.HelmParameter {
+	if len(h.Parameters) == 0 && len(h.FileParameters) == 0 {
+		return nil
+	}
+	params := make([]domain.HelmParameter, 0, len(h.Parameters)+len(h.FileParameters))
+	for _, p := range h.Parameters {
+		params = append(params, domain.HelmParameter{Name: p.Name, Value: p.Value, ForceString: p.ForceString})
+	}
+	for _, p := range h.FileParameters {
+		params = append(params, domain.HelmParameter{Name: p.Name, Value: p.Path, File: true})
+	}
+	return params
+}
+
 // GetEnvironmentConfig implements ports.EnvironmentConfigPort.
 // It looks up environments for the given chart name from Argo Application manifests.
 // If the chart is not found, returns empty environments (fallback will be used).
@@ -251,6 +402,15 @@ func (a *Adapter) GetEnvironmentConfig(
 		config.Environments = append(config.Environments, domain.EnvironmentConfig{
 			Name:       app.Environment,
 			ValueFiles: app.ValueFiles,
+			RenderOptions: domain.RenderOptions{
+				ReleaseName: app.ReleaseName,
+				Namespace:   app.Namespace,
+				KubeVersion: app.KubeVersion,
+				APIVersions: app.APIVersions,
+				Values:      app.Values,
+				Parameters:  app.Parameters,
+			},
+			ValuesRefs: app.ValuesRefs,
 		})
 	}
 
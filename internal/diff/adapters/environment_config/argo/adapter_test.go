@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/nathantilsley/chart-val/internal/diff/domain"
@@ -76,6 +77,75 @@ func TestParseArgoApp(t *testing.T) {
 			wantErr:     true,
 			errContains: "chart and",
 		},
+		{
+			name:    "application with render options",
+			fixture: "applications/app-render-options.yaml",
+			wantApp: &AppData{
+				ChartPath:   "charts/my-app",
+				ValueFiles:  []string{"values-prod.yaml"},
+				RepoURL:     "https://github.com/example/charts",
+				ReleaseName: "my-app",
+				Namespace:   "my-app",
+				KubeVersion: "1.29.0",
+				APIVersions: []string{"monitoring.coreos.com/v1"},
+			},
+		},
+		{
+			name:    "release name defaults to application name",
+			fixture: "applications/app-default-release-name.yaml",
+			wantApp: &AppData{
+				ChartPath:   "charts/my-app",
+				RepoURL:     "https://github.com/example/charts",
+				ReleaseName: "my-app-staging",
+				Namespace:   "my-app-staging",
+			},
+		},
+		{
+			name:    "application with inline values and parameters",
+			fixture: "applications/app-helm-parameters.yaml",
+			wantApp: &AppData{
+				ChartPath:   "charts/my-app",
+				ValueFiles:  []string{"values-prod.yaml"},
+				RepoURL:     "https://github.com/example/charts",
+				ReleaseName: "my-app-prod",
+				Values:      "replicaCount: 3\n",
+				Parameters: []domain.HelmParameter{
+					{Name: "image.tag", Value: "1.2.3"},
+					{Name: "build.number", Value: "0012", ForceString: true},
+					{Name: "config", Value: "files/config.json", File: true},
+				},
+			},
+		},
+		{
+			name:    "valuesObject takes precedence over values",
+			fixture: "applications/app-values-object.yaml",
+			wantApp: &AppData{
+				ChartPath:   "charts/my-app",
+				RepoURL:     "https://github.com/example/charts",
+				ReleaseName: "my-app-dev",
+				Values:      "replicaCount: 1\n",
+			},
+		},
+		{
+			name:    "multi-source application with values ref",
+			fixture: "applications/app-multi-source.yaml",
+			wantApp: &AppData{
+				ChartPath:   "charts/my-app",
+				ValueFiles:  []string{"values-prod.yaml", "$values/envs/prod/my-app.yaml"},
+				RepoURL:     "https://github.com/example/charts",
+				ReleaseName: "my-app-prod",
+				Namespace:   "my-app",
+				ValuesRefs: []domain.ValuesRef{
+					{Name: "values", RepoURL: "https://github.com/example/gitops", Revision: "main"},
+				},
+			},
+		},
+		{
+			name:        "multi-source application without chart source",
+			fixture:     "invalid/multi-source-no-chart.yaml",
+			wantErr:     true,
+			errContains: "chart or path",
+		},
 		{
 			name:    "application without valueFiles",
 			fixture: "applications/app-no-valuefiles.yaml",
@@ -130,6 +200,27 @@ func TestParseArgoApp(t *testing.T) {
 			if !equalStringSlices(app.ValueFiles, tt.wantApp.ValueFiles) {
 				t.Errorf("ValueFiles = %v, want %v", app.ValueFiles, tt.wantApp.ValueFiles)
 			}
+			if app.ReleaseName != tt.wantApp.ReleaseName {
+				t.Errorf("ReleaseName = %q, want %q", app.ReleaseName, tt.wantApp.ReleaseName)
+			}
+			if app.Namespace != tt.wantApp.Namespace {
+				t.Errorf("Namespace = %q, want %q", app.Namespace, tt.wantApp.Namespace)
+			}
+			if app.KubeVersion != tt.wantApp.KubeVersion {
+				t.Errorf("KubeVersion = %q, want %q", app.KubeVersion, tt.wantApp.KubeVersion)
+			}
+			if !equalStringSlices(app.APIVersions, tt.wantApp.APIVersions) {
+				t.Errorf("APIVersions = %v, want %v", app.APIVersions, tt.wantApp.APIVersions)
+			}
+			if app.Values != tt.wantApp.Values {
+				t.Errorf("Values = %q, want %q", app.Values, tt.wantApp.Values)
+			}
+			if !slices.Equal(app.Parameters, tt.wantApp.Parameters) {
+				t.Errorf("Parameters = %+v, want %+v", app.Parameters, tt.wantApp.Parameters)
+			}
+			if !slices.Equal(app.ValuesRefs, tt.wantApp.ValuesRefs) {
+				t.Errorf("ValuesRefs = %+v, want %+v", app.ValuesRefs, tt.wantApp.ValuesRefs)
+			}
 		})
 	}
 }
@@ -350,6 +441,8 @@ func TestGetEnvironmentConfig(t *testing.T) {
 					Environment: "prod",
 					ValueFiles:  []string{"values-prod.yaml"},
 					RepoURL:     "https://github.com/example/charts",
+					ReleaseName: "my-app",
+					Namespace:   "my-app-prod",
 				},
 				{
 					ChartName:   "my-app",
@@ -413,9 +506,16 @@ func TestGetEnvironmentConfig(t *testing.T) {
 					envMap[env.Name] = env
 				}
 
-				if _, hasProd := envMap["prod"]; !hasProd {
+				prod, hasProd := envMap["prod"]
+				if !hasProd {
 					t.Errorf("missing prod environment")
 				}
+				if prod.RenderOptions.ReleaseName != "my-app" || prod.RenderOptions.Namespace != "my-app-prod" {
+					t.Errorf(
+						"prod RenderOptions = %+v, want release my-app in namespace my-app-prod",
+						prod.RenderOptions,
+					)
+				}
 				if _, hasDev := envMap["dev"]; !hasDev {
 					t.Errorf("missing dev environment")
 				}
@@ -0,0 +1,571 @@
+package argo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// applicationSetManifest is the subset of an Argo CD ApplicationSet we read.
+// Generators and the template stay as YAML nodes: matrix children and the
+// template are rendered with each parameter set before being decoded.
+type applicationSetManifest struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		GoTemplate        bool        `yaml:"goTemplate"`
+		GoTemplateOptions []string    `yaml:"goTemplateOptions"`
+		Generators        []yaml.Node `yaml:"generators"`
+		Template          yaml.Node   `yaml:"template"`
+	} `yaml:"spec"`
+}
+
+// appSetGenerator is a single entry of spec.generators. Exactly one field is set.
+type appSetGenerator struct {
+	List *struct {
+		Elements []map[string]any `yaml:"elements"`
+	} `yaml:"list"`
+	Git *struct {
+		RepoURL     string               `yaml:"repoURL"`
+		Revision    string               `yaml:"revision"`
+		Directories []appSetGitDirectory `yaml:"directories"`
+		Files       []struct {
+			Path string `yaml:"path"`
+		} `yaml:"files"`
+	} `yaml:"git"`
+	Matrix *struct {
+		Generators []yaml.Node `yaml:"generators"`
+	} `yaml:"matrix"`
+}
+
+// appSetGitDirectory is a path pattern of the git directories generator.
+type appSetGitDirectory struct {
+	Path    string `yaml:"path"`
+	Exclude bool   `yaml:"exclude"`
+}
+
+// appSetParams is one parameter set produced by a generator, in the nested
+// shape goTemplate uses (e.g. {"path": {"basename": "prod"}}).
+type appSetParams map[string]any
+
+// processApplicationSetFile expands an ApplicationSet into the Applications it
+// generates. Files that are not ApplicationSets are skipped silently.
+func (a *Adapter) processApplicationSetFile(path string) []AppData {
+	apps, err := a.expandApplicationSet(path)
+	if errors.Is(err, ErrNotAnApplicationSet) {
+		return nil
+	}
+	if err != nil {
+		a.logger.Warn("failed to expand argo applicationset", "path", path, "error", err)
+		return nil
+	}
+	return apps
+}
+
+// expandApplicationSet parses an ApplicationSet and renders its template once
+// per generated parameter set. Git generators are evaluated against the local
+// clone, which is where they point in the usual single GitOps repo layout.
+//
+// Generated apps are indexed by the base name of their chart path, and the
+// environment is the app name with the chart name trimmed from either end
+// (e.g. "my-app-prod" → "prod").
+func (a *Adapter) expandApplicationSet(path string) ([]AppData, error) {
+	//nolint:gosec // G304: path is from filepath.Walk, not user input
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest applicationSetManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Kind != "ApplicationSet" {
+		return nil, ErrNotAnApplicationSet
+	}
+
+	r := appSetRenderer{goTemplate: manifest.Spec.GoTemplate, options: manifest.Spec.GoTemplateOptions}
+	var paramSets []appSetParams
+	for i := range manifest.Spec.Generators {
+		params, err := a.generate(r, &manifest.Spec.Generators[i])
+		if err != nil {
+			return nil, fmt.Errorf("spec.generators[%d]: %w", i, err)
+		}
+		paramSets = append(paramSets, params...)
+	}
+
+	apps := make([]AppData, 0, len(paramSets))
+	for _, params := range paramSets {
+		app, err := r.renderApp(&manifest.Spec.Template, params)
+		if err != nil {
+			a.logger.Warn("skipping generated application",
+				"applicationSet", manifest.Metadata.Name,
+				"path", path,
+				"error", err,
+			)
+			continue
+		}
+		apps = append(apps, *app)
+	}
+	return apps, nil
+}
+
+// generate returns the parameter sets of a single generator.
+func (a *Adapter) generate(r appSetRenderer, node *yaml.Node) ([]appSetParams, error) {
+	var gen appSetGenerator
+	if err := node.Decode(&gen); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case gen.List != nil:
+		params := make([]appSetParams, 0, len(gen.List.Elements))
+		for _, element := range gen.List.Elements {
+			params = append(params, element)
+		}
+		return params, nil
+	case gen.Git != nil && !a.isScannedRepo(gen.Git.RepoURL):
+		// Only the cloned apps repository can be read
+		a.logger.Warn("skipping git generator of another repository",
+			"repoURL", gen.Git.RepoURL,
+			"revision", gen.Git.Revision,
+			"scannedRepoURL", a.repoURL,
+		)
+		return nil, nil
+	case gen.Git != nil && len(gen.Git.Directories) > 0:
+		return a.gitDirectories(gen.Git.Directories)
+	case gen.Git != nil && len(gen.Git.Files) > 0:
+		patterns := make([]string, 0, len(gen.Git.Files))
+		for _, f := range gen.Git.Files {
+			patterns = append(patterns, f.Path)
+		}
+		return a.gitFiles(patterns)
+	case gen.Matrix != nil:
+		return a.matrix(r, gen.Matrix.Generators)
+	default:
+		return nil, errors.New("unsupported generator (supported: list, git directories, git files, matrix)")
+	}
+}
+
+// isScannedRepo reports whether repoURL is the cloned apps repository,
+// comparing owner and name so https, ssh and scp-like URLs all match. Any
+// repoURL matches when the adapter's own URL is unknown.
+func (a *Adapter) isScannedRepo(repoURL string) bool {
+	if a.repoURL == "" {
+		return true
+	}
+	owner, repo, err := domain.ParseRepoURL(repoURL)
+	if err != nil {
+		return false
+	}
+	scannedOwner, scannedRepo, err := domain.ParseRepoURL(a.repoURL)
+	return err == nil && strings.EqualFold(owner, scannedOwner) && strings.EqualFold(repo, scannedRepo)
+}
+
+// matrix combines the parameter sets of exactly two child generators. Like
+// Argo CD, the second child may reference parameters of the first.
+func (a *Adapter) matrix(r appSetRenderer, children []yaml.Node) ([]appSetParams, error) {
+	if len(children) != 2 {
+		return nil, fmt.Errorf("matrix needs exactly 2 generators, got %d", len(children))
+	}
+
+	first, err := a.generate(r, &children[0])
+	if err != nil {
+		return nil, fmt.Errorf("matrix.generators[0]: %w", err)
+	}
+
+	var combined []appSetParams
+	for _, left := range first {
+		second, err := r.renderNode(&children[1], left)
+		if err != nil {
+			return nil, fmt.Errorf("matrix.generators[1]: %w", err)
+		}
+		rights, err := a.generate(r, second)
+		if err != nil {
+			return nil, fmt.Errorf("matrix.generators[1]: %w", err)
+		}
+		for _, right := range rights {
+			merged := make(appSetParams, len(left)+len(right))
+			for k, v := range left {
+				merged[k] = v
+			}
+			for k, v := range right {
+				merged[k] = v
+			}
+			combined = append(combined, merged)
+		}
+	}
+	return combined, nil
+}
+
+// gitDirectories returns one parameter set per repository directory that
+// matches an included pattern and no excluded pattern.
+func (a *Adapter) gitDirectories(patterns []appSetGitDirectory) ([]appSetParams, error) {
+	var params []appSetParams
+	err := a.walkRepo(func(rel string, isDir bool) {
+		if !isDir {
+			return
+		}
+		included := false
+		for _, p := range patterns {
+			if matched, _ := path.Match(p.Path, rel); matched {
+				if p.Exclude {
+					return
+				}
+				included = true
+			}
+		}
+		if included {
+			params = append(params, appSetParams{"path": pathParams(rel)})
+		}
+	})
+	return params, err
+}
+
+// gitFiles returns the parameter sets of every repository file matching one of
+// the patterns. A file holding a YAML/JSON object yields one set; a list of
+// objects yields one set per entry.
+func (a *Adapter) gitFiles(patterns []string) ([]appSetParams, error) {
+	matchers := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		matchers = append(matchers, globRegexp(p))
+	}
+
+	var files []string
+	err := a.walkRepo(func(rel string, isDir bool) {
+		if isDir {
+			return
+		}
+		for _, m := range matchers {
+			if m.MatchString(rel) {
+				files = append(files, rel)
+				return
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var params []appSetParams
+	for _, rel := range files {
+		fileParams, err := a.readParamsFile(rel)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", rel, err)
+		}
+		params = append(params, fileParams...)
+	}
+	return params, nil
+}
+
+func (a *Adapter) readParamsFile(rel string) ([]appSetParams, error) {
+	//nolint:gosec // G304: rel is from walking the repo, not user input
+	data, err := os.ReadFile(filepath.Join(a.repoPath, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+
+	var content any
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+
+	var objects []map[string]any
+	switch c := content.(type) {
+	case map[string]any:
+		objects = []map[string]any{c}
+	case []any:
+		for _, entry := range c {
+			obj, ok := entry.(map[string]any)
+			if !ok {
+				return nil, errors.New("list entries must be objects")
+			}
+			objects = append(objects, obj)
+		}
+	default:
+		return nil, errors.New("file must hold an object or a list of objects")
+	}
+
+	params := make([]appSetParams, 0, len(objects))
+	for _, obj := range objects {
+		p := appSetParams(obj)
+		fileName := path.Base(rel)
+		pp := pathParams(path.Dir(rel))
+		pp["filename"] = fileName
+		pp["filenameNormalized"] = normalizeName(fileName)
+		p["path"] = pp
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// walkRepo calls fn with the slash-separated repo-relative path of every file
+// and directory in the repository, skipping .git.
+func (a *Adapter) walkRepo(fn func(rel string, isDir bool)) error {
+	return filepath.WalkDir(a.repoPath, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(a.repoPath, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		fn(filepath.ToSlash(rel), d.IsDir())
+		return nil
+	})
+}
+
+// pathParams builds the "path" parameters of the git generators for dir.
+func pathParams(dir string) map[string]any {
+	segments := strings.Split(dir, "/")
+	basename := path.Base(dir)
+	return map[string]any{
+		"path":               dir,
+		"basename":           basename,
+		"basenameNormalized": normalizeName(basename),
+		"segments":           segments,
+	}
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]`)
+
+// maxNameLength is the longest DNS subdomain name Kubernetes accepts.
+const maxNameLength = 253
+
+// normalizeName makes s usable as a Kubernetes resource name, the same way
+// Argo CD derives basenameNormalized and filenameNormalized and implements
+// the normalize template function.
+func normalizeName(s string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(s), "-")
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return strings.Trim(name, "-.")
+}
+
+// globRegexp compiles a git files pattern: "*" and "?" stay within a path
+// segment, "**" matches across segments.
+func globRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// appSetRenderer substitutes generator parameters into ApplicationSet
+// templates, using either Go templates or Argo CD's default "{{param}}" syntax.
+type appSetRenderer struct {
+	goTemplate bool
+	options    []string // goTemplateOptions, e.g. "missingkey=error"
+}
+
+// renderApp renders the ApplicationSet template with params and extracts the
+// resulting Application.
+func (r appSetRenderer) renderApp(tmpl *yaml.Node, params appSetParams) (*AppData, error) {
+	rendered, err := r.renderNode(tmpl, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest applicationManifest
+	if err := rendered.Decode(&manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Metadata.Name == "" {
+		return nil, errors.New("generated application has no metadata.name")
+	}
+
+	app, err := manifest.appData()
+	if err != nil {
+		return nil, fmt.Errorf("application %s: %w", manifest.Metadata.Name, err)
+	}
+	app.ChartName = path.Base(app.ChartPath)
+	app.Environment = environmentName(manifest.Metadata.Name, app.ChartName)
+	return app, nil
+}
+
+// renderNode returns a copy of node with every scalar rendered with params.
+func (r appSetRenderer) renderNode(node *yaml.Node, params appSetParams) (*yaml.Node, error) {
+	out := *node
+	if node.Kind == yaml.ScalarNode {
+		value, err := r.render(node.Value, params)
+		if err != nil {
+			return nil, err
+		}
+		out.Value = value
+		return &out, nil
+	}
+
+	out.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		rendered, err := r.renderNode(child, params)
+		if err != nil {
+			return nil, err
+		}
+		out.Content[i] = rendered
+	}
+	return &out, nil
+}
+
+var fastTemplateTag = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+func (r appSetRenderer) render(s string, params appSetParams) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+
+	if !r.goTemplate {
+		// Unknown parameters are left as-is, like Argo CD does
+		flat := flattenParams(params)
+		return fastTemplateTag.ReplaceAllStringFunc(s, func(tag string) string {
+			if value, ok := flat[fastTemplateTag.FindStringSubmatch(tag)[1]]; ok {
+				return value
+			}
+			return tag
+		}), nil
+	}
+
+	tmpl, err := template.New("").Funcs(goTemplateFuncs).Option(r.options...).Parse(s)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]any(params)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// goTemplateFuncs are the functions Argo CD gives goTemplate ApplicationSets:
+// Sprig's, less those reading the environment or network, and its own YAML
+// and name helpers.
+var goTemplateFuncs = func() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	delete(funcs, "env")
+	delete(funcs, "expandenv")
+	delete(funcs, "getHostByName")
+	funcs["normalize"] = normalizeName
+	funcs["toYaml"] = toYAML
+	funcs["fromYaml"] = fromYAML
+	funcs["fromYamlArray"] = fromYAMLArray
+	return funcs
+}()
+
+// toYAML returns v as YAML without the trailing newline, or "" if it can't
+// be marshaled.
+func toYAML(v any) string {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// fromYAML decodes a YAML mapping, or returns an empty map if s isn't one.
+func fromYAML(s string) map[string]any {
+	m := make(map[string]any)
+	if err := yaml.Unmarshal([]byte(s), &m); err != nil {
+		return map[string]any{}
+	}
+	return m
+}
+
+// fromYAMLArray decodes a YAML sequence, or returns nil if s isn't one.
+func fromYAMLArray(s string) []any {
+	var a []any
+	if err := yaml.Unmarshal([]byte(s), &a); err != nil {
+		return nil
+	}
+	return a
+}
+
+// flattenParams converts params to the flat keys of Argo CD's default
+// template syntax: nested keys are joined with dots, and the git generators'
+// path parameters become "path", "path.basename" and "path[N]".
+func flattenParams(params appSetParams) map[string]string {
+	flat := make(map[string]string)
+	for key, value := range params {
+		pp, isPath := value.(map[string]any)
+		if key != "path" || !isPath {
+			flattenInto(flat, key, value)
+			continue
+		}
+		for k, v := range pp {
+			switch k {
+			case "path":
+				flat["path"] = fmt.Sprint(v)
+			case "segments":
+				segments, _ := v.([]string)
+				for i, segment := range segments {
+					flat["path["+strconv.Itoa(i)+"]"] = segment
+				}
+			default:
+				flat["path."+k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return flat
+}
+
+func flattenInto(flat map[string]string, key string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			flattenInto(flat, key+"."+k, child)
+		}
+	case []any:
+		for i, child := range v {
+			flattenInto(flat, key+"."+strconv.Itoa(i), child)
+		}
+	case nil:
+		flat[key] = ""
+	default:
+		flat[key] = fmt.Sprint(v)
+	}
+}
+
+// environmentName derives the environment from a generated app name by
+// trimming the chart name from either end ("my-app-prod" or "prod-my-app" →
+// "prod"). Names that don't contain the chart name are used as-is.
+func environmentName(appName, chartName string) string {
+	if env, ok := strings.CutPrefix(appName, chartName+"-"); ok && env != "" {
+		return env
+	}
+	if env, ok := strings.CutSuffix(appName, "-"+chartName); ok && env != "" {
+		return env
+	}
+	return appName
+}
@@ -0,0 +1,194 @@
+package argo
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRebuildIndex_ApplicationSets(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	if err := copyDir(filepath.Join("testdata", "repos", "appsets"), tmpDir); err != nil {
+		t.Fatalf("failed to copy testdata: %v", err)
+	}
+
+	adapter := &Adapter{
+		repoPath:      tmpDir,
+		repoURL:       "git@github.com:example/gitops.git",
+		folderPattern: "{chartName}/{envName}",
+		index:         make(map[string][]AppData),
+		logger:        slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}
+	if err := adapter.rebuildIndex(); err != nil {
+		t.Fatalf("rebuildIndex failed: %v", err)
+	}
+
+	type generated struct {
+		valueFile, namespace, releaseName string
+	}
+	tests := []struct {
+		chart string
+		want  map[string]generated // env -> expected fields
+	}{
+		{
+			// List generator, alongside a hand-written Application
+			chart: "my-app",
+			want: map[string]generated{
+				"dev":     {"values-dev.yaml", "my-app-dev", "my-app-dev"},
+				"prod":    {"values-prod.yaml", "my-app-prod", "my-app-prod"},
+				"staging": {"values-staging.yaml", "", "my-app-staging"},
+			},
+		},
+		{
+			// Git directories generator with an excluded directory
+			chart: "other-app",
+			want: map[string]generated{
+				"qa":      {"values-qa.yaml", "other-qa", "qa-other-app"},
+				"staging": {"values-staging.yaml", "other-staging", "staging-other-app"},
+			},
+		},
+		{
+			// Git files generator with goTemplate
+			chart: "third-app",
+			want: map[string]generated{
+				"legacy":       {"values-legacy.yaml", "ns-legacy", "third-app-legacy"},
+				"qa":           {"values-qa.yaml", "ns-qa", "third-app-qa"},
+				"staging-east": {"values-staging.yaml", "ns-staging", "third-app-staging-east"},
+			},
+		},
+		{
+			// Matrix of git directories and a list that references them
+			chart: "fourth-app",
+			want: map[string]generated{
+				"qa-blue":       {"qa-blue.yaml", "", "fourth-app-qa-blue"},
+				"qa-green":      {"qa-green.yaml", "", "fourth-app-qa-green"},
+				"staging-blue":  {"staging-blue.yaml", "", "fourth-app-staging-blue"},
+				"staging-green": {"staging-green.yaml", "", "fourth-app-staging-green"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		apps := adapter.index[tt.chart]
+		if len(apps) != len(tt.want) {
+			t.Errorf("%s: expected %d apps, got %d: %+v", tt.chart, len(tt.want), len(apps), apps)
+			continue
+		}
+		for _, app := range apps {
+			want, ok := tt.want[app.Environment]
+			if !ok {
+				t.Errorf("%s: unexpected environment %q", tt.chart, app.Environment)
+				continue
+			}
+			if app.ChartPath != "charts/"+tt.chart {
+				t.Errorf("%s/%s: ChartPath = %q", tt.chart, app.Environment, app.ChartPath)
+			}
+			if !slices.Equal(app.ValueFiles, []string{want.valueFile}) {
+				t.Errorf("%s/%s: ValueFiles = %v, want [%s]", tt.chart, app.Environment, app.ValueFiles, want.valueFile)
+			}
+			if app.Namespace != want.namespace || app.ReleaseName != want.releaseName {
+				t.Errorf("%s/%s: Namespace = %q, ReleaseName = %q, want %q, %q",
+					tt.chart, app.Environment, app.Namespace, app.ReleaseName, want.namespace, want.releaseName)
+			}
+		}
+	}
+
+	// ApplicationSets with unsupported generators are skipped
+	if _, exists := adapter.index["fifth-app"]; exists {
+		t.Errorf("fifth-app uses an unsupported generator and should not be indexed")
+	}
+	// Git generators of another repository are skipped
+	if _, exists := adapter.index["sixth-app"]; exists {
+		t.Errorf("sixth-app's git generator reads another repository and should not be indexed")
+	}
+	if len(adapter.index) != len(tests) {
+		t.Errorf("expected %d charts in index, got %d", len(tests), len(adapter.index))
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"clusters/*/config.json", "clusters/qa/config.json", true},
+		{"clusters/*/config.json", "clusters/eu/qa/config.json", false},
+		{"clusters/**/config.json", "clusters/eu/qa/config.json", true},
+		{"clusters/**/config.json", "clusters/config.json", true},
+		{"**/config.json", "config.json", true},
+		{"clusters/?a/config.json", "clusters/qa/config.json", true},
+		{"clusters/*.json", "clusters/qa.yaml", false},
+		{"clusters/a+b.json", "clusters/a+b.json", true},
+	}
+
+	for _, tt := range tests {
+		if got := globRegexp(tt.pattern).MatchString(tt.path); got != tt.want {
+			t.Errorf("globRegexp(%q).MatchString(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEnvironmentName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		appName string
+		want    string
+	}{
+		{"my-app-prod", "prod"},
+		{"prod-my-app", "prod"},
+		{"my-app", "my-app"},
+		{"payments", "payments"},
+	}
+
+	for _, tt := range tests {
+		if got := environmentName(tt.appName, "my-app"); got != tt.want {
+			t.Errorf("environmentName(%q) = %q, want %q", tt.appName, got, tt.want)
+		}
+	}
+}
+
+func TestAppSetRenderer_GoTemplateFunctions(t *testing.T) {
+	t.Parallel()
+
+	params := appSetParams{
+		"path":    map[string]any{"path": "envs/Staging_East", "basename": "Staging_East"},
+		"cluster": map[string]any{"name": "eu-1", "labels": map[string]any{"tier": "gold"}},
+	}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{{ .path.basename | lower }}", "staging_east"},
+		{"{{ .path.basename | normalize }}", "staging-east"},
+		{`{{ .cluster.region | default "us-east-1" }}`, "us-east-1"},
+		{`{{ printf "%s-%s" .cluster.name (.path.basename | kebabcase) | trunc 12 }}`, "eu-1-staging"},
+		{"{{ .cluster.labels | toYaml }}", "tier: gold"},
+		{`{{ (fromYaml "replicas: 3").replicas }}`, "3"},
+		{`{{ index (fromYamlArray "[a, b]") 1 }}`, "b"},
+	}
+
+	r := appSetRenderer{goTemplate: true}
+	for _, tt := range tests {
+		got, err := r.render(tt.template, params)
+		if err != nil {
+			t.Errorf("render(%q) error = %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("render(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	// Like Argo CD, templates can't read the environment
+	if _, err := r.render(`{{ env "HOME" }}`, params); err == nil {
+		t.Error("expected env to be undefined")
+	}
+}
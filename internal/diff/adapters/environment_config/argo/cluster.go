@@ -0,0 +1,109 @@
+package argo
+
+import (
+	"encoding/base64"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// clusterSecretTypeLabel marks a Secret as a declarative Argo CD cluster.
+	clusterSecretTypeLabel = "argocd.argoproj.io/secret-type"
+
+	// kubeVersionKey is the label or annotation on an Argo CD cluster Secret
+	// holding the cluster's Kubernetes version (e.g. "1.29"). Applications
+	// deployed to the cluster without a helm.kubeVersion inherit it.
+	kubeVersionKey = "chart-val/kube-version"
+)
+
+// clusterData is the minimal data we need from an Argo CD cluster Secret.
+type clusterData struct {
+	Name        string // From data.name
+	Server      string // From data.server
+	KubeVersion string // From the kubeVersionKey label or annotation
+}
+
+// clusterSecretManifest is the subset of a declarative cluster Secret we read.
+type clusterSecretManifest struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+	StringData map[string]string `yaml:"stringData"`
+}
+
+// parseClusterSecret parses a declarative Argo CD cluster Secret from a file.
+// Returns false if the file isn't one, or names no Kubernetes version.
+func parseClusterSecret(path string) (clusterData, bool) {
+	//nolint:gosec // G304: path is from filepath.Walk, not user input
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return clusterData{}, false
+	}
+
+	var manifest clusterSecretManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return clusterData{}, false
+	}
+	if manifest.Kind != "Secret" || manifest.Metadata.Labels[clusterSecretTypeLabel] != "cluster" {
+		return clusterData{}, false
+	}
+
+	version := manifest.Metadata.Annotations[kubeVersionKey]
+	if version == "" {
+		version = manifest.Metadata.Labels[kubeVersionKey]
+	}
+	if version == "" {
+		return clusterData{}, false
+	}
+	return clusterData{
+		Name:        manifest.field("name"),
+		Server:      manifest.field("server"),
+		KubeVersion: version,
+	}, true
+}
+
+// field returns a Secret field from stringData, or decoded from data.
+func (m *clusterSecretManifest) field(key string) string {
+	if v, ok := m.StringData[key]; ok {
+		return v
+	}
+	decoded, err := base64.StdEncoding.DecodeString(m.Data[key])
+	if err != nil {
+		return ""
+	}
+	return string(decoded)
+}
+
+// applyClusterVersions sets the Kubernetes version of apps that set none from
+// the cluster they are deployed to, matched by server URL or cluster name.
+func applyClusterVersions(index map[string][]AppData, clusters []clusterData) {
+	if len(clusters) == 0 {
+		return
+	}
+	versions := make(map[string]string, 2*len(clusters))
+	for _, c := range clusters {
+		if c.Server != "" {
+			versions["server:"+c.Server] = c.KubeVersion
+		}
+		if c.Name != "" {
+			versions["name:"+c.Name] = c.KubeVersion
+		}
+	}
+	for _, apps := range index {
+		for i := range apps {
+			app := &apps[i]
+			if app.KubeVersion != "" {
+				continue
+			}
+			if v, ok := versions["server:"+app.DestinationServer]; ok && app.DestinationServer != "" {
+				app.KubeVersion = v
+			} else if v, ok := versions["name:"+app.DestinationName]; ok && app.DestinationName != "" {
+				app.KubeVersion = v
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package argo
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRebuildIndex_ClusterKubeVersions(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	if err := copyDir(filepath.Join("testdata", "repos", "clusters"), tmpDir); err != nil {
+		t.Fatalf("failed to copy testdata: %v", err)
+	}
+
+	adapter := &Adapter{
+		repoPath:      tmpDir,
+		folderPattern: "{chartName}/{envName}",
+		index:         make(map[string][]AppData),
+		logger:        slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}
+	if err := adapter.rebuildIndex(); err != nil {
+		t.Fatalf("rebuildIndex failed: %v", err)
+	}
+
+	want := map[string]string{
+		"prod":    "1.29",   // Cluster matched by server, version from an annotation
+		"dev":     "1.31",   // Cluster matched by name, version from a label in base64 data
+		"staging": "1.28.0", // helm.kubeVersion wins over the cluster's
+		"qa":      "",       // Cluster without a version
+	}
+	apps := adapter.index["my-app"]
+	if len(apps) != len(want) {
+		t.Fatalf("expected %d apps, got %d: %+v", len(want), len(apps), apps)
+	}
+	for _, app := range apps {
+		if app.KubeVersion != want[app.Environment] {
+			t.Errorf("%s: KubeVersion = %q, want %q", app.Environment, app.KubeVersion, want[app.Environment])
+		}
+	}
+	if len(adapter.index) != 1 {
+		t.Errorf("expected cluster Secrets to stay out of the index, got %d charts", len(adapter.index))
+	}
+}
@@ -2,33 +2,49 @@
 package filesystem
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/nathantilsley/chart-val/internal/diff/domain"
 	"github.com/nathantilsley/chart-val/internal/diff/ports"
 )
 
 // Adapter implements ports.EnvironmentConfigPort by scanning the chart's
-// env/ subdirectory for *-values.yaml files.
+// env/ subdirectory for *-values.yaml files. An optional sidecar file per
+// environment (e.g. env/prod-render.yaml) supplies its render options.
 type Adapter struct {
 	sourceControl    ports.SourceControlPort
 	chartDir         string
 	envDir           string
 	valuesFileSuffix string
+	renderFileSuffix string
+}
+
+// renderOptionsFile is the on-disk format of a per-environment sidecar.
+type renderOptionsFile struct {
+	ReleaseName string   `yaml:"releaseName"`
+	Namespace   string   `yaml:"namespace"`
+	KubeVersion string   `yaml:"kubeVersion"`
+	APIVersions []string `yaml:"apiVersions"`
 }
 
 // New creates a new filesystem environment config adapter.
-func New(sourceControl ports.SourceControlPort, chartDir, envDir, valuesFileSuffix string) *Adapter {
+func New(sourceControl ports.SourceControlPort, chartDir, envDir, valuesFileSuffix, renderFileSuffix string) *Adapter {
 	return &Adapter{
 		sourceControl:    sourceControl,
 		chartDir:         chartDir,
 		envDir:           envDir,
 		valuesFileSuffix: valuesFileSuffix,
+		renderFileSuffix: renderFileSuffix,
 	}
 }
 
@@ -49,7 +65,10 @@ func (a *Adapter) GetEnvironmentConfig(
 	defer cleanup()
 
 	// Discover environments from env/ directory
-	envs := a.discoverEnvironments(chartDir)
+	envs, err := a.discoverEnvironments(chartDir)
+	if err != nil {
+		return domain.ChartConfig{}, err
+	}
 
 	return domain.ChartConfig{
 		Path:         chartPath,
@@ -59,13 +78,13 @@ func (a *Adapter) GetEnvironmentConfig(
 
 // discoverEnvironments scans chartDir/env/ for files matching *-values.yaml.
 // If no env/ directory or no matching files exist, returns empty slice.
-func (a *Adapter) discoverEnvironments(chartDir string) []domain.EnvironmentConfig {
+func (a *Adapter) discoverEnvironments(chartDir string) ([]domain.EnvironmentConfig, error) {
 	envDir := filepath.Join(chartDir, a.envDir)
 
 	entries, err := os.ReadDir(envDir)
 	if err != nil {
 		// No env/ directory → return empty (service will handle fallback)
-		return []domain.EnvironmentConfig{}
+		return []domain.EnvironmentConfig{}, nil
 	}
 
 	var configs []domain.EnvironmentConfig
@@ -74,13 +93,19 @@ func (a *Adapter) discoverEnvironments(chartDir string) []domain.EnvironmentConf
 			continue
 		}
 		name := entry.Name()
-		if !strings.HasSuffix(name, a.valuesFileSuffix) {
+		// Sidecars may share the values suffix (e.g. VALUES_FILE_SUFFIX=.yaml)
+		if !strings.HasSuffix(name, a.valuesFileSuffix) || strings.HasSuffix(name, a.renderFileSuffix) {
 			continue
 		}
 		envName := strings.TrimSuffix(name, a.valuesFileSuffix)
+		opts, err := a.readRenderOptions(envDir, envName)
+		if err != nil {
+			return nil, err
+		}
 		configs = append(configs, domain.EnvironmentConfig{
-			Name:       envName,
-			ValueFiles: []string{filepath.Join(a.envDir, name)},
+			Name:          envName,
+			ValueFiles:    []string{filepath.Join(a.envDir, name)},
+			RenderOptions: opts,
 		})
 	}
 
@@ -88,5 +113,33 @@ func (a *Adapter) discoverEnvironments(chartDir string) []domain.EnvironmentConf
 		return configs[i].Name < configs[j].Name
 	})
 
-	return configs
+	return configs, nil
+}
+
+// readRenderOptions loads env/<envName><renderFileSuffix> if present.
+// A missing sidecar yields zero options (renderer defaults).
+func (a *Adapter) readRenderOptions(envDir, envName string) (domain.RenderOptions, error) {
+	name := envName + a.renderFileSuffix
+	//nolint:gosec // G304: envDir is inside a temp checkout we created
+	data, err := os.ReadFile(filepath.Join(envDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return domain.RenderOptions{}, nil
+	}
+	if err != nil {
+		return domain.RenderOptions{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	var f renderOptionsFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) // Catch typos like "namepsace" instead of silently ignoring them
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return domain.RenderOptions{}, fmt.Errorf("parsing %s: %w", name, err)
+	}
+
+	return domain.RenderOptions{
+		ReleaseName: f.ReleaseName,
+		Namespace:   f.Namespace,
+		KubeVersion: f.KubeVersion,
+		APIVersions: f.APIVersions,
+	}, nil
 }
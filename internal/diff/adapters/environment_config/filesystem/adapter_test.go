@@ -0,0 +1,105 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeEnvFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	chartDir := t.TempDir()
+	envDir := filepath.Join(chartDir, "env")
+	if err := os.MkdirAll(envDir, 0o755); err != nil {
+		t.Fatalf("creating env dir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(envDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	return chartDir
+}
+
+func TestDiscoverEnvironments_RenderOptionsSidecar(t *testing.T) {
+	chartDir := writeEnvFiles(t, map[string]string{
+		"dev-values.yaml":  "replicas: 1\n",
+		"prod-values.yaml": "replicas: 3\n",
+		"prod-render.yaml": `releaseName: my-app
+namespace: my-app-prod
+kubeVersion: "1.29.0"
+apiVersions:
+  - monitoring.coreos.com/v1
+`,
+	})
+	adapter := New(nil, "charts", "env", "-values.yaml", "-render.yaml")
+
+	envs, err := adapter.discoverEnvironments(chartDir)
+	if err != nil {
+		t.Fatalf("discoverEnvironments failed: %v", err)
+	}
+	if len(envs) != 2 {
+		t.Fatalf("expected 2 environments, got %d: %+v", len(envs), envs)
+	}
+
+	dev, prod := envs[0], envs[1]
+	if dev.Name != "dev" || dev.RenderOptions.ReleaseName != "" || dev.RenderOptions.Namespace != "" {
+		t.Errorf("dev should use renderer defaults, got %+v", dev)
+	}
+	if prod.Name != "prod" {
+		t.Fatalf("expected prod as second environment, got %q", prod.Name)
+	}
+	opts := prod.RenderOptions
+	if opts.ReleaseName != "my-app" || opts.Namespace != "my-app-prod" || opts.KubeVersion != "1.29.0" {
+		t.Errorf("prod RenderOptions = %+v", opts)
+	}
+	if !slices.Equal(opts.APIVersions, []string{"monitoring.coreos.com/v1"}) {
+		t.Errorf("prod APIVersions = %v", opts.APIVersions)
+	}
+}
+
+func TestDiscoverEnvironments_SidecarSharesValuesSuffix(t *testing.T) {
+	chartDir := writeEnvFiles(t, map[string]string{
+		"prod.yaml":        "replicas: 3\n",
+		"prod-render.yaml": "namespace: prod\n",
+	})
+	adapter := New(nil, "charts", "env", ".yaml", "-render.yaml")
+
+	envs, err := adapter.discoverEnvironments(chartDir)
+	if err != nil {
+		t.Fatalf("discoverEnvironments failed: %v", err)
+	}
+	if len(envs) != 1 || envs[0].Name != "prod" {
+		t.Fatalf("expected only the prod environment, got %+v", envs)
+	}
+}
+
+func TestDiscoverEnvironments_InvalidSidecar(t *testing.T) {
+	chartDir := writeEnvFiles(t, map[string]string{
+		"prod-values.yaml": "replicas: 3\n",
+		"prod-render.yaml": "namepsace: prod\n",
+	})
+	adapter := New(nil, "charts", "env", "-values.yaml", "-render.yaml")
+
+	_, err := adapter.discoverEnvironments(chartDir)
+	if err == nil {
+		t.Fatal("expected error for unknown sidecar field, got nil")
+	}
+	if !strings.Contains(err.Error(), "prod-render.yaml") {
+		t.Errorf("error %q should name the sidecar file", err.Error())
+	}
+}
+
+func TestDiscoverEnvironments_NoEnvDir(t *testing.T) {
+	adapter := New(nil, "charts", "env", "-values.yaml", "-render.yaml")
+
+	envs, err := adapter.discoverEnvironments(t.TempDir())
+	if err != nil {
+		t.Fatalf("discoverEnvironments failed: %v", err)
+	}
+	if len(envs) != 0 {
+		t.Errorf("expected no environments, got %+v", envs)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 
 	gogithub "github.com/google/go-github/v68/github"
 
@@ -16,17 +18,51 @@ import (
 
 const maxCheckRunTextLen = 65535
 
+// Check run conclusions.
+const (
+	conclusionSuccess        = "success"
+	conclusionFailure        = "failure"
+	conclusionActionRequired = "action_required"
+)
+
 // Adapter implements ports.ReportingPort by posting results via the
 // GitHub Checks API.
 type Adapter struct {
-	client  *gogithub.Client
-	appName string
-	appURL  string
+	client           *gogithub.Client
+	appName          string
+	appURL           string
+	collapseComments bool
+	templates        *Templates
+	repoTemplateDir  string
+
+	mu            sync.Mutex
+	repoTemplates map[string]repoTemplates // Keyed by "owner/repo"
 }
 
-// New creates a new GitHub reporting adapter.
-func New(client *gogithub.Client, appName, appURL string) *Adapter {
-	return &Adapter{client: client, appName: appName, appURL: appURL}
+// New creates a new GitHub reporting adapter. With collapseComments, the PR
+// comment of a chart that no longer changes anything is edited into a short
+// note instead of being left as it was. Reports are rendered with templates,
+// or the defaults if nil; if repoTemplateDir is set, the *.tmpl files in
+// that directory of the target repo override them.
+func New(
+	client *gogithub.Client,
+	appName, appURL string,
+	collapseComments bool,
+	templates *Templates,
+	repoTemplateDir string,
+) *Adapter {
+	if templates == nil {
+		templates = defaultTemplates
+	}
+	return &Adapter{
+		client:           client,
+		appName:          appName,
+		appURL:           appURL,
+		collapseComments: collapseComments,
+		templates:        templates,
+		repoTemplateDir:  repoTemplateDir,
+		repoTemplates:    make(map[string]repoTemplates),
+	}
 }
 
 // CreateInProgressCheck creates a single check run in "in_progress" status for the PR.
@@ -35,6 +71,7 @@ func (a *Adapter) CreateInProgressCheck(ctx context.Context, pr domain.PRContext
 	logger.Info("creating in-progress check", "pr", pr.PRNumber)
 
 	client := a.client
+	f := a.formatter(a.templatesFor(ctx, pr))
 
 	checkRun, _, err := client.Checks.CreateCheckRun(
 		ctx,
@@ -45,8 +82,8 @@ func (a *Adapter) CreateInProgressCheck(ctx context.Context, pr domain.PRContext
 			HeadSHA: pr.HeadSHA,
 			Status:  gogithub.Ptr("in_progress"),
 			Output: &gogithub.CheckRunOutput{
-				Title:   gogithub.Ptr("Helm Diff"),
-				Summary: gogithub.Ptr("Analyzing chart changes..."),
+				Title:   gogithub.Ptr(f.checkRunTitle(nil)),
+				Summary: gogithub.Ptr(f.templates.execute("check_run_in_progress", f.data(nil, nil))),
 			},
 		},
 	)
@@ -79,33 +116,55 @@ func (a *Adapter) UpdateCheckWithResults(
 	}
 
 	client := a.client
-	conclusion, summary, text := formatCheckRun(results)
-
-	_, _, err := client.Checks.UpdateCheckRun(
-		ctx,
-		pr.Owner,
-		pr.Repo,
-		checkRunID,
-		gogithub.UpdateCheckRunOptions{
-			Name:       a.appName,
-			Status:     gogithub.Ptr("completed"),
-			Conclusion: gogithub.Ptr(conclusion),
-			Output: &gogithub.CheckRunOutput{
-				Title:   gogithub.Ptr("Helm Diff"),
-				Summary: gogithub.Ptr(summary),
-				Text:    gogithub.Ptr(text),
-			},
+	f := a.formatter(a.templatesFor(ctx, pr))
+	conclusion, summary, text := f.checkRun(results)
+	title := f.checkRunTitle(results)
+	batches := annotationBatches(buildAnnotations(results))
+
+	opts := gogithub.UpdateCheckRunOptions{
+		Name:       a.appName,
+		Status:     gogithub.Ptr("completed"),
+		Conclusion: gogithub.Ptr(conclusion),
+		Output: &gogithub.CheckRunOutput{
+			Title:   gogithub.Ptr(title),
+			Summary: gogithub.Ptr(summary),
+			Text:    gogithub.Ptr(text),
 		},
-	)
+	}
+	// GitHub points reviewers at the details URL when action is required
+	if conclusion == conclusionActionRequired && a.appURL != "" {
+		opts.DetailsURL = gogithub.Ptr(a.appURL)
+	}
+	if len(batches) > 0 {
+		opts.Output.Annotations = batches[0]
+	}
+	_, _, err := client.Checks.UpdateCheckRun(ctx, pr.Owner, pr.Repo, checkRunID, opts)
 	if err != nil {
 		return fmt.Errorf("updating check run: %w", err)
 	}
 
-	logger.Info("check run updated successfully", "checkRunID", checkRunID)
+	// GitHub appends the annotations of each update, up to 50 per request
+	for i := 1; i < len(batches); i++ {
+		_, _, err := client.Checks.UpdateCheckRun(ctx, pr.Owner, pr.Repo, checkRunID, gogithub.UpdateCheckRunOptions{
+			Name: a.appName,
+			Output: &gogithub.CheckRunOutput{
+				Title:       gogithub.Ptr(title),
+				Summary:     gogithub.Ptr(summary),
+				Text:        gogithub.Ptr(text), // Omitting it would clear the text
+				Annotations: batches[i],
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("adding check run annotations (batch %d of %d): %w", i+1, len(batches), err)
+		}
+	}
+
+	logger.Info("check run updated successfully", "checkRunID", checkRunID, "annotationBatches", len(batches))
 	return nil
 }
 
-// PostComment posts a PR comment with the diff summary for a single chart.
+// PostComment posts a PR comment with the diff summary for a single chart,
+// editing the chart's comment from an earlier push in place if there is one.
 func (a *Adapter) PostComment(
 	ctx context.Context,
 	pr domain.PRContext,
@@ -120,84 +179,211 @@ func (a *Adapter) PostComment(
 	chartName := results[0].ChartName
 	logger.Info("posting PR comment", "chart", chartName, "pr", pr.PRNumber)
 
-	client := a.client
-	commentMarker := fmt.Sprintf("<!-- %s: %s -->", a.appName, chartName)
+	comments, err := a.listComments(ctx, pr)
+	if err != nil {
+		return err
+	}
 
-	// Delete old comments for this chart to avoid bloat
-	a.deleteMatchingComments(ctx, pr, commentMarker)
+	f := a.formatter(a.templatesFor(ctx, pr))
+	marker := a.commentMarker(chartName)
+	if err := a.upsertComments(ctx, pr, comments, marker, f.prComment(results, marker)); err != nil {
+		return fmt.Errorf("posting PR comment: %w", err)
+	}
+	logger.Info("PR comment posted successfully", "chart", chartName)
 
-	commentBody := a.FormatPRComment(results)
+	// Post unified diff comment if there is unified diff content
+	unifiedMarker := a.unifiedCommentMarker(chartName)
+	unifiedBodies := f.prCommentUnified(results, unifiedMarker)
+	if len(unifiedBodies) == 0 {
+		// A line diff from an earlier push no longer applies
+		body := f.collapsedComment(unifiedMarker, headingUnified, chartName)
+		if err := a.collapseComment(ctx, pr, comments, unifiedMarker, body); err != nil {
+			return fmt.Errorf("collapsing unified PR comment: %w", err)
+		}
+		return nil
+	}
+	if err := a.upsertComments(ctx, pr, comments, unifiedMarker, unifiedBodies); err != nil {
+		return fmt.Errorf("posting unified PR comment: %w", err)
+	}
+	logger.Info("unified PR comment posted successfully", "chart", chartName)
 
-	_, _, err := client.Issues.CreateComment(
-		ctx,
-		pr.Owner,
-		pr.Repo,
-		pr.PRNumber,
-		&gogithub.IssueComment{
-			Body: gogithub.Ptr(commentBody),
-		},
-	)
+	return nil
+}
+
+// CollapseComment edits the chart's PR comments from earlier pushes into a
+// note that they no longer apply, unless the adapter was created with
+// collapsing turned off.
+func (a *Adapter) CollapseComment(ctx context.Context, pr domain.PRContext, chartName string) error {
+	if !a.collapseComments {
+		return nil
+	}
+	comments, err := a.listComments(ctx, pr)
 	if err != nil {
-		return fmt.Errorf("creating PR comment: %w", err)
+		return err
+	}
+	f := a.formatter(a.templatesFor(ctx, pr))
+	marker := a.commentMarker(chartName)
+	body := f.collapsedComment(marker, headingDiff, chartName)
+	if err := a.collapseComment(ctx, pr, comments, marker, body); err != nil {
+		return fmt.Errorf("collapsing PR comment: %w", err)
 	}
+	unifiedMarker := a.unifiedCommentMarker(chartName)
+	body = f.collapsedComment(unifiedMarker, headingUnified, chartName)
+	if err := a.collapseComment(ctx, pr, comments, unifiedMarker, body); err != nil {
+		return fmt.Errorf("collapsing unified PR comment: %w", err)
+	}
+	return nil
+}
 
-	logger.Info("PR comment posted successfully", "chart", chartName)
+func (a *Adapter) commentMarker(chartName string) string {
+	return fmt.Sprintf("<!-- %s: %s -->", a.appName, chartName)
+}
 
-	// Post unified diff comment if there is unified diff content
-	unifiedBody := a.FormatPRCommentUnified(results)
-	if unifiedBody != "" {
-		unifiedMarker := fmt.Sprintf("<!-- %s-unified: %s -->", a.appName, chartName)
-		a.deleteMatchingComments(ctx, pr, unifiedMarker)
-
-		_, _, err = client.Issues.CreateComment(
-			ctx,
-			pr.Owner,
-			pr.Repo,
-			pr.PRNumber,
-			&gogithub.IssueComment{
-				Body: gogithub.Ptr(unifiedBody),
-			},
-		)
+func (a *Adapter) unifiedCommentMarker(chartName string) string {
+	return fmt.Sprintf("<!-- %s-unified: %s -->", a.appName, chartName)
+}
+
+func (a *Adapter) summaryCommentMarker() string {
+	return fmt.Sprintf("<!-- %s-summary -->", a.appName)
+}
+
+// listComments returns all comments on the PR, oldest first, reading every
+// page.
+func (a *Adapter) listComments(ctx context.Context, pr domain.PRContext) ([]*gogithub.IssueComment, error) {
+	opts := &gogithub.IssueListCommentsOptions{ListOptions: gogithub.ListOptions{PerPage: 100}}
+	var all []*gogithub.IssueComment
+	for {
+		comments, resp, err := a.client.Issues.ListComments(ctx, pr.Owner, pr.Repo, pr.PRNumber, opts)
 		if err != nil {
-			return fmt.Errorf("creating unified PR comment: %w", err)
+			return nil, fmt.Errorf("listing PR comments: %w", err)
 		}
-		logger.Info("unified PR comment posted successfully", "chart", chartName)
+		all = append(all, comments...)
+		if resp == nil || resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
 	}
+}
 
+// upsertComments posts the bodies of a report split across comments, the
+// first with marker and the rest with part markers. Parts an earlier push
+// needed beyond these are deleted.
+func (a *Adapter) upsertComments(
+	ctx context.Context,
+	pr domain.PRContext,
+	comments []*gogithub.IssueComment,
+	marker string,
+	bodies []string,
+) error {
+	for i, body := range bodies {
+		if err := a.upsertComment(ctx, pr, comments, partMarker(marker, i+1), body); err != nil {
+			return err
+		}
+	}
+	a.deleteComments(ctx, pr, laterParts(comments, marker, len(bodies)))
 	return nil
 }
 
-// deleteMatchingComments deletes comments containing the given marker.
-func (a *Adapter) deleteMatchingComments(ctx context.Context, pr domain.PRContext, marker string) {
-	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
-	client := a.client
+// laterParts returns the comments holding parts after the first n of the
+// report whose first comment has marker.
+func laterParts(comments []*gogithub.IssueComment, marker string, n int) []*gogithub.IssueComment {
+	var later []*gogithub.IssueComment
+	for part := n + 1; ; part++ {
+		matching := matchingComments(comments, partMarker(marker, part))
+		if len(matching) == 0 {
+			return later
+		}
+		later = append(later, matching...)
+	}
+}
 
-	comments, _, err := client.Issues.ListComments(
-		ctx,
-		pr.Owner,
-		pr.Repo,
-		pr.PRNumber,
-		&gogithub.IssueListCommentsOptions{},
-	)
+// upsertComment edits the oldest of comments containing marker to body, or
+// creates a comment if there is none. Later duplicates, left behind by
+// versions that recreated the comment on every push, are deleted.
+func (a *Adapter) upsertComment(
+	ctx context.Context,
+	pr domain.PRContext,
+	comments []*gogithub.IssueComment,
+	marker, body string,
+) error {
+	matching := matchingComments(comments, marker)
+	if len(matching) == 0 {
+		_, _, err := a.client.Issues.CreateComment(ctx, pr.Owner, pr.Repo, pr.PRNumber,
+			&gogithub.IssueComment{Body: gogithub.Ptr(body)})
+		if err != nil {
+			return fmt.Errorf("creating comment: %w", err)
+		}
+		return nil
+	}
+
+	if err := a.editComment(ctx, pr, matching[0], body); err != nil {
+		return err
+	}
+	a.deleteComments(ctx, pr, matching[1:])
+	return nil
+}
+
+// collapseComment edits the comments containing marker into body, a note
+// that they no longer apply, and deletes the later parts of their report, if
+// collapsing is on. The marker is kept, so a later push that changes the
+// chart again edits the same comment.
+func (a *Adapter) collapseComment(
+	ctx context.Context,
+	pr domain.PRContext,
+	comments []*gogithub.IssueComment,
+	marker, body string,
+) error {
+	matching := matchingComments(comments, marker)
+	if !a.collapseComments || len(matching) == 0 {
+		return nil
+	}
+	if err := a.editComment(ctx, pr, matching[0], body); err != nil {
+		return err
+	}
+	a.deleteComments(ctx, pr, append(matching[1:], laterParts(comments, marker, 1)...))
+	return nil
+}
+
+// editComment sets the comment's body, skipping the request if it is
+// unchanged so the comment's edit history only records real changes.
+func (a *Adapter) editComment(
+	ctx context.Context,
+	pr domain.PRContext,
+	comment *gogithub.IssueComment,
+	body string,
+) error {
+	if comment.GetBody() == body {
+		return nil
+	}
+	_, _, err := a.client.Issues.EditComment(ctx, pr.Owner, pr.Repo, comment.GetID(),
+		&gogithub.IssueComment{Body: gogithub.Ptr(body)})
 	if err != nil {
-		logger.Warn("failed to list comments, continuing anyway", "error", err)
-		return
+		return fmt.Errorf("editing comment %d: %w", comment.GetID(), err)
 	}
+	return nil
+}
+
+// deleteComments deletes duplicate and leftover comments; failures are only
+// logged.
+func (a *Adapter) deleteComments(ctx context.Context, pr domain.PRContext, comments []*gogithub.IssueComment) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	for _, comment := range comments {
+		logger.Info("deleting old comment", "commentID", comment.GetID())
+		if _, err := a.client.Issues.DeleteComment(ctx, pr.Owner, pr.Repo, comment.GetID()); err != nil {
+			logger.Warn("failed to delete old comment", "commentID", comment.GetID(), "error", err)
+		}
+	}
+}
+
+// matchingComments returns the comments whose body contains marker.
+func matchingComments(comments []*gogithub.IssueComment, marker string) []*gogithub.IssueComment {
+	var matching []*gogithub.IssueComment
 	for _, comment := range comments {
 		if strings.Contains(comment.GetBody(), marker) {
-			logger.Info("deleting old comment", "commentID", comment.GetID())
-			_, err := client.Issues.DeleteComment(ctx, pr.Owner, pr.Repo, comment.GetID())
-			if err != nil {
-				logger.Warn(
-					"failed to delete old comment",
-					"commentID",
-					comment.GetID(),
-					"error",
-					err,
-				)
-			}
+			matching = append(matching, comment)
 		}
 	}
+	return matching
 }
 
 // FormatCheckRunMarkdown formats a complete check run markdown document for testing.
@@ -207,39 +393,52 @@ func (a *Adapter) FormatCheckRunMarkdown(results []domain.DiffResult) string {
 		return ""
 	}
 
-	conclusion, summary, text := formatCheckRun(results)
+	f := a.formatter(a.templates)
+	conclusion, summary, text := f.checkRun(results)
 
 	var sb strings.Builder
 	fmt.Fprintf(&sb, "# %s\n\n", a.appName)
 	sb.WriteString("**Status:** completed\n")
 	fmt.Fprintf(&sb, "**Conclusion:** %s\n\n", conclusion)
-	sb.WriteString("## Helm Diff\n\n")
+	fmt.Fprintf(&sb, "## %s\n\n", f.checkRunTitle(results))
 	fmt.Fprintf(&sb, "### Summary\n%s\n\n", summary)
 	fmt.Fprintf(&sb, "### Output\n%s", text)
 
 	return sb.String()
 }
 
-// formatCheckRun builds the conclusion, summary, and collapsible text for the check run.
-// Groups results by chart, showing diffs for changed charts and listing unchanged charts.
-func formatCheckRun(results []domain.DiffResult) (conclusion, summary, text string) {
-	_, _, errorCount := domain.CountByStatus(results)
-	conclusion = determineConclusion(errorCount)
-
-	grouped, chartOrder := groupResultsByChart(results)
-	changedCharts, unchangedCharts := separateChangedCharts(grouped, chartOrder)
-
-	summary = buildSummary(chartOrder, changedCharts, unchangedCharts)
-	text = buildCheckRunText(grouped, changedCharts, unchangedCharts)
+// FormatPRComment formats the PR comment bodies for a single chart's diff
+// results: one, or more if the report doesn't fit in a single comment.
+// Exported for use in integration tests.
+func (a *Adapter) FormatPRComment(results []domain.DiffResult) []string {
+	if len(results) == 0 {
+		return nil
+	}
+	return a.formatter(a.templates).prComment(results, a.commentMarker(results[0].ChartName))
+}
 
-	return conclusion, summary, text
+// FormatPRCommentUnified formats the PR comment bodies for a single chart
+// using unified (line-based) diff, or nil if there is none.
+// Exported for use in integration tests.
+func (a *Adapter) FormatPRCommentUnified(results []domain.DiffResult) []string {
+	if len(results) == 0 {
+		return nil
+	}
+	return a.formatter(a.templates).prCommentUnified(results, a.unifiedCommentMarker(results[0].ChartName))
 }
 
-func determineConclusion(errorCount int) string {
-	if errorCount > 0 {
-		return "failure"
+// determineConclusion returns the check run conclusion, by precedence:
+// failure if any environment failed, action_required if any change is
+// high-risk, otherwise success.
+func determineConclusion(failedCount, highRiskCount int) string {
+	switch {
+	case failedCount > 0:
+		return conclusionFailure
+	case highRiskCount > 0:
+		return conclusionActionRequired
+	default:
+		return conclusionSuccess
 	}
-	return "success"
 }
 
 func groupResultsByChart(results []domain.DiffResult) (map[string][]domain.DiffResult, []string) {
@@ -259,7 +458,7 @@ func separateChangedCharts(
 	chartOrder []string,
 ) (changed, unchanged []string) {
 	for _, name := range chartOrder {
-		if chartHasChanges(grouped[name]) {
+		if slices.ContainsFunc(grouped[name], domain.DiffResult.HasFindings) {
 			changed = append(changed, name)
 		} else {
 			unchanged = append(unchanged, name)
@@ -268,229 +467,129 @@ func separateChangedCharts(
 	return changed, unchanged
 }
 
-func buildSummary(chartOrder, changedCharts, unchangedCharts []string) string {
-	return fmt.Sprintf("Analyzed %d chart(s): %d with changes, %d unchanged",
-		len(chartOrder), len(changedCharts), len(unchangedCharts))
-}
-
-func buildCheckRunText(
-	grouped map[string][]domain.DiffResult,
-	changedCharts, unchangedCharts []string,
-) string {
-	var sb strings.Builder
-	formatChangedCharts(&sb, grouped, changedCharts)
-	formatUnchangedCharts(&sb, unchangedCharts)
-	return truncateIfNeeded(sb.String())
-}
-
-func formatChangedCharts(
-	sb *strings.Builder,
-	grouped map[string][]domain.DiffResult,
-	changedCharts []string,
-) {
-	for _, chartName := range changedCharts {
-		fmt.Fprintf(sb, "## %s\n\n", chartName)
-		for _, r := range grouped[chartName] {
-			formatEnvironmentResult(sb, r)
-		}
-	}
-}
-
-func formatEnvironmentResult(sb *strings.Builder, r domain.DiffResult) {
-	statusLabel := getStatusLabel(r.Status)
-	fmt.Fprintf(sb, "<details><summary>%s — %s</summary>\n\n", r.Environment, statusLabel)
-
-	switch {
-	case r.Status == domain.StatusError:
-		fmt.Fprintf(sb, "%s\n", r.Summary)
-	case r.UnifiedDiff == "" && r.SemanticDiff == "":
-		sb.WriteString("No changes detected.\n")
-	default:
-		formatDiffs(sb, r)
-	}
-
-	sb.WriteString("\n</details>\n\n")
-}
-
-func getStatusLabel(status domain.Status) string {
-	switch status {
-	case domain.StatusError:
-		return "Error"
-	case domain.StatusChanges:
-		return "Changed"
-	case domain.StatusSuccess:
-		return "No Changes"
-	default:
-		return "Unknown"
+// countLintFindings sums lint errors and warnings over all results.
+func countLintFindings(results []domain.DiffResult) (errors, warnings int) {
+	for _, r := range results {
+		e, w := domain.CountLintFindings(r.LintFindings)
+		errors += e
+		warnings += w
 	}
+	return errors, warnings
 }
 
-func formatDiffs(sb *strings.Builder, r domain.DiffResult) {
-	if r.SemanticDiff != "" {
-		sb.WriteString("**Semantic Diff (dyff):**\n")
-		fmt.Fprintf(sb, "```diff\n%s\n```\n\n", r.SemanticDiff)
-	}
-	if r.UnifiedDiff != "" {
-		sb.WriteString("**Unified Diff (line-based):**\n")
-		fmt.Fprintf(sb, "```diff\n%s\n```\n", r.UnifiedDiff)
+// countPolicyViolations sums failed and warning policies over all results.
+func countPolicyViolations(results []domain.DiffResult) (failed, warnings int) {
+	for _, r := range results {
+		f, w := domain.CountPolicyViolations(r.PolicyViolations)
+		failed += f
+		warnings += w
 	}
+	return failed, warnings
 }
 
-func formatUnchangedCharts(sb *strings.Builder, unchangedCharts []string) {
-	if len(unchangedCharts) == 0 {
-		return
-	}
-
-	sb.WriteString("## Unchanged charts\n\n")
-	sb.WriteString(
-		"The following charts were analyzed and had no changes across all environments:\n\n",
-	)
-	for _, name := range unchangedCharts {
-		fmt.Fprintf(sb, "- `%s`\n", name)
+// countDeprecations sums removed and deprecated API versions over all
+// results, and how many of them the PR introduces.
+func countDeprecations(results []domain.DiffResult) (removed, deprecated, introduced int) {
+	for _, r := range results {
+		rm, dep := domain.CountDeprecations(r.Deprecations)
+		removed += rm
+		deprecated += dep
+		for _, d := range r.Deprecations {
+			if d.Introduced {
+				introduced++
+			}
+		}
 	}
-	sb.WriteString("\n")
+	return removed, deprecated, introduced
 }
 
-func truncateIfNeeded(text string) string {
-	if len(text) > maxCheckRunTextLen {
-		truncMsg := "\n\n... (output truncated)"
-		return text[:maxCheckRunTextLen-len(truncMsg)] + truncMsg
+// countFailedDeprecations returns the number of removed API versions that
+// fail the check run, over all results.
+func countFailedDeprecations(results []domain.DiffResult) int {
+	n := 0
+	for _, r := range results {
+		for _, d := range r.Deprecations {
+			if d.Severity == domain.SeverityError {
+				n++
+			}
+		}
 	}
-	return text
+	return n
 }
 
-// chartHasChanges returns true if any result for a chart has changes or errors.
-func chartHasChanges(results []domain.DiffResult) bool {
+// countHighRisk returns the number of results with high-risk changes.
+func countHighRisk(results []domain.DiffResult) int {
+	n := 0
 	for _, r := range results {
-		if r.Status == domain.StatusChanges || r.Status == domain.StatusError {
-			return true
+		if domain.HighestRisk(r.Risks) == domain.RiskHigh {
+			n++
 		}
 	}
-	return false
+	return n
 }
 
-func writePRStatusSummary(sb *strings.Builder, results []domain.DiffResult) {
-	_, changes, errorCount := domain.CountByStatus(results)
-
-	switch {
-	case errorCount > 0:
-		sb.WriteString("❌ **Status:** Failed to analyze chart\n\n")
-	case changes > 0:
-		fmt.Fprintf(
-			sb,
-			"✅ **Status:** Analysis complete — %d environment(s) with changes\n\n",
-			changes,
-		)
-	default:
-		sb.WriteString("✅ **Status:** Analysis complete — No changes detected\n\n")
+// chartVersionCheck returns the version check shared by a chart's results,
+// or nil if its version wasn't checked.
+func chartVersionCheck(results []domain.DiffResult) *domain.VersionCheck {
+	for _, r := range results {
+		if r.ChartVersion != nil {
+			return r.ChartVersion
+		}
 	}
+	return nil
 }
 
-func writePREnvironmentTable(sb *strings.Builder, results []domain.DiffResult) {
-	sb.WriteString("| Environment | Status |\n")
-	sb.WriteString("|-------------|--------|\n")
+// countVersionFailures returns the number of charts whose version check failed.
+func countVersionFailures(results []domain.DiffResult) int {
+	failed := make(map[string]bool)
 	for _, r := range results {
-		var statusLabel string
-		switch r.Status {
-		case domain.StatusError:
-			statusLabel = "❌ Error"
-		case domain.StatusChanges:
-			statusLabel = "📝 Changed"
-		case domain.StatusSuccess:
-			statusLabel = "✅ No changes"
+		if r.ChartVersion != nil && r.ChartVersion.Failed() {
+			failed[r.ChartName] = true
 		}
-		fmt.Fprintf(sb, "| `%s` | %s |\n", r.Environment, statusLabel)
 	}
-	sb.WriteString("\n")
+	return len(failed)
 }
 
-func writePRDiffDetails(
-	sb *strings.Builder,
-	results []domain.DiffResult,
-	diffContent func(domain.DiffResult) string,
-) {
+// chartValuesChanges returns the breaking values changes shared by a chart's
+// results.
+func chartValuesChanges(results []domain.DiffResult) []domain.ValuesChange {
 	for _, r := range results {
-		switch r.Status {
-		case domain.StatusError:
-			fmt.Fprintf(
-				sb,
-				"<details>\n<summary><b>%s</b> — Error details</summary>\n\n",
-				r.Environment,
-			)
-			fmt.Fprintf(sb, "%s\n\n", r.Summary)
-			sb.WriteString("</details>\n\n")
-		case domain.StatusChanges:
-			if content := diffContent(r); content != "" {
-				fmt.Fprintf(
-					sb,
-					"<details>\n<summary><b>%s</b> — View diff</summary>\n\n",
-					r.Environment,
-				)
-				fmt.Fprintf(sb, "```diff\n%s\n```\n\n", content)
-				sb.WriteString("</details>\n\n")
-			}
-		case domain.StatusSuccess:
-			// Skip environments with no changes (already shown in table)
+		if len(r.ValuesChanges) > 0 {
+			return r.ValuesChanges
 		}
 	}
+	return nil
 }
 
-func (a *Adapter) writePRFooter(sb *strings.Builder) {
-	sb.WriteString("---\n")
-	if a.appURL != "" {
-		fmt.Fprintf(sb, "_Posted by [%s](%s)_\n", a.appName, a.appURL)
-	} else {
-		fmt.Fprintf(sb, "_Posted by %s_\n", a.appName)
+// countValuesChanges sums the breaking values changes over all charts.
+func countValuesChanges(results []domain.DiffResult) int {
+	changes := make(map[string]int)
+	for _, r := range results {
+		changes[r.ChartName] = len(r.ValuesChanges)
 	}
-}
-
-// FormatPRComment formats a PR comment body for a single chart's diff results.
-// Exported for use in integration tests.
-func (a *Adapter) FormatPRComment(results []domain.DiffResult) string {
-	if len(results) == 0 {
-		return ""
+	n := 0
+	for _, c := range changes {
+		n += c
 	}
-
-	chartName := results[0].ChartName
-	var sb strings.Builder
-
-	fmt.Fprintf(&sb, "<!-- %s: %s -->\n", a.appName, chartName)
-	fmt.Fprintf(&sb, "## 📊 Helm Diff Report: `%s`\n\n", chartName)
-	writePRStatusSummary(&sb, results)
-	writePREnvironmentTable(&sb, results)
-	writePRDiffDetails(&sb, results, func(r domain.DiffResult) string { return r.PreferredDiff() })
-	a.writePRFooter(&sb)
-
-	return sb.String()
+	return n
 }
 
-// FormatPRCommentUnified formats a PR comment body using unified (line-based) diff.
-// Exported for use in integration tests.
-func (a *Adapter) FormatPRCommentUnified(results []domain.DiffResult) string {
-	if len(results) == 0 {
-		return ""
+// countStaleOverrides sums failed and warning overrides of removed values
+// keys over all results.
+func countStaleOverrides(results []domain.DiffResult) (failed, warnings int) {
+	for _, r := range results {
+		f, w := domain.CountStaleOverrides(r.StaleOverrides)
+		failed += f
+		warnings += w
 	}
+	return failed, warnings
+}
 
-	hasUnified := false
+// countUnusedValues sums the unused values keys over all results.
+func countUnusedValues(results []domain.DiffResult) int {
+	n := 0
 	for _, r := range results {
-		if r.UnifiedDiff != "" {
-			hasUnified = true
-			break
-		}
+		n += len(r.UnusedValues)
 	}
-	if !hasUnified {
-		return ""
-	}
-
-	chartName := results[0].ChartName
-	var sb strings.Builder
-
-	fmt.Fprintf(&sb, "<!-- %s-unified: %s -->\n", a.appName, chartName)
-	fmt.Fprintf(&sb, "## 📊 Helm Line Diff: `%s`\n\n", chartName)
-	writePRStatusSummary(&sb, results)
-	writePREnvironmentTable(&sb, results)
-	writePRDiffDetails(&sb, results, func(r domain.DiffResult) string { return r.UnifiedDiff })
-	a.writePRFooter(&sb)
-
-	return sb.String()
+	return n
 }
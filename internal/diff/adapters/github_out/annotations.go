@@ -0,0 +1,127 @@
+package githubout
+
+import (
+	"fmt"
+	"strings"
+
+	gogithub "github.com/google/go-github/v68/github"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// maxAnnotationsPerRequest is how many annotations GitHub accepts in one
+// check run update; more are added by further updates.
+const maxAnnotationsPerRequest = 50
+
+// Check run annotation levels.
+const (
+	annotationFailure = "failure"
+	annotationWarning = "warning"
+)
+
+// annotation is a problem at a file and line of the PR's head commit, with
+// the environments it occurs in.
+type annotation struct {
+	path    string
+	line    int
+	level   string
+	title   string // e.g. "Render error"
+	message string
+	chart   string
+	envs    []string
+}
+
+// buildAnnotations returns the check run annotations for the results:
+// head render errors at their template line, schema and policy violations
+// at the template that rendered the resource, and lint findings, stale
+// overrides and unused values at their value file. A problem that occurs in
+// several environments of a chart is annotated once.
+func buildAnnotations(results []domain.DiffResult) []*gogithub.CheckRunAnnotation {
+	var annotations []*annotation
+	index := make(map[string]*annotation)
+	add := func(r domain.DiffResult, file string, line int, level, title, message string) {
+		p, ok := r.RepoPath(file)
+		if !ok {
+			return
+		}
+		key := fmt.Sprintf("%s\x00%d\x00%s\x00%s\x00%s", p, line, level, title, message)
+		if a, exists := index[key]; exists {
+			a.envs = append(a.envs, r.Environment)
+			return
+		}
+		a := &annotation{
+			path: p, line: max(line, 1), level: level, title: title, message: message,
+			chart: r.ChartName, envs: []string{r.Environment},
+		}
+		index[key] = a
+		annotations = append(annotations, a)
+	}
+
+	for _, r := range results {
+		if re := r.RenderError; re != nil {
+			// Helm names templates after the chart, e.g. "my-app/templates/deployment.yaml"
+			_, template, _ := strings.Cut(re.Template, "/")
+			add(r, template, re.Line, annotationFailure, "Render error", re.Message)
+		}
+		for _, v := range r.Violations {
+			_, template, _ := strings.Cut(v.Template, "/")
+			add(r, template, 1, annotationFailure, "Schema violation", fmt.Sprintf("%s: %s", v.Resource, v.Message))
+		}
+		for _, v := range r.PolicyViolations {
+			level, title := annotationWarning, "Policy warning"
+			if v.Severity == domain.SeverityError {
+				level, title = annotationFailure, "Policy violation"
+			}
+			_, template, _ := strings.Cut(v.Template, "/")
+			add(r, template, 1, level, title, fmt.Sprintf("%s on %s: %s", v.Policy, v.Resource, v.Message))
+		}
+		for _, f := range r.LintFindings {
+			level, title := annotationWarning, "Lint warning"
+			if f.Severity == domain.SeverityError {
+				level, title = annotationFailure, "Lint error"
+			}
+			message := f.Message
+			if f.Key != "" {
+				message = fmt.Sprintf("%s: %s", f.Key, f.Message)
+			}
+			add(r, f.File, f.Line, level, title, message)
+		}
+		for _, o := range r.StaleOverrides {
+			level := annotationWarning
+			if o.Severity == domain.SeverityError {
+				level = annotationFailure
+			}
+			add(r, o.File, o.Line, level, "Stale values override", o.Message())
+		}
+		for _, u := range r.UnusedValues {
+			add(r, u.File, u.Line, annotationWarning, "Unused value", u.Message())
+		}
+	}
+
+	out := make([]*gogithub.CheckRunAnnotation, len(annotations))
+	for i, a := range annotations {
+		out[i] = &gogithub.CheckRunAnnotation{
+			Path:            gogithub.Ptr(a.path),
+			StartLine:       gogithub.Ptr(a.line),
+			EndLine:         gogithub.Ptr(a.line),
+			AnnotationLevel: gogithub.Ptr(a.level),
+			Title:           gogithub.Ptr(fmt.Sprintf("%s in %s (%s)", a.title, a.chart, strings.Join(a.envs, ", "))),
+			Message:         gogithub.Ptr(a.message),
+		}
+	}
+	return out
+}
+
+// annotationBatches splits annotations into batches GitHub accepts in one
+// request.
+func annotationBatches(annotations []*gogithub.CheckRunAnnotation) [][]*gogithub.CheckRunAnnotation {
+	var batches [][]*gogithub.CheckRunAnnotation
+	for len(annotations) > maxAnnotationsPerRequest {
+		batches = append(batches, annotations[:maxAnnotationsPerRequest])
+		annotations = annotations[maxAnnotationsPerRequest:]
+	}
+	if len(annotations) > 0 {
+		batches = append(batches, annotations)
+	}
+	return batches
+}
@@ -0,0 +1,158 @@
+package githubout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	gogithub "github.com/google/go-github/v68/github"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+func TestBuildAnnotations(t *testing.T) {
+	renderErr := &domain.RenderError{
+		Template: "my-app/templates/deployment.yaml", Line: 12, Message: "nil pointer evaluating .Values.image.tag",
+	}
+	stale := domain.StaleOverride{
+		File: "env/prod-values.yaml", Line: 4,
+		Change: domain.ValuesChange{Path: []string{"metrics"}, Type: domain.ValuesKeyRemoved},
+	}
+	results := []domain.DiffResult{
+		{ChartName: "my-app", ChartPath: "charts/my-app", Environment: "dev", RenderError: renderErr},
+		{ChartName: "my-app", ChartPath: "charts/my-app", Environment: "prod", RenderError: renderErr,
+			StaleOverrides: []domain.StaleOverride{stale, {File: "inline values", Line: 1, Change: stale.Change}},
+			LintFindings: []domain.LintFinding{
+				{File: "env/prod-values.yaml", Line: 2, Key: "replicas", Message: "got string, want integer",
+					Severity: domain.SeverityError},
+				{File: "charts/redis/values.schema.json", Message: "invalid schema", Severity: domain.SeverityError},
+				{File: "templates/", Message: "selector doesn't match labels", Severity: domain.SeverityWarning},
+			},
+			Violations: []domain.SchemaViolation{
+				{Resource: "apps/v1/Deployment/my-app", Message: ".spec.replicas: expected integer, got string",
+					Template: "my-app/templates/deployment.yaml"},
+				{Resource: "document 2", Message: "missing kind"},
+			},
+			PolicyViolations: []domain.PolicyViolation{
+				{Policy: "require-limits", Resource: "apps/v1/Deployment/my-app", Message: "containers need limits",
+					Severity: domain.SeverityError, Template: "my-app/templates/deployment.yaml"},
+				{Policy: "team-label", Resource: "v1/Service/my-app", Message: "missing team label",
+					Severity: domain.SeverityWarning, Template: "my-app/templates/service.yaml"},
+				{Policy: "no-subchart-latest", Resource: "apps/v1/Deployment/redis", Message: "uses latest",
+					Severity: domain.SeverityError, Template: "my-app/charts/redis/templates/deployment.yaml"},
+			},
+			UnusedValues: []domain.UnusedValue{
+				{File: "../../shared/values.yaml", Line: 7, Path: []string{"replica"}},
+				{File: "../../../tmp/other-repo/values.yaml", Line: 3, Path: []string{"replica"}},
+			},
+		},
+	}
+
+	got := buildAnnotations(results)
+	want := []string{
+		"failure charts/my-app/templates/deployment.yaml:12 Render error in my-app (dev, prod): " +
+			"nil pointer evaluating .Values.image.tag",
+		"failure charts/my-app/templates/deployment.yaml:1 Schema violation in my-app (prod): " +
+			"apps/v1/Deployment/my-app: .spec.replicas: expected integer, got string",
+		"failure charts/my-app/templates/deployment.yaml:1 Policy violation in my-app (prod): " +
+			"require-limits on apps/v1/Deployment/my-app: containers need limits",
+		"warning charts/my-app/templates/service.yaml:1 Policy warning in my-app (prod): " +
+			"team-label on v1/Service/my-app: missing team label",
+		"failure charts/my-app/env/prod-values.yaml:2 Lint error in my-app (prod): replicas: got string, want integer",
+		"warning charts/my-app/env/prod-values.yaml:4 Stale values override in my-app (prod): " +
+			"env/prod-values.yaml sets metrics, which was removed",
+		"warning shared/values.yaml:7 Unused value in my-app (prod): " +
+			"../../shared/values.yaml sets replica, which the chart doesn't use",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d annotation(s), want %d: %v", len(got), len(want), describe(got))
+	}
+	for i, a := range describe(got) {
+		if a != want[i] {
+			t.Errorf("annotation %d =\n%s\nwant\n%s", i, a, want[i])
+		}
+	}
+}
+
+func describe(annotations []*gogithub.CheckRunAnnotation) []string {
+	out := make([]string, len(annotations))
+	for i, a := range annotations {
+		out[i] = fmt.Sprintf("%s %s:%d %s: %s",
+			a.GetAnnotationLevel(), a.GetPath(), a.GetStartLine(), a.GetTitle(), a.GetMessage())
+	}
+	return out
+}
+
+func TestAnnotationBatches(t *testing.T) {
+	annotations := make([]*gogithub.CheckRunAnnotation, 2*maxAnnotationsPerRequest+1)
+	batches := annotationBatches(annotations)
+	if len(batches) != 3 || len(batches[0]) != maxAnnotationsPerRequest || len(batches[2]) != 1 {
+		t.Errorf("annotationBatches(%d) = %d batch(es), want 50 + 50 + 1", len(annotations), len(batches))
+	}
+	if batches := annotationBatches(nil); len(batches) != 0 {
+		t.Errorf("annotationBatches(nil) = %d batch(es), want none", len(batches))
+	}
+}
+
+func TestAdapter_UpdateCheckWithResults_AnnotationBatches(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		outputs []gogithub.CheckRunOutput
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/repos/o/r/check-runs/7" {
+			http.NotFound(w, r)
+			return
+		}
+		var opts gogithub.UpdateCheckRunOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		outputs = append(outputs, *opts.Output)
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(&gogithub.CheckRun{ID: gogithub.Ptr(int64(7))})
+	}))
+	t.Cleanup(server.Close)
+	client := gogithub.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	a := New(client, "chart-val", "", false, nil, "")
+
+	findings := make([]domain.LintFinding, maxAnnotationsPerRequest+3)
+	for i := range findings {
+		findings[i] = domain.LintFinding{
+			File: "values.yaml", Line: i + 1, Message: "unknown key", Severity: domain.SeverityWarning,
+		}
+	}
+	results := []domain.DiffResult{{
+		ChartName: "my-app", ChartPath: "charts/my-app", Environment: "prod",
+		Status: domain.StatusSuccess, LintFindings: findings,
+	}}
+	if err := a.UpdateCheckWithResults(context.Background(), testPR, 7, results); err != nil {
+		t.Fatalf("UpdateCheckWithResults() error = %v", err)
+	}
+
+	if len(outputs) != 2 {
+		t.Fatalf("got %d update(s), want 2", len(outputs))
+	}
+	if n := len(outputs[0].Annotations) + len(outputs[1].Annotations); len(outputs[0].Annotations) !=
+		maxAnnotationsPerRequest || n != len(findings) {
+		t.Errorf("annotations per update = %d + %d, want %d + 3",
+			len(outputs[0].Annotations), len(outputs[1].Annotations), maxAnnotationsPerRequest)
+	}
+	text := outputs[0].GetText()
+	if text == "" {
+		t.Fatal("first update has no text")
+	}
+	for i, o := range outputs[1:] {
+		if o.GetText() != text || o.GetSummary() != outputs[0].GetSummary() {
+			t.Errorf("update %d text or summary differs from the first; GitHub would replace them", i+2)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package githubout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	gogithub "github.com/google/go-github/v68/github"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// fakeComments serves a PR's issue comments like the GitHub API, two per
+// page, and records the requests that change them.
+type fakeComments struct {
+	mu       sync.Mutex
+	comments []*gogithub.IssueComment
+	nextID   int64
+	requests []string // e.g. "PATCH 3", "DELETE 4", "POST"
+}
+
+func (f *fakeComments) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var id int64
+	if _, err := fmt.Sscanf(r.URL.Path, "/repos/o/r/issues/comments/%d", &id); err == nil {
+		f.requests = append(f.requests, fmt.Sprintf("%s %d", r.Method, id))
+		i := slices.IndexFunc(f.comments, func(c *gogithub.IssueComment) bool { return c.GetID() == id })
+		if r.Method == http.MethodDelete {
+			f.comments = slices.Delete(f.comments, i, i+1)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var edit gogithub.IssueComment
+		_ = json.NewDecoder(r.Body).Decode(&edit)
+		f.comments[i].Body = edit.Body
+		_ = json.NewEncoder(w).Encode(f.comments[i])
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		f.requests = append(f.requests, "POST")
+		var comment gogithub.IssueComment
+		_ = json.NewDecoder(r.Body).Decode(&comment)
+		f.nextID++
+		comment.ID = gogithub.Ptr(f.nextID)
+		f.comments = append(f.comments, &comment)
+		_ = json.NewEncoder(w).Encode(comment)
+		return
+	}
+
+	page := 1
+	_, _ = fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+	start := min(2*(page-1), len(f.comments))
+	end := min(start+2, len(f.comments))
+	if end < len(f.comments) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+	}
+	_ = json.NewEncoder(w).Encode(f.comments[start:end])
+}
+
+func (f *fakeComments) add(body string) {
+	f.nextID++
+	f.comments = append(f.comments, &gogithub.IssueComment{ID: gogithub.Ptr(f.nextID), Body: gogithub.Ptr(body)})
+}
+
+func newTestAdapter(t *testing.T, f *fakeComments, collapse bool) *Adapter {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	client := gogithub.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return New(client, "chart-val", "", collapse, nil, "")
+}
+
+var testPR = domain.PRContext{Owner: "o", Repo: "r", PRNumber: 1}
+
+func TestAdapter_PostComment_EditsInPlace(t *testing.T) {
+	f := &fakeComments{}
+	f.add("LGTM")
+	f.add("nit: typo")
+	f.add("<!-- chart-val: other-app -->\nold report")
+	f.add("<!-- chart-val: my-app -->\nold report")            // page 2: edited
+	f.add("<!-- chart-val: my-app -->\nolder duplicate")       // page 3: deleted
+	f.add("<!-- chart-val-unified: my-app -->\nold line diff") // collapsed: no line diff this time
+	a := newTestAdapter(t, f, true)
+
+	results := []domain.DiffResult{{ChartName: "my-app", Environment: "prod", Status: domain.StatusChanges}}
+	if err := a.PostComment(context.Background(), testPR, results); err != nil {
+		t.Fatalf("PostComment() error = %v", err)
+	}
+
+	want := []string{"PATCH 4", "DELETE 5", "PATCH 6"}
+	if !slices.Equal(f.requests, want) {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+	if got := f.comments[3].GetBody(); got != a.FormatPRComment(results)[0] {
+		t.Errorf("comment body =\n%s\nwant the formatted report", got)
+	}
+	if got := f.comments[4].GetBody(); !strings.Contains(got, "No longer applicable") ||
+		!strings.HasPrefix(got, "<!-- chart-val-unified: my-app -->") {
+		t.Errorf("unified comment body =\n%s\nwant a collapsed note keeping its marker", got)
+	}
+
+	// Posting the same results again changes nothing
+	f.requests = nil
+	if err := a.PostComment(context.Background(), testPR, results); err != nil {
+		t.Fatalf("PostComment() error = %v", err)
+	}
+	if len(f.requests) != 0 {
+		t.Errorf("requests = %v, want none for unchanged comments", f.requests)
+	}
+}
+
+func TestAdapter_PostComment_CreatesWhenMissing(t *testing.T) {
+	f := &fakeComments{}
+	f.add("<!-- chart-val: my-app-2 -->\nreport of another chart")
+	a := newTestAdapter(t, f, true)
+
+	results := []domain.DiffResult{{ChartName: "my-app", Environment: "prod", Status: domain.StatusChanges}}
+	if err := a.PostComment(context.Background(), testPR, results); err != nil {
+		t.Fatalf("PostComment() error = %v", err)
+	}
+	if want := []string{"POST"}; !slices.Equal(f.requests, want) {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+}
+
+func TestAdapter_CollapseComment(t *testing.T) {
+	for _, collapse := range []bool{true, false} {
+		t.Run(fmt.Sprintf("collapse=%v", collapse), func(t *testing.T) {
+			f := &fakeComments{}
+			f.add("<!-- chart-val: my-app -->\nold report")
+			a := newTestAdapter(t, f, collapse)
+
+			if err := a.CollapseComment(context.Background(), testPR, "my-app"); err != nil {
+				t.Fatalf("CollapseComment() error = %v", err)
+			}
+			if err := a.CollapseComment(context.Background(), testPR, "no-comment"); err != nil {
+				t.Fatalf("CollapseComment() error = %v", err)
+			}
+
+			var want []string
+			if collapse {
+				want = []string{"PATCH 1"}
+			}
+			if !slices.Equal(f.requests, want) {
+				t.Errorf("requests = %v, want %v", f.requests, want)
+			}
+		})
+	}
+}
+
+func TestAdapter_FormatPRSummaryComment(t *testing.T) {
+	a := New(nil, "chart-val", "", true, nil, "")
+	results := []domain.DiffResult{
+		{ChartName: "app-a", Environment: "dev", Status: domain.StatusChanges, SemanticDiff: "~ replicas: 1 -> 3"},
+		{ChartName: "app-a", Environment: "prod", Status: domain.StatusSuccess},
+		{ChartName: "app-b", Environment: "prod", Status: domain.StatusSuccess},
+	}
+
+	bodies := a.FormatPRSummaryComment(results)
+	if len(bodies) != 1 {
+		t.Fatalf("FormatPRSummaryComment() = %d bodies, want 1", len(bodies))
+	}
+	got := bodies[0]
+	for _, want := range []string{
+		"<!-- chart-val-summary -->\n## 📊 Helm Diff Summary\n",
+		"| Chart | `dev` | `prod` |\n|-------|--------|--------|\n",
+		"| `app-a` | 📝 Changed | ✅ No changes |\n",
+		"| `app-b` | — | ✅ No changes |\n",
+		"<details>\n<summary><b>app-a</b></summary>\n",
+		"~ replicas: 1 -> 3",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPRSummaryComment() is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<summary><b>app-b</b>") {
+		t.Errorf("FormatPRSummaryComment() has a section for app-b, which has no changes:\n%s", got)
+	}
+}
+
+func TestAdapter_PostSummaryComment_NoChanges(t *testing.T) {
+	f := &fakeComments{}
+	f.add("<!-- chart-val-summary -->\nold summary")
+	a := newTestAdapter(t, f, true)
+
+	results := []domain.DiffResult{{ChartName: "app-a", Environment: "prod", Status: domain.StatusSuccess}}
+	if err := a.PostSummaryComment(context.Background(), testPR, results); err != nil {
+		t.Fatalf("PostSummaryComment() error = %v", err)
+	}
+	if want := []string{"PATCH 1"}; !slices.Equal(f.requests, want) {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+	if got := f.comments[0].GetBody(); !strings.Contains(got, "No longer applicable") {
+		t.Errorf("summary comment body =\n%s\nwant a collapsed note", got)
+	}
+}
@@ -0,0 +1,139 @@
+package githubout
+
+import (
+	"slices"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// reportFormatter renders check runs and PR comments with a set of
+// templates.
+type reportFormatter struct {
+	templates *Templates
+	appName   string
+	appURL    string
+}
+
+// formatter returns a formatter rendering with templates.
+func (a *Adapter) formatter(templates *Templates) reportFormatter {
+	return reportFormatter{templates: templates, appName: a.appName, appURL: a.appURL}
+}
+
+// data returns the report data of results, with their diffs cut to limits.
+func (f reportFormatter) data(results []domain.DiffResult, limits diffLimits) ReportData {
+	return newReportData(f.appName, f.appURL, results, domain.DiffResult.PreferredDiff, limits)
+}
+
+// checkRunTitle returns the title of the check run.
+func (f reportFormatter) checkRunTitle(results []domain.DiffResult) string {
+	return f.templates.execute("check_run_title", f.data(results, nil))
+}
+
+// checkRun builds the conclusion, summary, and collapsible text for the check run.
+// Groups results by chart, showing diffs for changed charts and listing unchanged charts.
+func (f reportFormatter) checkRun(results []domain.DiffResult) (conclusion, summary, text string) {
+	_, _, errorCount, invalidCount := domain.CountByStatus(results)
+	failedPolicies, _ := countPolicyViolations(results)
+	failedStale, _ := countStaleOverrides(results)
+	failedCount := errorCount + invalidCount + failedPolicies + countFailedDeprecations(results) + countVersionFailures(results) +
+		failedStale
+	conclusion = determineConclusion(failedCount, countHighRisk(results))
+
+	data := f.data(results, nil)
+	summary = truncateIfNeeded(f.templates.execute("check_run_summary", data), maxCheckRunTextLen)
+
+	// If the text is too long for GitHub, the diffs are cut to fit, so
+	// statuses, findings and risks are always shown.
+	var diffs []string
+	for _, chart := range data.ChangedCharts {
+		for _, env := range chart.Environments {
+			diffs = append(diffs, env.SemanticDiff, env.UnifiedDiff)
+		}
+	}
+	render := func(limits diffLimits) string {
+		return f.templates.execute("check_run_text", f.data(results, limits))
+	}
+	text = truncateIfNeeded(render(fitDiffs(diffs, maxCheckRunTextLen, render)), maxCheckRunTextLen)
+
+	return conclusion, summary, text
+}
+
+// prComment formats the PR comment bodies for a single chart's diff results.
+func (f reportFormatter) prComment(results []domain.DiffResult, marker string) []string {
+	return f.chartComment(results, marker, headingDiff, domain.DiffResult.PreferredDiff).bodies()
+}
+
+// prCommentUnified formats the PR comment bodies for a single chart using
+// unified (line-based) diff, or nil if there is none.
+func (f reportFormatter) prCommentUnified(results []domain.DiffResult, marker string) []string {
+	if !slices.ContainsFunc(results, func(r domain.DiffResult) bool { return r.UnifiedDiff != "" }) {
+		return nil
+	}
+	unifiedDiff := func(r domain.DiffResult) string { return r.UnifiedDiff }
+	return f.chartComment(results, marker, headingUnified, unifiedDiff).bodies()
+}
+
+// heading returns the heading of each part of a comment report.
+func (f reportFormatter) heading(kind, chartName string) func(part int) string {
+	return func(part int) string {
+		return f.templates.execute("comment_heading", Heading{Kind: kind, Chart: chartName, Part: part})
+	}
+}
+
+// chartComment returns the PR comment report of a chart, showing the diff
+// diffContent picks for each environment.
+func (f reportFormatter) chartComment(
+	results []domain.DiffResult,
+	marker, kind string,
+	diffContent func(domain.DiffResult) string,
+) commentReport {
+	chart := newChartReport(results, diffContent, nil)
+	report := commentReport{
+		marker:  marker,
+		heading: f.heading(kind, chart.Name),
+		head:    f.templates.execute("comment_head", chart),
+		footer:  f.templates.execute("comment_footer", f.data(results, nil)),
+	}
+	for _, env := range chart.Environments {
+		report.sections = append(report.sections, commentSection{
+			diffs: []string{env.Diff},
+			render: func(limits diffLimits) string {
+				return f.templates.execute("comment_env", env.withLimits(limits))
+			},
+		})
+	}
+	return report
+}
+
+// summaryComment returns the report of the summary comment: a chart ×
+// environment matrix, then a collapsible section per chart with changes.
+func (f reportFormatter) summaryComment(results []domain.DiffResult, marker string) commentReport {
+	data := f.data(results, nil)
+	report := commentReport{
+		marker:  marker,
+		heading: f.heading(headingSummary, ""),
+		head:    f.templates.execute("summary_comment_head", data),
+		footer:  f.templates.execute("comment_footer", data),
+	}
+	for _, chart := range data.ChangedCharts {
+		var diffs []string
+		for _, env := range chart.Environments {
+			diffs = append(diffs, env.Diff)
+		}
+		report.sections = append(report.sections, commentSection{
+			diffs: diffs,
+			render: func(limits diffLimits) string {
+				return f.templates.execute("summary_comment_chart", chart.withLimits(limits))
+			},
+		})
+	}
+	return report
+}
+
+// collapsedComment formats the note a comment is collapsed to once its
+// charts no longer change anything.
+func (f reportFormatter) collapsedComment(marker, kind, chartName string) string {
+	data := f.data(nil, nil)
+	return marker + "\n" + f.heading(kind, chartName)(1) + "\n\n" +
+		f.templates.execute("comment_collapsed", data) + f.templates.execute("comment_footer", data)
+}
@@ -0,0 +1,115 @@
+package githubout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+func TestFormatCheckRun_StaleOverrides(t *testing.T) {
+	stale := domain.StaleOverride{
+		File: "env/prod-values.yaml", Line: 4,
+		Change: domain.ValuesChange{Path: []string{"metrics"}, Type: domain.ValuesKeyRemoved},
+	}
+	f := reportFormatter{templates: defaultTemplates, appName: "chart-val"}
+	tests := []struct {
+		name           string
+		severity       domain.Severity
+		wantConclusion string
+		wantIcon       string
+	}{
+		{
+			name:           "warnings by default",
+			severity:       domain.SeverityWarning,
+			wantConclusion: conclusionSuccess,
+			wantIcon:       "⚠️",
+		},
+		{
+			name:           "errors when configured",
+			severity:       domain.SeverityError,
+			wantConclusion: conclusionFailure,
+			wantIcon:       "❌",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := stale
+			o.Severity = tt.severity
+			results := []domain.DiffResult{{
+				ChartName: "my-app", Environment: "prod", Status: domain.StatusSuccess,
+				ValuesChanges: []domain.ValuesChange{stale.Change}, StaleOverrides: []domain.StaleOverride{o},
+			}}
+			conclusion, summary, text := f.checkRun(results)
+			if conclusion != tt.wantConclusion {
+				t.Errorf("conclusion = %q, want %q", conclusion, tt.wantConclusion)
+			}
+			failing := tt.severity == domain.SeverityError
+			if strings.Contains(summary, "(1 failed)") != failing {
+				t.Errorf("summary =\n%s\nwant the failed count only when stale overrides fail", summary)
+			}
+			comment := f.prComment(results, "<!-- chart-val: my-app -->")[0]
+			if strings.Contains(comment, "**Status:** Stale values overrides") != failing {
+				t.Errorf("comment =\n%s\nwant the stale overrides status only when they fail", comment)
+			}
+			if want := tt.wantIcon + " " + o.Message(); !strings.Contains(text, want) {
+				t.Errorf("text =\n%s\nwant %q", text, want)
+			}
+		})
+	}
+}
+
+func TestFormatCheckRun_RemovedAPIs(t *testing.T) {
+	pdb := domain.APIDeprecation{
+		Resource: "policy/v1beta1/PodDisruptionBudget/my-app", APIVersion: "policy/v1beta1",
+		Kind: "PodDisruptionBudget", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "policy/v1",
+		Removed: true,
+	}
+	f := reportFormatter{templates: defaultTemplates, appName: "chart-val"}
+	tests := []struct {
+		name           string
+		target         string
+		severity       domain.Severity
+		wantConclusion string
+		wantIcon       string
+	}{
+		{
+			name:           "warnings without a target version",
+			severity:       domain.SeverityWarning,
+			wantConclusion: conclusionSuccess,
+			wantIcon:       "⚠️",
+		},
+		{
+			name:           "errors when the target version no longer serves them",
+			target:         "1.29",
+			severity:       domain.SeverityError,
+			wantConclusion: conclusionFailure,
+			wantIcon:       "❌",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := pdb
+			d.Target, d.Severity = tt.target, tt.severity
+			results := []domain.DiffResult{{
+				ChartName: "my-app", Environment: "prod", Status: domain.StatusSuccess,
+				Deprecations: []domain.APIDeprecation{d},
+			}}
+			conclusion, summary, text := f.checkRun(results)
+			if conclusion != tt.wantConclusion {
+				t.Errorf("conclusion = %q, want %q", conclusion, tt.wantConclusion)
+			}
+			failing := tt.severity == domain.SeverityError
+			if strings.Contains(summary, "(1 failed)") != failing {
+				t.Errorf("summary =\n%s\nwant the failed count only when removed APIs fail", summary)
+			}
+			comment := f.prComment(results, "<!-- chart-val: my-app -->")[0]
+			if strings.Contains(comment, "**Status:** Removed API versions") != failing {
+				t.Errorf("comment =\n%s\nwant the removed APIs status only when they fail", comment)
+			}
+			if want := tt.wantIcon + " `" + d.Resource + "`: " + d.Message(); !strings.Contains(text, want) {
+				t.Errorf("text =\n%s\nwant %q", text, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package githubout
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// maxCommentLen is the most GitHub accepts in a PR comment body.
+const maxCommentLen = 65536
+
+// maxCommentParts caps how many comments one report is split across; beyond
+// that, diffs are cut instead.
+const maxCommentParts = 4
+
+// cutNoteSlack is room kept per cut diff for its fence and note, which are
+// longer than the note of a diff left out entirely.
+const cutNoteSlack = 48
+
+// diffLimits caps how many bytes of each diff a report shows, keyed by the
+// diff text. Diffs not in it are shown whole.
+type diffLimits map[string]int
+
+// cut returns the lines of diff that fit in its limit and the number of
+// lines left out.
+func (l diffLimits) cut(diff string) (shown string, omitted int) {
+	limit, ok := l[diff]
+	if !ok || len(diff) <= limit {
+		return diff, 0
+	}
+	diff = strings.TrimRight(diff, "\n")
+	if len(diff) <= limit {
+		return diff, 0
+	}
+	shown = diff[:max(limit, 0)]
+	if diff[len(shown)] != '\n' {
+		// Leave out the line the limit falls in
+		shown = shown[:max(strings.LastIndexByte(shown, '\n'), 0)]
+	}
+	omitted = strings.Count(diff[len(shown):], "\n")
+	if shown == "" {
+		omitted++
+	}
+	return shown, omitted
+}
+
+// lower lowers l's limits to those in other.
+func (l diffLimits) lower(other diffLimits) {
+	for diff, limit := range other {
+		if current, ok := l[diff]; !ok || limit < current {
+			l[diff] = limit
+		}
+	}
+}
+
+// fitDiffs returns the limits under which render's output fits in size
+// bytes, or nil if it fits as it is. Everything but the diffs is kept, and
+// the space left is shared among the diffs: small ones stay whole and the
+// largest are cut.
+func fitDiffs(diffs []string, size int, render func(diffLimits) string) diffLimits {
+	if len(render(nil)) <= size {
+		return nil
+	}
+
+	uses := make(map[string]int)
+	for _, d := range diffs {
+		uses[d]++
+	}
+	bySize := slices.SortedFunc(maps.Keys(uses), func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(a), len(b)), strings.Compare(a, b))
+	})
+
+	limits := make(diffLimits, len(bySize))
+	for _, d := range bySize {
+		limits[d] = 0
+	}
+	space := size - len(render(limits)) - cutNoteSlack*len(diffs)
+	remaining := len(diffs)
+	for _, d := range bySize {
+		limits[d] = min(len(d), max(space, 0)/remaining)
+		space -= limits[d] * uses[d]
+		remaining -= uses[d]
+	}
+	return limits
+}
+
+// commentSection is a collapsible part of a PR comment that can go in any of
+// the comments a report is split across.
+type commentSection struct {
+	diffs  []string                // Diffs the section shows
+	render func(diffLimits) string // Renders the section with its diffs cut to the limits
+}
+
+// commentReport is the content of a PR comment, before it is split to fit
+// GitHub's comment size limit.
+type commentReport struct {
+	marker   string                // Marker of the first comment; later ones add their part number
+	heading  func(part int) string // Heading of each comment, after its marker
+	head     string                // Status and tables, first comment only
+	sections []commentSection
+	footer   string
+}
+
+// partMarker returns the marker of part n of a report whose first comment
+// has marker, e.g. "<!-- chart-val: my-app (part 2) -->".
+func partMarker(marker string, part int) string {
+	if part == 1 {
+		return marker
+	}
+	return fmt.Sprintf("%s (part %d) -->", strings.TrimSuffix(marker, " -->"), part)
+}
+
+func (c commentReport) header(part int) string {
+	return partMarker(c.marker, part) + "\n" + c.heading(part) + "\n\n"
+}
+
+// bodies returns the report's comment bodies, each within GitHub's limit.
+// Sections are split across up to maxCommentParts comments; a section too
+// big for a comment of its own, or a report too big for them all, has its
+// diffs cut.
+func (c commentReport) bodies() []string {
+	space := maxCommentLen - len(c.header(maxCommentParts)) - len(c.footer)
+	limits := make(diffLimits)
+	var diffs []string
+	for _, s := range c.sections {
+		limits.lower(fitDiffs(s.diffs, space, s.render))
+		diffs = append(diffs, s.diffs...)
+	}
+
+	all := func(l diffLimits) string {
+		var sb strings.Builder
+		for _, s := range c.sections {
+			sb.WriteString(s.render(l))
+		}
+		return sb.String()
+	}
+
+	bodies := c.pack(limits)
+	budget := maxCommentParts*space - len(c.head)
+	for ; len(bodies) > maxCommentParts && budget > 0; budget -= space / 2 {
+		shared := make(diffLimits)
+		shared.lower(fitDiffs(diffs, budget, all))
+		shared.lower(limits)
+		bodies = c.pack(shared)
+	}
+	return bodies
+}
+
+// pack fills comments with the sections in order, starting a new comment
+// when the next section doesn't fit.
+func (c commentReport) pack(limits diffLimits) []string {
+	var bodies []string
+	var sb strings.Builder
+	sb.WriteString(c.header(1))
+	sb.WriteString(c.head)
+	empty := false // Whether the comment has nothing but its header
+	for _, s := range c.sections {
+		section := s.render(limits)
+		if !empty && sb.Len()+len(section)+len(c.footer) > maxCommentLen {
+			sb.WriteString(c.footer)
+			bodies = append(bodies, sb.String())
+			sb.Reset()
+			sb.WriteString(c.header(len(bodies) + 1))
+			empty = true
+		}
+		sb.WriteString(section)
+		empty = false
+	}
+	sb.WriteString(c.footer)
+	bodies = append(bodies, sb.String())
+
+	for i := range bodies {
+		bodies[i] = truncateIfNeeded(bodies[i], maxCommentLen)
+	}
+	return bodies
+}
+
+// truncateIfNeeded cuts text to size bytes at a line boundary, closing the
+// code fence and <details> blocks the cut leaves open.
+func truncateIfNeeded(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	const truncMsg = "\n_... (output truncated)_\n"
+
+	cut := text[:max(size-len(truncMsg)-256, 0)]
+	cut = cut[:strings.LastIndexByte(cut, '\n')+1]
+	var closing strings.Builder
+	if fence := openFence(cut); fence != "" {
+		closing.WriteString(fence + "\n")
+	}
+	for range strings.Count(cut, "<details>") - strings.Count(cut, "</details>") {
+		closing.WriteString("</details>\n")
+	}
+	return cut + closing.String() + truncMsg
+}
+
+// openFence returns the code fence left open at the end of text, or "".
+func openFence(text string) string {
+	open := ""
+	for line := range strings.Lines(text) {
+		line = strings.TrimRight(line, "\n")
+		run := len(line) - len(strings.TrimLeft(line, "`"))
+		switch {
+		case open == "" && run >= 3:
+			open = line[:run]
+		case open != "" && run >= len(open) && strings.Trim(line, "`") == "":
+			open = ""
+		}
+	}
+	return open
+}
@@ -0,0 +1,224 @@
+package githubout
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// bigDiff returns a diff of n numbered lines of about 100 bytes each.
+func bigDiff(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		fmt.Fprintf(&sb, "+ line %05d %s\n", i, strings.Repeat("x", 87))
+	}
+	return sb.String()
+}
+
+// assertFencesClosed fails if text leaves a code fence or <details> open.
+func assertFencesClosed(t *testing.T, name, text string) {
+	t.Helper()
+	if fence := openFence(text); fence != "" {
+		t.Errorf("%s leaves a %s fence open", name, fence)
+	}
+	if open, closed := strings.Count(text, "<details>"), strings.Count(text, "</details>"); open != closed {
+		t.Errorf("%s has %d <details> and %d </details>", name, open, closed)
+	}
+}
+
+// renderDiff renders diff with the default "diff" template, cut to limits.
+func renderDiff(r domain.DiffResult, diff string, limits diffLimits) string {
+	return defaultTemplates.execute("diff", newEnvReport(r, diff, limits).Cut(diff))
+}
+
+func TestDiffLimits_Cut(t *testing.T) {
+	diff := "aaa\nbbb\nccc\n"
+	tests := []struct {
+		limit       int
+		wantShown   string
+		wantOmitted int
+	}{
+		{limit: 100, wantShown: diff},
+		{limit: 11, wantShown: "aaa\nbbb\nccc"}, // Only the trailing newline is over
+		{limit: 7, wantShown: "aaa\nbbb", wantOmitted: 1},
+		{limit: 6, wantShown: "aaa", wantOmitted: 2}, // Never part of a line
+		{limit: 2, wantShown: "", wantOmitted: 3},
+		{limit: 0, wantShown: "", wantOmitted: 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit=%d", tt.limit), func(t *testing.T) {
+			shown, omitted := diffLimits{diff: tt.limit}.cut(diff)
+			if shown != tt.wantShown || omitted != tt.wantOmitted {
+				t.Errorf("cut() = %q, %d, want %q, %d", shown, omitted, tt.wantShown, tt.wantOmitted)
+			}
+		})
+	}
+
+	if shown, omitted := diffLimits(nil).cut(diff); shown != diff || omitted != 0 {
+		t.Errorf("cut() without limits = %q, %d, want the whole diff", shown, omitted)
+	}
+}
+
+func TestFitDiffs(t *testing.T) {
+	small, large := bigDiff(5), bigDiff(500)
+	render := func(limits diffLimits) string {
+		var sb strings.Builder
+		sb.WriteString("## Summary\n\n")
+		for _, d := range []string{small, large} {
+			sb.WriteString(renderDiff(domain.DiffResult{}, d, limits))
+		}
+		return sb.String()
+	}
+
+	if limits := fitDiffs([]string{small, large}, 1<<20, render); limits != nil {
+		t.Errorf("fitDiffs() = %v, want nil when everything fits", limits)
+	}
+
+	const size = 10000
+	limits := fitDiffs([]string{small, large}, size, render)
+	got := render(limits)
+	if len(got) > size {
+		t.Errorf("rendered %d bytes, want at most %d", len(got), size)
+	}
+	if limits[small] != len(small) {
+		t.Errorf("small diff limit = %d, want it shown whole (%d)", limits[small], len(small))
+	}
+	if !strings.Contains(got, "## Summary") || !strings.Contains(got, "more line(s) left out") {
+		t.Errorf("render() = %q, want the summary kept and the large diff cut", got)
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	diff := "+ a: |\n+   ```\n+   code\n+   ```\n"
+	r := domain.DiffResult{ReportURL: "https://chart-val.example.com/reports/abc"}
+
+	got := renderDiff(r, diff, nil)
+	if !strings.HasPrefix(got, "````diff\n") || !strings.HasSuffix(got, "\n````\n") {
+		t.Errorf("renderDiff() = %q, want a fence longer than the backticks in the diff", got)
+	}
+	assertFencesClosed(t, "renderDiff()", got)
+
+	got = renderDiff(r, diff, diffLimits{diff: 8})
+	want := "_… 3 more line(s) left out to fit GitHub's size limit — [full diff](" + r.ReportURL + ")_\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("renderDiff() = %q, want it to end with %q", got, want)
+	}
+	assertFencesClosed(t, "renderDiff()", got)
+}
+
+func TestTruncateIfNeeded(t *testing.T) {
+	text := "<details>\n<summary>prod</summary>\n\n```diff\n" + bigDiff(1000)
+	got := truncateIfNeeded(text, 5000)
+	if len(got) > 5000 {
+		t.Errorf("truncateIfNeeded() = %d bytes, want at most 5000", len(got))
+	}
+	if !strings.HasSuffix(got, "```\n</details>\n\n_... (output truncated)_\n") {
+		t.Errorf("truncateIfNeeded() ends with %q, want the fence and details closed", got[len(got)-80:])
+	}
+	assertFencesClosed(t, "truncateIfNeeded()", got)
+
+	if got := truncateIfNeeded("short", 5000); got != "short" {
+		t.Errorf("truncateIfNeeded() = %q, want text that fits unchanged", got)
+	}
+}
+
+func TestAdapter_FormatPRComment_Overflow(t *testing.T) {
+	a := New(nil, "chart-val", "", true, nil, "")
+
+	// Each environment's diff fits in a comment, but not all of them together
+	var results []domain.DiffResult
+	for _, env := range []string{"dev", "qa", "staging", "prod"} {
+		results = append(results, domain.DiffResult{
+			ChartName:    "my-app",
+			Environment:  env,
+			Status:       domain.StatusChanges,
+			SemanticDiff: bigDiff(300),
+			Risks:        []domain.ChangeRisk{{Level: domain.RiskHigh, Detail: "Replicas scaled to zero"}},
+		})
+	}
+	bodies := a.FormatPRComment(results)
+	if len(bodies) < 2 || len(bodies) > maxCommentParts {
+		t.Fatalf("FormatPRComment() = %d bodies, want the report split across 2 to %d", len(bodies), maxCommentParts)
+	}
+	for i, body := range bodies {
+		name := fmt.Sprintf("part %d", i+1)
+		if len(body) > maxCommentLen {
+			t.Errorf("%s is %d bytes, over GitHub's limit", name, len(body))
+		}
+		if !strings.HasPrefix(body, partMarker("<!-- chart-val: my-app -->", i+1)+"\n") {
+			t.Errorf("%s starts with %q, want its part marker", name, body[:50])
+		}
+		if strings.Contains(body, "left out") {
+			t.Errorf("%s cuts a diff that fits in a comment of its own", name)
+		}
+		assertFencesClosed(t, name, body)
+	}
+	if !strings.Contains(bodies[0], "| Environment |") {
+		t.Errorf("part 1 is missing the environment table:\n%s", bodies[0][:500])
+	}
+
+	// Too much for every part: diffs are cut, and the risks kept
+	for i := range results {
+		results[i].SemanticDiff = bigDiff(1000)
+		results[i].ReportURL = "https://chart-val.example.com/reports/" + results[i].Environment
+	}
+	bodies = a.FormatPRComment(results)
+	if len(bodies) > maxCommentParts {
+		t.Fatalf("FormatPRComment() = %d bodies, want at most %d", len(bodies), maxCommentParts)
+	}
+	all := strings.Join(bodies, "\n")
+	for _, r := range results {
+		if !strings.Contains(all, "[full diff]("+r.ReportURL+")") {
+			t.Errorf("FormatPRComment() has no link to the full %s diff", r.Environment)
+		}
+	}
+	if got := strings.Count(all, "Replicas scaled to zero"); got != len(results) {
+		t.Errorf("FormatPRComment() has %d risks, want all %d", got, len(results))
+	}
+	for i, body := range bodies {
+		if len(body) > maxCommentLen {
+			t.Errorf("part %d is %d bytes, over GitHub's limit", i+1, len(body))
+		}
+		assertFencesClosed(t, fmt.Sprintf("part %d", i+1), body)
+	}
+}
+
+func TestFormatCheckRun_Overflow(t *testing.T) {
+	results := []domain.DiffResult{{
+		ChartName:    "my-app",
+		Environment:  "prod",
+		Status:       domain.StatusChanges,
+		SemanticDiff: bigDiff(1000),
+		UnifiedDiff:  bigDiff(1000),
+		ReportURL:    "https://chart-val.example.com/reports/prod",
+	}}
+	_, summary, text := reportFormatter{templates: defaultTemplates, appName: "chart-val"}.checkRun(results)
+	if len(text) > maxCheckRunTextLen || len(summary) > maxCheckRunTextLen {
+		t.Errorf("check run summary and text are %d and %d bytes, want at most %d",
+			len(summary), len(text), maxCheckRunTextLen)
+	}
+	if !strings.Contains(text, "[full diff](https://chart-val.example.com/reports/prod)") {
+		t.Errorf("check run text has no link to the full diff")
+	}
+	assertFencesClosed(t, "check run text", text)
+}
+
+func TestAdapter_PostComment_DeletesLaterParts(t *testing.T) {
+	f := &fakeComments{}
+	f.add("<!-- chart-val: my-app -->\nold report, part 1")
+	f.add("<!-- chart-val: my-app (part 2) -->\nold report, part 2")
+	f.add("<!-- chart-val: my-app-2 (part 2) -->\nreport of another chart")
+	a := newTestAdapter(t, f, true)
+
+	results := []domain.DiffResult{{ChartName: "my-app", Environment: "prod", Status: domain.StatusChanges}}
+	if err := a.PostComment(context.Background(), testPR, results); err != nil {
+		t.Fatalf("PostComment() error = %v", err)
+	}
+	if want := []string{"PATCH 1", "DELETE 2"}; !slices.Equal(f.requests, want) {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+}
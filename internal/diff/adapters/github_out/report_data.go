@@ -0,0 +1,249 @@
+package githubout
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// ReportData is what report templates are executed with for the whole PR:
+// the check run and the summary comment.
+type ReportData struct {
+	AppName string // APP_NAME, e.g. "chart-val"
+	AppURL  string // APP_URL; "" if not set
+
+	Results         []domain.DiffResult // Every chart and environment, in the order they were diffed
+	Charts          []ChartReport       // Every chart, in the order first seen
+	ChangedCharts   []ChartReport       // Charts with changes or findings to report
+	UnchangedCharts []ChartReport       // Charts with nothing to report
+	Environments    []string            // Names of every environment of any chart, in the order first seen
+	Counts          Counts              // Totals over Results
+}
+
+// ChartReport is the data of a chart in a report, and what a chart's PR
+// comment templates are executed with.
+type ChartReport struct {
+	Name          string
+	Environments  []EnvReport
+	Changed       bool                 // Whether the chart has changes or findings to report
+	Version       *domain.VersionCheck // nil if the version wasn't checked
+	ValuesChanges []domain.ValuesChange
+	Counts        Counts // Totals over the chart's environments
+}
+
+// Environment returns the chart's report for the named environment, or nil
+// if the chart isn't deployed there.
+func (c ChartReport) Environment(name string) *EnvReport {
+	i := slices.IndexFunc(c.Environments, func(e EnvReport) bool { return e.DiffResult.Environment == name })
+	if i < 0 {
+		return nil
+	}
+	return &c.Environments[i]
+}
+
+// withLimits returns c with its diffs cut to limits.
+func (c ChartReport) withLimits(limits diffLimits) ChartReport {
+	envs := make([]EnvReport, len(c.Environments))
+	for i, e := range c.Environments {
+		envs[i] = e.withLimits(limits)
+	}
+	c.Environments = envs
+	return c
+}
+
+// EnvReport is the data of one environment of a chart: its DiffResult, with
+// counts and labels derived from it.
+type EnvReport struct {
+	domain.DiffResult
+
+	// "success", "changes", "error" or "invalid"; DiffResult.Status is the domain value
+	Status string
+	// Highest risk of the changes: "low", "medium" or "high"
+	Risk string
+	// Changed resources by kind, e.g. "2 Deployments modified, 1 Service added"; "" if none
+	ResourceSummary  string
+	RemovedResources []domain.ResourceChange
+
+	// The diff a PR comment shows: the semantic diff (falling back to the
+	// line diff), or the line diff in the line-diff comment
+	Diff string
+
+	LintErrors     int
+	LintWarnings   int
+	PolicyFailures int
+	PolicyWarnings int
+	RemovedAPIs    int
+	DeprecatedAPIs int
+
+	limits diffLimits
+}
+
+// Cut returns diff cut to what fits in the report, for the "diff" template.
+func (e EnvReport) Cut(diff string) DiffCut {
+	shown, omitted := e.limits.cut(diff)
+	fence := "```"
+	for strings.Contains(shown, fence) {
+		fence += "`"
+	}
+	return DiffCut{Shown: shown, Fence: fence, Omitted: omitted, ReportURL: e.ReportURL}
+}
+
+// withLimits returns e with its diffs cut to limits.
+func (e EnvReport) withLimits(limits diffLimits) EnvReport {
+	e.limits = limits
+	return e
+}
+
+// DiffCut is a diff as a report shows it.
+type DiffCut struct {
+	Shown     string // The lines that fit; the whole diff unless the report is too large for GitHub
+	Fence     string // Code fence longer than any run of backticks in Shown
+	Omitted   int    // Number of lines left out
+	ReportURL string // Where the full diff can be read; "" if not stored
+}
+
+// Counts are totals over a set of results.
+type Counts struct {
+	Charts          int
+	ChangedCharts   int
+	UnchangedCharts int
+
+	// Environments by status
+	Unchanged int
+	Changes   int
+	Errors    int
+	Invalid   int
+
+	// Changed resources
+	Resources         int
+	ModifiedResources int
+	AddedResources    int
+	RemovedResources  int
+	Suppressed        int // Changes hidden by ignore rules
+
+	LintErrors      int
+	LintWarnings    int
+	PolicyFailures  int
+	PolicyWarnings  int
+	RemovedAPIs     int
+	DeprecatedAPIs  int
+	IntroducedAPIs  int // Removed and deprecated API versions the PR introduces
+	FailedAPIs      int // Removed API versions that fail the check run
+	HighRisk        int // Environments with high-risk changes
+	VersionFailures int // Charts whose version check failed
+	ValuesChanges   int // Breaking values changes, over all charts
+	StaleOverrides  int
+	StaleFailures   int // Stale overrides that fail the check run
+	UnusedValues    int
+}
+
+// newReportData returns the report data of results, with the diffs
+// diffContent picks cut to limits.
+func newReportData(
+	appName, appURL string,
+	results []domain.DiffResult,
+	diffContent func(domain.DiffResult) string,
+	limits diffLimits,
+) ReportData {
+	data := ReportData{AppName: appName, AppURL: appURL, Results: results, Counts: countResults(results)}
+	grouped, chartOrder := groupResultsByChart(results)
+	for _, name := range chartOrder {
+		chart := newChartReport(grouped[name], diffContent, limits)
+		data.Charts = append(data.Charts, chart)
+		if chart.Changed {
+			data.ChangedCharts = append(data.ChangedCharts, chart)
+		} else {
+			data.UnchangedCharts = append(data.UnchangedCharts, chart)
+		}
+		for _, r := range grouped[name] {
+			if !slices.Contains(data.Environments, r.Environment) {
+				data.Environments = append(data.Environments, r.Environment)
+			}
+		}
+	}
+	return data
+}
+
+// newChartReport returns the report data of a chart's results, with the
+// diffs diffContent picks cut to limits.
+func newChartReport(
+	results []domain.DiffResult,
+	diffContent func(domain.DiffResult) string,
+	limits diffLimits,
+) ChartReport {
+	chart := ChartReport{
+		Name:          results[0].ChartName,
+		Changed:       slices.ContainsFunc(results, domain.DiffResult.HasFindings),
+		Version:       chartVersionCheck(results),
+		ValuesChanges: chartValuesChanges(results),
+		Counts:        countResults(results),
+	}
+	for _, r := range results {
+		chart.Environments = append(chart.Environments, newEnvReport(r, diffContent(r), limits))
+	}
+	return chart
+}
+
+func newEnvReport(r domain.DiffResult, diff string, limits diffLimits) EnvReport {
+	env := EnvReport{
+		DiffResult:       r,
+		Status:           strings.ToLower(r.Status.String()),
+		Risk:             domain.HighestRisk(r.Risks).String(),
+		RemovedResources: domain.DeletedResources(r.Resources),
+		Diff:             diff,
+		limits:           limits,
+	}
+	if r.Status == domain.StatusChanges {
+		env.ResourceSummary = domain.SummarizeResourceChanges(r.Resources)
+	}
+	env.LintErrors, env.LintWarnings = domain.CountLintFindings(r.LintFindings)
+	env.PolicyFailures, env.PolicyWarnings = domain.CountPolicyViolations(r.PolicyViolations)
+	env.RemovedAPIs, env.DeprecatedAPIs = domain.CountDeprecations(r.Deprecations)
+	return env
+}
+
+// countResults returns the totals over results.
+func countResults(results []domain.DiffResult) Counts {
+	var c Counts
+	grouped, chartOrder := groupResultsByChart(results)
+	changed, unchanged := separateChangedCharts(grouped, chartOrder)
+	c.Charts, c.ChangedCharts, c.UnchangedCharts = len(chartOrder), len(changed), len(unchanged)
+	c.Unchanged, c.Changes, c.Errors, c.Invalid = domain.CountByStatus(results)
+
+	var resources []domain.ResourceChange
+	for _, r := range results {
+		resources = append(resources, r.Resources...)
+		c.Suppressed += r.Suppressed
+	}
+	c.Resources = len(resources)
+	c.ModifiedResources = len(domain.FilterResourceChanges(resources, domain.ChangeModified))
+	c.AddedResources = len(domain.FilterResourceChanges(resources, domain.ChangeAdded))
+	c.RemovedResources = len(domain.DeletedResources(resources))
+
+	c.LintErrors, c.LintWarnings = countLintFindings(results)
+	c.PolicyFailures, c.PolicyWarnings = countPolicyViolations(results)
+	c.RemovedAPIs, c.DeprecatedAPIs, c.IntroducedAPIs = countDeprecations(results)
+	c.FailedAPIs = countFailedDeprecations(results)
+	c.HighRisk = countHighRisk(results)
+	c.VersionFailures = countVersionFailures(results)
+	c.ValuesChanges = countValuesChanges(results)
+	c.StaleFailures, c.StaleOverrides = countStaleOverrides(results)
+	c.StaleOverrides += c.StaleFailures
+	c.UnusedValues = countUnusedValues(results)
+	return c
+}
+
+// Heading is what the comment_heading template is executed with.
+type Heading struct {
+	Kind  string // headingDiff, headingUnified (the line-diff comment) or headingSummary
+	Chart string // "" for the summary comment
+	Part  int    // Which of the comments a report is split across, from 1
+}
+
+// Heading kinds.
+const (
+	headingDiff    = "diff"
+	headingUnified = "unified"
+	headingSummary = "summary"
+)
@@ -0,0 +1,52 @@
+package githubout
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// PostSummaryComment posts one PR comment with the results of every chart,
+// editing the summary comment from an earlier push in place if there is one.
+// If no chart has changes, that comment is collapsed and none is created.
+func (a *Adapter) PostSummaryComment(ctx context.Context, pr domain.PRContext, results []domain.DiffResult) error {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	comments, err := a.listComments(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	f := a.formatter(a.templatesFor(ctx, pr))
+	marker := a.summaryCommentMarker()
+	grouped, chartOrder := groupResultsByChart(results)
+	if changed, _ := separateChangedCharts(grouped, chartOrder); len(changed) == 0 {
+		logger.Info("no changes in any chart, skipping summary comment", "pr", pr.PRNumber)
+		body := f.collapsedComment(marker, headingSummary, "")
+		if err := a.collapseComment(ctx, pr, comments, marker, body); err != nil {
+			return fmt.Errorf("collapsing PR summary comment: %w", err)
+		}
+		return nil
+	}
+
+	logger.Info("posting PR summary comment", "charts", len(chartOrder), "pr", pr.PRNumber)
+	if err := a.upsertComments(ctx, pr, comments, marker, f.summaryComment(results, marker).bodies()); err != nil {
+		return fmt.Errorf("posting PR summary comment: %w", err)
+	}
+	logger.Info("PR summary comment posted successfully", "pr", pr.PRNumber)
+	return nil
+}
+
+// FormatPRSummaryComment formats the PR comment bodies for the results of
+// every chart: a chart × environment matrix, then a collapsible section per
+// chart with changes. There is more than one body if the report doesn't fit
+// in a single comment. Exported for use in integration tests.
+func (a *Adapter) FormatPRSummaryComment(results []domain.DiffResult) []string {
+	if len(results) == 0 {
+		return nil
+	}
+	return a.formatter(a.templates).summaryComment(results, a.summaryCommentMarker()).bodies()
+}
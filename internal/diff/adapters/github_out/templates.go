@@ -0,0 +1,243 @@
+package githubout
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	gogithub "github.com/google/go-github/v68/github"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+//go:embed templates/*.md.tmpl
+var defaultTemplateFS embed.FS
+
+// templateExt is the extension of template files, both the embedded
+// defaults and overrides.
+const templateExt = ".tmpl"
+
+// Templates renders check runs and PR comments from Go text/template
+// definitions. The defaults are embedded; overrides redefine any of the
+// named templates, and the rest keep their default definition.
+type Templates struct {
+	set *template.Template
+}
+
+// defaultTemplates holds the embedded defaults, which overrides fall back on.
+var defaultTemplates = &Templates{
+	set: template.Must(template.New("").ParseFS(defaultTemplateFS, "templates/*"+templateExt)),
+}
+
+// LoadTemplates returns the default templates overridden by the *.tmpl files
+// in dir, or the defaults if dir is "". Returns an error if a file can't be
+// read or parsed, or a template fails to render.
+func LoadTemplates(dir string) (*Templates, error) {
+	if dir == "" {
+		return defaultTemplates, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading template directory: %w", err)
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != templateExt {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading template: %w", err)
+		}
+		files[e.Name()] = string(content)
+	}
+	return defaultTemplates.override(files)
+}
+
+// override returns t with the templates defined in files, keyed by file
+// name, replacing those of the same name. Each is checked by rendering it
+// with sample data.
+func (t *Templates) override(files map[string]string) (*Templates, error) {
+	if len(files) == 0 {
+		return t, nil
+	}
+	set, err := t.set.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("cloning templates: %w", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if _, err := set.New(name).Parse(files[name]); err != nil {
+			return nil, fmt.Errorf("parsing template %s: %w", name, err)
+		}
+	}
+	overridden := &Templates{set: set}
+	if err := overridden.check(); err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
+// check renders every top-level template with sample data, so an override
+// that refers to data that doesn't exist is rejected when it's loaded.
+func (t *Templates) check() error {
+	data := sampleReportData()
+	chart := data.Charts[0]
+	samples := []struct {
+		name string
+		data any
+	}{
+		{"check_run_title", data},
+		{"check_run_in_progress", data},
+		{"check_run_summary", data},
+		{"check_run_text", data},
+		{"comment_heading", Heading{Kind: headingDiff, Chart: chart.Name, Part: 2}},
+		{"comment_head", chart},
+		{"comment_footer", data},
+		{"comment_collapsed", data},
+		{"summary_comment_head", data},
+		{"summary_comment_chart", chart},
+	}
+	for _, env := range chart.Environments {
+		samples = append(samples, struct {
+			name string
+			data any
+		}{"comment_env", env})
+	}
+	for _, s := range samples {
+		if err := t.set.ExecuteTemplate(io.Discard, s.name, s.data); err != nil {
+			return fmt.Errorf("rendering template %q: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+// execute renders the named template with data. If an override fails to
+// render, the default is used instead, so a report is always posted.
+func (t *Templates) execute(name string, data any) string {
+	if t == nil {
+		t = defaultTemplates
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	var sb strings.Builder
+	err := t.set.ExecuteTemplate(&sb, name, data)
+	switch {
+	case err == nil:
+	case t != defaultTemplates:
+		logger.Warn("report template failed, using the default", "template", name, "error", err)
+		return defaultTemplates.execute(name, data)
+	default:
+		logger.Error("default report template failed", "template", name, "error", err)
+	}
+	return sb.String()
+}
+
+// sampleReportData returns report data with every field set, for checking
+// templates.
+func sampleReportData() ReportData {
+	removed := domain.ResourceChange{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Type: domain.ChangeRemoved}
+	env := domain.DiffResult{
+		ChartName:    "my-app",
+		Environment:  "prod",
+		Status:       domain.StatusChanges,
+		SemanticDiff: "~ replicas: 1 -> 3",
+		UnifiedDiff:  "-replicas: 1\n+replicas: 3",
+		Resources:    []domain.ResourceChange{removed},
+		Suppressed:   1,
+		Violations:   []domain.SchemaViolation{{Resource: "v1/Service/my-app", Message: "unknown field"}},
+		LintFindings: []domain.LintFinding{
+			{File: "env/prod-values.yaml", Line: 3, Key: "image.tag", Message: "bad", Severity: domain.SeverityError},
+		},
+		PolicyViolations: []domain.PolicyViolation{{Policy: "p", Message: "m", Severity: domain.SeverityWarning}},
+		Deprecations:     []domain.APIDeprecation{{APIVersion: "v1beta1", Kind: "K", Removed: true, Severity: domain.SeverityError}},
+		Risks:            []domain.ChangeRisk{{Resource: removed.ID(), Level: domain.RiskHigh, Detail: "deleted"}},
+		ChartVersion:     &domain.VersionCheck{BaseVersion: "1.0.0", HeadVersion: "1.0.1", Bump: domain.BumpPatch},
+		ValuesChanges:    []domain.ValuesChange{{Path: []string{"image", "tag"}, Type: domain.ValuesKeyRemoved}},
+		StaleOverrides:   []domain.StaleOverride{{File: "env/prod-values.yaml", Line: 3}},
+		UnusedValues:     []domain.UnusedValue{{File: "env/prod-values.yaml", Path: []string{"replica"}}},
+	}
+	results := []domain.DiffResult{env}
+	for _, status := range []domain.Status{domain.StatusSuccess, domain.StatusError, domain.StatusInvalid} {
+		r := env
+		r.Environment, r.Status = strings.ToLower(status.String()), status
+		results = append(results, r)
+	}
+	return newReportData("chart-val", "https://chart-val.example.com", results, domain.DiffResult.PreferredDiff,
+		diffLimits{env.SemanticDiff: 5})
+}
+
+// repoTemplates are the templates a target repo's overrides were loaded
+// into, for the push they were loaded for.
+type repoTemplates struct {
+	headSHA   string
+	templates *Templates
+}
+
+// templatesFor returns the templates to report on pr with: the adapter's,
+// overridden by the templates in the target repo's template directory if one
+// is configured. Overrides are read from the base branch, so a PR can't
+// change how its own report is rendered, and are loaded once per push. If
+// they can't be loaded, the adapter's templates are used.
+func (a *Adapter) templatesFor(ctx context.Context, pr domain.PRContext) *Templates {
+	if a.repoTemplateDir == "" {
+		return a.templates
+	}
+	key := pr.Owner + "/" + pr.Repo
+	a.mu.Lock()
+	cached, ok := a.repoTemplates[key]
+	a.mu.Unlock()
+	if ok && cached.headSHA == pr.HeadSHA {
+		return cached.templates
+	}
+
+	templates, err := a.loadRepoTemplates(ctx, pr)
+	if err != nil {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+		logger.Warn("failed to load repo templates, using the configured ones", "repo", key, "error", err)
+		templates = a.templates
+	}
+	a.mu.Lock()
+	a.repoTemplates[key] = repoTemplates{headSHA: pr.HeadSHA, templates: templates}
+	a.mu.Unlock()
+	return templates
+}
+
+// loadRepoTemplates returns the adapter's templates overridden by the *.tmpl
+// files in the template directory of pr's base branch. A repo without the
+// directory has no overrides.
+func (a *Adapter) loadRepoTemplates(ctx context.Context, pr domain.PRContext) (*Templates, error) {
+	opts := &gogithub.RepositoryContentGetOptions{Ref: pr.BaseRef}
+	_, entries, resp, err := a.client.Repositories.GetContents(ctx, pr.Owner, pr.Repo, a.repoTemplateDir, opts)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return a.templates, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", a.repoTemplateDir, err)
+	}
+
+	files := make(map[string]string)
+	for _, e := range entries {
+		if e.GetType() != "file" || path.Ext(e.GetName()) != templateExt {
+			continue
+		}
+		file, _, _, err := a.client.Repositories.GetContents(ctx, pr.Owner, pr.Repo, e.GetPath(), opts)
+		if err != nil {
+			return nil, fmt.Errorf("fetching template %s: %w", e.GetPath(), err)
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return nil, fmt.Errorf("decoding template %s: %w", e.GetPath(), err)
+		}
+		files[e.GetName()] = content
+	}
+	return a.templates.override(files)
+}
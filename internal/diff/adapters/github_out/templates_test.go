@@ -0,0 +1,195 @@
+package githubout
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	gogithub "github.com/google/go-github/v68/github"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const customFooter = `{{define "comment_footer"}}---
+_Rendered by {{.AppName}} for {{.Counts.Charts}} chart(s)_
+{{end}}`
+
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadTemplates_Default(t *testing.T) {
+	templates, err := LoadTemplates("")
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+	if templates != defaultTemplates {
+		t.Error("LoadTemplates(\"\") should return the default templates")
+	}
+	if err := defaultTemplates.check(); err != nil {
+		t.Errorf("default templates fail to render: %v", err)
+	}
+}
+
+func TestLoadTemplates_Override(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"footer.tmpl": customFooter,
+		"README.md":   "not a template {{",
+	})
+	templates, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+
+	a := New(nil, "chart-val", "", true, templates, "")
+	results := []domain.DiffResult{{ChartName: "my-app", Environment: "prod", Status: domain.StatusSuccess}}
+	body := a.FormatPRComment(results)[0]
+	if !strings.HasSuffix(body, "---\n_Rendered by chart-val for 1 chart(s)_\n") {
+		t.Errorf("comment = %q, want the overridden footer", body)
+	}
+	if !strings.HasPrefix(body, "<!-- chart-val: my-app -->\n## 📊 Helm Diff Report: `my-app`\n\n") {
+		t.Errorf("comment = %q, want the default heading kept", body)
+	}
+	// The defaults are left as they were
+	defaults := New(nil, "chart-val", "", true, nil, "")
+	if got := defaults.FormatPRComment(results)[0]; strings.Contains(got, "Rendered by") {
+		t.Errorf("default comment = %q, want the default footer", got)
+	}
+}
+
+func TestLoadTemplates_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"parse error":   `{{define "comment_footer"}}{{.AppName}{{end}}`,
+		"unknown field": `{{define "check_run_title"}}{{.Title}}{{end}}`,
+		"wrong data":    `{{define "comment_env"}}{{.Counts.Charts}}{{end}}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadTemplates(writeTemplates(t, map[string]string{"bad.tmpl": content})); err == nil {
+				t.Error("LoadTemplates() error = nil, want the template rejected")
+			}
+		})
+	}
+
+	if _, err := LoadTemplates(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadTemplates() error = nil, want an error for a missing directory")
+	}
+}
+
+func TestTemplates_ExecuteFallsBack(t *testing.T) {
+	// Renders with the sample data, but not for a chart named "boom"
+	templates, err := defaultTemplates.override(map[string]string{
+		"heading.tmpl": `{{define "comment_heading"}}{{if eq .Chart "boom"}}{{.Missing}}{{end}}## {{.Chart}}{{end}}`,
+	})
+	if err != nil {
+		t.Fatalf("override() error = %v", err)
+	}
+
+	heading := Heading{Kind: headingDiff, Chart: "my-app", Part: 1}
+	if got := templates.execute("comment_heading", heading); got != "## my-app" {
+		t.Errorf("execute() = %q, want the override", got)
+	}
+	heading.Chart = "boom"
+	want := "## 📊 Helm Diff Report: `boom`"
+	if got := templates.execute("comment_heading", heading); got != want {
+		t.Errorf("execute() = %q, want the default %q", got, want)
+	}
+}
+
+// fakeRepoTemplates serves a repo's template directory like the GitHub
+// contents API, counting the directory listings.
+type fakeRepoTemplates struct {
+	files    map[string]string // File name to content; nil serves a 404
+	listings atomic.Int32
+}
+
+func (f *fakeRepoTemplates) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.files == nil || r.URL.Query().Get("ref") != "main" {
+		http.NotFound(w, r)
+		return
+	}
+	name, ok := strings.CutPrefix(r.URL.Path, "/repos/o/r/contents/.chart-val")
+	if name == "" {
+		f.listings.Add(1)
+		entries := []*gogithub.RepositoryContent{}
+		for file := range f.files {
+			entries = append(entries, &gogithub.RepositoryContent{
+				Type: gogithub.Ptr("file"), Name: gogithub.Ptr(file), Path: gogithub.Ptr(".chart-val/" + file),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(entries)
+		return
+	}
+	content, found := f.files[strings.TrimPrefix(name, "/")]
+	if !ok || !found {
+		http.NotFound(w, r)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(&gogithub.RepositoryContent{
+		Type:     gogithub.Ptr("file"),
+		Encoding: gogithub.Ptr("base64"),
+		Content:  gogithub.Ptr(base64.StdEncoding.EncodeToString([]byte(content))),
+	})
+}
+
+func newRepoTemplatesAdapter(t *testing.T, f *fakeRepoTemplates) *Adapter {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	client := gogithub.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return New(client, "chart-val", "", true, nil, ".chart-val")
+}
+
+func TestAdapter_TemplatesFor(t *testing.T) {
+	pr := domain.PRContext{Owner: "o", Repo: "r", PRNumber: 1, BaseRef: "main", HeadSHA: "abc"}
+	ctx := context.Background()
+
+	t.Run("repo overrides", func(t *testing.T) {
+		f := &fakeRepoTemplates{files: map[string]string{"footer.tmpl": customFooter, "notes.txt": "{{"}}
+		a := newRepoTemplatesAdapter(t, f)
+		templates := a.templatesFor(ctx, pr)
+		footer := templates.execute("comment_footer", ReportData{AppName: "chart-val"})
+		if footer != "---\n_Rendered by chart-val for 0 chart(s)_\n" {
+			t.Errorf("comment_footer = %q, want the repo's override", footer)
+		}
+		if a.templatesFor(ctx, pr) != templates || f.listings.Load() != 1 {
+			t.Errorf("templates listed %d times, want them loaded once per push", f.listings.Load())
+		}
+		next := pr
+		next.HeadSHA = "def"
+		a.templatesFor(ctx, next)
+		if f.listings.Load() != 2 {
+			t.Errorf("templates listed %d times, want them reloaded on a new push", f.listings.Load())
+		}
+	})
+
+	t.Run("no template directory", func(t *testing.T) {
+		a := newRepoTemplatesAdapter(t, &fakeRepoTemplates{})
+		if a.templatesFor(ctx, pr) != defaultTemplates {
+			t.Error("templatesFor() should use the configured templates when the repo has none")
+		}
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		a := newRepoTemplatesAdapter(t, &fakeRepoTemplates{files: map[string]string{"bad.tmpl": "{{.Nope"}})
+		if a.templatesFor(ctx, pr) != defaultTemplates {
+			t.Error("templatesFor() should fall back to the configured templates")
+		}
+	})
+}
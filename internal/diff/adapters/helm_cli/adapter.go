@@ -3,14 +3,20 @@ package helmcli
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/helmvalues"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
 )
 
+const defaultReleaseName = "chart-val-render"
+
 // Adapter implements ports.RendererPort by shelling out to the helm CLI.
 type Adapter struct {
 	helmBin string
@@ -27,12 +33,23 @@ func New() (*Adapter, error) {
 }
 
 // Render runs `helm template` on the given chart directory with the
-// specified value files and returns the rendered manifest bytes.
-func (a *Adapter) Render(ctx context.Context, chartDir string, valueFiles []string) ([]byte, error) {
-	args := make([]string, 0, 3+2*len(valueFiles))
-	args = append(args, "template", "chart-val-render", chartDir)
-	for _, vf := range valueFiles {
-		args = append(args, "-f", filepath.Join(chartDir, vf))
+// specified value files and render options, and returns the rendered
+// manifest bytes.
+func (a *Adapter) Render(
+	ctx context.Context,
+	chartDir string,
+	valueFiles []string,
+	opts domain.RenderOptions,
+) ([]byte, error) {
+	inlineValuesFile, cleanup, err := helmvalues.WriteInline(opts.Values)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	args, err := templateArgs(chartDir, valueFiles, inlineValuesFile, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
@@ -54,3 +71,46 @@ func (a *Adapter) Render(ctx context.Context, chartDir string, valueFiles []stri
 	logger.Info("helm command completed", "outputSize", len(stdout.Bytes()))
 	return stdout.Bytes(), nil
 }
+
+// templateArgs builds the `helm template` argument list. Inline values are
+// passed as the last -f file and parameters as --set flags, which gives
+// Argo CD's precedence: value files < inline values < parameters.
+func templateArgs(
+	chartDir string,
+	valueFiles []string,
+	inlineValuesFile string,
+	opts domain.RenderOptions,
+) ([]string, error) {
+	args := make([]string, 0, 3+2*len(valueFiles)+6+2*len(opts.APIVersions)+2*len(opts.Parameters))
+	args = append(args, "template", cmp.Or(opts.ReleaseName, defaultReleaseName), chartDir)
+	for _, vf := range valueFiles {
+		args = append(args, "-f", filepath.Join(chartDir, vf))
+	}
+	if inlineValuesFile != "" {
+		args = append(args, "-f", inlineValuesFile)
+	}
+	for _, p := range opts.Parameters {
+		switch {
+		case p.File:
+			path, err := p.ResolveFile(chartDir)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "--set-file", p.Name+"="+path)
+		case p.ForceString:
+			args = append(args, "--set-string", p.SetArg())
+		default:
+			args = append(args, "--set", p.SetArg())
+		}
+	}
+	if opts.Namespace != "" {
+		args = append(args, "--namespace", opts.Namespace)
+	}
+	if opts.KubeVersion != "" {
+		args = append(args, "--kube-version", opts.KubeVersion)
+	}
+	for _, api := range opts.APIVersions {
+		args = append(args, "--api-versions", api)
+	}
+	return args, nil
+}
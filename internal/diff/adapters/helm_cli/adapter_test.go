@@ -0,0 +1,82 @@
+package helmcli
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+func TestTemplateArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		valueFiles   []string
+		inlineValues string
+		opts         domain.RenderOptions
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name: "defaults",
+			want: []string{"template", "chart-val-render", "/tmp/chart"},
+		},
+		{
+			name:       "value files joined to chart dir",
+			valueFiles: []string{"env/prod-values.yaml"},
+			want:       []string{"template", "chart-val-render", "/tmp/chart", "-f", "/tmp/chart/env/prod-values.yaml"},
+		},
+		{
+			name: "all render options",
+			opts: domain.RenderOptions{
+				ReleaseName: "my-app",
+				Namespace:   "my-app-prod",
+				KubeVersion: "1.29.0",
+				APIVersions: []string{"monitoring.coreos.com/v1", "policy/v1"},
+			},
+			want: []string{
+				"template", "my-app", "/tmp/chart",
+				"--namespace", "my-app-prod",
+				"--kube-version", "1.29.0",
+				"--api-versions", "monitoring.coreos.com/v1",
+				"--api-versions", "policy/v1",
+			},
+		},
+		{
+			name:         "inline values and parameters after value files",
+			valueFiles:   []string{"env/prod-values.yaml"},
+			inlineValues: "/tmp/inline.yaml",
+			opts: domain.RenderOptions{Parameters: []domain.HelmParameter{
+				{Name: "image.tag", Value: "1.2.3"},
+				{Name: "build", Value: "0012", ForceString: true},
+				{Name: "config", Value: "files/config.json", File: true},
+			}},
+			want: []string{
+				"template", "chart-val-render", "/tmp/chart",
+				"-f", "/tmp/chart/env/prod-values.yaml",
+				"-f", "/tmp/inline.yaml",
+				"--set", "image.tag=1.2.3",
+				"--set-string", "build=0012",
+				"--set-file", "config=/tmp/chart/files/config.json",
+			},
+		},
+		{
+			name: "file parameter outside chart",
+			opts: domain.RenderOptions{Parameters: []domain.HelmParameter{
+				{Name: "key", Value: "../../../root/.ssh/id_rsa", File: true},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := templateArgs("/tmp/chart", tt.valueFiles, tt.inlineValues, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("templateArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("templateArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package helmlint checks charts the way `helm lint` does, and validates each
+// environment's values against the chart's values.schema.json, reporting
+// problems per file and values key.
+package helmlint
+
+import (
+	"cmp"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/lint/rules"
+	"helm.sh/helm/v3/pkg/lint/support"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const defaultNamespace = "default"
+
+// Adapter implements ports.ChartLinterPort with Helm's lint rules, run
+// in-process with each environment's values.
+type Adapter struct {
+	getters getter.Providers
+}
+
+// New creates a new chart linter.
+func New() *Adapter {
+	return &Adapter{getters: getter.All(cli.New())}
+}
+
+// Lint merges the environment's values the way the renderer does, then runs
+// Helm's Chart.yaml, template and dependency lint rules and validates the
+// merged values against the chart's values.schema.json. Informational lint
+// messages are dropped, and so are deprecated API versions, which
+// ports.DeprecationCheckerPort reports for the environment's cluster.
+func (a *Adapter) Lint(chartDir string, valueFiles []string, opts domain.RenderOptions) []domain.LintFinding {
+	sources, findings := readSources(chartDir, valueFiles, opts)
+	if len(findings) > 0 {
+		return findings
+	}
+	vals, err := a.mergeValues(chartDir, valueFiles, opts)
+	if err != nil {
+		return []domain.LintFinding{{Message: err.Error(), Severity: domain.SeverityError}}
+	}
+
+	findings = lintRules(chartDir, vals, opts)
+	return append(findings, validateValues(chartDir, vals, sources)...)
+}
+
+// lintRules runs the rules `helm lint` does, other than values
+// validation, which validateValues reports per key.
+func lintRules(chartDir string, vals map[string]any, opts domain.RenderOptions) []domain.LintFinding {
+	linter := support.Linter{ChartDir: chartDir}
+	if abs, err := filepath.Abs(chartDir); err == nil {
+		linter.ChartDir = abs
+	}
+	// An invalid kube version fails the render, which reports it
+	kubeVersion, _ := chartutil.ParseKubeVersion(opts.KubeVersion)
+
+	rules.Chartfile(&linter)
+	rules.TemplatesWithSkipSchemaValidation(&linter, vals, cmp.Or(opts.Namespace, defaultNamespace), kubeVersion, true)
+	rules.Dependencies(&linter)
+
+	var findings []domain.LintFinding
+	for _, m := range linter.Messages {
+		severity, ok := lintSeverity(m.Severity)
+		if !ok || isDeprecation(m.Err) {
+			continue
+		}
+		findings = append(findings, lintFinding(m.Path, m.Err, severity))
+	}
+	return findings
+}
+
+// lintFinding converts a lint message, pointing template failures at the
+// template and line Helm reports.
+func lintFinding(path string, err error, severity domain.Severity) domain.LintFinding {
+	if renderErr := domain.ParseRenderError(err.Error()); renderErr != nil {
+		// Helm names templates after the chart, e.g. "my-app/templates/deployment.yaml"
+		_, template, _ := strings.Cut(renderErr.Template, "/")
+		return domain.LintFinding{
+			File:     template,
+			Line:     renderErr.Line,
+			Message:  renderErr.Message,
+			Severity: severity,
+		}
+	}
+	return domain.LintFinding{File: path, Message: err.Error(), Severity: severity}
+}
+
+func lintSeverity(sev int) (domain.Severity, bool) {
+	switch sev {
+	case support.ErrorSev:
+		return domain.SeverityError, true
+	case support.WarningSev:
+		return domain.SeverityWarning, true
+	default:
+		return "", false
+	}
+}
+
+// isDeprecation reports whether a lint message is Helm's deprecated API
+// warning, e.g. "batch/v1beta1 CronJob is deprecated in v1.21+, unavailable
+// in v1.25+; use batch/v1 CronJob". Helm doesn't export its error type.
+func isDeprecation(err error) bool {
+	return strings.Contains(err.Error(), " is deprecated in v")
+}
@@ -0,0 +1,142 @@
+package helmlint
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+func TestAdapter_Lint(t *testing.T) {
+	tests := []struct {
+		name       string
+		chart      string
+		valueFiles []string
+		opts       domain.RenderOptions
+		want       []domain.LintFinding
+	}{
+		{
+			name:       "valid values",
+			chart:      "schema-chart",
+			valueFiles: []string{"env/dev-values.yaml"},
+		},
+		{
+			name:       "schema violations point at the value file, line and key",
+			chart:      "schema-chart",
+			valueFiles: []string{"env/prod-values.yaml"},
+			want: []domain.LintFinding{
+				schemaError("env/prod-values.yaml", 3, "image.tag", "got number, want string"),
+				schemaError("env/prod-values.yaml", 6, "ports[0].port", "got string, want integer"),
+				schemaError("env/prod-values.yaml", 1, "replicas", "got string, want integer"),
+			},
+		},
+		{
+			name:       "undeclared key",
+			chart:      "schema-chart",
+			valueFiles: []string{"env/typo-values.yaml"},
+			want: []domain.LintFinding{
+				schemaError("env/typo-values.yaml", 1, "replica", "is not allowed by the schema"),
+			},
+		},
+		{
+			name:       "the highest-precedence source is blamed",
+			chart:      "schema-chart",
+			valueFiles: []string{"env/dev-values.yaml"},
+			opts: domain.RenderOptions{
+				Values:     "image:\n  tag: null\n",
+				Parameters: []domain.HelmParameter{{Name: "replicas", Value: "0"}},
+			},
+			want: []domain.LintFinding{
+				schemaError("inline values", 2, "image.tag", "is required"),
+				schemaError("parameter replicas", 0, "replicas", "minimum: got 0, want 1"),
+			},
+		},
+		{
+			name:  "template failure points at the template line",
+			chart: "broken-chart",
+			want: []domain.LintFinding{
+				{
+					File:     "templates/configmap.yaml",
+					Line:     6,
+					Message:  `function "nosuchfunc" not defined`,
+					Severity: domain.SeverityError,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New().Lint(filepath.Join("testdata", tt.chart), tt.valueFiles, tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func schemaError(file string, line int, key, msg string) domain.LintFinding {
+	return domain.LintFinding{File: file, Line: line, Key: key, Message: msg, Severity: domain.SeverityError}
+}
+
+func TestAdapter_Lint_InvalidValueFile(t *testing.T) {
+	chartDir := filepath.Join("testdata", "schema-chart")
+	got := New().Lint(chartDir, []string{"env/broken-values.yaml"}, domain.RenderOptions{})
+	if len(got) != 1 || got[0].File != "env/broken-values.yaml" || got[0].Severity != domain.SeverityError {
+		t.Errorf("Lint() = %#v, want one error for env/broken-values.yaml", got)
+	}
+}
+
+func TestKeyLine(t *testing.T) {
+	data := []byte(`base: &base
+  tag: "1.0"
+image:
+  repository: image
+  tag: "1.2"
+ports:
+  - name: http
+    port: 80
+copy: *base
+`)
+	tests := []struct {
+		path []string
+		want int
+	}{
+		{[]string{"image", "tag"}, 5},
+		{[]string{"image", "repository"}, 4},
+		{[]string{"ports", "0", "port"}, 8},
+		{[]string{"copy", "tag"}, 2},
+		{[]string{"image", "digest"}, 0},
+		{[]string{"ports", "1"}, 0},
+		{[]string{"image", "tag", "deeper"}, 0},
+	}
+	for _, tt := range tests {
+		if got := keyLine(data, tt.path); got != tt.want {
+			t.Errorf("keyLine(%v) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+	if got := keyLine(nil, []string{"image"}); got != 0 {
+		t.Errorf("keyLine(nil) = %d, want 0", got)
+	}
+}
+
+func TestFormatKey(t *testing.T) {
+	vals := map[string]any{
+		"ports": []any{map[string]any{"name": "http"}},
+		"env":   map[string]any{"0": "zero"},
+	}
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"ports", "0", "name"}, "ports[0].name"},
+		{[]string{"env", "0"}, "env.0"},
+		{[]string{"missing", "key"}, "missing.key"},
+	}
+	for _, tt := range tests {
+		if got := formatKey(tt.path, vals); got != tt.want {
+			t.Errorf("formatKey(%v) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package helmlint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const schemaURL = "file:///values.schema.json"
+
+var printer = message.NewPrinter(language.English)
+
+// validateValues validates the environment's values, coalesced with the
+// chart's defaults as Helm renders them, against the values.schema.json of
+// the chart and of each subchart given values. Each failure is a finding for
+// the key and the source that set it.
+func validateValues(chartDir string, vals map[string]any, sources []valuesSource) []domain.LintFinding {
+	chrt, err := loader.Load(chartDir)
+	if err != nil {
+		return nil // The template lint rule reports charts that don't load
+	}
+	coalesced, err := chartutil.CoalesceValues(chrt, vals)
+	if err != nil {
+		msg := fmt.Sprintf("coalescing values: %v", err)
+		return []domain.LintFinding{{Message: msg, Severity: domain.SeverityError}}
+	}
+	v := valuesValidator{root: coalesced, sources: sources}
+	return v.validateChart(chrt, coalesced, nil)
+}
+
+// valuesValidator validates the values of a chart and its subcharts.
+type valuesValidator struct {
+	root    map[string]any // Coalesced values of the top-level chart
+	sources []valuesSource
+}
+
+// validateChart validates vals, the values of chrt found at prefix in the
+// top-level values, and recurses into its subcharts.
+func (v valuesValidator) validateChart(chrt *chart.Chart, vals map[string]any, prefix []string) []domain.LintFinding {
+	var findings []domain.LintFinding
+	if chrt.Schema != nil {
+		findings = v.validateSchema(chrt.Schema, vals, prefix)
+	}
+	for _, sub := range chrt.Dependencies() {
+		raw, ok := vals[sub.Name()]
+		if !ok || raw == nil {
+			continue
+		}
+		path := join(prefix, nil, sub.Name())
+		subVals, ok := raw.(map[string]any)
+		if !ok {
+			findings = append(findings, v.keyFinding(path, fmt.Sprintf("got %T, want object", raw)))
+			continue
+		}
+		findings = append(findings, v.validateChart(sub, subVals, path)...)
+	}
+	return findings
+}
+
+func (v valuesValidator) validateSchema(schemaJSON []byte, vals map[string]any, prefix []string) []domain.LintFinding {
+	schema, err := compileSchema(schemaJSON)
+	if err != nil {
+		return []domain.LintFinding{{File: schemaFile(prefix), Message: err.Error(), Severity: domain.SeverityError}}
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(schema.Validate(vals), &validationErr) {
+		return nil
+	}
+
+	var findings []domain.LintFinding
+	for _, leaf := range leafErrors(validationErr) {
+		loc := leaf.InstanceLocation
+		switch k := leaf.ErrorKind.(type) {
+		case *kind.Required:
+			for _, missing := range k.Missing {
+				findings = append(findings, v.keyFinding(join(prefix, loc, missing), "is required"))
+			}
+		case *kind.AdditionalProperties:
+			for _, extra := range k.Properties {
+				findings = append(findings, v.keyFinding(join(prefix, loc, extra), "is not allowed by the schema"))
+			}
+		default:
+			findings = append(findings, v.keyFinding(join(prefix, loc), leaf.ErrorKind.LocalizedString(printer)))
+		}
+	}
+	slices.SortStableFunc(findings, func(a, b domain.LintFinding) int { return strings.Compare(a.Key, b.Key) })
+	return findings
+}
+
+// compileSchema compiles a values schema the way Helm does, without
+// fetching remote references.
+func compileSchema(schemaJSON []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("invalid values schema: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, fmt.Errorf("invalid values schema: %w", err)
+	}
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid values schema: %w", err)
+	}
+	return schema, nil
+}
+
+// leafErrors returns the most specific failures under err, each of which
+// names a single key and problem.
+func leafErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, leafErrors(cause)...)
+	}
+	return leaves
+}
+
+// keyFinding is a schema failure at path in the top-level values.
+func (v valuesValidator) keyFinding(path []string, msg string) domain.LintFinding {
+	src := setBy(v.sources, path)
+	return domain.LintFinding{
+		File:     src.name,
+		Line:     keyLine(src.data, path),
+		Key:      formatKey(path, v.root),
+		Message:  msg,
+		Severity: domain.SeverityError,
+	}
+}
+
+// formatKey formats path the way values are written in --set flags, e.g.
+// "ports[0].name", looking up which elements index a list in vals.
+func formatKey(path []string, vals map[string]any) string {
+	var sb strings.Builder
+	var node any = vals
+	for _, elem := range path {
+		list, isList := node.([]any)
+		if i, ok := listIndex(list, elem); isList && ok {
+			fmt.Fprintf(&sb, "[%d]", i)
+			node = list[i]
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(elem)
+		if m, ok := node.(map[string]any); ok {
+			node = m[elem]
+		} else {
+			node = nil
+		}
+	}
+	return sb.String()
+}
+
+func listIndex(list []any, elem string) (int, bool) {
+	i, err := strconv.Atoi(elem)
+	return i, err == nil && i >= 0 && i < len(list)
+}
+
+// join returns the full path of a key: the chart's prefix, the location of
+// the failing value and, for a missing or extra property, its name.
+func join(prefix, loc []string, name ...string) []string {
+	return slices.Concat(prefix, loc, name)
+}
+
+// schemaFile names the values schema of the chart at prefix, e.g.
+// "charts/redis/values.schema.json" for a subchart.
+func schemaFile(prefix []string) string {
+	var sb strings.Builder
+	for _, sub := range prefix {
+		sb.WriteString("charts/" + sub + "/")
+	}
+	return sb.String() + "values.schema.json"
+}
@@ -0,0 +1,221 @@
+package helmlint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/strvals"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/helmvalues"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// valuesSource is one of the places an environment's values come from, so
+// a problem with a key can be traced to the file that set it.
+type valuesSource struct {
+	name   string // e.g. "env/prod-values.yaml", "inline values" or "parameter image.tag"
+	values map[string]any
+	data   []byte // The YAML the values were parsed from; nil for parameters
+}
+
+// readSources parses each source of the environment's values, highest
+// precedence first: parameters, inline values, value files from last to
+// first, then the chart's values.yaml. Returns a finding for each source
+// that can't be parsed.
+func readSources(
+	chartDir string,
+	valueFiles []string,
+	opts domain.RenderOptions,
+) ([]valuesSource, []domain.LintFinding) {
+	var sources []valuesSource
+	var findings []domain.LintFinding
+
+	for _, p := range slices.Backward(opts.Parameters) {
+		// Only the keys matter; the merge reports bad parameter values
+		keys := map[string]any{}
+		if err := strvals.ParseInto(p.Name+"=x", keys); err == nil {
+			sources = append(sources, valuesSource{name: "parameter " + p.Name, values: keys})
+		}
+	}
+	if opts.Values != "" {
+		vals, err := chartutil.ReadValues([]byte(opts.Values))
+		if err != nil {
+			findings = append(findings, invalidValues("inline values", err))
+		}
+		sources = append(sources, valuesSource{name: "inline values", values: vals, data: []byte(opts.Values)})
+	}
+	for _, vf := range slices.Backward(valueFiles) {
+		vals, data, err := readValuesFile(filepath.Join(chartDir, vf))
+		if err != nil {
+			findings = append(findings, invalidValues(vf, err))
+		}
+		sources = append(sources, valuesSource{name: vf, values: vals, data: data})
+	}
+
+	vals, data, err := readValuesFile(filepath.Join(chartDir, chartutil.ValuesfileName))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		findings = append(findings, invalidValues(chartutil.ValuesfileName, err))
+	default:
+		sources = append(sources, valuesSource{name: chartutil.ValuesfileName, values: vals, data: data})
+	}
+	return sources, findings
+}
+
+func readValuesFile(path string) (map[string]any, []byte, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is a value file of our own temp checkout
+	if err != nil {
+		return nil, nil, err
+	}
+	vals, err := chartutil.ReadValues(data)
+	return vals, data, err
+}
+
+func invalidValues(source string, err error) domain.LintFinding {
+	return domain.LintFinding{
+		File:     source,
+		Message:  fmt.Sprintf("invalid values: %v", err),
+		Severity: domain.SeverityError,
+	}
+}
+
+// setBy returns the highest-precedence source that sets the value at path,
+// or a source without a name if none does, e.g. for a missing value.
+func setBy(sources []valuesSource, path []string) valuesSource {
+	for _, src := range sources {
+		if hasPath(src.values, path) {
+			return src
+		}
+	}
+	return valuesSource{}
+}
+
+// keyLine returns the 1-based line of the key at path in the YAML document
+// data, whose elements are map keys or list indexes, or 0 if it can't be
+// found.
+func keyLine(data []byte, path []string) int {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return 0
+	}
+	node, line := doc.Content[0], 0
+	for _, elem := range path {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			key, value := mappingEntry(node, elem)
+			if key == nil {
+				return 0
+			}
+			line, node = key.Line, value
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(elem)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return 0
+			}
+			line, node = node.Content[i].Line, node.Content[i]
+		default:
+			return 0
+		}
+	}
+	return line
+}
+
+// hasPath reports whether node has a value at path, whose elements are map
+// keys or list indexes.
+func hasPath(node any, path []string) bool {
+	for _, elem := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[elem]
+			if !ok {
+				return false
+			}
+			node = child
+		case []any:
+			i, ok := listIndex(n, elem)
+			if !ok {
+				return false
+			}
+			node = n[i]
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// mappingEntry returns the key and value nodes of name in a YAML mapping,
+// or nils if it has no such key.
+func mappingEntry(mapping *yaml.Node, name string) (key, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// mergeValues merges the environment's values exactly like the renderer:
+// value files < inline values < parameters.
+func (a *Adapter) mergeValues(chartDir string, valueFiles []string, opts domain.RenderOptions) (map[string]any, error) {
+	inlineValuesFile, cleanup, err := helmvalues.WriteInline(opts.Values)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	valueOpts, err := valueOptions(chartDir, valueFiles, inlineValuesFile, opts.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := valueOpts.MergeValues(a.getters)
+	if err != nil {
+		return nil, fmt.Errorf("merging values: %w", err)
+	}
+	return vals, nil
+}
+
+// valueOptions maps value files, inline values and parameters onto Helm's
+// value flags. MergeValues applies files before --set flags, which gives
+// Argo CD's precedence: value files < inline values < parameters.
+func valueOptions(
+	chartDir string,
+	valueFiles []string,
+	inlineValuesFile string,
+	params []domain.HelmParameter,
+) (*values.Options, error) {
+	valueOpts := &values.Options{}
+	for _, vf := range valueFiles {
+		valueOpts.ValueFiles = append(valueOpts.ValueFiles, filepath.Join(chartDir, vf))
+	}
+	if inlineValuesFile != "" {
+		valueOpts.ValueFiles = append(valueOpts.ValueFiles, inlineValuesFile)
+	}
+	for _, p := range params {
+		switch {
+		case p.File:
+			path, err := p.ResolveFile(chartDir)
+			if err != nil {
+				return nil, err
+			}
+			valueOpts.FileValues = append(valueOpts.FileValues, p.Name+"="+path)
+		case p.ForceString:
+			valueOpts.StringValues = append(valueOpts.StringValues, p.SetArg())
+		default:
+			valueOpts.Values = append(valueOpts.Values, p.SetArg())
+		}
+	}
+	return valueOpts, nil
+}
@@ -0,0 +1,155 @@
+// Package helmsdk provides in-process Helm template rendering via the Helm Go SDK.
+package helmsdk
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/helmvalues"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const (
+	defaultReleaseName = "chart-val-render"
+	defaultNamespace   = "default"
+)
+
+// Adapter implements ports.RendererPort by rendering charts with the Helm
+// libraries in-process, without requiring the helm binary.
+type Adapter struct {
+	getters getter.Providers
+	logger  *slog.Logger
+}
+
+// New creates a new Helm SDK adapter.
+func New(logger *slog.Logger) *Adapter {
+	return &Adapter{
+		getters: getter.All(cli.New()),
+		logger:  logger,
+	}
+}
+
+// Render renders the chart in chartDir with the specified value files and
+// render options, producing the same output as `helm template`. Template
+// failures are returned as *domain.RenderError when Helm reports a file and line.
+func (a *Adapter) Render(
+	ctx context.Context,
+	chartDir string,
+	valueFiles []string,
+	opts domain.RenderOptions,
+) ([]byte, error) {
+	chrt, err := loader.Load(chartDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading chart: %w", err)
+	}
+
+	inlineValuesFile, cleanup, err := helmvalues.WriteInline(opts.Values)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	valueOpts, err := valueOptions(chartDir, valueFiles, inlineValuesFile, opts.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := valueOpts.MergeValues(a.getters)
+	if err != nil {
+		return nil, fmt.Errorf("merging values: %w", err)
+	}
+
+	client, err := a.newInstall(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Debug("rendering chart in-process", "chartDir", chartDir, "valueFiles", valueFiles, "options", opts)
+
+	rel, err := client.RunWithContext(ctx, chrt, vals)
+	if err != nil {
+		if renderErr := domain.ParseRenderError(err.Error()); renderErr != nil {
+			return nil, fmt.Errorf("helm template failed: %w", renderErr)
+		}
+		return nil, fmt.Errorf("helm template failed: %w", err)
+	}
+
+	// Mirror `helm template` output: rendered manifests followed by hooks.
+	var out bytes.Buffer
+	fmt.Fprintln(&out, strings.TrimSpace(rel.Manifest))
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(&out, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+	}
+
+	a.logger.Debug("chart rendered", "chartDir", chartDir, "outputSize", out.Len())
+	return out.Bytes(), nil
+}
+
+// valueOptions maps value files, inline values and parameters onto Helm's
+// value flags. MergeValues applies files before --set flags, which gives
+// Argo CD's precedence: value files < inline values < parameters.
+func valueOptions(
+	chartDir string,
+	valueFiles []string,
+	inlineValuesFile string,
+	params []domain.HelmParameter,
+) (*values.Options, error) {
+	valueOpts := &values.Options{}
+	for _, vf := range valueFiles {
+		valueOpts.ValueFiles = append(valueOpts.ValueFiles, filepath.Join(chartDir, vf))
+	}
+	if inlineValuesFile != "" {
+		valueOpts.ValueFiles = append(valueOpts.ValueFiles, inlineValuesFile)
+	}
+	for _, p := range params {
+		switch {
+		case p.File:
+			path, err := p.ResolveFile(chartDir)
+			if err != nil {
+				return nil, err
+			}
+			valueOpts.FileValues = append(valueOpts.FileValues, p.Name+"="+path)
+		case p.ForceString:
+			valueOpts.StringValues = append(valueOpts.StringValues, p.SetArg())
+		default:
+			valueOpts.Values = append(valueOpts.Values, p.SetArg())
+		}
+	}
+	return valueOpts, nil
+}
+
+// newInstall builds a client-only dry-run install action, the same setup
+// `helm template` uses, applying the environment's render options.
+func (a *Adapter) newInstall(opts domain.RenderOptions) (*action.Install, error) {
+	cfg := &action.Configuration{
+		Log: func(format string, v ...any) { a.logger.Debug(fmt.Sprintf(format, v...)) },
+	}
+	client := action.NewInstall(cfg)
+	client.DryRun = true
+	client.DryRunOption = "true"
+	client.ClientOnly = true
+	client.Replace = true
+	client.ReleaseName = cmp.Or(opts.ReleaseName, defaultReleaseName)
+	client.Namespace = cmp.Or(opts.Namespace, defaultNamespace)
+	client.APIVersions = chartutil.VersionSet(opts.APIVersions)
+
+	if opts.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(opts.KubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube version %q: %w", opts.KubeVersion, err)
+		}
+		client.KubeVersion = kubeVersion
+	}
+	return client, nil
+}
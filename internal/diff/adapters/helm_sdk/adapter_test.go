@@ -0,0 +1,203 @@
+package helmsdk
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+func newTestAdapter() *Adapter {
+	return New(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+}
+
+func TestAdapter_Render_DefaultValues(t *testing.T) {
+	adapter := newTestAdapter()
+
+	out, err := adapter.Render(
+		context.Background(),
+		filepath.Join("testdata", "basic-chart"),
+		nil,
+		domain.RenderOptions{},
+	)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	manifest := string(out)
+	for _, want := range []string{
+		"# Source: basic-chart/templates/configmap.yaml",
+		"name: basic-chart-config",
+		"namespace: default",
+		"LOG_LEVEL: info",
+		"RELEASE: chart-val-render",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("rendered manifest missing %q:\n%s", want, manifest)
+		}
+	}
+}
+
+func TestAdapter_Render_ValueFiles(t *testing.T) {
+	adapter := newTestAdapter()
+
+	out, err := adapter.Render(
+		context.Background(),
+		filepath.Join("testdata", "basic-chart"),
+		[]string{"env/prod-values.yaml"},
+		domain.RenderOptions{},
+	)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if !strings.Contains(string(out), "LOG_LEVEL: warn") {
+		t.Errorf("expected value file override to apply, got:\n%s", out)
+	}
+}
+
+func TestAdapter_Render_RenderOptions(t *testing.T) {
+	adapter := newTestAdapter()
+
+	out, err := adapter.Render(
+		context.Background(),
+		filepath.Join("testdata", "basic-chart"),
+		nil,
+		domain.RenderOptions{
+			ReleaseName: "my-app",
+			Namespace:   "my-app-prod",
+			KubeVersion: "1.29.3",
+			APIVersions: []string{"monitoring.coreos.com/v1"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	manifest := string(out)
+	for _, want := range []string{
+		"namespace: my-app-prod",
+		"RELEASE: my-app",
+		`KUBE_VERSION: "v1.29.3"`,
+		`MONITORING: "enabled"`,
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("rendered manifest missing %q:\n%s", want, manifest)
+		}
+	}
+}
+
+func TestAdapter_Render_ValuePrecedence(t *testing.T) {
+	adapter := newTestAdapter()
+
+	tests := []struct {
+		name string
+		opts domain.RenderOptions
+		want string
+	}{
+		{
+			name: "inline values override value files",
+			opts: domain.RenderOptions{Values: "logLevel: error\n"},
+			want: "LOG_LEVEL: error",
+		},
+		{
+			name: "parameters override inline values",
+			opts: domain.RenderOptions{
+				Values:     "logLevel: error\n",
+				Parameters: []domain.HelmParameter{{Name: "logLevel", Value: "debug"}},
+			},
+			want: "LOG_LEVEL: debug",
+		},
+		{
+			name: "file parameter",
+			opts: domain.RenderOptions{
+				Parameters: []domain.HelmParameter{{Name: "motd", Value: "files/motd.txt", File: true}},
+			},
+			want: `MOTD: "hello from file"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := adapter.Render(
+				context.Background(),
+				filepath.Join("testdata", "basic-chart"),
+				[]string{"env/prod-values.yaml"},
+				tt.opts,
+			)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if !strings.Contains(string(out), tt.want) {
+				t.Errorf("rendered manifest missing %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestAdapter_Render_InvalidKubeVersion(t *testing.T) {
+	adapter := newTestAdapter()
+
+	_, err := adapter.Render(
+		context.Background(),
+		filepath.Join("testdata", "basic-chart"),
+		nil,
+		domain.RenderOptions{KubeVersion: "not-a-version"},
+	)
+	if err == nil {
+		t.Fatal("expected error for invalid kube version, got nil")
+	}
+	if !strings.Contains(err.Error(), "invalid kube version") {
+		t.Errorf("error %q should mention the invalid kube version", err.Error())
+	}
+}
+
+func TestAdapter_Render_StructuredError(t *testing.T) {
+	adapter := newTestAdapter()
+
+	_, err := adapter.Render(
+		context.Background(),
+		filepath.Join("testdata", "broken-chart"),
+		nil,
+		domain.RenderOptions{},
+	)
+	if err == nil {
+		t.Fatal("expected render error, got nil")
+	}
+
+	var renderErr *domain.RenderError
+	if !errors.As(err, &renderErr) {
+		t.Fatalf("expected *domain.RenderError, got %T: %v", err, err)
+	}
+	if renderErr.Template != "broken-chart/templates/deployment.yaml" {
+		t.Errorf("Template = %q, want %q", renderErr.Template, "broken-chart/templates/deployment.yaml")
+	}
+	if renderErr.Line != 10 {
+		t.Errorf("Line = %d, want 10", renderErr.Line)
+	}
+	if !strings.Contains(renderErr.Message, "image is required") {
+		t.Errorf("Message = %q, want it to mention the required value", renderErr.Message)
+	}
+}
+
+func TestAdapter_Render_MissingChart(t *testing.T) {
+	adapter := newTestAdapter()
+
+	_, err := adapter.Render(
+		context.Background(),
+		filepath.Join("testdata", "does-not-exist"),
+		nil,
+		domain.RenderOptions{},
+	)
+	if err == nil {
+		t.Fatal("expected error for missing chart, got nil")
+	}
+	if !strings.Contains(err.Error(), "loading chart") {
+		t.Errorf("error %q should mention loading chart", err.Error())
+	}
+}
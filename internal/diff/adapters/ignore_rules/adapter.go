@@ -0,0 +1,372 @@
+// Package ignorerules removes fields matched by diff ignore rules from
+// rendered manifests before they are diffed.
+package ignorerules
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// Adapter implements ports.ManifestFilterPort. Fields matched by a rule are
+// removed from both manifests; each matched field whose value differs
+// between them counts as one suppressed change.
+type Adapter struct {
+	rules    []domain.IgnoreRule // Rules applied to every chart
+	repoFile string              // Rules file in each target repo, "" for none
+
+	mu       sync.Mutex
+	compiled map[domain.IgnoreRule]rule // Compiled rules, by rule
+}
+
+type rule struct {
+	domain.IgnoreRule
+	path    []segment      // Set for path rules
+	pattern *regexp.Regexp // Set for pattern rules
+}
+
+// New creates a filter applying rules to every chart, plus those in the
+// repoFile of each target repo ("" for none). Returns an error if a rule has
+// neither or both of Path and Pattern, if either fails to parse, or if
+// repoFile isn't a relative path inside the repo.
+func New(rules []domain.IgnoreRule, repoFile string) (*Adapter, error) {
+	if repoFile != "" && !filepath.IsLocal(repoFile) {
+		return nil, fmt.Errorf("repo ignore rules file %q must be a relative path inside the repo", repoFile)
+	}
+	a := &Adapter{rules: rules, repoFile: repoFile, compiled: make(map[domain.IgnoreRule]rule)}
+	for i, r := range rules {
+		if _, err := a.compile(r); err != nil {
+			return nil, fmt.Errorf("ignore rule %d: %w", i+1, err)
+		}
+	}
+	return a, nil
+}
+
+// LoadRules returns the adapter's rules plus those in the repo rules file
+// under repoDir, a checkout of the target repo ("" for none). A repo without
+// the file adds no rules. Returns an error if the file or a rule in it is
+// invalid.
+func (a *Adapter) LoadRules(repoDir string) ([]domain.IgnoreRule, error) {
+	if repoDir == "" || a.repoFile == "" {
+		return a.rules, nil
+	}
+	repoRules, err := ReadRules(filepath.Join(repoDir, a.repoFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return a.rules, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range repoRules {
+		if _, err := a.compile(r); err != nil {
+			return nil, fmt.Errorf("%s: ignore rule %d: %w", a.repoFile, i+1, err)
+		}
+	}
+	return slices.Concat(a.rules, repoRules), nil
+}
+
+// compile parses the path or pattern of r, once per distinct rule.
+func (a *Adapter) compile(r domain.IgnoreRule) (rule, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if compiled, ok := a.compiled[r]; ok {
+		return compiled, nil
+	}
+
+	compiled := rule{IgnoreRule: r}
+	var err error
+	switch {
+	case r.Path != "" && r.Pattern != "":
+		err = errors.New("path and pattern are mutually exclusive")
+	case r.Path != "":
+		compiled.path, err = parsePath(r.Path)
+	case r.Pattern != "":
+		compiled.pattern, err = regexp.Compile(r.Pattern)
+	default:
+		err = errors.New("path or pattern is required")
+	}
+	if err != nil {
+		return rule{}, err
+	}
+	a.compiled[r] = compiled
+	return compiled, nil
+}
+
+// ReadRules reads ignore rules from a YAML file of the form:
+//
+//	rules:
+//	  - kind: Secret
+//	    name: my-app-*
+//	    path: .data.password
+//	  - pattern: '(^|\.)annotations\.rollme$'
+func ReadRules(file string) ([]domain.IgnoreRule, error) {
+	data, err := os.ReadFile(file) //nolint:gosec // G304: path comes from operator config
+	if err != nil {
+		return nil, fmt.Errorf("reading ignore rules: %w", err)
+	}
+
+	var parsed struct {
+		Rules []struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Namespace  string `yaml:"namespace"`
+			Name       string `yaml:"name"`
+			Path       string `yaml:"path"`
+			Pattern    string `yaml:"pattern"`
+		} `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing ignore rules %s: %w", file, err)
+	}
+
+	rules := make([]domain.IgnoreRule, 0, len(parsed.Rules))
+	for _, r := range parsed.Rules {
+		rules = append(rules, domain.IgnoreRule(r))
+	}
+	return rules, nil
+}
+
+// Filter removes the fields rules ignore from base and head. Resources are
+// matched by apiVersion/kind/namespace/name; fields of resources that only
+// exist on one side are removed but not counted, since the whole resource is
+// a change. Documents without ignored fields keep their original text.
+func (a *Adapter) Filter(
+	rules []domain.IgnoreRule,
+	base, head []byte,
+) (filteredBase, filteredHead []byte, suppressed int) {
+	compiled := make([]rule, 0, len(rules))
+	for _, r := range rules {
+		c, err := a.compile(r)
+		if err != nil {
+			slog.Warn("skipping invalid ignore rule", "rule", r, "error", err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	if len(compiled) == 0 {
+		return base, head, 0
+	}
+
+	baseManifest, err := parseManifest(base)
+	if err != nil {
+		slog.Warn("failed to parse base manifests for ignore rules", "error", err)
+		return base, head, 0
+	}
+	headManifest, err := parseManifest(head)
+	if err != nil {
+		slog.Warn("failed to parse head manifests for ignore rules", "error", err)
+		return base, head, 0
+	}
+
+	headByID := make(map[string]*document)
+	for _, doc := range headManifest.documents() {
+		headByID[doc.id] = doc
+	}
+	matched := make(map[*document]bool)
+	for _, doc := range baseManifest.documents() {
+		other := headByID[doc.id]
+		matched[other] = true
+		suppressed += strip(compiled, doc, other)
+	}
+	for _, doc := range headManifest.documents() {
+		if !matched[doc] {
+			strip(compiled, doc, nil)
+		}
+	}
+
+	return baseManifest.bytes(), headManifest.bytes(), suppressed
+}
+
+// strip removes the fields rules ignore from a resource and its counterpart
+// in head (nil if none), returning how many of those fields differed.
+func strip(rules []rule, base, head *document) int {
+	baseLocs := locate(rules, base)
+	var headLocs []location
+	if head != nil {
+		headLocs = locate(rules, head)
+	}
+
+	baseValues := valuesByPath(baseLocs)
+	headValues := valuesByPath(headLocs)
+	suppressed := 0
+	if head != nil {
+		for path, value := range baseValues {
+			if headValues[path] != value {
+				suppressed++
+			}
+		}
+		for path := range headValues {
+			if _, ok := baseValues[path]; !ok {
+				suppressed++
+			}
+		}
+	}
+
+	base.remove(baseLocs)
+	if head != nil {
+		head.remove(headLocs)
+	}
+	return suppressed
+}
+
+// locate returns the fields of doc matched by any rule selecting it.
+func locate(rules []rule, doc *document) []location {
+	var locs []location
+	for _, r := range rules {
+		if !r.Selects(doc.resource) {
+			continue
+		}
+		if r.pattern != nil {
+			locs = append(locs, findPattern(doc.root(), r.pattern, nil)...)
+		} else {
+			locs = append(locs, findPath(doc.root(), r.path, nil)...)
+		}
+	}
+	return locs
+}
+
+func valuesByPath(locs []location) map[string]string {
+	values := make(map[string]string, len(locs))
+	for _, loc := range locs {
+		values[loc.path] = encode(loc.value())
+	}
+	return values
+}
+
+// manifest is a multi-document manifest split on "---" lines, keeping the
+// separators so unchanged documents round-trip byte for byte.
+type manifest struct {
+	parts []*document
+}
+
+// document is one part of a manifest: a separator line, or the text between
+// separators and its parsed node (nil if empty).
+type document struct {
+	text      string
+	separator bool
+	node      *yaml.Node
+	id        string
+	resource  domain.ResourceChange
+	changed   bool
+}
+
+func parseManifest(data []byte) (*manifest, error) {
+	m := &manifest{}
+	docs, separators := yamldoc.SplitSeparators(data)
+	for i, text := range docs {
+		if i > 0 {
+			m.parts = append(m.parts, &document{text: separators[i-1], separator: true})
+		}
+		doc := &document{text: text}
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(text), &node); err != nil {
+			return nil, err
+		}
+		if len(node.Content) > 0 && node.Content[0].ShortTag() != "!!null" {
+			doc.node = &node
+		}
+		m.parts = append(m.parts, doc)
+	}
+
+	m.identify()
+	return m, nil
+}
+
+// identify names each resource apiVersion/kind[/namespace]/name, numbering
+// duplicates and naming non-resources by position.
+func (m *manifest) identify() {
+	seen := make(map[string]int)
+	for i, doc := range m.documents() {
+		root := doc.root()
+		metadata := yamldoc.ValueOf(root, "metadata")
+		doc.resource = domain.ResourceChange{
+			APIVersion: yamldoc.ScalarAt(root, "apiVersion"),
+			Kind:       yamldoc.ScalarAt(root, "kind"),
+			Namespace:  yamldoc.ScalarAt(metadata, "namespace"),
+			Name:       yamldoc.ScalarAt(metadata, "name"),
+		}
+		if doc.resource.Kind == "" || doc.resource.Name == "" {
+			doc.resource = domain.ResourceChange{Name: fmt.Sprintf("document %d", i+1)}
+		}
+		doc.id = doc.resource.ID()
+		seen[doc.id]++
+		if n := seen[doc.id]; n > 1 {
+			doc.id = fmt.Sprintf("%s#%d", doc.id, n)
+		}
+	}
+}
+
+// documents returns the parts that hold a YAML document.
+func (m *manifest) documents() []*document {
+	var docs []*document
+	for _, part := range m.parts {
+		if part.node != nil {
+			docs = append(docs, part)
+		}
+	}
+	return docs
+}
+
+func (m *manifest) bytes() []byte {
+	var sb strings.Builder
+	for _, part := range m.parts {
+		if part.changed {
+			sb.WriteString(encode(part.node) + "\n")
+		} else {
+			sb.WriteString(part.text)
+		}
+	}
+	return []byte(sb.String())
+}
+
+func (d *document) root() *yaml.Node {
+	return d.node.Content[0]
+}
+
+// remove deletes the located fields, highest index first so earlier indexes
+// stay valid. Fields located by more than one rule are removed once.
+func (d *document) remove(locs []location) {
+	slices.SortFunc(locs, func(a, b location) int { return b.index - a.index })
+	type field struct {
+		parent *yaml.Node
+		index  int
+	}
+	removed := make(map[field]bool)
+	for _, loc := range locs {
+		f := field{parent: loc.parent, index: loc.index}
+		if removed[f] {
+			continue
+		}
+		removed[f] = true
+		width := 1
+		if loc.parent.Kind == yaml.MappingNode {
+			width = 2
+		}
+		loc.parent.Content = slices.Delete(loc.parent.Content, loc.index, loc.index+width)
+		d.changed = true
+	}
+}
+
+// encode renders node as YAML with two-space indentation.
+func encode(node *yaml.Node) string {
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	_ = enc.Close()
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,309 @@
+package ignorerules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const deployment = `# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  labels:
+    app: my-app
+    helm.sh/chart: my-app-0.1.0
+spec:
+  template:
+    metadata:
+      annotations:
+        checksum/config: abc123
+    spec:
+      containers:
+        - name: my-app
+          image: my-app:1.24.0
+`
+
+const secret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-token
+data:
+  token: cmFuZG9tMQ==
+`
+
+const configMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  config.yaml: |
+    a: 1
+    ---
+    b: 2
+  log-level: info
+`
+
+func TestAdapter_Filter(t *testing.T) {
+	bumped := strings.ReplaceAll(deployment, "my-app-0.1.0", "my-app-0.2.0")
+	unlabeled := strings.ReplaceAll(deployment, "    helm.sh/chart: my-app-0.1.0\n", "")
+	emptySecret := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: my-app-token\ndata: {}\n"
+
+	tests := []struct {
+		name           string
+		rules          []domain.IgnoreRule
+		base           string
+		head           string
+		wantBase       string
+		wantHead       string
+		wantSuppressed int
+	}{
+		{
+			name:           "no rules leaves manifests untouched",
+			base:           deployment,
+			head:           bumped,
+			wantBase:       deployment,
+			wantHead:       bumped,
+			wantSuppressed: 0,
+		},
+		{
+			name:  "default rules strip chart label and checksums",
+			rules: domain.DefaultIgnoreRules(),
+			base:  deployment,
+			head:  strings.ReplaceAll(bumped, "abc123", "def456"),
+			wantBase: `# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  labels:
+    app: my-app
+spec:
+  template:
+    metadata:
+      annotations: {}
+    spec:
+      containers:
+        - name: my-app
+          image: my-app:1.24.0
+`,
+			wantHead: `# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  labels:
+    app: my-app
+spec:
+  template:
+    metadata:
+      annotations: {}
+    spec:
+      containers:
+        - name: my-app
+          image: my-app:1.24.0
+`,
+			wantSuppressed: 2,
+		},
+		{
+			name:           "unchanged ignored fields are removed but not counted",
+			rules:          []domain.IgnoreRule{{Path: `.metadata.labels["helm.sh/chart"]`}},
+			base:           "---\n" + secret + "---\n" + deployment,
+			head:           "---\n" + secret + "---\n" + deployment,
+			wantBase:       "---\n" + secret + "---\n" + unlabeled,
+			wantHead:       "---\n" + secret + "---\n" + unlabeled,
+			wantSuppressed: 0,
+		},
+		{
+			name:           "selector limits rules to matching resources",
+			rules:          []domain.IgnoreRule{{Kind: "Secret", Name: "*-token", Path: ".data.*"}},
+			base:           secret + "---\n" + deployment,
+			head:           strings.ReplaceAll(secret, "cmFuZG9tMQ==", "cmFuZG9tMg==") + "---\n" + deployment,
+			wantBase:       emptySecret + "---\n" + deployment,
+			wantHead:       emptySecret + "---\n" + deployment,
+			wantSuppressed: 1,
+		},
+		{
+			name: "list items by wildcard",
+			rules: []domain.IgnoreRule{
+				{Kind: "Deployment", Path: ".spec.template.spec.containers[*].image"},
+			},
+			base:           deployment,
+			head:           strings.ReplaceAll(deployment, "my-app:1.24.0", "my-app:1.25.0"),
+			wantBase:       strings.ReplaceAll(deployment, "          image: my-app:1.24.0\n", ""),
+			wantHead:       strings.ReplaceAll(deployment, "          image: my-app:1.24.0\n", ""),
+			wantSuppressed: 1,
+		},
+		{
+			name:           "fields of added resources are not counted",
+			rules:          []domain.IgnoreRule{{Kind: "Secret", Path: ".data"}},
+			base:           "",
+			head:           secret,
+			wantBase:       "",
+			wantHead:       "apiVersion: v1\nkind: Secret\nmetadata:\n  name: my-app-token\n",
+			wantSuppressed: 0,
+		},
+		{
+			name:           "indented separators in block scalars don't split documents",
+			rules:          []domain.IgnoreRule{{Kind: "ConfigMap", Path: `.data["log-level"]`}},
+			base:           configMap + "---\n" + deployment,
+			head:           strings.ReplaceAll(configMap, "info", "debug") + "---\n" + deployment,
+			wantBase:       strings.ReplaceAll(configMap, "  log-level: info\n", "") + "---\n" + deployment,
+			wantHead:       strings.ReplaceAll(configMap, "  log-level: info\n", "") + "---\n" + deployment,
+			wantSuppressed: 1,
+		},
+		{
+			name:           "unparseable manifests are returned unchanged",
+			rules:          domain.DefaultIgnoreRules(),
+			base:           "key: [unclosed",
+			head:           deployment,
+			wantBase:       "key: [unclosed",
+			wantHead:       deployment,
+			wantSuppressed: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, err := New(tt.rules, "")
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			base, head, suppressed := adapter.Filter(tt.rules, []byte(tt.base), []byte(tt.head))
+			if string(base) != tt.wantBase {
+				t.Errorf("base mismatch\ngot:\n%s\nwant:\n%s", base, tt.wantBase)
+			}
+			if string(head) != tt.wantHead {
+				t.Errorf("head mismatch\ngot:\n%s\nwant:\n%s", head, tt.wantHead)
+			}
+			if suppressed != tt.wantSuppressed {
+				t.Errorf("suppressed = %d, want %d", suppressed, tt.wantSuppressed)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule domain.IgnoreRule
+	}{
+		{"no field selector", domain.IgnoreRule{Kind: "Secret"}},
+		{"path and pattern", domain.IgnoreRule{Path: ".data", Pattern: "data"}},
+		{"bad pattern", domain.IgnoreRule{Pattern: "(unclosed"}},
+		{"bad list index", domain.IgnoreRule{Path: ".spec.containers[x]"}},
+		{"unterminated quote", domain.IgnoreRule{Path: `.metadata.labels["helm.sh/chart]`}},
+		{"empty segment", domain.IgnoreRule{Path: ".metadata..labels"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New([]domain.IgnoreRule{tt.rule}, ""); err == nil {
+				t.Errorf("New(%+v) expected error, got nil", tt.rule)
+			}
+		})
+	}
+}
+
+func TestReadRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ignore.yaml")
+	content := `rules:
+  - kind: Secret
+    name: my-app-*
+    path: .data.password
+  - pattern: '(^|\.)annotations\.rollme$'
+`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadRules(file)
+	if err != nil {
+		t.Fatalf("ReadRules() error = %v", err)
+	}
+	want := []domain.IgnoreRule{
+		{Kind: "Secret", Name: "my-app-*", Path: ".data.password"},
+		{Pattern: `(^|\.)annotations\.rollme$`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadRules() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ReadRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("ReadRules() expected error for missing file")
+	}
+}
+
+func TestAdapter_LoadRules(t *testing.T) {
+	configured := []domain.IgnoreRule{{Pattern: `(^|\.)annotations\.rollme$`}}
+	adapter, err := New(configured, ".chart-val/ignore-rules.yaml")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	writeRepoRules := func(t *testing.T, content string) string {
+		t.Helper()
+		repoDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(repoDir, ".chart-val"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		file := filepath.Join(repoDir, ".chart-val", "ignore-rules.yaml")
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return repoDir
+	}
+
+	t.Run("repo rules are added to the configured ones", func(t *testing.T) {
+		repoDir := writeRepoRules(t, "rules:\n  - kind: Secret\n    path: .data.token\n")
+		got, err := adapter.LoadRules(repoDir)
+		if err != nil {
+			t.Fatalf("LoadRules() error = %v", err)
+		}
+		want := append(slices.Clone(configured), domain.IgnoreRule{Kind: "Secret", Path: ".data.token"})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("LoadRules() = %+v, want %+v", got, want)
+		}
+
+		// The repo rule applies when filtering
+		base, head, suppressed := adapter.Filter(got, []byte(secret),
+			[]byte(strings.Replace(secret, "cmFuZG9tMQ==", "cmFuZG9tMg==", 1)))
+		if suppressed != 1 || strings.Contains(string(base), "token:") || strings.Contains(string(head), "token:") {
+			t.Errorf("Filter() suppressed %d, base:\n%s\nhead:\n%s", suppressed, base, head)
+		}
+	})
+
+	t.Run("no repo or no rules file", func(t *testing.T) {
+		for _, repoDir := range []string{"", t.TempDir()} {
+			got, err := adapter.LoadRules(repoDir)
+			if err != nil {
+				t.Fatalf("LoadRules(%q) error = %v", repoDir, err)
+			}
+			if !reflect.DeepEqual(got, configured) {
+				t.Errorf("LoadRules(%q) = %+v, want the configured rules", repoDir, got)
+			}
+		}
+	})
+
+	t.Run("invalid repo rule", func(t *testing.T) {
+		repoDir := writeRepoRules(t, "rules:\n  - kind: Secret\n")
+		if _, err := adapter.LoadRules(repoDir); err == nil || !strings.Contains(err.Error(), "path or pattern") {
+			t.Errorf("LoadRules() error = %v, want an invalid rule error", err)
+		}
+	})
+
+	t.Run("repo file outside the repo", func(t *testing.T) {
+		for _, file := range []string{"/etc/ignore.yaml", "../ignore.yaml"} {
+			if _, err := New(nil, file); err == nil {
+				t.Errorf("New(nil, %q) expected error, got nil", file)
+			}
+		}
+	})
+}
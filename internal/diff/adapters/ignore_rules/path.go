@@ -0,0 +1,178 @@
+package ignorerules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+)
+
+// segment is one step of a JSON path: a map key, a list index, or a
+// wildcard over either.
+type segment struct {
+	key      string
+	index    int // -1 unless the segment is a list index
+	wildcard bool
+}
+
+// parsePath parses the JSON path subset used by ignore rules:
+//
+//	.metadata.labels.app               map keys
+//	.metadata.labels["helm.sh/chart"]  keys containing dots
+//	.spec.containers[0].image          list index
+//	.spec.containers[*].image          any list item
+//	.metadata.annotations.*            any map key
+//
+// A leading "$" and the first "." are optional.
+func parsePath(p string) ([]segment, error) {
+	rest := strings.TrimPrefix(p, "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+
+	var segments []segment
+	for rest != "" {
+		var seg segment
+		var err error
+		switch rest[0] {
+		case '.':
+			seg, rest = parseKey(rest[1:])
+		case '[':
+			seg, rest, err = parseBracket(rest)
+		default:
+			err = fmt.Errorf("unexpected %q", rest[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", p, err)
+		}
+		if seg.key == "" && seg.index < 0 && !seg.wildcard {
+			return nil, fmt.Errorf("invalid path %q: empty segment", p)
+		}
+		segments = append(segments, seg)
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid path %q: no segments", p)
+	}
+	return segments, nil
+}
+
+func parseKey(s string) (segment, string) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		end = len(s)
+	}
+	if s[:end] == "*" {
+		return segment{index: -1, wildcard: true}, s[end:]
+	}
+	return segment{key: s[:end], index: -1}, s[end:]
+}
+
+func parseBracket(s string) (segment, string, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 2 {
+		return segment{}, "", errors.New("missing ]")
+	}
+	if quote := s[1:2]; quote == `"` || quote == `'` {
+		closing := strings.Index(s[2:], quote+"]")
+		if closing < 0 {
+			return segment{}, "", errors.New("unterminated quoted key")
+		}
+		return segment{key: s[2 : 2+closing], index: -1}, s[2+closing+2:], nil
+	}
+
+	inner := s[1:end]
+	if inner == "*" {
+		return segment{index: -1, wildcard: true}, s[end+1:], nil
+	}
+	i, err := strconv.Atoi(inner)
+	if err != nil || i < 0 {
+		return segment{}, "", fmt.Errorf("invalid list index %q", inner)
+	}
+	return segment{index: i}, s[end+1:], nil
+}
+
+// location is a field matched by a rule: entry index of a map key, or the
+// index of a list item, within parent.
+type location struct {
+	parent *yaml.Node
+	index  int
+	path   string // Dot-separated, list items by index
+}
+
+func (l location) value() *yaml.Node {
+	if l.parent.Kind == yaml.MappingNode {
+		return l.parent.Content[l.index+1]
+	}
+	return l.parent.Content[l.index]
+}
+
+// findPath returns the fields of node matching segments.
+func findPath(node *yaml.Node, segments []segment, prefix []string) []location {
+	node = yamldoc.Resolve(node)
+	seg, last := segments[0], len(segments) == 1
+
+	var found []location
+	visit := func(index int, key string, child *yaml.Node) {
+		path := append(prefix[:len(prefix):len(prefix)], key)
+		if last {
+			found = append(found, location{parent: node, index: index, path: strings.Join(path, ".")})
+			return
+		}
+		found = append(found, findPath(child, segments[1:], path)...)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if seg.index >= 0 && seg.key == "" {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; seg.wildcard || key == seg.key {
+				visit(i, key, node.Content[i+1])
+			}
+		}
+	case yaml.SequenceNode:
+		if seg.key != "" {
+			return nil
+		}
+		for i, item := range node.Content {
+			if seg.wildcard || i == seg.index {
+				visit(i, strconv.Itoa(i), item)
+			}
+		}
+	}
+	return found
+}
+
+// findPattern returns the outermost fields of node whose dot-separated path
+// matches re.
+func findPattern(node *yaml.Node, re *regexp.Regexp, prefix []string) []location {
+	node = yamldoc.Resolve(node)
+
+	var found []location
+	visit := func(index int, key string, child *yaml.Node) {
+		path := append(prefix[:len(prefix):len(prefix)], key)
+		if joined := strings.Join(path, "."); re.MatchString(joined) {
+			found = append(found, location{parent: node, index: index, path: joined})
+			return
+		}
+		found = append(found, findPattern(child, re, path)...)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			visit(i, node.Content[i].Value, node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			visit(i, strconv.Itoa(i), item)
+		}
+	}
+	return found
+}
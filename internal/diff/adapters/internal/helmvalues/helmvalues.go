@@ -0,0 +1,32 @@
+// Package helmvalues holds value file helpers shared by the adapters that run
+// Helm: the CLI and SDK renderers and the linter.
+package helmvalues
+
+import (
+	"fmt"
+	"os"
+)
+
+// WriteInline writes inline values YAML (an Application's values or
+// valuesObject) to a temp file, so it merges exactly like a value file.
+// Returns an empty path when there are no inline values.
+func WriteInline(values string) (path string, cleanup func(), err error) {
+	if values == "" {
+		return "", func() {}, nil
+	}
+	f, err := os.CreateTemp("", "chart-val-values-*.yaml")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating inline values file: %w", err)
+	}
+	cleanup = func() { _ = os.Remove(f.Name()) }
+	if _, err := f.WriteString(values); err != nil {
+		_ = f.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("writing inline values file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("writing inline values file: %w", err)
+	}
+	return f.Name(), cleanup, nil
+}
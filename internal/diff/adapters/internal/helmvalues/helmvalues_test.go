@@ -0,0 +1,36 @@
+package helmvalues
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWriteInline(t *testing.T) {
+	path, cleanup, err := WriteInline("image:\n  tag: v2\n")
+	if err != nil {
+		t.Fatalf("WriteInline() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading inline values file: %v", err)
+	}
+	if string(data) != "image:\n  tag: v2\n" {
+		t.Errorf("inline values file = %q", data)
+	}
+
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected cleanup to remove %s, got %v", path, err)
+	}
+}
+
+func TestWriteInline_NoValues(t *testing.T) {
+	path, cleanup, err := WriteInline("")
+	if err != nil {
+		t.Fatalf("WriteInline() error = %v", err)
+	}
+	defer cleanup()
+	if path != "" {
+		t.Errorf("WriteInline(\"\") path = %q, want empty", path)
+	}
+}
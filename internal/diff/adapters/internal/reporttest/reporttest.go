@@ -0,0 +1,139 @@
+// Package reporttest holds the diff results the report formatter adapters
+// are tested against, and their golden file helper.
+package reporttest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// PR is the pull request the results are for.
+var PR = domain.PRContext{
+	Owner: "acme", Repo: "charts", PRNumber: 42,
+	BaseRef: "main", HeadRef: "feat/pdb", HeadSHA: "abc1234",
+}
+
+// Results returns the results of PR, which changes two charts, my-app and
+// worker, and leaves stable unchanged.
+func Results() []domain.DiffResult {
+	pdb := domain.APIDeprecation{
+		Resource: "policy/v1beta1/PodDisruptionBudget/my-app", APIVersion: "policy/v1beta1",
+		Kind: "PodDisruptionBudget", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "policy/v1",
+		Target: "1.24", Severity: domain.SeverityWarning, Introduced: true,
+	}
+	removedPDB := pdb
+	removedPDB.Target, removedPDB.Removed, removedPDB.Severity = "1.29", true, domain.SeverityError
+	icon := domain.LintFinding{File: "Chart.yaml", Message: "icon is recommended", Severity: domain.SeverityWarning}
+	deployment := domain.ResourceChange{
+		APIVersion: "apps/v1", Kind: "Deployment", Namespace: "my-app", Name: "my-app",
+		Type: domain.ChangeModified,
+		Fields: []domain.FieldChange{{
+			Path: "spec.template.spec.containers.app.image", Type: domain.ChangeModified,
+			Old: "my-app:1.0.0", New: "my-app:latest",
+		}},
+	}
+	removedTag := domain.ValuesChange{Path: []string{"image", "tag"}, Type: domain.ValuesKeyRemoved}
+	reasons := []string{"values.yaml: image.tag removed"}
+
+	return []domain.DiffResult{
+		{
+			ChartName: "my-app", ChartPath: "charts/my-app", Environment: "dev",
+			BaseRef: "main", HeadRef: "feat/pdb", Status: domain.StatusChanges,
+			Summary: "2 resources changed",
+			SemanticDiff: "spec.template.spec.containers.app.image\n  ± value change\n" +
+				"    - my-app:1.0.0\n    + my-app:latest\n",
+			ReportURL: "https://chart-val.example.com/reports/0123abcd",
+			Resources: []domain.ResourceChange{deployment, {
+				APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", Namespace: "my-app", Name: "my-app",
+				Type: domain.ChangeAdded,
+			}},
+			Suppressed:    1,
+			LintFindings:  []domain.LintFinding{icon},
+			Deprecations:  []domain.APIDeprecation{pdb},
+			ValuesChanges: []domain.ValuesChange{removedTag},
+			UnusedValues: []domain.UnusedValue{
+				{File: "env/dev-values.yaml", Line: 7, Path: []string{"image", "pullPolicy"}},
+			},
+		},
+		{
+			ChartName: "my-app", ChartPath: "charts/my-app", Environment: "prod",
+			BaseRef: "main", HeadRef: "feat/pdb", Status: domain.StatusInvalid,
+			Summary:   "1 resource changed",
+			Resources: []domain.ResourceChange{deployment},
+			Violations: []domain.SchemaViolation{
+				{Resource: "apps/v1/Deployment/my-app/my-app", Message: ".spec.replicas: expected integer"},
+			},
+			LintFindings: []domain.LintFinding{icon, {
+				File: "env/prod-values.yaml", Line: 3, Key: "replicaCount", Message: "got string, want integer",
+				Severity: domain.SeverityError,
+			}},
+			PolicyViolations: []domain.PolicyViolation{{
+				Policy: "no-latest-tag", Resource: "apps/v1/Deployment/my-app/my-app",
+				Message: "images must not use the latest tag", Severity: domain.SeverityError,
+			}},
+			Deprecations: []domain.APIDeprecation{removedPDB},
+			Risks: []domain.ChangeRisk{{
+				Resource: "apps/v1/Deployment/my-app/my-app", Level: domain.RiskHigh,
+				Reason: domain.RiskScaleToZero, Detail: "spec.replicas goes from 3 to 0",
+			}},
+			ChartVersion: &domain.VersionCheck{
+				BaseVersion: "1.2.0", HeadVersion: "1.2.1", Bump: domain.BumpPatch, Required: domain.BumpMajor,
+				Reasons: reasons,
+				Problem: domain.CheckVersionBump("1.2.0", "1.2.1", domain.BumpPatch, domain.BumpMajor, reasons),
+			},
+			ValuesChanges: []domain.ValuesChange{removedTag},
+			StaleOverrides: []domain.StaleOverride{
+				{File: "env/prod-values.yaml", Line: 5, Change: removedTag, Severity: domain.SeverityError},
+				{File: "inline values", Change: removedTag, Severity: domain.SeverityWarning},
+			},
+		},
+		{
+			ChartName: "worker", ChartPath: "charts/worker", Environment: "prod",
+			BaseRef: "main", HeadRef: "feat/pdb", Status: domain.StatusError,
+			Summary: "Failed to render head chart: template: worker/templates/cronjob.yaml:8:14: " +
+				"nil pointer evaluating interface {}.schedule",
+			RenderError: &domain.RenderError{
+				Template: "worker/templates/cronjob.yaml", Line: 8,
+				Message: "nil pointer evaluating interface {}.schedule",
+			},
+		},
+		{
+			ChartName: "stable", ChartPath: "charts/stable", Environment: "default",
+			BaseRef: "main", HeadRef: "feat/pdb", Status: domain.StatusSuccess,
+			Summary: "No changes detected.",
+		},
+	}
+}
+
+// CompareOrUpdateGolden compares actual with the golden file at path, or
+// rewrites it when run with -update.
+func CompareOrUpdateGolden(t *testing.T, path, actual string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("creating golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o600); err != nil {
+			t.Fatalf("writing golden file %s: %v", path, err)
+		}
+		t.Logf("updated golden file: %s", path)
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file %s (run with -update to create): %v", path, err)
+	}
+
+	if string(expected) != actual {
+		t.Errorf("output does not match golden file %s\n\n--- expected ---\n%s\n--- actual ---\n%s",
+			path, string(expected), actual)
+	}
+}
@@ -0,0 +1,83 @@
+// Package yamldoc holds manifest and YAML node helpers shared by the adapters
+// that read rendered manifests: the differs, filters and checkers.
+package yamldoc
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Split splits a multi-document manifest on "---" separator lines. Like
+// Helm, only an unindented "---" separates documents; an indented one belongs
+// to a block scalar, such as a multi-document config file in a ConfigMap.
+// Documents are returned as is, so empty and comment-only ones are included.
+func Split(manifest []byte) []string {
+	docs, _ := SplitSeparators(manifest)
+	return docs
+}
+
+// SplitSeparators splits manifest like Split, also returning the separator
+// lines, line endings included, so that the manifest can be put back
+// together: separators[i] comes between docs[i] and docs[i+1].
+func SplitSeparators(manifest []byte) (docs, separators []string) {
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(string(manifest), "\n") {
+		if isSeparator(line) {
+			docs = append(docs, current.String())
+			separators = append(separators, line)
+			current.Reset()
+			continue
+		}
+		current.WriteString(line)
+	}
+	return append(docs, current.String()), separators
+}
+
+// isSeparator reports whether line is a document separator: "---" at the
+// start of the line, then only whitespace.
+func isSeparator(line string) bool {
+	return strings.HasPrefix(line, "---") && strings.TrimSpace(line[3:]) == ""
+}
+
+// Source returns the template Helm rendered a document from, as named by its
+// "# Source:" comment (e.g. "my-app/templates/deployment.yaml"), or "".
+func Source(doc string) string {
+	for line := range strings.SplitSeq(doc, "\n") {
+		if source, ok := strings.CutPrefix(strings.TrimSpace(line), "# Source: "); ok {
+			return strings.TrimSpace(source)
+		}
+	}
+	return ""
+}
+
+// Resolve follows aliases to the node they refer to.
+func Resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// ValueOf returns the value for key in a mapping node, with aliases
+// resolved, or nil.
+func ValueOf(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return Resolve(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// ScalarAt returns the scalar value for key in a mapping node, or "".
+func ScalarAt(node *yaml.Node, key string) string {
+	value := ValueOf(node, key)
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return value.Value
+}
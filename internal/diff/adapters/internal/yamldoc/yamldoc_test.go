@@ -0,0 +1,66 @@
+package yamldoc
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSplit(t *testing.T) {
+	got := Split([]byte("---\na: 1\n--- \n# comment\n---\nb: 2\n"))
+	want := []string{"", "a: 1\n", "# comment\n", "b: 2\n"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split() = %q, want %q", got, want)
+	}
+}
+
+func TestSplit_IndentedSeparator(t *testing.T) {
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n" +
+		"  config.yaml: |\n    a: 1\n    ---\n    b: 2\n"
+	got := Split([]byte(configMap + "---\napiVersion: v1\nkind: Secret\n"))
+	want := []string{configMap, "apiVersion: v1\nkind: Secret\n"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split() = %q, want %q", got, want)
+	}
+}
+
+func TestSplitSeparators(t *testing.T) {
+	manifest := "---\na: 1\n--- \n  ---\n---"
+	docs, separators := SplitSeparators([]byte(manifest))
+	if want := []string{"", "a: 1\n", "  ---\n", ""}; !reflect.DeepEqual(docs, want) {
+		t.Errorf("SplitSeparators() docs = %q, want %q", docs, want)
+	}
+	if want := []string{"---\n", "--- \n", "---"}; !reflect.DeepEqual(separators, want) {
+		t.Errorf("SplitSeparators() separators = %q, want %q", separators, want)
+	}
+}
+
+func TestSource(t *testing.T) {
+	doc := "# Source: my-app/templates/deployment.yaml\napiVersion: apps/v1\n"
+	if got := Source(doc); got != "my-app/templates/deployment.yaml" {
+		t.Errorf("Source() = %q, want the template path", got)
+	}
+	if got := Source("apiVersion: v1\n"); got != "" {
+		t.Errorf("Source() = %q, want empty without a source comment", got)
+	}
+}
+
+func TestValueOf(t *testing.T) {
+	var doc yaml.Node
+	src := "base: &base {name: web}\nalias: *base\nlist: [1]\nempty:\n"
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Content[0]
+
+	if got := ScalarAt(ValueOf(root, "alias"), "name"); got != "web" {
+		t.Errorf("ScalarAt(alias, name) = %q, want the anchored value", got)
+	}
+	if got := ScalarAt(root, "list"); got != "" {
+		t.Errorf("ScalarAt(list) = %q, want empty for a non-scalar", got)
+	}
+	if ValueOf(root, "missing") != nil || ValueOf(nil, "base") != nil || ValueOf(ValueOf(root, "list"), "x") != nil {
+		t.Error("ValueOf() want nil for missing keys and non-mapping nodes")
+	}
+}
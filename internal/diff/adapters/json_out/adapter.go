@@ -0,0 +1,280 @@
+// Package jsonout serialises diff results as versioned JSON, for dashboards
+// and other tools that track chart changes across PRs.
+package jsonout
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// SchemaVersion is the version of the JSON document's schema. It is bumped
+// when a field is removed or changes meaning; added fields don't bump it.
+const SchemaVersion = 1
+
+// Adapter implements ports.ReportFormatterPort, formatting results as JSON.
+type Adapter struct{}
+
+// New creates a new JSON formatter.
+func New() *Adapter {
+	return &Adapter{}
+}
+
+// Name returns "json".
+func (a *Adapter) Name() string { return "json" }
+
+// FileExtension returns ".json".
+func (a *Adapter) FileExtension() string { return ".json" }
+
+// FormatResults returns the results of pr as an indented JSON document.
+func (a *Adapter) FormatResults(pr domain.PRContext, results []domain.DiffResult) ([]byte, error) {
+	doc := report{
+		Version: SchemaVersion,
+		PullRequest: pullRequest{
+			Owner:   pr.Owner,
+			Repo:    pr.Repo,
+			Number:  pr.PRNumber,
+			BaseRef: pr.BaseRef,
+			HeadRef: pr.HeadRef,
+			HeadSHA: pr.HeadSHA,
+		},
+		Results: make([]result, 0, len(results)),
+	}
+	doc.Summary.Results = len(results)
+	doc.Summary.Charts = len(domain.GroupByChart(results))
+	doc.Summary.Success, doc.Summary.Changes, doc.Summary.Errors, doc.Summary.Invalid = domain.CountByStatus(results)
+	for _, r := range results {
+		doc.Results = append(doc.Results, newResult(r))
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling results: %w", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// report is the JSON document.
+type report struct {
+	Version     int         `json:"version"`
+	PullRequest pullRequest `json:"pullRequest"`
+	Summary     summary     `json:"summary"`
+	Results     []result    `json:"results"`
+}
+
+type pullRequest struct {
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Number  int    `json:"number"`
+	BaseRef string `json:"baseRef"`
+	HeadRef string `json:"headRef"`
+	HeadSHA string `json:"headSha"`
+}
+
+// summary counts results by status.
+type summary struct {
+	Charts  int `json:"charts"`
+	Results int `json:"results"`
+	Success int `json:"success"`
+	Changes int `json:"changes"`
+	Errors  int `json:"errors"`
+	Invalid int `json:"invalid"`
+}
+
+// result is a domain.DiffResult, with enums as lowercase strings.
+type result struct {
+	Chart        string `json:"chart"`
+	ChartPath    string `json:"chartPath,omitempty"`
+	Environment  string `json:"environment"`
+	BaseRef      string `json:"baseRef"`
+	HeadRef      string `json:"headRef"`
+	Status       string `json:"status"` // "success", "changes", "error" or "invalid"
+	Summary      string `json:"summary,omitempty"`
+	SemanticDiff string `json:"semanticDiff,omitempty"`
+	UnifiedDiff  string `json:"unifiedDiff,omitempty"`
+	ReportURL    string `json:"reportUrl,omitempty"`
+
+	RenderError      *renderError      `json:"renderError,omitempty"`
+	Resources        []resourceChange  `json:"resources,omitempty"`
+	Suppressed       int               `json:"suppressed,omitempty"`
+	Violations       []schemaViolation `json:"violations,omitempty"`
+	LintFindings     []lintFinding     `json:"lintFindings,omitempty"`
+	PolicyViolations []policyViolation `json:"policyViolations,omitempty"`
+	Deprecations     []deprecation     `json:"deprecations,omitempty"`
+	Risks            []risk            `json:"risks,omitempty"`
+	ChartVersion     *versionCheck     `json:"chartVersion,omitempty"`
+	ValuesChanges    []valuesChange    `json:"valuesChanges,omitempty"`
+	StaleOverrides   []valuesFinding   `json:"staleOverrides,omitempty"`
+	UnusedValues     []valuesFinding   `json:"unusedValues,omitempty"`
+}
+
+type renderError struct {
+	Template string `json:"template"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+type resourceChange struct {
+	ID         string        `json:"id"` // apiVersion/kind[/namespace]/name
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Namespace  string        `json:"namespace,omitempty"`
+	Name       string        `json:"name"`
+	Change     string        `json:"change"` // "added", "removed" or "modified"
+	Fields     []fieldChange `json:"fields,omitempty"`
+}
+
+type fieldChange struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+type schemaViolation struct {
+	Resource string `json:"resource"`
+	Message  string `json:"message"`
+}
+
+type lintFinding struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"` // "error" or "warning"
+}
+
+type policyViolation struct {
+	Policy   string `json:"policy"`
+	Resource string `json:"resource"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+type deprecation struct {
+	Resource     string `json:"resource"`
+	APIVersion   string `json:"apiVersion"`
+	Kind         string `json:"kind"`
+	DeprecatedIn string `json:"deprecatedIn"`
+	RemovedIn    string `json:"removedIn,omitempty"`
+	Replacement  string `json:"replacement,omitempty"`
+	Target       string `json:"target,omitempty"`
+	Removed      bool   `json:"removed"`
+	Introduced   bool   `json:"introduced"`
+	Message      string `json:"message"`
+	Severity     string `json:"severity"`
+}
+
+type risk struct {
+	Resource string `json:"resource"`
+	Level    string `json:"level"` // "low", "medium" or "high"
+	Reason   string `json:"reason"`
+	Detail   string `json:"detail"`
+}
+
+type versionCheck struct {
+	BaseVersion string   `json:"baseVersion,omitempty"`
+	HeadVersion string   `json:"headVersion"`
+	Bump        string   `json:"bump"`
+	Required    string   `json:"required"`
+	Reasons     []string `json:"reasons,omitempty"`
+	Problem     string   `json:"problem,omitempty"`
+	Failed      bool     `json:"failed"`
+}
+
+type valuesChange struct {
+	Key     string `json:"key"`
+	Change  string `json:"change"` // "removed", "renamed" or "type changed"
+	NewKey  string `json:"newKey,omitempty"`
+	OldType string `json:"oldType,omitempty"`
+	NewType string `json:"newType,omitempty"`
+	Message string `json:"message"`
+}
+
+// valuesFinding is a stale override or an unused value.
+type valuesFinding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Key      string `json:"key"`
+	Message  string `json:"message"`
+	Severity string `json:"severity,omitempty"` // Stale overrides only: "error" or "warning"
+}
+
+func newResult(r domain.DiffResult) result {
+	out := result{
+		Chart:        r.ChartName,
+		ChartPath:    r.ChartPath,
+		Environment:  r.Environment,
+		BaseRef:      r.BaseRef,
+		HeadRef:      r.HeadRef,
+		Status:       strings.ToLower(r.Status.String()),
+		Summary:      r.Summary,
+		SemanticDiff: r.SemanticDiff,
+		UnifiedDiff:  r.UnifiedDiff,
+		ReportURL:    r.ReportURL,
+		Suppressed:   r.Suppressed,
+	}
+	if re := r.RenderError; re != nil {
+		out.RenderError = &renderError{Template: re.Template, Line: re.Line, Message: re.Message}
+	}
+	for _, c := range r.Resources {
+		rc := resourceChange{
+			ID: c.ID(), APIVersion: c.APIVersion, Kind: c.Kind, Namespace: c.Namespace, Name: c.Name,
+			Change: string(c.Type),
+		}
+		for _, f := range c.Fields {
+			rc.Fields = append(rc.Fields, fieldChange{Path: f.Path, Change: string(f.Type), Old: f.Old, New: f.New})
+		}
+		out.Resources = append(out.Resources, rc)
+	}
+	for _, v := range r.Violations {
+		out.Violations = append(out.Violations, schemaViolation{Resource: v.Resource, Message: v.Message})
+	}
+	for _, f := range r.LintFindings {
+		out.LintFindings = append(out.LintFindings, lintFinding{
+			File: f.File, Line: f.Line, Key: f.Key, Message: f.Message, Severity: string(f.Severity),
+		})
+	}
+	for _, v := range r.PolicyViolations {
+		out.PolicyViolations = append(out.PolicyViolations, policyViolation{
+			Policy: v.Policy, Resource: v.Resource, Message: v.Message, Severity: string(v.Severity),
+		})
+	}
+	for _, d := range r.Deprecations {
+		out.Deprecations = append(out.Deprecations, deprecation{
+			Resource: d.Resource, APIVersion: d.APIVersion, Kind: d.Kind, DeprecatedIn: d.DeprecatedIn,
+			RemovedIn: d.RemovedIn, Replacement: d.Replacement, Target: d.Target, Removed: d.Removed,
+			Introduced: d.Introduced, Message: d.Message(), Severity: string(d.Severity),
+		})
+	}
+	for _, rk := range r.Risks {
+		out.Risks = append(out.Risks, risk{
+			Resource: rk.Resource, Level: rk.Level.String(), Reason: string(rk.Reason), Detail: rk.Detail,
+		})
+	}
+	if v := r.ChartVersion; v != nil {
+		out.ChartVersion = &versionCheck{
+			BaseVersion: v.BaseVersion, HeadVersion: v.HeadVersion, Bump: v.Bump.String(),
+			Required: v.Required.String(), Reasons: v.Reasons, Problem: v.Problem, Failed: v.Failed(),
+		}
+	}
+	for _, c := range r.ValuesChanges {
+		out.ValuesChanges = append(out.ValuesChanges, valuesChange{
+			Key: c.Key(), Change: string(c.Type), NewKey: strings.Join(c.NewPath, "."), OldType: c.OldType,
+			NewType: c.NewType, Message: c.Message(),
+		})
+	}
+	for _, o := range r.StaleOverrides {
+		out.StaleOverrides = append(out.StaleOverrides, valuesFinding{
+			File: o.File, Line: o.Line, Key: o.Change.Key(), Message: o.Message(), Severity: string(o.Severity),
+		})
+	}
+	for _, u := range r.UnusedValues {
+		out.UnusedValues = append(out.UnusedValues, valuesFinding{
+			File: u.File, Line: u.Line, Key: u.Key(), Message: u.Message(),
+		})
+	}
+	return out
+}
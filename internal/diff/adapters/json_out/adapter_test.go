@@ -0,0 +1,37 @@
+package jsonout
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/reporttest"
+)
+
+func TestAdapter_FormatResults(t *testing.T) {
+	out, err := New().FormatResults(reporttest.PR, reporttest.Results())
+	if err != nil {
+		t.Fatalf("FormatResults() error = %v", err)
+	}
+	if !json.Valid(out) {
+		t.Fatalf("FormatResults() = %s, want valid JSON", out)
+	}
+	reporttest.CompareOrUpdateGolden(t, filepath.Join("testdata", "golden", "results.json"), string(out))
+}
+
+func TestAdapter_FormatResults_NoResults(t *testing.T) {
+	out, err := New().FormatResults(reporttest.PR, nil)
+	if err != nil {
+		t.Fatalf("FormatResults() error = %v", err)
+	}
+	var doc struct {
+		Version int               `json:"version"`
+		Results []json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unmarshaling output: %v", err)
+	}
+	if doc.Version != SchemaVersion || doc.Results == nil || len(doc.Results) != 0 {
+		t.Errorf("FormatResults() = %s, want version %d and an empty results array", out, SchemaVersion)
+	}
+}
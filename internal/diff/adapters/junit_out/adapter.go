@@ -0,0 +1,198 @@
+// Package junitout serialises diff results as JUnit XML, so CI systems show
+// each chart environment as a test case that passes or fails like the check
+// run.
+package junitout
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// Adapter implements ports.ReportFormatterPort, formatting results as JUnit
+// XML: a test suite per chart and a test case per environment.
+type Adapter struct {
+	name string
+}
+
+// New creates a new JUnit formatter whose test suites are grouped under name.
+func New(name string) *Adapter {
+	return &Adapter{name: name}
+}
+
+// Name returns "junit".
+func (a *Adapter) Name() string { return "junit" }
+
+// FileExtension returns ".xml".
+func (a *Adapter) FileExtension() string { return ".xml" }
+
+// FormatResults returns the results of pr as a JUnit XML document. An
+// environment that fails to render is an error, and one with problems that
+// fail the check run is a failure listing them; warnings and the changes
+// themselves go in its output.
+func (a *Adapter) FormatResults(pr domain.PRContext, results []domain.DiffResult) ([]byte, error) {
+	doc := testSuites{Name: a.name}
+	for _, chartResults := range domain.GroupByChart(results) {
+		suite := testSuite{Name: chartResults[0].ChartName}
+		for _, r := range chartResults {
+			tc := newTestCase(pr, r)
+			suite.Tests++
+			switch {
+			case tc.Error != nil:
+				suite.Errors++
+			case tc.Failure != nil:
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		doc.TestSuites = append(doc.TestSuites, suite)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling JUnit XML: %w", err)
+	}
+	return append(append([]byte(xml.Header), out...), '\n'), nil
+}
+
+type testSuites struct {
+	XMLName    xml.Name    `xml:"testsuites"`
+	Name       string      `xml:"name,attr"`
+	Tests      int         `xml:"tests,attr"`
+	Failures   int         `xml:"failures,attr"`
+	Errors     int         `xml:"errors,attr"`
+	TestSuites []testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Errors    int        `xml:"errors,attr"`
+	TestCases []testCase `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Error     *problem `xml:"error"`
+	Failure   *problem `xml:"failure"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+type problem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// newTestCase returns the test case of an environment.
+func newTestCase(pr domain.PRContext, r domain.DiffResult) testCase {
+	tc := testCase{Name: r.Environment, ClassName: r.ChartName}
+	if r.Status == domain.StatusError {
+		message, _, _ := strings.Cut(r.Summary, "\n")
+		tc.Error = &problem{Message: message, Type: "error", Text: r.Summary}
+	}
+	if failures := failures(r); len(failures) > 0 && tc.Error == nil {
+		tc.Failure = &problem{
+			Message: fmt.Sprintf("%d problem(s) fail the check", len(failures)),
+			Type:    strings.ToLower(r.Status.String()),
+			Text:    strings.Join(failures, "\n"),
+		}
+	}
+
+	var out []string
+	switch r.Status {
+	case domain.StatusChanges:
+		out = append(out, fmt.Sprintf("Changed between %s and %s: %s", r.BaseRef, pr.HeadSHA,
+			domain.SummarizeResourceChanges(r.Resources)))
+	case domain.StatusSuccess:
+		out = append(out, "No changes")
+	case domain.StatusError, domain.StatusInvalid:
+	}
+	if r.Suppressed > 0 {
+		out = append(out, fmt.Sprintf("Suppressed by ignore rules: %d change(s)", r.Suppressed))
+	}
+	if r.ReportURL != "" {
+		out = append(out, "Full diff: "+r.ReportURL)
+	}
+	tc.SystemOut = strings.Join(append(out, warnings(r)...), "\n")
+	return tc
+}
+
+// failures lists the problems of r that fail the check run.
+func failures(r domain.DiffResult) []string {
+	var lines []string
+	for _, v := range r.Violations {
+		lines = append(lines, fmt.Sprintf("Schema violation: %s: %s", v.Resource, v.Message))
+	}
+	for _, f := range r.LintFindings {
+		if f.Severity == domain.SeverityError {
+			lines = append(lines, "Lint error: "+lintMessage(f))
+		}
+	}
+	for _, v := range r.PolicyViolations {
+		if v.Severity == domain.SeverityError {
+			lines = append(lines, fmt.Sprintf("Policy %s failed on %s: %s", v.Policy, v.Resource, v.Message))
+		}
+	}
+	for _, d := range r.Deprecations {
+		if d.Severity == domain.SeverityError {
+			lines = append(lines, fmt.Sprintf("Removed API version: %s: %s", d.Resource, d.Message()))
+		}
+	}
+	if v := r.ChartVersion; v != nil && v.Failed() {
+		lines = append(lines, "Chart version: "+v.Problem)
+	}
+	for _, o := range r.StaleOverrides {
+		if o.Severity == domain.SeverityError {
+			lines = append(lines, "Stale override: "+o.Message())
+		}
+	}
+	return lines
+}
+
+// warnings lists the problems of r that are only reported, and its risky
+// changes.
+func warnings(r domain.DiffResult) []string {
+	var lines []string
+	for _, f := range r.LintFindings {
+		if f.Severity != domain.SeverityError {
+			lines = append(lines, "Lint warning: "+lintMessage(f))
+		}
+	}
+	for _, v := range r.PolicyViolations {
+		if v.Severity != domain.SeverityError {
+			lines = append(lines, fmt.Sprintf("Policy %s warning on %s: %s", v.Policy, v.Resource, v.Message))
+		}
+	}
+	for _, d := range r.Deprecations {
+		if d.Severity != domain.SeverityError {
+			lines = append(lines, fmt.Sprintf("Deprecated API version: %s: %s", d.Resource, d.Message()))
+		}
+	}
+	for _, o := range r.StaleOverrides {
+		if o.Severity != domain.SeverityError {
+			lines = append(lines, "Stale override: "+o.Message())
+		}
+	}
+	for _, u := range r.UnusedValues {
+		lines = append(lines, "Unused value: "+u.Message())
+	}
+	for _, rk := range r.Risks {
+		lines = append(lines, fmt.Sprintf("%s-risk change: %s: %s", rk.Level, rk.Resource, rk.Detail))
+	}
+	return lines
+}
+
+func lintMessage(f domain.LintFinding) string {
+	if location := f.Location(); location != "" {
+		return location + ": " + f.Message
+	}
+	return f.Message
+}
@@ -0,0 +1,27 @@
+package junitout
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/reporttest"
+)
+
+func TestAdapter_FormatResults(t *testing.T) {
+	out, err := New("chart-val").FormatResults(reporttest.PR, reporttest.Results())
+	if err != nil {
+		t.Fatalf("FormatResults() error = %v", err)
+	}
+	reporttest.CompareOrUpdateGolden(t, filepath.Join("testdata", "golden", "results.xml"), string(out))
+
+	var doc testSuites
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unmarshaling output: %v", err)
+	}
+	// my-app's prod fails the check, worker's prod doesn't render
+	if doc.Tests != 4 || doc.Failures != 1 || doc.Errors != 1 || len(doc.TestSuites) != 3 {
+		t.Errorf("testsuites = %d tests, %d failures, %d errors in %d suites; want 4, 1, 1 in 3",
+			doc.Tests, doc.Failures, doc.Errors, len(doc.TestSuites))
+	}
+}
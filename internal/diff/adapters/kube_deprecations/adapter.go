@@ -0,0 +1,144 @@
+// Package kubedeprecations finds resources in rendered manifests that use
+// deprecated or removed Kubernetes API versions, using a bundled table of
+// the upstream deprecation guide.
+package kubedeprecations
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"sigs.k8s.io/yaml"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// Adapter implements ports.DeprecationCheckerPort. API versions the target
+// Kubernetes version no longer serves are errors; deprecated ones it still
+// serves are warnings. Without a target, the newest release is assumed, and
+// the API versions it no longer serves are only warnings, since the cluster
+// may be older.
+type Adapter struct {
+	kubeVersion *semver.Version            // Default target; nil for the newest release
+	envVersions map[string]*semver.Version // Targets by environment name
+}
+
+// New creates a checker targeting kubeVersion (e.g. "1.29.0"; empty for the
+// newest release) by default, and envVersions (environment name to version)
+// for environments whose render options set no kube version. Returns an
+// error if a version doesn't parse.
+func New(kubeVersion string, envVersions map[string]string) (*Adapter, error) {
+	a := &Adapter{envVersions: make(map[string]*semver.Version, len(envVersions))}
+	if kubeVersion != "" {
+		v, err := parseKubeVersion(kubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		a.kubeVersion = v
+	}
+	for env, version := range envVersions {
+		v, err := parseKubeVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", env, err)
+		}
+		a.envVersions[env] = v
+	}
+	return a, nil
+}
+
+// Check returns the resources in manifest whose API version is deprecated in
+// the target Kubernetes version: kubeVersion if set, else the one configured
+// for envName, else the adapter's default. Documents that don't parse are
+// skipped; they fail schema validation instead.
+func (a *Adapter) Check(manifest []byte, envName, kubeVersion string) []domain.APIDeprecation {
+	target := a.target(envName, kubeVersion)
+
+	var deprecations []domain.APIDeprecation
+	for _, doc := range yamldoc.Split(manifest) {
+		var obj struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Metadata   struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj.Kind == "" {
+			continue
+		}
+		d, ok := check(obj.APIVersion, obj.Kind, target)
+		if !ok {
+			continue
+		}
+		d.Resource = domain.ResourceChange{
+			APIVersion: obj.APIVersion,
+			Kind:       obj.Kind,
+			Namespace:  obj.Metadata.Namespace,
+			Name:       obj.Metadata.Name,
+		}.ID()
+		deprecations = append(deprecations, d)
+	}
+	return deprecations
+}
+
+// target returns the Kubernetes version to check an environment against, or
+// nil for the newest release.
+func (a *Adapter) target(envName, kubeVersion string) *semver.Version {
+	if kubeVersion != "" {
+		v, err := parseKubeVersion(kubeVersion)
+		if err == nil {
+			return v
+		}
+		slog.Warn("ignoring kube version for deprecation checks", "environment", envName, "error", err)
+	}
+	if v, ok := a.envVersions[envName]; ok {
+		return v
+	}
+	return a.kubeVersion
+}
+
+// check looks up apiVersion and kind in the deprecation table, returning
+// false if target doesn't deprecate them.
+func check(apiVersion, kind string, target *semver.Version) (domain.APIDeprecation, bool) {
+	for _, api := range deprecatedAPIs {
+		if api.apiVersion != apiVersion || !slices.Contains(api.kinds, kind) {
+			continue
+		}
+		if target != nil && target.LessThan(semver.MustParse(api.deprecatedIn)) {
+			return domain.APIDeprecation{}, false
+		}
+
+		d := domain.APIDeprecation{
+			APIVersion:   apiVersion,
+			Kind:         kind,
+			DeprecatedIn: api.deprecatedIn,
+			RemovedIn:    api.removedIn,
+			Replacement:  api.replacement,
+			Severity:     domain.SeverityWarning,
+		}
+		if target != nil {
+			d.Target = fmt.Sprintf("%d.%d", target.Major(), target.Minor())
+		}
+		if api.removedIn != "" && (target == nil || !target.LessThan(semver.MustParse(api.removedIn))) {
+			d.Removed = true
+			if target != nil {
+				d.Severity = domain.SeverityError
+			}
+		}
+		return d, true
+	}
+	return domain.APIDeprecation{}, false
+}
+
+// parseKubeVersion parses a Kubernetes version such as "1.29", "v1.29.3" or
+// "1.29.3-gke.100", keeping only major and minor, which is what API removals
+// are tied to.
+func parseKubeVersion(v string) (*semver.Version, error) {
+	parsed, err := semver.NewVersion(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kube version %q: %w", v, err)
+	}
+	return semver.New(parsed.Major(), parsed.Minor(), 0, "", ""), nil
+}
@@ -0,0 +1,135 @@
+package kubedeprecations
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const manifest = `# Source: my-app/templates/pdb.yaml
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: my-app
+  namespace: prod
+---
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: my-app
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+---
+key: [unclosed
+`
+
+func TestAdapter_Check(t *testing.T) {
+	pdb := domain.APIDeprecation{
+		Resource:     "policy/v1beta1/PodDisruptionBudget/prod/my-app",
+		APIVersion:   "policy/v1beta1",
+		Kind:         "PodDisruptionBudget",
+		DeprecatedIn: "1.21",
+		RemovedIn:    "1.25",
+		Replacement:  "policy/v1",
+	}
+	hpa := domain.APIDeprecation{
+		Resource:     "autoscaling/v2beta2/HorizontalPodAutoscaler/my-app",
+		APIVersion:   "autoscaling/v2beta2",
+		Kind:         "HorizontalPodAutoscaler",
+		DeprecatedIn: "1.23",
+		RemovedIn:    "1.26",
+		Replacement:  "autoscaling/v2",
+	}
+	with := func(d domain.APIDeprecation, target string, removed bool, severity domain.Severity) domain.APIDeprecation {
+		d.Target, d.Removed, d.Severity = target, removed, severity
+		return d
+	}
+
+	tests := []struct {
+		name        string
+		env         string
+		kubeVersion string
+		want        []domain.APIDeprecation
+	}{
+		{
+			name: "removals from the newest release are warnings without a target",
+			env:  "staging",
+			want: []domain.APIDeprecation{
+				with(pdb, "", true, domain.SeverityWarning),
+				with(hpa, "", true, domain.SeverityWarning),
+			},
+		},
+		{
+			name:        "deprecated but still served",
+			env:         "staging",
+			kubeVersion: "v1.24.3",
+			want: []domain.APIDeprecation{
+				with(pdb, "1.24", false, domain.SeverityWarning),
+				with(hpa, "1.24", false, domain.SeverityWarning),
+			},
+		},
+		{
+			name:        "removed in the target version",
+			env:         "staging",
+			kubeVersion: "1.25.0-gke.100",
+			want: []domain.APIDeprecation{
+				with(pdb, "1.25", true, domain.SeverityError),
+				with(hpa, "1.25", false, domain.SeverityWarning),
+			},
+		},
+		{
+			name: "environment version",
+			env:  "legacy",
+			want: []domain.APIDeprecation{with(pdb, "1.22", false, domain.SeverityWarning)},
+		},
+		{
+			name:        "render options take precedence over the environment version",
+			env:         "legacy",
+			kubeVersion: "1.20",
+		},
+		{
+			name:        "invalid kube version falls back to the environment version",
+			env:         "legacy",
+			kubeVersion: "latest",
+			want:        []domain.APIDeprecation{with(pdb, "1.22", false, domain.SeverityWarning)},
+		},
+	}
+
+	adapter, err := New("", map[string]string{"legacy": "1.22"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adapter.Check([]byte(manifest), tt.env, tt.kubeVersion)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdapter_Check_DefaultVersion(t *testing.T) {
+	adapter, err := New("1.29.0", nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got := adapter.Check([]byte("apiVersion: batch/v1beta1\nkind: CronJob\nmetadata: {name: my-app}\n"), "prod", "")
+	want := "batch/v1beta1 CronJob is not served by Kubernetes 1.29 (removed in 1.25); use batch/v1"
+	if len(got) != 1 || got[0].Message() != want {
+		t.Errorf("Check() = %+v, want one deprecation %q", got, want)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := New("not-a-version", nil); err == nil {
+		t.Error("New() expected error for invalid kube version")
+	}
+	if _, err := New("", map[string]string{"prod": "1.x"}); err == nil {
+		t.Error("New() expected error for invalid environment kube version")
+	}
+}
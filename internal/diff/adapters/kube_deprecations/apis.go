@@ -0,0 +1,82 @@
+package kubedeprecations
+
+// deprecatedAPI is an API version of built-in kinds that Kubernetes
+// deprecated, and usually later stopped serving.
+type deprecatedAPI struct {
+	apiVersion   string
+	kinds        []string
+	deprecatedIn string // Kubernetes minor version
+	removedIn    string // Kubernetes minor version, empty if no removal is planned
+	replacement  string // apiVersion to migrate to, empty if the kind has no replacement
+}
+
+// deprecatedAPIs lists deprecated API versions of built-in kinds, following
+// https://kubernetes.io/docs/reference/using-api/deprecation-guide/.
+var deprecatedAPIs = []deprecatedAPI{
+	{"extensions/v1beta1", []string{"DaemonSet", "Deployment", "ReplicaSet"}, "1.9", "1.16", "apps/v1"},
+	{"extensions/v1beta1", []string{"NetworkPolicy"}, "1.9", "1.16", "networking.k8s.io/v1"},
+	{"extensions/v1beta1", []string{"PodSecurityPolicy"}, "1.10", "1.16", "policy/v1beta1"},
+	{"apps/v1beta1", []string{"Deployment", "StatefulSet"}, "1.9", "1.16", "apps/v1"},
+	{"apps/v1beta2", []string{"DaemonSet", "Deployment", "ReplicaSet", "StatefulSet"}, "1.9", "1.16", "apps/v1"},
+	{"extensions/v1beta1", []string{"Ingress"}, "1.14", "1.22", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", []string{"Ingress", "IngressClass"}, "1.19", "1.22", "networking.k8s.io/v1"},
+	{
+		"rbac.authorization.k8s.io/v1beta1",
+		[]string{"ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"},
+		"1.17",
+		"1.22",
+		"rbac.authorization.k8s.io/v1",
+	},
+	{"apiextensions.k8s.io/v1beta1", []string{"CustomResourceDefinition"}, "1.16", "1.22", "apiextensions.k8s.io/v1"},
+	{
+		"admissionregistration.k8s.io/v1beta1",
+		[]string{"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"},
+		"1.16",
+		"1.22",
+		"admissionregistration.k8s.io/v1",
+	},
+	{"apiregistration.k8s.io/v1beta1", []string{"APIService"}, "1.19", "1.22", "apiregistration.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", []string{"CertificateSigningRequest"}, "1.19", "1.22", "certificates.k8s.io/v1"},
+	{"coordination.k8s.io/v1beta1", []string{"Lease"}, "1.14", "1.22", "coordination.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", []string{"PriorityClass"}, "1.14", "1.22", "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", []string{"CSINode"}, "1.17", "1.22", "storage.k8s.io/v1"},
+	{
+		"storage.k8s.io/v1beta1",
+		[]string{"CSIDriver", "StorageClass", "VolumeAttachment"},
+		"1.19",
+		"1.22",
+		"storage.k8s.io/v1",
+	},
+	{"batch/v1beta1", []string{"CronJob"}, "1.21", "1.25", "batch/v1"},
+	{"discovery.k8s.io/v1beta1", []string{"EndpointSlice"}, "1.21", "1.25", "discovery.k8s.io/v1"},
+	{"events.k8s.io/v1beta1", []string{"Event"}, "1.19", "1.25", "events.k8s.io/v1"},
+	{"autoscaling/v2beta1", []string{"HorizontalPodAutoscaler"}, "1.22", "1.25", "autoscaling/v2"},
+	{"policy/v1beta1", []string{"PodDisruptionBudget"}, "1.21", "1.25", "policy/v1"},
+	{"policy/v1beta1", []string{"PodSecurityPolicy"}, "1.21", "1.25", ""},
+	{"node.k8s.io/v1beta1", []string{"RuntimeClass"}, "1.20", "1.25", "node.k8s.io/v1"},
+	{"autoscaling/v2beta2", []string{"HorizontalPodAutoscaler"}, "1.23", "1.26", "autoscaling/v2"},
+	{
+		"flowcontrol.apiserver.k8s.io/v1beta1",
+		[]string{"FlowSchema", "PriorityLevelConfiguration"},
+		"1.23",
+		"1.26",
+		"flowcontrol.apiserver.k8s.io/v1",
+	},
+	{"storage.k8s.io/v1beta1", []string{"CSIStorageCapacity"}, "1.24", "1.27", "storage.k8s.io/v1"},
+	{
+		"flowcontrol.apiserver.k8s.io/v1beta2",
+		[]string{"FlowSchema", "PriorityLevelConfiguration"},
+		"1.26",
+		"1.29",
+		"flowcontrol.apiserver.k8s.io/v1",
+	},
+	{
+		"flowcontrol.apiserver.k8s.io/v1beta3",
+		[]string{"FlowSchema", "PriorityLevelConfiguration"},
+		"1.29",
+		"1.32",
+		"flowcontrol.apiserver.k8s.io/v1",
+	},
+	{"v1", []string{"ComponentStatus"}, "1.19", "", ""},
+	{"v1", []string{"Endpoints"}, "1.33", "", "discovery.k8s.io/v1 EndpointSlice"},
+}
@@ -0,0 +1,252 @@
+// Package kubeschema validates rendered manifests against Kubernetes OpenAPI
+// schemas: those of the built-in API types bundled with client-go or loaded
+// for other Kubernetes versions from a local directory, plus
+// CustomResourceDefinitions loaded from a local directory.
+package kubeschema
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/managedfields"
+	"k8s.io/client-go/applyconfigurations"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/structured-merge-diff/v6/typed"
+	"sigs.k8s.io/yaml"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// bundledKubeVersion is the Kubernetes version whose built-in API schemas
+// client-go bundles. Keep it in step with k8s.io/client-go in go.mod.
+const bundledKubeVersion = "1.37"
+
+// Options configures a validator. The zero value validates every environment
+// against the bundled schemas, without CRDs.
+type Options struct {
+	CRDDir          string            // CustomResourceDefinition manifests; "" for none
+	SchemaDir       string            // OpenAPI documents named <major>.<minor>.json; "" for none
+	KubeVersion     string            // Default target (e.g. "1.29.0"); "" for the bundled version
+	EnvKubeVersions map[string]string // Targets by environment name
+}
+
+// Adapter implements ports.ManifestValidatorPort. Documents are checked for
+// unknown fields and values of the wrong type, using the built-in schemas of
+// the environment's Kubernetes version. Kinds without a known schema are
+// skipped, so unlisted CRDs never fail validation.
+type Adapter struct {
+	builtins    map[string]kubeSchema // Built-in schemas by "major.minor" version
+	crds        managedfields.TypeConverter
+	crdKinds    map[schema.GroupVersionKind]bool
+	kubeVersion string            // Default target, as "major.minor"
+	envVersions map[string]string // Targets by environment name, as "major.minor"
+}
+
+// kubeSchema holds the built-in API schemas of one Kubernetes version.
+type kubeSchema struct {
+	converter  managedfields.TypeConverter
+	recognizes func(schema.GroupVersionKind) bool
+}
+
+// New creates a validator from opts. Returns an error if the CRDs or schemas
+// can't be loaded, or if a configured Kubernetes version doesn't parse or has
+// no schema.
+func New(opts Options) (*Adapter, error) {
+	a := &Adapter{
+		builtins: map[string]kubeSchema{
+			bundledKubeVersion: {
+				converter:  applyconfigurations.NewTypeConverter(scheme.Scheme),
+				recognizes: scheme.Scheme.Recognizes,
+			},
+		},
+		kubeVersion: bundledKubeVersion,
+		envVersions: make(map[string]string, len(opts.EnvKubeVersions)),
+	}
+
+	if opts.SchemaDir != "" {
+		if err := a.loadSchemas(opts.SchemaDir); err != nil {
+			return nil, err
+		}
+	}
+	if opts.CRDDir != "" {
+		crds, kinds, err := loadCRDs(opts.CRDDir)
+		if err != nil {
+			return nil, err
+		}
+		a.crds, a.crdKinds = crds, kinds
+	}
+
+	if opts.KubeVersion != "" {
+		v, err := a.supportedVersion(opts.KubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		a.kubeVersion = v
+	}
+	for env, version := range opts.EnvKubeVersions {
+		v, err := a.supportedVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", env, err)
+		}
+		a.envVersions[env] = v
+	}
+	return a, nil
+}
+
+// Validate returns the schema violations in manifest, checked against the
+// target Kubernetes version: kubeVersion if set, else the one configured for
+// envName, else the adapter's default. Configured versions are checked by New,
+// so a target without a schema comes from kubeVersion (the Argo CD app or its
+// cluster); validation is then skipped rather than run against another version.
+func (a *Adapter) Validate(manifest []byte, envName, kubeVersion string) []domain.SchemaViolation {
+	target := a.target(envName, kubeVersion)
+	builtin, ok := a.builtins[target]
+	if !ok {
+		slog.Warn("skipping schema validation", "environment", envName,
+			"reason", fmt.Sprintf("no schema for Kubernetes %s; %s", target, a.available()))
+		return nil
+	}
+
+	var violations []domain.SchemaViolation
+	n := 0
+	for _, doc := range yamldoc.Split(manifest) {
+		var obj map[string]any
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			n++
+			violations = append(violations, domain.SchemaViolation{
+				Resource: fmt.Sprintf("document %d", n),
+				Message:  fmt.Sprintf("invalid YAML: %s", err),
+			})
+			continue
+		}
+		if obj == nil {
+			continue // Empty or comment-only document
+		}
+		n++
+		template := yamldoc.Source(doc)
+		for _, v := range a.validateObject(builtin, &unstructured.Unstructured{Object: obj}, n) {
+			v.Template = template
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// target returns the "major.minor" Kubernetes version to validate an
+// environment against.
+func (a *Adapter) target(envName, kubeVersion string) string {
+	if kubeVersion != "" {
+		v, err := parseKubeVersion(kubeVersion)
+		if err == nil {
+			return v
+		}
+		slog.Warn("ignoring kube version for schema validation", "environment", envName, "error", err)
+	}
+	if v, ok := a.envVersions[envName]; ok {
+		return v
+	}
+	return a.kubeVersion
+}
+
+// supportedVersion parses a configured Kubernetes version, returning an error
+// if the validator has no schema for it.
+func (a *Adapter) supportedVersion(version string) (string, error) {
+	v, err := parseKubeVersion(version)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := a.builtins[v]; !ok {
+		return "", fmt.Errorf("no schema for Kubernetes %s; %s", v, a.available())
+	}
+	return v, nil
+}
+
+// available lists the Kubernetes versions the validator has schemas for.
+func (a *Adapter) available() string {
+	versions := make([]string, 0, len(a.builtins))
+	for v := range a.builtins {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	return "schemas are available for " + strings.Join(versions, ", ")
+}
+
+// parseKubeVersion parses a Kubernetes version such as "1.29", "v1.29.3" or
+// "1.29.3-gke.100" into "major.minor", which is what API schemas change with.
+func parseKubeVersion(v string) (string, error) {
+	parsed, err := semver.NewVersion(v)
+	if err != nil {
+		return "", fmt.Errorf("invalid kube version %q: %w", v, err)
+	}
+	return fmt.Sprintf("%d.%d", parsed.Major(), parsed.Minor()), nil
+}
+
+// validateObject validates the n-th document of a manifest.
+func (a *Adapter) validateObject(
+	builtin kubeSchema,
+	obj *unstructured.Unstructured,
+	n int,
+) []domain.SchemaViolation {
+	resource := resourceID(obj, n)
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return []domain.SchemaViolation{{Resource: resource, Message: "apiVersion and kind are required"}}
+	}
+
+	converter := a.converterFor(builtin, obj.GroupVersionKind())
+	if converter == nil {
+		return nil
+	}
+	_, err := converter.ObjectToTyped(obj)
+	if err == nil {
+		return nil
+	}
+
+	var errs typed.ValidationErrors
+	if !errors.As(err, &errs) {
+		slog.Debug("skipping schema validation", "resource", resource, "error", err)
+		return nil
+	}
+	violations := make([]domain.SchemaViolation, 0, len(errs))
+	for _, e := range errs {
+		violations = append(violations, domain.SchemaViolation{Resource: resource, Message: e.Error()})
+	}
+	// Errors come from map iteration, so sort them for stable reports
+	slices.SortFunc(violations, func(x, y domain.SchemaViolation) int {
+		return strings.Compare(x.Message, y.Message)
+	})
+	return violations
+}
+
+// converterFor returns the type converter holding the schema for gvk, or nil
+// if there is none.
+func (a *Adapter) converterFor(builtin kubeSchema, gvk schema.GroupVersionKind) managedfields.TypeConverter {
+	switch {
+	case builtin.recognizes(gvk):
+		return builtin.converter
+	case a.crdKinds[gvk]:
+		return a.crds
+	default:
+		return nil
+	}
+}
+
+// resourceID identifies a document as apiVersion/kind[/namespace]/name, or by
+// its position if it has no kind or name.
+func resourceID(obj *unstructured.Unstructured, n int) string {
+	if obj.GetKind() == "" || obj.GetName() == "" {
+		return fmt.Sprintf("document %d", n)
+	}
+	return domain.ResourceChange{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}.ID()
+}
@@ -0,0 +1,262 @@
+package kubeschema
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const deployment = `# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  namespace: prod
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+        - name: my-app
+          image: my-app:1.24.0
+          ports:
+            - containerPort: 8080
+          resources:
+            limits:
+              cpu: 1
+              memory: 128Mi
+`
+
+const widgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              properties:
+                size:
+                  type: integer
+`
+
+func TestAdapter_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     []domain.SchemaViolation
+	}{
+		{
+			name:     "valid built-in resources",
+			manifest: "---\n" + deployment + "---\napiVersion: v1\nkind: Service\nmetadata: {name: my-app}\n",
+		},
+		{
+			name: "unknown fields and wrong types",
+			manifest: `# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  replicas: "2"
+  template:
+    spec:
+      contianers: []
+`,
+			want: []domain.SchemaViolation{
+				{
+					Resource: "apps/v1/Deployment/my-app",
+					Message:  ".spec.replicas: expected numeric (int or float), got string",
+					Template: "my-app/templates/deployment.yaml",
+				},
+				{
+					Resource: "apps/v1/Deployment/my-app",
+					Message:  ".spec.template.spec.contianers: field not declared in schema",
+					Template: "my-app/templates/deployment.yaml",
+				},
+			},
+		},
+		{
+			name:     "unknown kinds are skipped",
+			manifest: "apiVersion: example.com/v1\nkind: Gadget\nmetadata: {name: g}\nspec: {anything: true}\n",
+		},
+		{
+			name:     "CRD schemas are applied",
+			manifest: "apiVersion: example.com/v1\nkind: Widget\nmetadata: {name: w}\nspec: {size: large}\n",
+			want: []domain.SchemaViolation{{
+				Resource: "example.com/v1/Widget/w",
+				Message:  ".spec.size: expected numeric (int or float), got string",
+			}},
+		},
+		{
+			name:     "documents without kind or invalid YAML",
+			manifest: "# only a comment\n---\nmetadata: {name: x}\n---\nkey: [unclosed\n",
+			want: []domain.SchemaViolation{
+				{Resource: "document 1", Message: "apiVersion and kind are required"},
+				{
+					Resource: "document 2",
+					Message: "invalid YAML: error converting YAML to JSON: " +
+						"yaml: line 1: did not find expected ',' or ']'",
+				},
+			},
+		},
+	}
+
+	crdDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(crdDir, "widgets.yaml"), []byte(widgetCRD), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	adapter, err := New(Options{CRDDir: crdDir})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adapter.Validate([]byte(tt.manifest), "prod", "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// configMapSchema is a cut-down OpenAPI document for an older Kubernetes
+// release, whose ConfigMap has no immutable field and which has no apps/v1.
+const configMapSchema = `{
+  "swagger": "2.0",
+  "definitions": {
+    "io.k8s.api.core.v1.ConfigMap": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string"},
+        "kind": {"type": "string"},
+        "metadata": {"type": "object"},
+        "data": {"type": "object", "additionalProperties": {"type": "string"}}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "", "version": "v1", "kind": "ConfigMap"}]
+    }
+  }
+}`
+
+func TestAdapter_Validate_KubeVersion(t *testing.T) {
+	const configMap = "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: c}\nimmutable: true\ndata: {a: b}\n"
+	oldSchema := []domain.SchemaViolation{{
+		Resource: "v1/ConfigMap/c",
+		Message:  ".immutable: field not declared in schema",
+	}}
+
+	tests := []struct {
+		name        string
+		envName     string
+		kubeVersion string
+		manifest    string
+		want        []domain.SchemaViolation
+	}{
+		{
+			name:     "default version uses the bundled schemas",
+			envName:  "prod",
+			manifest: configMap,
+		},
+		{
+			name:        "environment's own version wins",
+			envName:     "prod",
+			kubeVersion: "v1.20.15-gke.100",
+			manifest:    configMap,
+			want:        oldSchema,
+		},
+		{
+			name:     "configured environment version",
+			envName:  "legacy",
+			manifest: configMap,
+			want:     oldSchema,
+		},
+		{
+			name:     "kinds the version has no schema for are skipped",
+			envName:  "legacy",
+			manifest: deployment,
+		},
+		{
+			name:        "runtime versions without a schema are skipped",
+			envName:     "prod",
+			kubeVersion: "1.21",
+			manifest:    configMap,
+		},
+		{
+			name:        "invalid versions fall back to the configured one",
+			envName:     "legacy",
+			kubeVersion: "latest",
+			manifest:    configMap,
+			want:        oldSchema,
+		},
+	}
+
+	schemaDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(schemaDir, "1.20.json"), []byte(configMapSchema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	adapter, err := New(Options{SchemaDir: schemaDir, EnvKubeVersions: map[string]string{"legacy": "1.20"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adapter.Validate([]byte(tt.manifest), tt.envName, tt.kubeVersion)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	crdDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(crdDir, "bad.yaml"), []byte("key: [unclosed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(Options{CRDDir: crdDir}); err == nil {
+		t.Error("New() expected error for unparseable CRD file")
+	}
+	if _, err := New(Options{CRDDir: filepath.Join(crdDir, "missing")}); err == nil {
+		t.Error("New() expected error for missing CRD directory")
+	}
+
+	schemaDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(schemaDir, "latest.json"), []byte(configMapSchema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(Options{SchemaDir: schemaDir}); err == nil {
+		t.Error("New() expected error for schema file not named after a version")
+	}
+	if _, err := New(Options{SchemaDir: filepath.Join(schemaDir, "missing")}); err == nil {
+		t.Error("New() expected error for missing schema directory")
+	}
+	if _, err := New(Options{KubeVersion: "1.29"}); err == nil {
+		t.Error("New() expected error for a default version without a schema")
+	}
+	if _, err := New(Options{EnvKubeVersions: map[string]string{"prod": "1.x"}}); err == nil {
+		t.Error("New() expected error for an unparseable environment version")
+	}
+}
@@ -0,0 +1,121 @@
+package kubeschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/managedfields"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"sigs.k8s.io/yaml"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+)
+
+// loadCRDs reads the CustomResourceDefinitions in the YAML and JSON files
+// under dir, and returns a type converter for every served version that
+// declares a schema, along with the kinds it covers.
+func loadCRDs(dir string) (managedfields.TypeConverter, map[schema.GroupVersionKind]bool, error) {
+	models := make(map[string]*spec.Schema)
+	kinds := make(map[schema.GroupVersionKind]bool)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		data, err := os.ReadFile(path) //nolint:gosec // G304: path is within the configured CRD directory
+		if err != nil {
+			return err
+		}
+		for _, doc := range yamldoc.Split(data) {
+			if err := addCRDModels(models, kinds, doc); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading CRD schemas from %s: %w", dir, err)
+	}
+
+	converter, err := managedfields.NewTypeConverter(models, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("converting CRD schemas from %s: %w", dir, err)
+	}
+	return converter, kinds, nil
+}
+
+// addCRDModels adds the schemas of doc to models if it is a CRD. Other
+// documents are ignored.
+func addCRDModels(models map[string]*spec.Schema, kinds map[schema.GroupVersionKind]bool, doc string) error {
+	var meta struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := yaml.Unmarshal([]byte(doc), &meta); err != nil {
+		return err
+	}
+	if meta.APIVersion != apiextensionsv1.SchemeGroupVersion.String() || meta.Kind != "CustomResourceDefinition" {
+		return nil
+	}
+
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal([]byte(doc), &crd); err != nil {
+		return err
+	}
+	for _, v := range crd.Spec.Versions {
+		if v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		model, err := toSpecSchema(v.Schema.OpenAPIV3Schema)
+		if err != nil {
+			return fmt.Errorf("%s version %s: %w", crd.Name, v.Name, err)
+		}
+
+		gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: v.Name, Kind: crd.Spec.Names.Kind}
+		model.AddExtension("x-kubernetes-group-version-kind", []any{
+			map[string]any{"group": gvk.Group, "version": gvk.Version, "kind": gvk.Kind},
+		})
+		models[gvk.GroupVersion().String()+"."+gvk.Kind] = model
+		kinds[gvk] = true
+	}
+	return nil
+}
+
+// toSpecSchema converts a CRD's structural schema to an OpenAPI schema.
+// CRD schemas often leave out apiVersion and kind, so they are declared here
+// when missing. metadata is always accepted as is, as the API server checks it
+// against ObjectMeta rather than the CRD schema.
+func toSpecSchema(props *apiextensionsv1.JSONSchemaProps) (*spec.Schema, error) {
+	data, err := json.Marshal(props)
+	if err != nil {
+		return nil, err
+	}
+	var s spec.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+
+	if len(s.Properties) > 0 {
+		for _, field := range []string{"apiVersion", "kind"} {
+			if _, ok := s.Properties[field]; !ok {
+				s.SetProperty(field, *spec.StringProperty())
+			}
+		}
+		s.SetProperty("metadata", *new(spec.Schema).Typed("object", ""))
+	}
+	return &s, nil
+}
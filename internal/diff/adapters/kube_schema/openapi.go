@@ -0,0 +1,98 @@
+package kubeschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/managedfields"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// loadSchemas adds the built-in API schemas of the Kubernetes versions in
+// dir. Each version is an OpenAPI document named after it, such as
+// 1.29.json: the swagger.json of that release (api/openapi-spec in the
+// Kubernetes repository), or an OpenAPI v3 document with the same models.
+func (a *Adapter) loadSchemas(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("loading Kubernetes schemas from %s: %w", dir, err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("loading Kubernetes schemas from %s: %w", dir, err)
+	}
+
+	for _, path := range paths {
+		version, err := parseKubeVersion(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return fmt.Errorf("%s: file name must be a Kubernetes version: %w", path, err)
+		}
+		s, err := loadSchema(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		a.builtins[version] = s
+	}
+	return nil
+}
+
+// loadSchema reads the models of an OpenAPI document and returns a type
+// converter for them, along with the kinds they declare.
+func loadSchema(path string) (kubeSchema, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is within the configured schema directory
+	if err != nil {
+		return kubeSchema{}, err
+	}
+	var doc struct {
+		Definitions map[string]*spec.Schema `json:"definitions"` // OpenAPI v2
+		Components  struct {
+			Schemas map[string]*spec.Schema `json:"schemas"` // OpenAPI v3
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return kubeSchema{}, err
+	}
+	models := doc.Definitions
+	if len(models) == 0 {
+		models = doc.Components.Schemas
+	}
+	if len(models) == 0 {
+		return kubeSchema{}, errors.New("no models in OpenAPI document")
+	}
+
+	converter, err := managedfields.NewTypeConverter(models, false)
+	if err != nil {
+		return kubeSchema{}, err
+	}
+	kinds := make(map[schema.GroupVersionKind]bool)
+	for _, model := range models {
+		for _, gvk := range groupVersionKinds(model) {
+			kinds[gvk] = true
+		}
+	}
+	return kubeSchema{
+		converter:  converter,
+		recognizes: func(gvk schema.GroupVersionKind) bool { return kinds[gvk] },
+	}, nil
+}
+
+// groupVersionKinds returns the kinds a model is the schema of, from its
+// x-kubernetes-group-version-kind extension.
+func groupVersionKinds(model *spec.Schema) []schema.GroupVersionKind {
+	list, _ := model.Extensions["x-kubernetes-group-version-kind"].([]any)
+	gvks := make([]schema.GroupVersionKind, 0, len(list))
+	for _, item := range list {
+		m, _ := item.(map[string]any)
+		group, _ := m["group"].(string)
+		version, _ := m["version"].(string)
+		kind, _ := m["kind"].(string)
+		if kind != "" {
+			gvks = append(gvks, schema.GroupVersionKind{Group: group, Version: version, Kind: kind})
+		}
+	}
+	return gvks
+}
@@ -6,6 +6,10 @@ import (
 	"strings"
 
 	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
 )
 
 // Adapter implements ports.DiffPort using traditional line-by-line unified diff.
@@ -16,8 +20,9 @@ func New() *Adapter {
 	return &Adapter{}
 }
 
-// ComputeDiff performs traditional line-by-line unified diff.
-func (a *Adapter) ComputeDiff(baseName, headName string, base, head []byte) string {
+// ComputeDiff performs traditional line-by-line unified diff. Resources are
+// compared by their text, so modified resources carry no field changes.
+func (a *Adapter) ComputeDiff(baseName, headName string, base, head []byte) domain.ManifestDiff {
 	ud := difflib.UnifiedDiff{
 		A:        difflib.SplitLines(string(base)),
 		B:        difflib.SplitLines(string(head)),
@@ -27,7 +32,80 @@ func (a *Adapter) ComputeDiff(baseName, headName string, base, head []byte) stri
 	}
 	text, err := difflib.GetUnifiedDiffString(ud)
 	if err != nil {
-		return fmt.Sprintf("error computing diff: %s", err)
+		return domain.ManifestDiff{Text: fmt.Sprintf("error computing diff: %s", err)}
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return domain.ManifestDiff{}
+	}
+	return domain.ManifestDiff{Text: text, Resources: resourceChanges(base, head)}
+}
+
+// resourceChanges compares the documents of base and head by resource ID.
+// Documents that aren't Kubernetes resources are ignored.
+func resourceChanges(base, head []byte) []domain.ResourceChange {
+	baseDocs, baseOrder := splitResources(base)
+	headDocs, headOrder := splitResources(head)
+
+	var changes []domain.ResourceChange
+	for _, id := range baseOrder {
+		doc := baseDocs[id]
+		other, ok := headDocs[id]
+		switch {
+		case !ok:
+			changes = append(changes, withType(doc.resource, domain.ChangeRemoved))
+		case other.text != doc.text:
+			changes = append(changes, withType(doc.resource, domain.ChangeModified))
+		}
 	}
-	return strings.TrimSpace(text)
+	for _, id := range headOrder {
+		if _, ok := baseDocs[id]; !ok {
+			changes = append(changes, withType(headDocs[id].resource, domain.ChangeAdded))
+		}
+	}
+	return changes
+}
+
+type resourceDoc struct {
+	resource domain.ResourceChange
+	text     string
+}
+
+// splitResources splits a multi-document manifest on "---" lines and indexes
+// the documents by resource ID, returning the IDs in document order.
+func splitResources(manifest []byte) (map[string]resourceDoc, []string) {
+	docs := make(map[string]resourceDoc)
+	var order []string
+	for _, doc := range yamldoc.Split(manifest) {
+		text := strings.TrimSpace(doc)
+		var header struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Metadata   struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(text), &header); err != nil || header.Kind == "" || header.Metadata.Name == "" {
+			continue
+		}
+
+		resource := domain.ResourceChange{
+			APIVersion: header.APIVersion,
+			Kind:       header.Kind,
+			Namespace:  header.Metadata.Namespace,
+			Name:       header.Metadata.Name,
+		}
+		id := resource.ID()
+		if _, dup := docs[id]; !dup {
+			order = append(order, id)
+		}
+		docs[id] = resourceDoc{resource: resource, text: text}
+	}
+	return docs, order
+}
+
+func withType(resource domain.ResourceChange, changeType domain.ChangeType) domain.ResourceChange {
+	resource.Type = changeType
+	return resource
 }
@@ -1,8 +1,11 @@
 package linediff
 
 import (
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
 )
 
 func TestAdapter_ComputeDiff(t *testing.T) {
@@ -67,7 +70,7 @@ func TestAdapter_ComputeDiff(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			adapter := New()
-			got := adapter.ComputeDiff(tt.baseName, tt.headName, tt.base, tt.head)
+			got := adapter.ComputeDiff(tt.baseName, tt.headName, tt.base, tt.head).Text
 
 			if tt.want == "" && got != "" {
 				t.Errorf("ComputeDiff() expected empty diff, got:\n%s", got)
@@ -115,7 +118,7 @@ line8
 line9
 `)
 
-	diff := adapter.ComputeDiff("test (main)", "test (feature)", base, head)
+	diff := adapter.ComputeDiff("test (main)", "test (feature)", base, head).Text
 
 	// Should include 3 lines before and after the change
 	if !strings.Contains(diff, "line2") { // Context before
@@ -131,3 +134,52 @@ line9
 		t.Error("Expected added line '+CHANGED'")
 	}
 }
+
+func TestAdapter_ComputeDiff_Resources(t *testing.T) {
+	adapter := New()
+
+	base := []byte(`---
+# Source: app/templates/cm.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  level: info
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: prod
+`)
+	head := []byte(`---
+# Source: app/templates/cm.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  level: debug
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+
+	got := adapter.ComputeDiff("test (main)", "test (feature)", base, head).Resources
+
+	want := []domain.ResourceChange{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Type: domain.ChangeModified},
+		{APIVersion: "v1", Kind: "Secret", Namespace: "prod", Name: "creds", Type: domain.ChangeRemoved},
+		{APIVersion: "v1", Kind: "Service", Name: "web", Type: domain.ChangeAdded},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resources mismatch\ngot:  %+v\nwant: %+v", got, want)
+	}
+
+	if unchanged := adapter.ComputeDiff("a", "b", base, base); unchanged.Resources != nil {
+		t.Errorf("expected no resources for identical manifests, got %+v", unchanged.Resources)
+	}
+}
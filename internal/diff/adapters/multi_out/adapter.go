@@ -0,0 +1,100 @@
+// Package multiout fans reporting out to several reporters, so results
+// posted to GitHub can also reach other sinks such as chat notifications.
+package multiout
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+	"github.com/nathantilsley/chart-val/internal/diff/ports"
+)
+
+// Sink is a reporter results are also sent to.
+type Sink struct {
+	Name     string // Named in logs, e.g. "webhook"
+	Reporter ports.ReportingPort
+}
+
+// Adapter implements ports.ReportingPort by making each call on a primary
+// reporter, which owns the check run, and on every sink concurrently. The
+// primary's errors are returned as they would be without sinks; a sink's
+// are logged, so a failing sink never blocks the primary or the other
+// sinks.
+type Adapter struct {
+	primary ports.ReportingPort
+	sinks   []Sink
+	logger  *slog.Logger
+}
+
+// New creates a new reporter fanning out from primary to sinks.
+func New(primary ports.ReportingPort, sinks []Sink, logger *slog.Logger) *Adapter {
+	return &Adapter{primary: primary, sinks: sinks, logger: logger}
+}
+
+// CreateInProgressCheck returns the primary's check run ID; the IDs of
+// the sinks are dropped.
+func (a *Adapter) CreateInProgressCheck(ctx context.Context, pr domain.PRContext) (int64, error) {
+	var checkRunID int64
+	err := a.fanOut("CreateInProgressCheck", func(r ports.ReportingPort, primary bool) error {
+		id, err := r.CreateInProgressCheck(ctx, pr)
+		if primary {
+			checkRunID = id
+		}
+		return err
+	})
+	return checkRunID, err
+}
+
+// UpdateCheckWithResults sends results to every reporter.
+func (a *Adapter) UpdateCheckWithResults(
+	ctx context.Context,
+	pr domain.PRContext,
+	checkRunID int64,
+	results []domain.DiffResult,
+) error {
+	return a.fanOut("UpdateCheckWithResults", func(r ports.ReportingPort, _ bool) error {
+		return r.UpdateCheckWithResults(ctx, pr, checkRunID, results)
+	})
+}
+
+// PostComment sends a chart's results to every reporter.
+func (a *Adapter) PostComment(ctx context.Context, pr domain.PRContext, results []domain.DiffResult) error {
+	return a.fanOut("PostComment", func(r ports.ReportingPort, _ bool) error {
+		return r.PostComment(ctx, pr, results)
+	})
+}
+
+// CollapseComment tells every reporter the chart no longer changes.
+func (a *Adapter) CollapseComment(ctx context.Context, pr domain.PRContext, chartName string) error {
+	return a.fanOut("CollapseComment", func(r ports.ReportingPort, _ bool) error {
+		return r.CollapseComment(ctx, pr, chartName)
+	})
+}
+
+// PostSummaryComment sends every chart's results to every reporter.
+func (a *Adapter) PostSummaryComment(ctx context.Context, pr domain.PRContext, results []domain.DiffResult) error {
+	return a.fanOut("PostSummaryComment", func(r ports.ReportingPort, _ bool) error {
+		return r.PostSummaryComment(ctx, pr, results)
+	})
+}
+
+// fanOut makes call on the primary and, concurrently, every sink. Once all
+// are done it logs the sinks' errors and returns the primary's.
+func (a *Adapter) fanOut(method string, call func(r ports.ReportingPort, primary bool) error) error {
+	errs := make([]error, len(a.sinks))
+	var wg sync.WaitGroup
+	for i, sink := range a.sinks {
+		wg.Go(func() { errs[i] = call(sink.Reporter, false) })
+	}
+	err := call(a.primary, true)
+	wg.Wait()
+
+	for i, sinkErr := range errs {
+		if sinkErr != nil {
+			a.logger.Warn("reporting to sink failed", "sink", a.sinks[i].Name, "method", method, "error", sinkErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package multiout
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+	"github.com/nathantilsley/chart-val/internal/platform/logger"
+)
+
+// mockReporter records the calls made on it, failing each with err.
+type mockReporter struct {
+	checkRunID int64
+	err        error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (m *mockReporter) record(method string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, method)
+	return m.err
+}
+
+func (m *mockReporter) CreateInProgressCheck(_ context.Context, _ domain.PRContext) (int64, error) {
+	return m.checkRunID, m.record("CreateInProgressCheck")
+}
+
+func (m *mockReporter) UpdateCheckWithResults(
+	_ context.Context,
+	_ domain.PRContext,
+	_ int64,
+	_ []domain.DiffResult,
+) error {
+	return m.record("UpdateCheckWithResults")
+}
+
+func (m *mockReporter) PostComment(_ context.Context, _ domain.PRContext, _ []domain.DiffResult) error {
+	return m.record("PostComment")
+}
+
+func (m *mockReporter) CollapseComment(_ context.Context, _ domain.PRContext, _ string) error {
+	return m.record("CollapseComment")
+}
+
+func (m *mockReporter) PostSummaryComment(_ context.Context, _ domain.PRContext, _ []domain.DiffResult) error {
+	return m.record("PostSummaryComment")
+}
+
+func TestAdapter_FansOut(t *testing.T) {
+	primary := &mockReporter{checkRunID: 7}
+	failing := &mockReporter{checkRunID: 99, err: errors.New("webhook down")}
+	working := &mockReporter{}
+	a := New(primary, []Sink{{Name: "failing", Reporter: failing}, {Name: "working", Reporter: working}},
+		logger.New("error"))
+
+	ctx := context.Background()
+	pr := domain.PRContext{Owner: "o", Repo: "r", PRNumber: 1}
+	id, err := a.CreateInProgressCheck(ctx, pr)
+	if err != nil || id != 7 {
+		t.Errorf("CreateInProgressCheck() = %d, %v; want the primary's ID 7 and no error", id, err)
+	}
+	// A failing sink is logged, never returned
+	for name, call := range map[string]func() error{
+		"UpdateCheckWithResults": func() error { return a.UpdateCheckWithResults(ctx, pr, id, nil) },
+		"PostComment":            func() error { return a.PostComment(ctx, pr, nil) },
+		"CollapseComment":        func() error { return a.CollapseComment(ctx, pr, "my-app") },
+		"PostSummaryComment":     func() error { return a.PostSummaryComment(ctx, pr, nil) },
+	} {
+		if err := call(); err != nil {
+			t.Errorf("%s() error = %v, want nil", name, err)
+		}
+	}
+
+	for name, r := range map[string]*mockReporter{"primary": primary, "failing": failing, "working": working} {
+		if len(r.calls) != 5 {
+			t.Errorf("%s got calls %v, want all 5 methods", name, r.calls)
+		}
+	}
+}
+
+func TestAdapter_PrimaryError(t *testing.T) {
+	primary := &mockReporter{err: errors.New("GitHub down")}
+	sink := &mockReporter{}
+	a := New(primary, []Sink{{Name: "webhook", Reporter: sink}}, logger.New("error"))
+
+	err := a.UpdateCheckWithResults(context.Background(), domain.PRContext{}, 1, nil)
+	if !errors.Is(err, primary.err) {
+		t.Errorf("UpdateCheckWithResults() error = %v, want the primary's", err)
+	}
+	if len(sink.calls) != 1 {
+		t.Errorf("sink got calls %v, want it called despite the primary failing", sink.calls)
+	}
+}
@@ -0,0 +1,69 @@
+// Package outputdir writes machine-readable reports to a directory, one
+// directory per pull request, for CI jobs and dashboards to collect.
+package outputdir
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// Store implements ports.OutputStorePort. Outputs are written to
+// dir/<owner>/<repo>/<PR number>/<name>, each push replacing the outputs
+// of the one before.
+type Store struct {
+	dir string
+}
+
+// New creates an output store writing under dir.
+func New(dir string) (*Store, error) {
+	//nolint:gosec // G301: output directory is shared by the service user only
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating output directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// StoreOutput writes content as the output name of pr. The file is replaced
+// atomically, so readers never see a partial output.
+func (s *Store) StoreOutput(_ context.Context, pr domain.PRContext, name string, content []byte) error {
+	for _, part := range []string{pr.Owner, pr.Repo, name} {
+		if !validName(part) {
+			return fmt.Errorf("invalid output path element %q", part)
+		}
+	}
+	dir := filepath.Join(s.dir, pr.Owner, pr.Repo, strconv.Itoa(pr.PRNumber))
+	//nolint:gosec // G301: output directory is shared by the service user only
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating output: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("writing output: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("writing output: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("replacing output: %w", err)
+	}
+	return nil
+}
+
+// validName reports whether name is a single path element, so outputs
+// can't be written outside the store's directory.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name
+}
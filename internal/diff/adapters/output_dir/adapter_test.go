@@ -0,0 +1,64 @@
+package outputdir
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+func TestStore_StoreOutput(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	pr := domain.PRContext{Owner: "acme", Repo: "charts", PRNumber: 42}
+	ctx := context.Background()
+
+	for _, content := range []string{"first push", "second push"} {
+		if err := s.StoreOutput(ctx, pr, "results.json", []byte(content)); err != nil {
+			t.Fatalf("StoreOutput() error = %v", err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "acme", "charts", "42", "results.json"))
+		if err != nil {
+			t.Fatalf("reading output: %v", err)
+		}
+		if string(got) != content {
+			t.Errorf("output = %q, want %q", got, content)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "acme", "charts", "42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("output directory has %d entries, want only the output", len(entries))
+	}
+}
+
+func TestStore_StoreOutput_RejectsPaths(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	tests := map[string]struct {
+		pr   domain.PRContext
+		name string
+	}{
+		"name with directory": {domain.PRContext{Owner: "acme", Repo: "charts"}, "../results.json"},
+		"parent repo":         {domain.PRContext{Owner: "acme", Repo: ".."}, "results.json"},
+		"empty owner":         {domain.PRContext{Repo: "charts"}, "results.json"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := s.StoreOutput(ctx, tt.pr, tt.name, []byte("x")); err == nil {
+				t.Error("StoreOutput() error = nil, want the path rejected")
+			}
+		})
+	}
+}
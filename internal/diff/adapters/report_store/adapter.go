@@ -0,0 +1,135 @@
+// Package reportstore keeps the full diffs of each result on disk and serves
+// them over HTTP, so reports cut to fit GitHub's size limits can link to them.
+package reportstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// idLen is the number of random bytes in a report ID; IDs are unguessable,
+// so only those given a report's link can read it.
+const idLen = 16
+
+// pruneInterval is how often expired reports are removed.
+const pruneInterval = time.Hour
+
+// Store implements ports.ReportStorePort and serves the reports it stores.
+// Reports are plain text files under dir, named by their ID, and are
+// removed once they are older than the retention period.
+type Store struct {
+	dir       string
+	baseURL   string
+	retention time.Duration
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// New creates a report store that keeps reports in dir for retention and
+// links to them under baseURL, the public URL of the server serving them.
+func New(dir, baseURL string, retention time.Duration) (*Store, error) {
+	//nolint:gosec // G301: report directory is shared by the service user only
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating report directory: %w", err)
+	}
+	return &Store{
+		dir:       dir,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		retention: retention,
+	}, nil
+}
+
+// StoreReport writes the semantic and line diffs of result to a new report
+// and returns its URL.
+func (s *Store) StoreReport(_ context.Context, pr domain.PRContext, result domain.DiffResult) (string, error) {
+	s.pruneIfDue()
+
+	raw := make([]byte, idLen)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generating report ID: %w", err)
+	}
+	id := hex.EncodeToString(raw)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Repository:  %s/%s\n", pr.Owner, pr.Repo)
+	fmt.Fprintf(&sb, "PR:          #%d (%s)\n", pr.PRNumber, pr.HeadSHA)
+	fmt.Fprintf(&sb, "Chart:       %s\n", result.ChartName)
+	fmt.Fprintf(&sb, "Environment: %s\n", result.Environment)
+	for _, d := range []struct{ title, diff string }{
+		{"Semantic diff", result.SemanticDiff},
+		{"Line diff", result.UnifiedDiff},
+	} {
+		if d.diff == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n=== %s ===\n\n%s\n", d.title, strings.TrimRight(d.diff, "\n"))
+	}
+
+	if err := os.WriteFile(filepath.Join(s.dir, id), []byte(sb.String()), 0o600); err != nil {
+		return "", fmt.Errorf("writing report: %w", err)
+	}
+	return s.baseURL + "/reports/" + id, nil
+}
+
+// ServeHTTP serves GET /reports/{id}.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !validID(id) {
+		http.NotFound(w, r)
+		return
+	}
+	path := filepath.Join(s.dir, id)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > s.retention {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeFile(w, r, path)
+}
+
+// validID reports whether id could have been generated by StoreReport, so
+// requests can't reach other files.
+func validID(id string) bool {
+	if len(id) != 2*idLen {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil && strings.ToLower(id) == id
+}
+
+// pruneIfDue removes expired reports, at most once per pruneInterval.
+func (s *Store) pruneIfDue() {
+	s.mu.Lock()
+	if time.Since(s.lastPrune) < pruneInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPrune = time.Now()
+	s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !validID(e.Name()) || time.Since(info.ModTime()) <= s.retention {
+			continue
+		}
+		_ = os.Remove(filepath.Join(s.dir, e.Name()))
+	}
+}
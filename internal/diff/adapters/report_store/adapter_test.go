@@ -0,0 +1,124 @@
+package reportstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+func newTestServer(t *testing.T, s *Store) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("GET /reports/{id}", s)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestStore_StoreAndServe(t *testing.T) {
+	s, err := New(t.TempDir(), "https://chart-val.example.com/", time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	server := newTestServer(t, s)
+
+	pr := domain.PRContext{Owner: "o", Repo: "r", PRNumber: 7, HeadSHA: "abc123"}
+	result := domain.DiffResult{
+		ChartName:    "my-app",
+		Environment:  "prod",
+		SemanticDiff: "~ replicas: 1 -> 3",
+		UnifiedDiff:  "-replicas: 1\n+replicas: 3\n",
+	}
+	url, err := s.StoreReport(context.Background(), pr, result)
+	if err != nil {
+		t.Fatalf("StoreReport() error = %v", err)
+	}
+	id, ok := strings.CutPrefix(url, "https://chart-val.example.com/reports/")
+	if !ok {
+		t.Fatalf("StoreReport() = %q, want a URL under the base URL", url)
+	}
+
+	resp, body := get(t, server.URL+"/reports/"+id)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/plain", got)
+	}
+	for _, want := range []string{
+		"PR:          #7 (abc123)",
+		"Chart:       my-app",
+		"=== Semantic diff ===\n\n~ replicas: 1 -> 3\n",
+		"=== Line diff ===\n\n-replicas: 1\n+replicas: 3\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("report is missing %q:\n%s", want, body)
+		}
+	}
+
+	other, err := s.StoreReport(context.Background(), pr, result)
+	if err != nil {
+		t.Fatalf("StoreReport() error = %v", err)
+	}
+	if other == url {
+		t.Errorf("StoreReport() returned %q twice, want a new report each time", url)
+	}
+}
+
+func TestStore_NotFound(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, "https://chart-val.example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	server := newTestServer(t, s)
+
+	expired := strings.Repeat("ab", idLen)
+	path := filepath.Join(dir, expired)
+	if err := os.WriteFile(path, []byte("old report"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{
+		expired,
+		strings.Repeat("cd", idLen), // never stored
+		"..%2F..%2Fetc%2Fpasswd",
+		strings.Repeat("AB", idLen), // IDs are lower case
+	} {
+		if resp, _ := get(t, server.URL+"/reports/"+id); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET /reports/%s status = %d, want 404", id, resp.StatusCode)
+		}
+	}
+
+	// Storing a report prunes expired ones
+	if _, err := s.StoreReport(context.Background(), domain.PRContext{}, domain.DiffResult{}); err != nil {
+		t.Fatalf("StoreReport() error = %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expired report still exists: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// Package sarifout serialises the validation findings of diff results as
+// SARIF 2.1.0, so they show up in GitHub code scanning and other SARIF
+// viewers.
+package sarifout
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// SARIF result levels.
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+)
+
+// rule is a kind of finding.
+type rule struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description message     `json:"shortDescription"`
+	Default     ruleDefault `json:"defaultConfiguration"`
+}
+
+type ruleDefault struct {
+	Level string `json:"level"`
+}
+
+// rules are every kind of finding, in the order of the driver's rule list.
+var rules = []rule{
+	{"render-error", "RenderError", message{"The chart fails to render"}, ruleDefault{levelError}},
+	{"lint", "Lint", message{"The chart or an environment's values fail helm lint or values.schema.json"},
+		ruleDefault{levelError}},
+	{"schema-violation", "SchemaViolation", message{"A rendered manifest doesn't match its Kubernetes schema"},
+		ruleDefault{levelError}},
+	{"policy-violation", "PolicyViolation", message{"A rendered resource fails a policy"}, ruleDefault{levelError}},
+	{"deprecated-api", "DeprecatedAPI", message{"A rendered resource uses a deprecated or removed API version"},
+		ruleDefault{levelWarning}},
+	{"chart-version", "ChartVersion", message{"The chart's version isn't bumped enough for its changes"},
+		ruleDefault{levelError}},
+	{"stale-override", "StaleOverride", message{"An environment sets a values key the chart no longer has"},
+		ruleDefault{levelWarning}},
+	{"unused-value", "UnusedValue", message{"An environment sets a values key the chart doesn't use"},
+		ruleDefault{levelWarning}},
+}
+
+// Adapter implements ports.ReportFormatterPort, formatting findings as SARIF.
+type Adapter struct {
+	toolName string
+	toolURL  string
+}
+
+// New creates a new SARIF formatter naming the tool toolName, with
+// toolURL, if set, as where to find out about it.
+func New(toolName, toolURL string) *Adapter {
+	return &Adapter{toolName: toolName, toolURL: toolURL}
+}
+
+// Name returns "sarif".
+func (a *Adapter) Name() string { return "sarif" }
+
+// FileExtension returns ".sarif".
+func (a *Adapter) FileExtension() string { return ".sarif" }
+
+// FormatResults returns the findings of results as a SARIF log with one run.
+// Findings are located at the file and line at fault in the PR's head
+// commit when they have one, and at the chart's Chart.yaml otherwise. A
+// finding shared by several environments of a chart is reported once,
+// listing the environments.
+func (a *Adapter) FormatResults(_ domain.PRContext, results []domain.DiffResult) ([]byte, error) {
+	findings := make([]*finding, 0) // SARIF requires results, even if there are none
+	index := make(map[string]*finding)
+	add := func(r domain.DiffResult, file string, line int, ruleID, level, text string) {
+		f := &finding{RuleID: ruleID, Level: level, Message: message{text}}
+		if p, ok := r.RepoPath(file); ok {
+			f.Locations = []location{newLocation(p, line)}
+		} else if p, ok := r.RepoPath("Chart.yaml"); ok {
+			f.Locations = []location{newLocation(p, 1)}
+		}
+		key := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%d", r.ChartName, ruleID, level, text, file, line)
+		if existing, ok := index[key]; ok {
+			existing.Properties.Environments = append(existing.Properties.Environments, r.Environment)
+			return
+		}
+		f.Properties = properties{Chart: r.ChartName, Environments: []string{r.Environment}}
+		index[key] = f
+		findings = append(findings, f)
+	}
+
+	for _, r := range results {
+		if r.Status == domain.StatusError {
+			if re := r.RenderError; re != nil {
+				// Helm names templates after the chart, e.g. "my-app/templates/deployment.yaml"
+				_, template, _ := strings.Cut(re.Template, "/")
+				add(r, template, re.Line, "render-error", levelError, re.Message)
+			} else {
+				add(r, "", 0, "render-error", levelError, r.Summary)
+			}
+		}
+		for _, f := range r.LintFindings {
+			text := f.Message
+			if f.Key != "" {
+				text = f.Key + ": " + f.Message
+			}
+			add(r, f.File, f.Line, "lint", severityLevel(f.Severity), text)
+		}
+		for _, v := range r.Violations {
+			add(r, "", 0, "schema-violation", levelError, fmt.Sprintf("%s: %s", v.Resource, v.Message))
+		}
+		for _, v := range r.PolicyViolations {
+			text := fmt.Sprintf("%s on %s: %s", v.Policy, v.Resource, v.Message)
+			add(r, "", 0, "policy-violation", severityLevel(v.Severity), text)
+		}
+		for _, d := range r.Deprecations {
+			text := fmt.Sprintf("%s: %s", d.Resource, d.Message())
+			add(r, "", 0, "deprecated-api", severityLevel(d.Severity), text)
+		}
+		if v := r.ChartVersion; v != nil && v.Failed() {
+			add(r, "Chart.yaml", 1, "chart-version", levelError, v.Problem)
+		}
+		for _, o := range r.StaleOverrides {
+			add(r, o.File, o.Line, "stale-override", severityLevel(o.Severity), o.Message())
+		}
+		for _, u := range r.UnusedValues {
+			add(r, u.File, u.Line, "unused-value", levelWarning, u.Message())
+		}
+	}
+
+	doc := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []run{{
+			Tool:    tool{Driver: driver{Name: a.toolName, InformationURI: a.toolURL, Rules: rules}},
+			Results: findings,
+		}},
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling SARIF log: %w", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// severityLevel returns the SARIF level of a finding of severity.
+func severityLevel(severity domain.Severity) string {
+	if severity == domain.SeverityError {
+		return levelError
+	}
+	return levelWarning
+}
+
+func newLocation(path string, line int) location {
+	return location{PhysicalLocation: physicalLocation{
+		ArtifactLocation: artifactLocation{URI: path, URIBaseID: "%SRCROOT%"},
+		Region:           region{StartLine: max(line, 1)},
+	}}
+}
+
+// sarifLog is the SARIF document.
+type sarifLog struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []run  `json:"runs"`
+}
+
+type run struct {
+	Tool    tool       `json:"tool"`
+	Results []*finding `json:"results"`
+}
+
+type tool struct {
+	Driver driver `json:"driver"`
+}
+
+type driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []rule `json:"rules"`
+}
+
+// finding is a SARIF result.
+type finding struct {
+	RuleID     string     `json:"ruleId"`
+	Level      string     `json:"level"`
+	Message    message    `json:"message"`
+	Locations  []location `json:"locations,omitempty"`
+	Properties properties `json:"properties"`
+}
+
+type message struct {
+	Text string `json:"text"`
+}
+
+type location struct {
+	PhysicalLocation physicalLocation `json:"physicalLocation"`
+}
+
+type physicalLocation struct {
+	ArtifactLocation artifactLocation `json:"artifactLocation"`
+	Region           region           `json:"region"`
+}
+
+type artifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type region struct {
+	StartLine int `json:"startLine"`
+}
+
+// properties say which chart and environments a finding is in.
+type properties struct {
+	Chart        string   `json:"chart"`
+	Environments []string `json:"environments"`
+}
@@ -0,0 +1,125 @@
+package sarifout
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/reporttest"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+func TestAdapter_FormatResults(t *testing.T) {
+	a := New("chart-val", "https://github.com/nathantilsley/chart-val")
+	out, err := a.FormatResults(reporttest.PR, reporttest.Results())
+	if err != nil {
+		t.Fatalf("FormatResults() error = %v", err)
+	}
+	if !json.Valid(out) {
+		t.Fatalf("FormatResults() = %s, want valid JSON", out)
+	}
+	reporttest.CompareOrUpdateGolden(t, filepath.Join("testdata", "golden", "results.sarif"), string(out))
+}
+
+func formatFindings(t *testing.T, results []domain.DiffResult) []finding {
+	t.Helper()
+	out, err := New("chart-val", "").FormatResults(reporttest.PR, results)
+	if err != nil {
+		t.Fatalf("FormatResults() error = %v", err)
+	}
+	var doc struct {
+		Runs []struct {
+			Results []finding `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unmarshaling output: %v", err)
+	}
+	if len(doc.Runs) != 1 || doc.Runs[0].Results == nil {
+		t.Fatalf("FormatResults() = %s, want one run with a results array", out)
+	}
+	return doc.Runs[0].Results
+}
+
+func TestAdapter_FormatResults_SharedFindings(t *testing.T) {
+	icon := []domain.LintFinding{
+		{File: "Chart.yaml", Message: "icon is recommended", Severity: domain.SeverityWarning},
+	}
+	results := []domain.DiffResult{
+		{ChartName: "my-app", ChartPath: "charts/my-app", Environment: "dev", LintFindings: icon},
+		{ChartName: "my-app", ChartPath: "charts/my-app", Environment: "prod", LintFindings: icon},
+		{ChartName: "other", ChartPath: "charts/other", Environment: "prod", LintFindings: icon},
+	}
+
+	findings := formatFindings(t, results)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want one per chart", len(findings))
+	}
+	if got := findings[0].Properties.Environments; !slices.Equal(got, []string{"dev", "prod"}) {
+		t.Errorf("environments = %v, want [dev prod]", got)
+	}
+	if got := findings[1].Locations[0].PhysicalLocation.ArtifactLocation.URI; got != "charts/other/Chart.yaml" {
+		t.Errorf("location = %q, want charts/other/Chart.yaml", got)
+	}
+}
+
+func TestAdapter_FormatResults_Locations(t *testing.T) {
+	violation := []domain.SchemaViolation{{Resource: "v1/ConfigMap/cfg", Message: ".data: expected object"}}
+	tests := []struct {
+		name     string
+		result   domain.DiffResult
+		wantURI  string
+		wantLine int
+	}{
+		{
+			name: "file and line",
+			result: domain.DiffResult{ChartPath: "charts/my-app", UnusedValues: []domain.UnusedValue{
+				{File: "env/prod-values.yaml", Line: 4, Path: []string{"debug"}},
+			}},
+			wantURI:  "charts/my-app/env/prod-values.yaml",
+			wantLine: 4,
+		},
+		{
+			name:     "no file falls back to Chart.yaml",
+			result:   domain.DiffResult{ChartPath: "charts/my-app", Violations: violation},
+			wantURI:  "charts/my-app/Chart.yaml",
+			wantLine: 1,
+		},
+		{
+			name: "file outside the repo falls back to Chart.yaml",
+			result: domain.DiffResult{ChartPath: "charts/my-app", UnusedValues: []domain.UnusedValue{
+				{File: "inline values", Path: []string{"debug"}},
+			}},
+			wantURI:  "charts/my-app/Chart.yaml",
+			wantLine: 1,
+		},
+		{
+			name:   "unknown chart path",
+			result: domain.DiffResult{Violations: violation},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.result.ChartName, tt.result.Environment = "my-app", "prod"
+			findings := formatFindings(t, []domain.DiffResult{tt.result})
+			if len(findings) != 1 {
+				t.Fatalf("got %d findings, want 1", len(findings))
+			}
+			if tt.wantURI == "" {
+				if len(findings[0].Locations) != 0 {
+					t.Errorf("locations = %+v, want none", findings[0].Locations)
+				}
+				return
+			}
+			if len(findings[0].Locations) != 1 {
+				t.Fatalf("locations = %+v, want 1", findings[0].Locations)
+			}
+			loc := findings[0].Locations[0].PhysicalLocation
+			if loc.ArtifactLocation.URI != tt.wantURI || loc.Region.StartLine != tt.wantLine {
+				t.Errorf("location = %s:%d, want %s:%d",
+					loc.ArtifactLocation.URI, loc.Region.StartLine, tt.wantURI, tt.wantLine)
+			}
+		})
+	}
+}
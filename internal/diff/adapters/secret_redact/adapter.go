@@ -0,0 +1,243 @@
+// Package secretredact masks secret values in rendered manifests before they
+// are diffed and posted to pull requests.
+package secretredact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+)
+
+// defaultKeyPatterns match keys whose values are credentials, anywhere in a
+// resource (e.g. ConfigMap data, Helm-rendered config maps, env var names).
+// They are anchored at the end so that references such as secretName or
+// secretKeyRef are left alone.
+var defaultKeyPatterns = []string{
+	`(?i)(password|passwd|pwd|passphrase)$`,
+	`(?i)(secret|token|credentials?)$`,
+	`(?i)(api|access|private|secret)[-_]?key$`,
+}
+
+// valuePatterns match credentials by their shape, whatever their key.
+var valuePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),                    // AWS access key ID
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),          // GitHub token
+	regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^/\s:@]+:[^/\s@]+@`), // Credentials in a URL
+}
+
+const (
+	placeholderPrefix = "<redacted:"
+	hashLen           = 12 // Hex characters of the HMAC kept in placeholders
+)
+
+// Adapter implements ports.RedactorPort. Values are replaced by a keyed hash
+// ("<redacted:1f2e3d4c5b6a>"): equal values get equal placeholders as long as
+// the key stays the same, so a reviewer can still see that a value changed,
+// while the secret key stops anyone from confirming a guessed value.
+type Adapter struct {
+	key         []byte
+	keyPatterns []*regexp.Regexp
+}
+
+// New creates a redactor that masks Secret data, values of keys matching the
+// default or extra key patterns (regexes), and values that look like
+// credentials. Placeholders are keyed with hmacKey; if it is empty, a random
+// key is generated, so placeholders differ across restarts and between
+// replicas, and a value redacted in an earlier report won't match a later one.
+// Returns an error if an extra pattern fails to compile.
+func New(hmacKey string, extraKeyPatterns []string) (*Adapter, error) {
+	key := []byte(hmacKey)
+	if hmacKey == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("generating redaction key: %w", err)
+		}
+	}
+
+	a := &Adapter{key: key}
+	for _, p := range slices.Concat(defaultKeyPatterns, extraKeyPatterns) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sensitive key pattern %q: %w", p, err)
+		}
+		a.keyPatterns = append(a.keyPatterns, re)
+	}
+	return a, nil
+}
+
+// Redact returns manifest with sensitive values replaced. Documents without
+// sensitive values keep their original text. A document that fails to parse
+// is masked whole, keeping only its "# Source:" comment, since its secret
+// values can't be told apart from the rest.
+func (a *Adapter) Redact(manifest []byte) []byte {
+	var sb strings.Builder
+	failed := 0
+	docs, separators := yamldoc.SplitSeparators(manifest)
+	for i, text := range docs {
+		if i > 0 {
+			sb.WriteString(separators[i-1])
+		}
+		doc, err := a.redactDocument(text)
+		if err != nil {
+			failed++
+			doc = a.maskDocument(text)
+		}
+		sb.WriteString(doc)
+	}
+
+	if failed > 0 {
+		slog.Warn("failed to parse manifest documents for redaction, masking them whole", "documents", failed)
+	}
+	return []byte(sb.String())
+}
+
+// redactDocument redacts a single YAML document, returning text unchanged
+// when nothing in it is sensitive.
+func (a *Adapter) redactDocument(text string) (string, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &node); err != nil {
+		return "", err
+	}
+	if len(node.Content) == 0 {
+		return text, nil
+	}
+
+	root := node.Content[0]
+	changed := a.redactNode(root)
+	if yamldoc.ScalarAt(root, "kind") == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			if data := yamldoc.ValueOf(root, field); data != nil && a.redactAll(data) {
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return text, nil
+	}
+
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return "", err
+	}
+	_ = enc.Close()
+	return sb.String(), nil
+}
+
+// redactNode walks node, masking scalar values of sensitive keys, env-style
+// {name: SOME_TOKEN, value: ...} pairs, and values that look like
+// credentials. Collections under sensitive keys are walked rather than
+// masked, so references like "secret: {secretName: ...}" stay readable.
+// Reports whether anything was masked.
+func (a *Adapter) redactNode(node *yaml.Node) bool {
+	changed := false
+	switch node.Kind {
+	case yaml.MappingNode:
+		sensitiveName := a.sensitiveKey(yamldoc.ScalarAt(node, "name"))
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			sensitive := a.sensitiveKey(key) || (sensitiveName && key == "value")
+			if sensitive && value.Kind == yaml.ScalarNode {
+				changed = a.mask(value) || changed
+				continue
+			}
+			changed = a.redactNode(value) || changed
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			changed = a.redactNode(item) || changed
+		}
+	case yaml.ScalarNode:
+		if looksLikeCredential(node.Value) {
+			changed = a.mask(node)
+		}
+	}
+	return changed
+}
+
+// redactAll masks every string scalar within node.
+func (a *Adapter) redactAll(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.MappingNode:
+		changed := false
+		for i := 1; i < len(node.Content); i += 2 {
+			changed = a.redactAll(node.Content[i]) || changed
+		}
+		return changed
+	case yaml.SequenceNode:
+		changed := false
+		for _, item := range node.Content {
+			changed = a.redactAll(item) || changed
+		}
+		return changed
+	case yaml.ScalarNode:
+		return a.mask(node)
+	default:
+		return false
+	}
+}
+
+// mask replaces a non-empty string scalar with its placeholder. Values that
+// are already placeholders are left alone, so redaction is idempotent.
+func (a *Adapter) mask(node *yaml.Node) bool {
+	if !isString(node) || node.Value == "" || strings.HasPrefix(node.Value, placeholderPrefix) {
+		return false
+	}
+	node.Value = a.placeholder(node.Value)
+	node.Style = 0
+	node.Tag = "!!str"
+	return true
+}
+
+func (a *Adapter) placeholder(value string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(value))
+	return placeholderPrefix + hex.EncodeToString(mac.Sum(nil))[:hashLen] + ">"
+}
+
+func (a *Adapter) sensitiveKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, re := range a.keyPatterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskDocument replaces a document that can't be parsed with a placeholder
+// for its whole text, after its "# Source:" comment if it has one.
+func (a *Adapter) maskDocument(text string) string {
+	masked := a.placeholder(text) + "\n"
+	if source := yamldoc.Source(text); source != "" {
+		masked = "# Source: " + source + "\n" + masked
+	}
+	return masked
+}
+
+func looksLikeCredential(value string) bool {
+	for _, re := range valuePatterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isString(node *yaml.Node) bool {
+	return node.ShortTag() == "!!str" || node.ShortTag() == "!!binary"
+}
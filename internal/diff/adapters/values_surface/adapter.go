@@ -0,0 +1,157 @@
+// Package valuessurface compares the values a chart accepts, the defaults in
+// values.yaml and the properties in values.schema.json, between two checkouts,
+// and checks environment values against them: overrides of the keys a change
+// breaks, and keys the chart doesn't use at all.
+package valuessurface
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/strvals"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// schemaFile is the JSON Schema Helm validates a chart's values against.
+const schemaFile = "values.schema.json"
+
+// Adapter implements ports.ValuesSurfacePort. A key missing from head is
+// renamed if exactly one new key has its type and default (or schema) and
+// either its parent or its name; otherwise it's removed.
+type Adapter struct{}
+
+// New creates a new values surface comparer.
+func New() *Adapter {
+	return &Adapter{}
+}
+
+// CompareValues compares the values surfaces of the charts in baseDir and
+// headDir.
+func (a *Adapter) CompareValues(baseDir, headDir string) ([]domain.ValuesChange, error) {
+	base, err := readSurface(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("base: %w", err)
+	}
+	head, err := readSurface(headDir)
+	if err != nil {
+		return nil, fmt.Errorf("head: %w", err)
+	}
+	return compareSurfaces(base, head), nil
+}
+
+// FindStaleOverrides parses the environment's value files, inline values and
+// parameters, in the order they apply, and returns the removed and renamed
+// keys each still sets. Sources that don't parse are skipped; rendering or
+// linting reports them.
+func (a *Adapter) FindStaleOverrides(
+	chartDir string,
+	valueFiles []string,
+	opts domain.RenderOptions,
+	changes []domain.ValuesChange,
+) []domain.StaleOverride {
+	if len(changes) == 0 {
+		return nil
+	}
+	var stale []domain.StaleOverride
+	for _, vf := range valueFiles {
+		//nolint:gosec // G304: vf is a value file of our own temp checkout
+		data, err := os.ReadFile(filepath.Join(chartDir, vf))
+		if err != nil {
+			continue
+		}
+		stale = append(stale, findStaleOverrides(changes, vf, data)...)
+	}
+	if opts.Values != "" {
+		stale = append(stale, findStaleOverrides(changes, "inline values", []byte(opts.Values))...)
+	}
+	for _, p := range opts.Parameters {
+		keys := map[string]any{}
+		if err := strvals.ParseInto(p.Name+"=x", keys); err == nil {
+			stale = append(stale, domain.FindStaleOverrides(changes, "parameter "+p.Name, keys)...)
+		}
+	}
+	return stale
+}
+
+// FindUnusedValues parses the environment's value files and inline values and
+// returns the keys each sets that the chart neither declares in values.yaml or
+// values.schema.json nor references in its templates. Keys of free-form maps
+// and those passed on to subcharts are never unused. Returns nothing if the
+// chart can't be read; sources that don't parse are skipped.
+func (a *Adapter) FindUnusedValues(
+	chartDir string,
+	valueFiles []string,
+	opts domain.RenderOptions,
+) []domain.UnusedValue {
+	u, err := readUsage(chartDir)
+	if err != nil {
+		return nil
+	}
+	var unused []domain.UnusedValue
+	for _, vf := range valueFiles {
+		//nolint:gosec // G304: vf is a value file of our own temp checkout
+		data, err := os.ReadFile(filepath.Join(chartDir, vf))
+		if err != nil {
+			continue
+		}
+		unused = append(unused, u.findUnused(vf, data)...)
+	}
+	if opts.Values != "" {
+		unused = append(unused, u.findUnused("inline values", []byte(opts.Values))...)
+	}
+	return unused
+}
+
+// findStaleOverrides returns the removed and renamed keys among changes that
+// the YAML values data, from the source named file, still sets.
+func findStaleOverrides(changes []domain.ValuesChange, file string, data []byte) []domain.StaleOverride {
+	vals, err := chartutil.ReadValues(data)
+	if err != nil {
+		return nil
+	}
+	stale := domain.FindStaleOverrides(changes, file, vals)
+	for i := range stale {
+		stale[i].Line = keyLine(data, stale[i].Change.Path)
+	}
+	return stale
+}
+
+// readSurface reads the values surface of the chart in chartDir. A chart
+// without values.yaml or values.schema.json has no keys from it.
+func readSurface(chartDir string) (surface, error) {
+	s := surface{}
+	//nolint:gosec // G304: chartDir is our own temp checkout
+	data, err := os.ReadFile(filepath.Join(chartDir, chartutil.ValuesfileName))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading %s: %w", chartutil.ValuesfileName, err)
+	default:
+		values, err := chartutil.ReadValues(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", chartutil.ValuesfileName, err)
+		}
+		s.addDefaults(values, nil)
+	}
+
+	//nolint:gosec // G304: chartDir is our own temp checkout
+	data, err = os.ReadFile(filepath.Join(chartDir, schemaFile))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading %s: %w", schemaFile, err)
+	default:
+		var schema map[string]any
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", schemaFile, err)
+		}
+		s.addSchema(schema, nil)
+	}
+	return s, nil
+}
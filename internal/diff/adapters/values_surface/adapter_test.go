@@ -0,0 +1,273 @@
+package valuessurface
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// writeChart writes files into a temporary chart directory.
+func writeChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const baseValues = `
+replicas: 1
+image:
+  repository: nginx
+  tag: "1.27"
+  pullSecret: ""
+metrics:
+  enabled: false
+  port: 9090
+podAnnotations: {}
+`
+
+func TestAdapter_CompareValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		base, head map[string]string
+		want       []domain.ValuesChange
+	}{
+		{
+			name: "unchanged",
+			base: map[string]string{"values.yaml": baseValues},
+			head: map[string]string{"values.yaml": baseValues},
+		},
+		{
+			name: "new keys are not breaking",
+			base: map[string]string{"values.yaml": baseValues},
+			head: map[string]string{"values.yaml": baseValues + "tolerations: []\n"},
+		},
+		{
+			name: "removed map reports only its own key",
+			base: map[string]string{"values.yaml": baseValues},
+			head: map[string]string{"values.yaml": `
+replicas: 1
+image:
+  repository: nginx
+  tag: "1.27"
+  pullSecret: ""
+podAnnotations: {}
+`},
+			want: []domain.ValuesChange{{Path: []string{"metrics"}, Type: domain.ValuesKeyRemoved}},
+		},
+		{
+			name: "renamed under the same parent",
+			base: map[string]string{"values.yaml": baseValues},
+			head: map[string]string{"values.yaml": `
+replicas: 1
+image:
+  repository: nginx
+  version: "1.27"
+  pullSecret: ""
+metrics:
+  enabled: false
+  port: 9090
+podAnnotations: {}
+`},
+			want: []domain.ValuesChange{{
+				Path: []string{"image", "tag"}, Type: domain.ValuesKeyRenamed, NewPath: []string{"image", "version"},
+			}},
+		},
+		{
+			name: "moved to another parent, and an empty default that can't be matched",
+			base: map[string]string{"values.yaml": baseValues},
+			head: map[string]string{"values.yaml": `
+replicas: 1
+image:
+  repository: nginx
+  tag: "1.27"
+  credentials:
+    pullSecret: ""
+monitoring:
+  metrics:
+    enabled: false
+    port: 9090
+podAnnotations: {}
+`},
+			want: []domain.ValuesChange{
+				{Path: []string{"image", "pullSecret"}, Type: domain.ValuesKeyRemoved},
+				{Path: []string{"metrics"}, Type: domain.ValuesKeyRenamed, NewPath: []string{"monitoring", "metrics"}},
+			},
+		},
+		{
+			name: "type changed",
+			base: map[string]string{"values.yaml": baseValues},
+			head: map[string]string{"values.yaml": `
+replicas: "1"
+image:
+  repository: nginx
+  tag: "1.27"
+  pullSecret: ""
+metrics: true
+podAnnotations: {}
+`},
+			want: []domain.ValuesChange{
+				{Path: []string{"metrics"}, Type: domain.ValuesKeyTypeChanged, OldType: "object", NewType: "boolean"},
+				{Path: []string{"replicas"}, Type: domain.ValuesKeyTypeChanged, OldType: "integer", NewType: "string"},
+			},
+		},
+		{
+			name: "schema properties without defaults",
+			base: map[string]string{"values.schema.json": `{"properties": {
+				"nodeSelector": {"type": "object"},
+				"ingress": {"type": "object", "properties": {"host": {"type": "string"}, "port": {"type": "integer"}}}
+			}}`},
+			head: map[string]string{"values.schema.json": `{"properties": {
+				"ingress": {"type": "object", "properties": {"host": {"type": "string"}, "port": {"type": "number"}}},
+				"nodeSelector": {"type": ["object", "null"]}
+			}}`},
+			want: []domain.ValuesChange{{
+				Path:    []string{"nodeSelector"},
+				Type:    domain.ValuesKeyTypeChanged,
+				OldType: "object",
+				NewType: "null or object",
+			}},
+		},
+		{
+			name: "schema-declared keys count even without a default",
+			base: map[string]string{
+				"values.yaml": "replicas: 1\n",
+				"values.schema.json": `{"properties": {
+					"replicas": {"type": "integer"},
+					"extraEnv": {"type": "array"}
+				}}`,
+			},
+			head: map[string]string{
+				"values.yaml":        "replicas: 1\n",
+				"values.schema.json": `{"properties": {"replicas": {"type": "integer"}}}`,
+			},
+			want: []domain.ValuesChange{{Path: []string{"extraEnv"}, Type: domain.ValuesKeyRemoved}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New().CompareValues(writeChart(t, tt.base), writeChart(t, tt.head))
+			if err != nil {
+				t.Fatalf("CompareValues() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareValues() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdapter_CompareValues_InvalidSchema(t *testing.T) {
+	base := writeChart(t, map[string]string{"values.yaml": baseValues})
+	head := writeChart(t, map[string]string{"values.schema.json": "{"})
+	if _, err := New().CompareValues(base, head); err == nil {
+		t.Error("CompareValues() error = nil, want an error for an invalid schema")
+	}
+}
+
+func TestAdapter_FindStaleOverrides(t *testing.T) {
+	removed := domain.ValuesChange{Path: []string{"metrics"}, Type: domain.ValuesKeyRemoved}
+	renamed := domain.ValuesChange{
+		Path: []string{"image", "tag"}, Type: domain.ValuesKeyRenamed, NewPath: []string{"image", "version"},
+	}
+	chartDir := writeChart(t, map[string]string{
+		"env/prod-values.yaml": "image:\n  tag: \"1.28\"\nreplicas: 3\n",
+		"env/bad-values.yaml":  "image: [\n",
+	})
+	opts := domain.RenderOptions{
+		Values:     "metrics:\n  enabled: true\n",
+		Parameters: []domain.HelmParameter{{Name: "image.tag", Value: "1.29"}, {Name: "replicas", Value: "2"}},
+	}
+
+	got := New().FindStaleOverrides(chartDir,
+		[]string{"env/prod-values.yaml", "env/bad-values.yaml", "env/missing.yaml"}, opts,
+		[]domain.ValuesChange{removed, renamed})
+	want := []domain.StaleOverride{
+		{File: "env/prod-values.yaml", Line: 2, Change: renamed},
+		{File: "inline values", Line: 1, Change: removed},
+		{File: "parameter image.tag", Change: renamed},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindStaleOverrides() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestAdapter_FindUnusedValues(t *testing.T) {
+	chartDir := writeChart(t, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: app
+version: 1.0.0
+dependencies:
+  - name: redis
+    alias: cache
+    version: 18.0.0
+`,
+		"values.yaml": `
+image:
+  repository: nginx
+  tag: "1.27"
+podAnnotations: {}
+`,
+		"values.schema.json": `{"properties": {
+			"extraLabels": {"type": "object", "additionalProperties": {"type": "string"}}
+		}}`,
+		"templates/deployment.yaml": `
+image: {{ .Values.image.repository }}:{{ .Values.image.tag }}
+{{- if .Values.featureFlags }}
+path: {{ $.Values.probes.liveness.path }}
+{{- end }}
+`,
+		"charts/common/templates/_labels.tpl": `{{ .Values.commonLabels | toYaml }}`,
+		"env/prod-values.yaml": `
+image:
+  tag: "1.28"
+  digest: sha256:abc
+podAnnotations:
+  team: web
+extraLabels:
+  tier: frontend
+featureFlags:
+  newUI: true
+probes:
+  liveness:
+    path: /healthz
+  readiness:
+    path: /ready
+commonLabels:
+  owner: web
+replica: 3
+global:
+  region: eu
+cache:
+  enabled: true
+`,
+		"env/bad-values.yaml": "image: [\n",
+	})
+	opts := domain.RenderOptions{
+		Values:     "replicaCount: 2\n",
+		Parameters: []domain.HelmParameter{{Name: "unknown", Value: "x"}},
+	}
+
+	got := New().FindUnusedValues(chartDir, []string{"env/prod-values.yaml", "env/bad-values.yaml"}, opts)
+	want := []domain.UnusedValue{
+		{File: "env/prod-values.yaml", Line: 4, Path: []string{"image", "digest"}},
+		{File: "env/prod-values.yaml", Line: 14, Path: []string{"probes", "readiness"}},
+		{File: "env/prod-values.yaml", Line: 18, Path: []string{"replica"}},
+		{File: "inline values", Line: 1, Path: []string{"replicaCount"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindUnusedValues() =\n%+v\nwant\n%+v", got, want)
+	}
+}
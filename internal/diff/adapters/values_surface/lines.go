@@ -0,0 +1,38 @@
+package valuessurface
+
+import "gopkg.in/yaml.v3"
+
+// keyLine returns the 1-based line of the key at path in the YAML document
+// data, or 0 if it can't be found.
+func keyLine(data []byte, path []string) int {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return 0
+	}
+	node, line := doc.Content[0], 0
+	for _, name := range path {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		if node.Kind != yaml.MappingNode {
+			return 0
+		}
+		key, value := mappingEntry(node, name)
+		if key == nil {
+			return 0
+		}
+		line, node = key.Line, value
+	}
+	return line
+}
+
+// mappingEntry returns the key and value nodes of name in a YAML mapping,
+// or nils if it has no such key.
+func mappingEntry(mapping *yaml.Node, name string) (key, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,250 @@
+package valuessurface
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// key is a key of a chart's values surface.
+type key struct {
+	path       []string
+	typ        string         // JSON Schema type, e.g. "integer"; "" if unknown
+	value      any            // Default in values.yaml, if hasDefault
+	hasDefault bool           // Whether values.yaml sets the key
+	schema     map[string]any // Property in values.schema.json; nil if undeclared
+}
+
+// surface is every key a chart's values.yaml sets or its values.schema.json
+// declares, by joined path. Keys below lists aren't part of it: list items
+// are replaced as a whole, so overrides don't address them by key.
+type surface map[string]*key
+
+func joinPath(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+func (s surface) get(path []string) *key {
+	k, ok := s[joinPath(path)]
+	if !ok {
+		k = &key{path: path}
+		s[joinPath(path)] = k
+	}
+	return k
+}
+
+// addDefaults adds the keys values sets below prefix.
+func (s surface) addDefaults(values map[string]any, prefix []string) {
+	for name, v := range values {
+		path := append(slices.Clone(prefix), name)
+		k := s.get(path)
+		k.value, k.hasDefault = v, true
+		if k.typ == "" {
+			k.typ = valueType(v)
+		}
+		if m, ok := v.(map[string]any); ok {
+			s.addDefaults(m, path)
+		}
+	}
+}
+
+// addSchema adds the properties the schema node declares below prefix. A
+// declared type takes precedence over the type of the default.
+func (s surface) addSchema(node map[string]any, prefix []string) {
+	props, _ := node["properties"].(map[string]any)
+	for name, p := range props {
+		prop, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		path := append(slices.Clone(prefix), name)
+		k := s.get(path)
+		k.schema = prop
+		if t := schemaType(prop); t != "" {
+			k.typ = t
+		}
+		s.addSchema(prop, path)
+	}
+}
+
+// sortedKeys returns the keys sorted by path, parents before their children.
+func (s surface) sortedKeys() []*key {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	keys := make([]*key, len(names))
+	for i, name := range names {
+		keys[i] = s[name]
+	}
+	return keys
+}
+
+// compareSurfaces returns the keys of base that head removes, renames or
+// changes the type of. Only the outermost key of a change is reported: the
+// keys below a removed map go with it.
+func compareSurfaces(base, head surface) []domain.ValuesChange {
+	var changes []domain.ValuesChange
+	var removed []*key
+	reported := map[string]bool{}
+	for _, k := range base.sortedKeys() {
+		if underReported(reported, k.path) {
+			continue
+		}
+		h, ok := head[joinPath(k.path)]
+		switch {
+		case !ok:
+			removed = append(removed, k)
+		case typeChanged(k.typ, h.typ):
+			changes = append(changes, domain.ValuesChange{
+				Path: k.path, Type: domain.ValuesKeyTypeChanged, OldType: k.typ, NewType: h.typ,
+			})
+		default:
+			continue
+		}
+		reported[joinPath(k.path)] = true
+	}
+
+	added := addedKeys(base, head)
+	for _, k := range removed {
+		if to := renamedTo(k, added); to != nil {
+			changes = append(changes, domain.ValuesChange{
+				Path: k.path, Type: domain.ValuesKeyRenamed, NewPath: to.path,
+			})
+			added = slices.DeleteFunc(added, func(a *key) bool { return a == to })
+			continue
+		}
+		changes = append(changes, domain.ValuesChange{Path: k.path, Type: domain.ValuesKeyRemoved})
+	}
+	slices.SortFunc(changes, func(a, b domain.ValuesChange) int {
+		return strings.Compare(joinPath(a.Path), joinPath(b.Path))
+	})
+	return changes
+}
+
+// addedKeys returns the keys head has and base doesn't, including those
+// below a new parent, since a key may move into one.
+func addedKeys(base, head surface) []*key {
+	var added []*key
+	for _, k := range head.sortedKeys() {
+		if _, ok := base[joinPath(k.path)]; !ok {
+			added = append(added, k)
+		}
+	}
+	return added
+}
+
+// renamedTo returns the added key that removed was renamed to: the only one
+// with the same type and default (or, without a default, the same schema),
+// either under the same parent or with the same name under another parent.
+func renamedTo(removed *key, added []*key) *key {
+	var match *key
+	for _, k := range added {
+		if !sameKeyContent(removed, k) || !samePlace(removed.path, k.path) {
+			continue
+		}
+		if match != nil {
+			return nil // Ambiguous
+		}
+		match = k
+	}
+	return match
+}
+
+func sameKeyContent(a, b *key) bool {
+	if a.typ != b.typ {
+		return false
+	}
+	if a.hasDefault || b.hasDefault {
+		return a.hasDefault && b.hasDefault && !isEmpty(a.value) && reflect.DeepEqual(a.value, b.value)
+	}
+	return len(a.schema) > 0 && reflect.DeepEqual(a.schema, b.schema)
+}
+
+func samePlace(a, b []string) bool {
+	sameParent := slices.Equal(a[:len(a)-1], b[:len(b)-1])
+	sameName := a[len(a)-1] == b[len(b)-1]
+	return sameParent || sameName
+}
+
+// underReported reports whether a parent of path is already reported.
+func underReported(reported map[string]bool, path []string) bool {
+	for i := 1; i < len(path); i++ {
+		if reported[joinPath(path[:i])] {
+			return true
+		}
+	}
+	return false
+}
+
+// typeChanged reports whether overrides of the old type may not fit the new
+// one. Widening an integer to a number is fine, and an unknown type (a null
+// default without a schema) can't be compared.
+func typeChanged(oldType, newType string) bool {
+	if oldType == "" || newType == "" || oldType == newType {
+		return false
+	}
+	return oldType != "integer" || newType != "number"
+}
+
+// valueType returns the JSON Schema type of a default value.
+func valueType(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return ""
+	}
+}
+
+// schemaType returns a schema node's declared type, e.g. "string" or
+// "integer or string"; "" if it declares none.
+func schemaType(node map[string]any) string {
+	switch t := node["type"].(type) {
+	case string:
+		return t
+	case []any:
+		var types []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		slices.Sort(types)
+		return strings.Join(types, " or ")
+	default:
+		return ""
+	}
+}
+
+// isEmpty reports whether a default is too generic to identify a renamed
+// key: null, "", false, 0 or an empty map or list.
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
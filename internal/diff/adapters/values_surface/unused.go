@@ -0,0 +1,160 @@
+package valuessurface
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// globalKey holds the values Helm shares with every subchart.
+const globalKey = "global"
+
+// valuesRef matches a template's reference to a values key, e.g.
+// ".Values.image.tag" or "$.Values.image". A bare .Values, as in
+// `index .Values "image"`, names no key and is skipped.
+var valuesRef = regexp.MustCompile(`\.Values((?:\.[A-Za-z_][A-Za-z0-9_]*)+)`)
+
+// usage is what a chart declares and reads of its values.
+type usage struct {
+	surface   surface
+	refs      [][]string      // Keys the templates reference
+	subcharts map[string]bool // Top-level keys passed on to subcharts
+}
+
+// readUsage reads the values surface of the chart in chartDir, the keys its
+// templates and those of its vendored subcharts reference (library charts
+// read the parent's values), and the names of its dependencies.
+func readUsage(chartDir string) (*usage, error) {
+	s, err := readSurface(chartDir)
+	if err != nil {
+		return nil, err
+	}
+	u := &usage{surface: s, subcharts: map[string]bool{globalKey: true}}
+	if meta, err := chartutil.LoadChartfile(filepath.Join(chartDir, chartutil.ChartfileName)); err == nil {
+		for _, dep := range meta.Dependencies {
+			u.subcharts[dep.Name] = true
+			if dep.Alias != "" {
+				u.subcharts[dep.Alias] = true
+			}
+		}
+	}
+
+	err = filepath.WalkDir(chartDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(chartDir, path)
+		if err != nil || !slices.Contains(strings.Split(filepath.ToSlash(rel), "/"), "templates") {
+			return err
+		}
+		//nolint:gosec // G304: path is under our own temp checkout
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range valuesRef.FindAllStringSubmatch(string(data), -1) {
+			u.refs = append(u.refs, strings.Split(strings.TrimPrefix(m[1], "."), "."))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// findUnused returns the outermost keys the YAML values data sets that the
+// chart neither declares nor references, attributed to file.
+func (u *usage) findUnused(file string, data []byte) []domain.UnusedValue {
+	values, err := chartutil.ReadValues(data)
+	if err != nil {
+		return nil
+	}
+	var unused []domain.UnusedValue
+	var walk func(values map[string]any, prefix []string)
+	walk = func(values map[string]any, prefix []string) {
+		for _, name := range sortedNames(values) {
+			path := append(slices.Clone(prefix), name)
+			if len(prefix) == 0 && u.subcharts[name] {
+				continue
+			}
+			child, isMap := values[name].(map[string]any)
+			switch {
+			case u.surface.has(path):
+				if isMap && !u.surface.freeForm(path) {
+					walk(child, path)
+				}
+			case u.referenced(path):
+			case isMap && u.referencedBelow(path):
+				walk(child, path)
+			default:
+				unused = append(unused, domain.UnusedValue{File: file, Line: keyLine(data, path), Path: path})
+			}
+		}
+	}
+	walk(values, nil)
+	return unused
+}
+
+// referenced reports whether a template reads path or a map above it.
+func (u *usage) referenced(path []string) bool {
+	for _, ref := range u.refs {
+		if len(ref) <= len(path) && slices.Equal(ref, path[:len(ref)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// referencedBelow reports whether a template reads a key below path.
+func (u *usage) referencedBelow(path []string) bool {
+	for _, ref := range u.refs {
+		if len(ref) > len(path) && slices.Equal(ref[:len(path)], path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s surface) has(path []string) bool {
+	_, ok := s[joinPath(path)]
+	return ok
+}
+
+// freeForm reports whether the chart accepts any keys below path: it
+// declares no keys below it, as for `podAnnotations: {}`, or its schema
+// allows undeclared properties.
+func (s surface) freeForm(path []string) bool {
+	k := s[joinPath(path)]
+	if k.schema != nil {
+		if _, ok := k.schema["patternProperties"]; ok {
+			return true
+		}
+		if extra, ok := k.schema["additionalProperties"]; ok && extra != false {
+			return true
+		}
+	}
+	prefix := joinPath(path) + "\x00"
+	for name := range s {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedNames(values map[string]any) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,167 @@
+// Package webhookout notifies an outbound webhook when a PR changes the
+// manifests of the charts and environments someone watches, with a
+// Slack-compatible JSON message.
+package webhookout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// requestTimeout bounds a notification, so a slow webhook can't hold up
+// the PR's other reports.
+const requestTimeout = 10 * time.Second
+
+// maxEnvSections is the most environments a message lists; Slack allows 50
+// blocks a message.
+const maxEnvSections = 20
+
+// maxRisks is the most risks listed per environment.
+const maxRisks = 5
+
+// Filter selects the changes worth a notification.
+type Filter struct {
+	Charts       []string         // path.Match patterns of chart names (e.g., "payments-*"); empty for all
+	Environments []string         // path.Match patterns of environments (e.g., "prod*"); empty for all
+	MinRisk      domain.RiskLevel // Lowest change risk notified; RiskLow for any change
+}
+
+// Adapter implements ports.ReportingPort by posting a message to a webhook
+// for the results of each push that change manifests and match its filter.
+// It has no check runs or comments; the other methods do nothing.
+type Adapter struct {
+	url        string
+	appName    string
+	filter     Filter
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// New creates a new webhook notifier posting to webhookURL. Returns an error if a
+// pattern of filter is malformed.
+func New(webhookURL, appName string, filter Filter, logger *slog.Logger) (*Adapter, error) {
+	for _, pattern := range slices.Concat(filter.Charts, filter.Environments) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid notification filter pattern %q: %w", pattern, err)
+		}
+	}
+	return &Adapter{
+		url:        webhookURL,
+		appName:    appName,
+		filter:     filter,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		logger:     logger,
+	}, nil
+}
+
+// CreateInProgressCheck does nothing; notifications are only sent for results.
+func (a *Adapter) CreateInProgressCheck(_ context.Context, _ domain.PRContext) (int64, error) {
+	return 0, nil
+}
+
+// UpdateCheckWithResults posts a message listing the environments among
+// results whose manifests change and that match the filter. Nothing is
+// posted if none do.
+func (a *Adapter) UpdateCheckWithResults(
+	ctx context.Context,
+	pr domain.PRContext,
+	_ int64,
+	results []domain.DiffResult,
+) error {
+	var matched []domain.DiffResult
+	for _, r := range results {
+		if a.matches(r) {
+			matched = append(matched, r)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(a.message(pr, matched))
+	if err != nil {
+		return fmt.Errorf("marshaling notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		// The URL is a secret for most chat webhooks, so it's left out
+		return fmt.Errorf("posting notification: %w", withoutURL(err))
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			a.logger.Warn("failed to close response body", "error", err)
+		}
+	}()
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting notification: unexpected status %d", resp.StatusCode)
+	}
+	a.logger.Info("notification sent", "environments", len(matched))
+	return nil
+}
+
+// PostComment does nothing; notifications are sent once per push.
+func (a *Adapter) PostComment(_ context.Context, _ domain.PRContext, _ []domain.DiffResult) error {
+	return nil
+}
+
+// CollapseComment does nothing.
+func (a *Adapter) CollapseComment(_ context.Context, _ domain.PRContext, _ string) error {
+	return nil
+}
+
+// PostSummaryComment does nothing; notifications are sent once per push.
+func (a *Adapter) PostSummaryComment(_ context.Context, _ domain.PRContext, _ []domain.DiffResult) error {
+	return nil
+}
+
+// matches reports whether r changes manifests, is of a chart and
+// environment the filter selects, and is risky enough.
+func (a *Adapter) matches(r domain.DiffResult) bool {
+	return r.PreferredDiff() != "" &&
+		matchAny(a.filter.Charts, r.ChartName) &&
+		matchAny(a.filter.Environments, r.Environment) &&
+		domain.HighestRisk(r.Risks) >= a.filter.MinRisk
+}
+
+// matchAny reports whether name matches one of patterns, or patterns is empty.
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutURL drops the request URL that net/http adds to errors.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,176 @@
+package webhookout
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+	"github.com/nathantilsley/chart-val/internal/platform/logger"
+)
+
+// fakeWebhook stands in for a chat webhook, keeping the messages posted to it.
+type fakeWebhook struct {
+	status   int
+	messages []message
+}
+
+func (f *fakeWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var msg message
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" ||
+		json.Unmarshal(body, &msg) != nil {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+		return
+	}
+	f.messages = append(f.messages, msg)
+	w.WriteHeader(f.status)
+}
+
+func newTestAdapter(t *testing.T, f *fakeWebhook, filter Filter) *Adapter {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	a, err := New(server.URL+"/services/T000/B000/secret", "chart-val", filter, logger.New("error"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return a
+}
+
+var testPR = domain.PRContext{
+	Owner: "acme", Repo: "charts", PRNumber: 42,
+	BaseRef: "main", HeadRef: "feat/scale", HeadSHA: "abc1234def",
+}
+
+func changed(chart, env string, risks ...domain.ChangeRisk) domain.DiffResult {
+	return domain.DiffResult{
+		ChartName: chart, Environment: env, Status: domain.StatusChanges,
+		SemanticDiff: "spec.replicas\n  ± value change\n    - 3\n    + 0\n",
+		Resources: []domain.ResourceChange{
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: chart, Type: domain.ChangeModified},
+		},
+		Risks: risks,
+	}
+}
+
+func TestAdapter_UpdateCheckWithResults(t *testing.T) {
+	scaleToZero := domain.ChangeRisk{
+		Resource: "apps/v1/Deployment/payments-api", Level: domain.RiskHigh,
+		Reason: domain.RiskScaleToZero, Detail: "spec.replicas goes from 3 to <0>",
+	}
+	lowRisk := domain.ChangeRisk{Resource: "v1/ConfigMap/payments-api", Level: domain.RiskLow}
+	f := &fakeWebhook{status: http.StatusOK}
+	a := newTestAdapter(t, f, Filter{
+		Charts:       []string{"payments-*"},
+		Environments: []string{"prod*"},
+		MinRisk:      domain.RiskMedium,
+	})
+
+	unchanged := domain.DiffResult{ChartName: "payments-worker", Environment: "prod", Status: domain.StatusSuccess}
+	results := []domain.DiffResult{
+		changed("payments-api", "prod", scaleToZero),
+		changed("payments-api", "dev", scaleToZero), // Environment not watched
+		changed("payments-api", "prod-eu", lowRisk), // Not risky enough
+		changed("web", "prod", scaleToZero),         // Chart not watched
+		unchanged,
+	}
+	if err := a.UpdateCheckWithResults(context.Background(), testPR, 1, results); err != nil {
+		t.Fatalf("UpdateCheckWithResults() error = %v", err)
+	}
+
+	if len(f.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(f.messages))
+	}
+	msg := f.messages[0]
+	if msg.Text != "acme/charts#42 changes manifests of payments-api/prod" {
+		t.Errorf("text = %q", msg.Text)
+	}
+	if len(msg.Blocks) != 3 {
+		t.Fatalf("got %d blocks, want the PR, one environment and the context", len(msg.Blocks))
+	}
+	wantHeader := "*<https://github.com/acme/charts/pull/42|acme/charts#42>* changes manifests of 1 environment(s): " +
+		"`feat/scale` → `main`"
+	if got := msg.Blocks[0].Text.Text; got != wantHeader {
+		t.Errorf("header = %q, want %q", got, wantHeader)
+	}
+	wantEnv := "*payments-api* · `prod` · 🔥 high risk\n1 Deployment modified\n" +
+		"• `apps/v1/Deployment/payments-api`: spec.replicas goes from 3 to &lt;0&gt;"
+	if got := msg.Blocks[1].Text.Text; got != wantEnv {
+		t.Errorf("environment section = %q, want %q", got, wantEnv)
+	}
+	if got := msg.Blocks[2].Elements[0].Text; got != "chart-val · head `abc1234`" {
+		t.Errorf("context = %q", got)
+	}
+}
+
+func TestAdapter_UpdateCheckWithResults_NothingToNotify(t *testing.T) {
+	f := &fakeWebhook{status: http.StatusOK}
+	a := newTestAdapter(t, f, Filter{Environments: []string{"prod"}})
+
+	results := []domain.DiffResult{
+		changed("my-app", "dev"),
+		{ChartName: "my-app", Environment: "prod", Status: domain.StatusSuccess},
+		{ChartName: "my-app", Environment: "prod", Status: domain.StatusError, Summary: "render failed"},
+	}
+	if err := a.UpdateCheckWithResults(context.Background(), testPR, 1, results); err != nil {
+		t.Fatalf("UpdateCheckWithResults() error = %v", err)
+	}
+	if len(f.messages) != 0 {
+		t.Errorf("got %d messages, want none", len(f.messages))
+	}
+}
+
+func TestAdapter_UpdateCheckWithResults_ManyEnvironments(t *testing.T) {
+	f := &fakeWebhook{status: http.StatusOK}
+	a := newTestAdapter(t, f, Filter{})
+
+	var results []domain.DiffResult
+	for range maxEnvSections + 5 {
+		results = append(results, changed("my-app", "prod"))
+	}
+	if err := a.UpdateCheckWithResults(context.Background(), testPR, 1, results); err != nil {
+		t.Fatalf("UpdateCheckWithResults() error = %v", err)
+	}
+	blocks := f.messages[0].Blocks
+	if len(blocks) != maxEnvSections+3 || blocks[len(blocks)-2].Text.Text != "_…and 5 more_" {
+		t.Errorf("got %d blocks, want %d environments, a note of the rest, the PR and the context",
+			len(blocks), maxEnvSections)
+	}
+}
+
+func TestAdapter_UpdateCheckWithResults_Errors(t *testing.T) {
+	results := []domain.DiffResult{changed("my-app", "prod")}
+
+	t.Run("rejected", func(t *testing.T) {
+		a := newTestAdapter(t, &fakeWebhook{status: http.StatusForbidden}, Filter{})
+		err := a.UpdateCheckWithResults(context.Background(), testPR, 1, results)
+		if err == nil || !strings.Contains(err.Error(), "403") {
+			t.Errorf("UpdateCheckWithResults() error = %v, want the status", err)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		a, err := New(server.URL+"/services/secret", "chart-val", Filter{}, logger.New("error"))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		err = a.UpdateCheckWithResults(context.Background(), testPR, 1, results)
+		if err == nil || strings.Contains(err.Error(), "secret") {
+			t.Errorf("UpdateCheckWithResults() error = %v, want an error without the URL", err)
+		}
+	})
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	if _, err := New("https://hooks.example.com", "chart-val", Filter{Charts: []string{"payments-["}},
+		logger.New("error")); err == nil {
+		t.Error("New() error = nil, want the malformed pattern rejected")
+	}
+}
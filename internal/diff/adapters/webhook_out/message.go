@@ -0,0 +1,109 @@
+package webhookout
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// message is a Slack-compatible message: Slack renders the blocks, and
+// other webhooks can read text or the blocks' mrkdwn.
+type message struct {
+	Text   string  `json:"text"`
+	Blocks []block `json:"blocks"`
+}
+
+type block struct {
+	Type     string  `json:"type"`
+	Text     *text   `json:"text,omitempty"`
+	Elements []*text `json:"elements,omitempty"`
+}
+
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func section(mrkdwn string) block {
+	return block{Type: "section", Text: &text{Type: "mrkdwn", Text: mrkdwn}}
+}
+
+// message returns the message for the matched results of pr: a line on
+// the PR, a section per environment and who sent it.
+func (a *Adapter) message(pr domain.PRContext, results []domain.DiffResult) message {
+	name := fmt.Sprintf("%s/%s#%d", pr.Owner, pr.Repo, pr.PRNumber)
+	prURL := fmt.Sprintf("https://github.com/%s/%s/pull/%d", pr.Owner, pr.Repo, pr.PRNumber)
+
+	var envs []string
+	for _, r := range results {
+		envs = append(envs, r.ChartName+"/"+r.Environment)
+	}
+	msg := message{Text: fmt.Sprintf("%s changes manifests of %s", name, strings.Join(envs, ", "))}
+
+	heading := fmt.Sprintf("*<%s|%s>* changes manifests of %d environment(s): `%s` → `%s`",
+		prURL, escape(name), len(results), escape(pr.HeadRef), escape(pr.BaseRef))
+	msg.Blocks = append(msg.Blocks, section(heading))
+	for i, r := range results {
+		if i == maxEnvSections {
+			msg.Blocks = append(msg.Blocks, section(fmt.Sprintf("_…and %d more_", len(results)-i)))
+			break
+		}
+		msg.Blocks = append(msg.Blocks, section(envSection(r)))
+	}
+	msg.Blocks = append(msg.Blocks, block{Type: "context", Elements: []*text{
+		{Type: "mrkdwn", Text: fmt.Sprintf("%s · head `%s`", escape(a.appName), escape(shortSHA(pr.HeadSHA)))},
+	}})
+	return msg
+}
+
+// envSection describes the changes of an environment and their risks.
+func envSection(r domain.DiffResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s* · `%s`", escape(r.ChartName), escape(r.Environment))
+	switch risk := domain.HighestRisk(r.Risks); risk {
+	case domain.RiskHigh:
+		sb.WriteString(" · 🔥 high risk")
+	case domain.RiskMedium:
+		sb.WriteString(" · ⚠️ medium risk")
+	case domain.RiskLow:
+	}
+	if r.Status == domain.StatusInvalid {
+		sb.WriteString(" · ❌ fails validation")
+	}
+
+	if len(r.Resources) > 0 {
+		sb.WriteString("\n" + escape(domain.SummarizeResourceChanges(r.Resources)))
+	} else {
+		sb.WriteString("\nManifests changed")
+	}
+	var listed int
+	for _, rk := range r.Risks {
+		if rk.Level == domain.RiskLow {
+			continue
+		}
+		if listed == maxRisks {
+			sb.WriteString("\n• _…more risks in the PR_")
+			break
+		}
+		fmt.Fprintf(&sb, "\n• `%s`: %s", escape(rk.Resource), escape(rk.Detail))
+		listed++
+	}
+	if r.ReportURL != "" {
+		fmt.Fprintf(&sb, "\n<%s|Full diff>", r.ReportURL)
+	}
+	return sb.String()
+}
+
+// escape escapes the characters Slack's mrkdwn reserves for links and
+// mentions.
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,218 @@
+// Package yamldiff provides semantic diffs of Kubernetes manifests in pure Go.
+package yamldiff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// Adapter implements ports.DiffPort by comparing manifests structurally.
+// Resources are matched by apiVersion/kind/namespace/name, and list items
+// are matched by their name, key or id field when every item has one.
+type Adapter struct{}
+
+// New creates a new semantic YAML diff adapter.
+func New() *Adapter {
+	return &Adapter{}
+}
+
+// ComputeDiff returns a path-based diff of the resources in base and head,
+// and the resources that changed. The diff is empty when they are
+// semantically equal. Manifests that fail to parse return an empty diff
+// marked Unparsed (caller should use fallback).
+func (a *Adapter) ComputeDiff(baseName, headName string, base, head []byte) domain.ManifestDiff {
+	baseDocs, err := parseDocuments(base)
+	if err != nil {
+		slog.Warn("failed to parse base manifests for semantic diff", "error", err)
+		return domain.ManifestDiff{Unparsed: true}
+	}
+	headDocs, err := parseDocuments(head)
+	if err != nil {
+		slog.Warn("failed to parse head manifests for semantic diff", "error", err)
+		return domain.ManifestDiff{Unparsed: true}
+	}
+
+	changes, resources := diffDocuments(baseDocs, headDocs)
+	if len(changes) == 0 {
+		return domain.ManifestDiff{}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n", baseName)
+	fmt.Fprintf(&sb, "+++ %s\n", headName)
+	for _, c := range changes {
+		sb.WriteString("\n")
+		c.write(&sb)
+	}
+	return domain.ManifestDiff{Text: strings.TrimSpace(sb.String()), Resources: resources}
+}
+
+// document is a single resource of a multi-document manifest.
+type document struct {
+	id       string                // apiVersion/kind[/namespace]/name
+	resource domain.ResourceChange // Identity of the resource, without a change type
+	node     *yaml.Node            // Document node, including its comments
+}
+
+// parseDocuments splits a multi-document manifest into resources, skipping
+// empty documents.
+func parseDocuments(data []byte) ([]document, error) {
+	var docs []document
+	seen := make(map[string]int)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 || node.Content[0].ShortTag() == "!!null" {
+			continue
+		}
+
+		resource := identify(node.Content[0], len(docs))
+		id := resource.ID()
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			id = fmt.Sprintf("%s#%d", id, n) // Keep duplicates apart
+		}
+		docs = append(docs, document{id: id, resource: resource, node: &node})
+	}
+}
+
+// identify reads apiVersion, kind, namespace and name of a resource. Documents
+// that are not resources are named by their position instead.
+func identify(root *yaml.Node, index int) domain.ResourceChange {
+	metadata := yamldoc.ValueOf(root, "metadata")
+	resource := domain.ResourceChange{
+		APIVersion: yamldoc.ScalarAt(root, "apiVersion"),
+		Kind:       yamldoc.ScalarAt(root, "kind"),
+		Namespace:  yamldoc.ScalarAt(metadata, "namespace"),
+		Name:       yamldoc.ScalarAt(metadata, "name"),
+	}
+	if resource.Kind == "" || resource.Name == "" {
+		return domain.ResourceChange{Name: fmt.Sprintf("document %d", index+1)}
+	}
+	return resource
+}
+
+// diffDocuments compares resources matched by ID. Changed and removed
+// resources come first in base order, then added resources in head order.
+func diffDocuments(base, head []document) ([]change, []domain.ResourceChange) {
+	headByID := make(map[string]document, len(head))
+	for _, doc := range head {
+		headByID[doc.id] = doc
+	}
+	baseIDs := make(map[string]bool, len(base))
+
+	d := &differ{}
+	var resources []domain.ResourceChange
+	for _, doc := range base {
+		baseIDs[doc.id] = true
+		d.resource = doc.id
+		other, ok := headByID[doc.id]
+		if !ok {
+			d.add(nil, fmt.Sprintf("- %s removed:", count(1, "document", "documents")), "---\n"+encode(doc.node))
+			resources = append(resources, withType(doc.resource, domain.ChangeRemoved, nil))
+			continue
+		}
+
+		first := len(d.changes)
+		d.compare(nil, doc.node.Content[0], other.node.Content[0])
+		if len(d.changes) > first {
+			var fields []domain.FieldChange
+			for _, c := range d.changes[first:] {
+				fields = append(fields, c.fields...)
+			}
+			resources = append(resources, withType(doc.resource, domain.ChangeModified, fields))
+		}
+	}
+	for _, doc := range head {
+		if baseIDs[doc.id] {
+			continue
+		}
+		d.resource = doc.id
+		d.add(nil, fmt.Sprintf("+ %s added:", count(1, "document", "documents")), "---\n"+encode(doc.node))
+		resources = append(resources, withType(doc.resource, domain.ChangeAdded, nil))
+	}
+	return d.changes, resources
+}
+
+func withType(
+	resource domain.ResourceChange,
+	changeType domain.ChangeType,
+	fields []domain.FieldChange,
+) domain.ResourceChange {
+	resource.Type = changeType
+	resource.Fields = fields
+	return resource
+}
+
+// change is a single difference at a path within a resource.
+type change struct {
+	resource string
+	path     []string // Nil for the document root
+	summary  string   // e.g. "± value change"
+	body     string   // Values to show below the summary, already prefixed
+	fields   []domain.FieldChange
+}
+
+func (c change) write(sb *strings.Builder) {
+	path := "(root level)"
+	if len(c.path) > 0 {
+		path = strings.Join(c.path, ".")
+	}
+	fmt.Fprintf(sb, "%s  (%s)\n", path, c.resource)
+	fmt.Fprintf(sb, "  %s\n", c.summary)
+	sb.WriteString(indent(c.body, "    "))
+	sb.WriteString("\n")
+}
+
+// encode renders node as YAML with two-space indentation.
+func encode(node *yaml.Node) string {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	_ = enc.Close()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var countWords = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+
+// count spells out small counts the way a reviewer would read them
+// ("one list entry", "three map entries").
+func count(n int, singular, plural string) string {
+	word := fmt.Sprint(n)
+	if n < len(countWords) {
+		word = countWords[n]
+	}
+	if n == 1 {
+		return word + " " + singular
+	}
+	return word + " " + plural
+}
@@ -0,0 +1,300 @@
+package yamldiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  labels:
+    version: 0.1.0
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: my-app
+          image: my-app:1.24.0
+          env:
+            - name: LOG_LEVEL
+              value: info
+        - name: sidecar
+          image: proxy:1.0
+`
+
+func TestAdapter_ComputeDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		head string
+		want string // Empty if no diff expected
+	}{
+		{
+			name: "identical content returns empty diff",
+			base: deployment,
+			head: deployment,
+			want: "",
+		},
+		{
+			name: "formatting and key order are ignored",
+			base: "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: cfg}\ndata: {a: \"1\", b: \"2\"}\n",
+			head: "kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: cfg\ndata:\n  b: \"2\"\n  a: \"1\"\n",
+			want: "",
+		},
+		{
+			name: "document order is ignored",
+			base: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\n" +
+				"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n",
+			head: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n---\n" +
+				"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n",
+			want: "",
+		},
+		{
+			name: "value changes inside containers matched by name",
+			base: deployment,
+			head: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  labels:
+    version: 0.2.0
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+        - name: sidecar
+          image: proxy:1.0
+        - name: my-app
+          image: my-app:1.25.0
+          env:
+            - name: LOG_LEVEL
+              value: info
+            - name: ENABLE_CACHE
+              value: "true"
+`,
+			want: `--- test (main)
++++ test (feature)
+
+metadata.labels.version  (apps/v1/Deployment/my-app)
+  ± value change
+    - 0.1.0
+    + 0.2.0
+
+spec.replicas  (apps/v1/Deployment/my-app)
+  ± value change
+    - 3
+    + 5
+
+spec.template.spec.containers  (apps/v1/Deployment/my-app)
+  ⇆ order changed
+    - my-app, sidecar
+    + sidecar, my-app
+
+spec.template.spec.containers.my-app.image  (apps/v1/Deployment/my-app)
+  ± value change
+    - my-app:1.24.0
+    + my-app:1.25.0
+
+spec.template.spec.containers.my-app.env  (apps/v1/Deployment/my-app)
+  + one list entry added:
+    - name: ENABLE_CACHE
+      value: "true"`,
+		},
+		{
+			name: "documents added and removed",
+			base: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: old\n  namespace: prod\n",
+			head: "# Source: my-app/templates/configmap.yaml\n" +
+				"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: new\n",
+			want: `--- test (main)
++++ test (feature)
+
+(root level)  (v1/ConfigMap/prod/old)
+  - one document removed:
+    ---
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: old
+      namespace: prod
+
+(root level)  (v1/ConfigMap/new)
+  + one document added:
+    ---
+    # Source: my-app/templates/configmap.yaml
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: new`,
+		},
+		{
+			name: "map entries added and removed",
+			base: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  a: \"1\"\n  b: \"2\"\n",
+			head: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\n" +
+				"data:\n  b: \"2\"\n  c: \"3\"\n  d: \"4\"\n",
+			want: `--- test (main)
++++ test (feature)
+
+data  (v1/ConfigMap/cfg)
+  - one map entry removed:
+    a: "1"
+
+data  (v1/ConfigMap/cfg)
+  + two map entries added:
+    c: "3"
+    d: "4"`,
+		},
+		{
+			name: "type change",
+			base: "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\nspec:\n  port: \"80\"\n",
+			head: "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\nspec:\n  port: 80\n",
+			want: `--- test (main)
++++ test (feature)
+
+spec.port  (v1/Service/svc)
+  ± type change from string to int
+    - 80
+    + 80`,
+		},
+		{
+			name: "unkeyed scalar lists",
+			base: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: p\nspec:\n  args: [a, b, c]\n  cmd: [x, y]\n",
+			head: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: p\nspec:\n  args: [a, c, d]\n  cmd: [y, x]\n",
+			want: `--- test (main)
++++ test (feature)
+
+spec.args  (v1/Pod/p)
+  - one list entry removed:
+    - b
+
+spec.args  (v1/Pod/p)
+  + one list entry added:
+    - d
+
+spec.cmd  (v1/Pod/p)
+  ⇆ order changed
+    - [x, y]
+    + [y, x]`,
+		},
+		{
+			name: "unkeyed map lists compare by position",
+			base: "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\nspec:\n  ports:\n    - port: 80\n",
+			head: "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\nspec:\n  ports:\n    - port: 8080\n",
+			want: `--- test (main)
++++ test (feature)
+
+spec.ports.0.port  (v1/Service/svc)
+  ± value change
+    - 80
+    + 8080`,
+		},
+		{
+			name: "multi-line values",
+			base: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  conf: |\n    a=1\n    b=2\n",
+			head: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  conf: |\n    a=1\n    b=3\n",
+			want: `--- test (main)
++++ test (feature)
+
+data.conf  (v1/ConfigMap/cfg)
+  ± value change
+    - a=1
+      b=2
+    + a=1
+      b=3`,
+		},
+	}
+
+	adapter := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adapter.ComputeDiff("test (main)", "test (feature)", []byte(tt.base), []byte(tt.head)).Text
+			if got != tt.want {
+				t.Errorf("ComputeDiff() mismatch\ngot:\n%s\n\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdapter_ComputeDiff_InvalidYAML(t *testing.T) {
+	adapter := New()
+
+	got := adapter.ComputeDiff("test (main)", "test (feature)", []byte("key: [unclosed"), []byte("key: value\n"))
+	if got.Text != "" || got.Resources != nil || !got.Unparsed {
+		t.Errorf("expected empty diff marked unparsed for unparseable manifests (caller falls back), got: %+v", got)
+	}
+
+	if same := adapter.ComputeDiff("a", "b", []byte("key: value\n"), []byte("key: value # note\n")); same.Unparsed {
+		t.Error("expected manifests that only differ in comments not to be marked unparsed")
+	}
+}
+
+func TestAdapter_ComputeDiff_Deterministic(t *testing.T) {
+	adapter := New()
+	base := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  a: \"1\"\n  b: \"2\"\n")
+	head := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  c: \"3\"\n  d: \"4\"\n")
+
+	first := adapter.ComputeDiff("a", "b", base, head).Text
+	for range 20 {
+		if got := adapter.ComputeDiff("a", "b", base, head).Text; got != first {
+			t.Fatalf("ComputeDiff is not deterministic:\n%s\n\nvs\n%s", got, first)
+		}
+	}
+}
+
+func TestAdapter_ComputeDiff_Resources(t *testing.T) {
+	adapter := New()
+	base := []byte(deployment + "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: old\n  namespace: prod\n")
+	head := []byte(strings.ReplaceAll(deployment, "my-app:1.24.0", "my-app:1.25.0") +
+		"---\napiVersion: v1\nkind: Service\nmetadata:\n  name: my-app\n")
+
+	got := adapter.ComputeDiff("test (main)", "test (feature)", base, head).Resources
+
+	want := []domain.ResourceChange{
+		{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app", Type: domain.ChangeModified,
+			Fields: []domain.FieldChange{{
+				Path: "spec.template.spec.containers.my-app.image",
+				Type: domain.ChangeModified,
+				Old:  "my-app:1.24.0",
+				New:  "my-app:1.25.0",
+			}},
+		},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "prod", Name: "old", Type: domain.ChangeRemoved},
+		{APIVersion: "v1", Kind: "Service", Name: "my-app", Type: domain.ChangeAdded},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resources mismatch\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestAdapter_ComputeDiff_FieldChanges(t *testing.T) {
+	adapter := New()
+	base := []byte(deployment)
+	head := []byte(strings.ReplaceAll(deployment, `        - name: sidecar
+          image: proxy:1.0
+`, ""))
+	head = append(head, []byte("  paused: true\n")...)
+
+	got := adapter.ComputeDiff("test (main)", "test (feature)", base, head).Resources
+	if len(got) != 1 {
+		t.Fatalf("expected 1 modified resource, got %+v", got)
+	}
+
+	want := []domain.FieldChange{
+		{Path: "spec.paused", Type: domain.ChangeAdded, New: "true"},
+		{
+			Path: "spec.template.spec.containers.sidecar",
+			Type: domain.ChangeRemoved,
+			Old:  "name: sidecar\nimage: proxy:1.0",
+		},
+	}
+	if !reflect.DeepEqual(got[0].Fields, want) {
+		t.Errorf("Fields mismatch\ngot:  %+v\nwant: %+v", got[0].Fields, want)
+	}
+}
@@ -0,0 +1,318 @@
+package yamldiff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nathantilsley/chart-val/internal/diff/adapters/internal/yamldoc"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// listKeys are the fields that identify list items, tried in order. A field
+// is used only when every item in both lists has a unique scalar value for it.
+var listKeys = []string{"name", "key", "id"}
+
+// differ accumulates the changes of one resource at a time.
+type differ struct {
+	resource string
+	changes  []change
+}
+
+func (d *differ) add(path []string, summary, body string, fields ...domain.FieldChange) {
+	d.changes = append(d.changes, change{
+		resource: d.resource,
+		path:     slices.Clone(path),
+		summary:  summary,
+		body:     body,
+		fields:   fields,
+	})
+}
+
+// compare records the differences between from and to at path.
+func (d *differ) compare(path []string, from, to *yaml.Node) {
+	from, to = yamldoc.Resolve(from), yamldoc.Resolve(to)
+
+	if typeName(from) != typeName(to) {
+		d.add(path, fmt.Sprintf("± type change from %s to %s", typeName(from), typeName(to)),
+			bullet("-", display(from))+"\n"+bullet("+", display(to)),
+			modified(path, display(from), display(to)))
+		return
+	}
+
+	switch from.Kind {
+	case yaml.MappingNode:
+		d.compareMaps(path, from, to)
+	case yaml.SequenceNode:
+		d.compareLists(path, from, to)
+	default:
+		if from.Value != to.Value {
+			d.add(path, "± value change", bullet("-", from.Value)+"\n"+bullet("+", to.Value),
+				modified(path, from.Value, to.Value))
+		}
+	}
+}
+
+// compareMaps reports removed and added entries at path, then recurses into
+// the common keys in base order.
+func (d *differ) compareMaps(path []string, from, to *yaml.Node) {
+	removed := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(from.Content); i += 2 {
+		if yamldoc.ValueOf(to, from.Content[i].Value) == nil {
+			removed.Content = append(removed.Content, from.Content[i], from.Content[i+1])
+		}
+	}
+	added := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(to.Content); i += 2 {
+		if yamldoc.ValueOf(from, to.Content[i].Value) == nil {
+			added.Content = append(added.Content, to.Content[i], to.Content[i+1])
+		}
+	}
+
+	if n := len(removed.Content) / 2; n > 0 {
+		d.add(path, fmt.Sprintf("- %s removed:", count(n, "map entry", "map entries")), encode(removed),
+			entryFields(path, domain.ChangeRemoved, removed)...)
+	}
+	if n := len(added.Content) / 2; n > 0 {
+		d.add(path, fmt.Sprintf("+ %s added:", count(n, "map entry", "map entries")), encode(added),
+			entryFields(path, domain.ChangeAdded, added)...)
+	}
+
+	for i := 0; i+1 < len(from.Content); i += 2 {
+		key := from.Content[i].Value
+		if other := yamldoc.ValueOf(to, key); other != nil {
+			d.compare(append(path, key), from.Content[i+1], other)
+		}
+	}
+}
+
+// compareLists matches items by an identifying field when possible, falls
+// back to comparing by position for lists of the same length, and otherwise
+// treats the lists as multisets.
+func (d *differ) compareLists(path []string, from, to *yaml.Node) {
+	if key := identifyingKey(from.Content, to.Content); key != "" {
+		d.compareKeyedLists(path, key, from, to)
+		return
+	}
+	if len(from.Content) == len(to.Content) && !allScalars(from.Content) {
+		for i := range from.Content {
+			d.compare(append(path, fmt.Sprint(i)), from.Content[i], to.Content[i])
+		}
+		return
+	}
+	d.compareUnkeyedLists(path, from, to)
+}
+
+func (d *differ) compareKeyedLists(path []string, key string, from, to *yaml.Node) {
+	fromByID := itemsByID(from.Content, key)
+	toByID := itemsByID(to.Content, key)
+
+	removed := &yaml.Node{Kind: yaml.SequenceNode}
+	var fromOrder []string
+	for _, item := range from.Content {
+		id := yamldoc.ScalarAt(yamldoc.Resolve(item), key)
+		if _, ok := toByID[id]; ok {
+			fromOrder = append(fromOrder, id)
+		} else {
+			removed.Content = append(removed.Content, item)
+		}
+	}
+	added := &yaml.Node{Kind: yaml.SequenceNode}
+	var toOrder []string
+	for _, item := range to.Content {
+		id := yamldoc.ScalarAt(yamldoc.Resolve(item), key)
+		if _, ok := fromByID[id]; ok {
+			toOrder = append(toOrder, id)
+		} else {
+			added.Content = append(added.Content, item)
+		}
+	}
+
+	d.addListEntries(path, key, removed, added)
+	if !slices.Equal(fromOrder, toOrder) {
+		before, after := strings.Join(fromOrder, ", "), strings.Join(toOrder, ", ")
+		d.add(path, "⇆ order changed", bullet("-", before)+"\n"+bullet("+", after), modified(path, before, after))
+	}
+
+	for _, id := range fromOrder {
+		d.compare(append(path, id), fromByID[id], toByID[id])
+	}
+}
+
+func (d *differ) compareUnkeyedLists(path []string, from, to *yaml.Node) {
+	// Pair up equal items so only the unmatched ones are reported
+	matched := make([]bool, len(to.Content))
+	removed := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, item := range from.Content {
+		i := indexOfUnmatched(to.Content, matched, encode(item))
+		if i < 0 {
+			removed.Content = append(removed.Content, item)
+			continue
+		}
+		matched[i] = true
+	}
+	added := &yaml.Node{Kind: yaml.SequenceNode}
+	for i, item := range to.Content {
+		if !matched[i] {
+			added.Content = append(added.Content, item)
+		}
+	}
+
+	if len(removed.Content) > 0 || len(added.Content) > 0 {
+		d.addListEntries(path, "", removed, added)
+		return
+	}
+	if encode(from) != encode(to) {
+		before, after := encodeFlow(from), encodeFlow(to)
+		d.add(path, "⇆ order changed", bullet("-", before)+"\n"+bullet("+", after), modified(path, before, after))
+	}
+}
+
+// indexOfUnmatched returns the first item not yet matched that encodes to
+// want, or -1.
+func indexOfUnmatched(items []*yaml.Node, matched []bool, want string) int {
+	for i, item := range items {
+		if !matched[i] && encode(item) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// addListEntries reports removed and added list items. Items of keyed lists
+// get their own field path (e.g. "containers.sidecar"); others share path.
+func (d *differ) addListEntries(path []string, key string, removed, added *yaml.Node) {
+	if n := len(removed.Content); n > 0 {
+		d.add(path, fmt.Sprintf("- %s removed:", count(n, "list entry", "list entries")), encode(removed),
+			itemFields(path, key, domain.ChangeRemoved, removed)...)
+	}
+	if n := len(added.Content); n > 0 {
+		d.add(path, fmt.Sprintf("+ %s added:", count(n, "list entry", "list entries")), encode(added),
+			itemFields(path, key, domain.ChangeAdded, added)...)
+	}
+}
+
+func modified(path []string, before, after string) domain.FieldChange {
+	return domain.FieldChange{Path: strings.Join(path, "."), Type: domain.ChangeModified, Old: before, New: after}
+}
+
+// entryFields returns one field change per key of a mapping node.
+func entryFields(path []string, changeType domain.ChangeType, entries *yaml.Node) []domain.FieldChange {
+	fields := make([]domain.FieldChange, 0, len(entries.Content)/2)
+	for i := 0; i+1 < len(entries.Content); i += 2 {
+		fields = append(fields, fieldChange(append(path, entries.Content[i].Value), changeType, entries.Content[i+1]))
+	}
+	return fields
+}
+
+// itemFields returns one field change per item of a sequence node.
+func itemFields(path []string, key string, changeType domain.ChangeType, items *yaml.Node) []domain.FieldChange {
+	fields := make([]domain.FieldChange, 0, len(items.Content))
+	for _, item := range items.Content {
+		itemPath := path
+		if key != "" {
+			itemPath = append(path, yamldoc.ScalarAt(yamldoc.Resolve(item), key))
+		}
+		fields = append(fields, fieldChange(itemPath, changeType, item))
+	}
+	return fields
+}
+
+func fieldChange(path []string, changeType domain.ChangeType, value *yaml.Node) domain.FieldChange {
+	field := domain.FieldChange{Path: strings.Join(path, "."), Type: changeType}
+	if changeType == domain.ChangeRemoved {
+		field.Old = display(yamldoc.Resolve(value))
+	} else {
+		field.New = display(yamldoc.Resolve(value))
+	}
+	return field
+}
+
+// identifyingKey returns the first of listKeys that uniquely identifies every
+// item of both lists, or "" if none does.
+func identifyingKey(from, to []*yaml.Node) string {
+	for _, key := range listKeys {
+		if identifies(from, key) && identifies(to, key) {
+			return key
+		}
+	}
+	return ""
+}
+
+func identifies(items []*yaml.Node, key string) bool {
+	if len(items) == 0 {
+		return true
+	}
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		id := yamldoc.ScalarAt(yamldoc.Resolve(item), key)
+		if id == "" || seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+func itemsByID(items []*yaml.Node, key string) map[string]*yaml.Node {
+	byID := make(map[string]*yaml.Node, len(items))
+	for _, item := range items {
+		byID[yamldoc.ScalarAt(yamldoc.Resolve(item), key)] = item
+	}
+	return byID
+}
+
+func allScalars(items []*yaml.Node) bool {
+	for _, item := range items {
+		if yamldoc.Resolve(item).Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+// typeName names the YAML type of node for type change summaries.
+func typeName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "map"
+	case yaml.SequenceNode:
+		return "list"
+	default:
+		switch node.ShortTag() {
+		case "!!int":
+			return "int"
+		case "!!float":
+			return "float"
+		case "!!bool":
+			return "bool"
+		case "!!null":
+			return "null"
+		default:
+			return "string"
+		}
+	}
+}
+
+// display returns a scalar's value, or the YAML of a collection.
+func display(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	return encode(node)
+}
+
+// encodeFlow renders a list of scalars on one line, e.g. "[a, b]".
+func encodeFlow(node *yaml.Node) string {
+	flow := *node
+	flow.Style = yaml.FlowStyle
+	return encode(&flow)
+}
+
+// bullet prefixes the first line of s with marker and aligns the rest.
+func bullet(marker, s string) string {
+	s = strings.TrimSuffix(s, "\n") // Block scalars keep their final newline
+	return marker + " " + strings.ReplaceAll(s, "\n", "\n"+strings.Repeat(" ", len(marker)+1))
+}
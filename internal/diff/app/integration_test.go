@@ -11,10 +11,10 @@ import (
 	"strings"
 	"testing"
 
-	dyffdiff "github.com/nathantilsley/chart-val/internal/diff/adapters/dyff_diff"
 	githubout "github.com/nathantilsley/chart-val/internal/diff/adapters/github_out"
 	helmcli "github.com/nathantilsley/chart-val/internal/diff/adapters/helm_cli"
 	linediff "github.com/nathantilsley/chart-val/internal/diff/adapters/line_diff"
+	yamldiff "github.com/nathantilsley/chart-val/internal/diff/adapters/yaml_diff"
 	"github.com/nathantilsley/chart-val/internal/diff/domain"
 )
 
@@ -50,19 +50,19 @@ func TestIntegration_FullDiffFlow(t *testing.T) {
 	}
 
 	// Init diff adapters
-	semanticDiff := dyffdiff.New()
+	semanticDiff := yamldiff.New()
 	unifiedDiff := linediff.New()
 
 	var allResults []domain.DiffResult
 
 	for _, env := range envs {
 		t.Run(env.Name, func(t *testing.T) {
-			baseManifest, err := renderer.Render(ctx, baseChartDir, env.ValueFiles)
+			baseManifest, err := renderer.Render(ctx, baseChartDir, env.ValueFiles, env.RenderOptions)
 			if err != nil {
 				t.Fatalf("rendering base for %s: %v", env.Name, err)
 			}
 
-			headManifest, err := renderer.Render(ctx, headChartDir, env.ValueFiles)
+			headManifest, err := renderer.Render(ctx, headChartDir, env.ValueFiles, env.RenderOptions)
 			if err != nil {
 				t.Fatalf("rendering head for %s: %v", env.Name, err)
 			}
@@ -77,7 +77,7 @@ func TestIntegration_FullDiffFlow(t *testing.T) {
 
 			var status domain.Status
 			var summary string
-			if semanticDiffOutput != "" || unifiedDiffOutput != "" {
+			if semanticDiffOutput.Text != "" || unifiedDiffOutput.Text != "" {
 				status = domain.StatusChanges
 				summary = fmt.Sprintf("Changes detected in my-app for environment %s.", env.Name)
 			} else {
@@ -91,9 +91,10 @@ func TestIntegration_FullDiffFlow(t *testing.T) {
 				BaseRef:      baseRef,
 				HeadRef:      headRef,
 				Status:       status,
-				UnifiedDiff:  unifiedDiffOutput,
-				SemanticDiff: semanticDiffOutput,
+				UnifiedDiff:  unifiedDiffOutput.Text,
+				SemanticDiff: semanticDiffOutput.Text,
 				Summary:      summary,
+				Resources:    semanticDiffOutput.Resources,
 			}
 			allResults = append(allResults, result)
 
@@ -104,7 +105,7 @@ func TestIntegration_FullDiffFlow(t *testing.T) {
 	}
 
 	// Generate grouped check run markdown (one per chart) - using production code
-	reporter := githubout.New(nil, "chart-val", "")
+	reporter := githubout.New(nil, "chart-val", "", true, nil, "")
 	checkRunMD := reporter.FormatCheckRunMarkdown(allResults)
 	goldenFile := filepath.Join(goldenDir, "check-run-my-app.md")
 	compareOrUpdateGolden(t, goldenFile, checkRunMD)
@@ -112,12 +113,12 @@ func TestIntegration_FullDiffFlow(t *testing.T) {
 	// Generate PR summary comment - using production code
 	prComment := reporter.FormatPRComment(allResults)
 	goldenFile = filepath.Join(goldenDir, "pr-comment.md")
-	compareOrUpdateGolden(t, goldenFile, prComment)
+	compareOrUpdateGolden(t, goldenFile, strings.Join(prComment, "\n"))
 
 	// Generate unified diff PR comment - using production code
 	prCommentUnified := reporter.FormatPRCommentUnified(allResults)
 	goldenFile = filepath.Join(goldenDir, "pr-comment-unified.md")
-	compareOrUpdateGolden(t, goldenFile, prCommentUnified)
+	compareOrUpdateGolden(t, goldenFile, strings.Join(prCommentUnified, "\n"))
 }
 
 // TestIntegration_NewChart tests the scenario where a chart is being added
@@ -153,7 +154,7 @@ func TestIntegration_NewChart(t *testing.T) {
 	}
 
 	// Init diff adapters
-	semanticDiff := dyffdiff.New()
+	semanticDiff := yamldiff.New()
 	unifiedDiff := linediff.New()
 
 	var allResults []domain.DiffResult
@@ -163,14 +164,14 @@ func TestIntegration_NewChart(t *testing.T) {
 			// For a new chart, base manifest should be empty
 			var baseManifest []byte
 			if _, err := os.Stat(baseChartDir); err == nil {
-				baseManifest, err = renderer.Render(ctx, baseChartDir, env.ValueFiles)
+				baseManifest, err = renderer.Render(ctx, baseChartDir, env.ValueFiles, env.RenderOptions)
 				if err != nil {
 					t.Fatalf("rendering base for %s: %v", env.Name, err)
 				}
 			}
 			// else: baseManifest remains empty (nil/empty byte slice)
 
-			headManifest, err := renderer.Render(ctx, headChartDir, env.ValueFiles)
+			headManifest, err := renderer.Render(ctx, headChartDir, env.ValueFiles, env.RenderOptions)
 			if err != nil {
 				t.Fatalf("rendering head for %s: %v", env.Name, err)
 			}
@@ -185,7 +186,7 @@ func TestIntegration_NewChart(t *testing.T) {
 
 			var status domain.Status
 			var summary string
-			if semanticDiffOutput != "" || unifiedDiffOutput != "" {
+			if semanticDiffOutput.Text != "" || unifiedDiffOutput.Text != "" {
 				status = domain.StatusChanges
 				summary = fmt.Sprintf("Changes detected in new-chart for environment %s.", env.Name)
 			} else {
@@ -199,9 +200,10 @@ func TestIntegration_NewChart(t *testing.T) {
 				BaseRef:      baseRef,
 				HeadRef:      headRef,
 				Status:       status,
-				UnifiedDiff:  unifiedDiffOutput,
-				SemanticDiff: semanticDiffOutput,
+				UnifiedDiff:  unifiedDiffOutput.Text,
+				SemanticDiff: semanticDiffOutput.Text,
 				Summary:      summary,
+				Resources:    semanticDiffOutput.Resources,
 			}
 			allResults = append(allResults, result)
 
@@ -212,7 +214,7 @@ func TestIntegration_NewChart(t *testing.T) {
 	}
 
 	// Generate grouped check run markdown - using production code
-	reporter := githubout.New(nil, "chart-val", "")
+	reporter := githubout.New(nil, "chart-val", "", true, nil, "")
 	checkRunMD := reporter.FormatCheckRunMarkdown(allResults)
 	goldenFile := filepath.Join(goldenDir, "check-run-new-chart.md")
 	compareOrUpdateGolden(t, goldenFile, checkRunMD)
@@ -250,7 +252,7 @@ func TestIntegration_ThreeChartsOneChanged(t *testing.T) {
 		{"another-app", false},
 	}
 
-	semanticDiff := dyffdiff.New()
+	semanticDiff := yamldiff.New()
 	unifiedDiff := linediff.New()
 
 	allResults := make([]domain.DiffResult, 0, len(charts))
@@ -266,12 +268,12 @@ func TestIntegration_ThreeChartsOneChanged(t *testing.T) {
 		}
 
 		for _, env := range envs {
-			baseManifest, err := renderer.Render(ctx, baseChartDir, env.ValueFiles)
+			baseManifest, err := renderer.Render(ctx, baseChartDir, env.ValueFiles, env.RenderOptions)
 			if err != nil {
 				t.Fatalf("rendering base for %s/%s: %v", chart.name, env.Name, err)
 			}
 
-			headManifest, err := renderer.Render(ctx, headChartDir, env.ValueFiles)
+			headManifest, err := renderer.Render(ctx, headChartDir, env.ValueFiles, env.RenderOptions)
 			if err != nil {
 				t.Fatalf("rendering head for %s/%s: %v", chart.name, env.Name, err)
 			}
@@ -284,7 +286,7 @@ func TestIntegration_ThreeChartsOneChanged(t *testing.T) {
 
 			var status domain.Status
 			var summary string
-			if semanticDiffOutput != "" || unifiedDiffOutput != "" {
+			if semanticDiffOutput.Text != "" || unifiedDiffOutput.Text != "" {
 				status = domain.StatusChanges
 				summary = fmt.Sprintf("Changes detected in %s for environment %s.", chart.name, env.Name)
 			} else {
@@ -298,9 +300,10 @@ func TestIntegration_ThreeChartsOneChanged(t *testing.T) {
 				BaseRef:      baseRef,
 				HeadRef:      headRef,
 				Status:       status,
-				UnifiedDiff:  unifiedDiffOutput,
-				SemanticDiff: semanticDiffOutput,
+				UnifiedDiff:  unifiedDiffOutput.Text,
+				SemanticDiff: semanticDiffOutput.Text,
 				Summary:      summary,
+				Resources:    semanticDiffOutput.Resources,
 			}
 			allResults = append(allResults, result)
 		}
@@ -323,7 +326,7 @@ func TestIntegration_ThreeChartsOneChanged(t *testing.T) {
 	}
 
 	// Check run should show all charts (changed + unchanged)
-	reporter := githubout.New(nil, "chart-val", "")
+	reporter := githubout.New(nil, "chart-val", "", true, nil, "")
 	checkRunMD := reporter.FormatCheckRunMarkdown(allResults)
 	goldenFile := filepath.Join(goldenDir, "check-run-three-charts.md")
 	compareOrUpdateGolden(t, goldenFile, checkRunMD)
@@ -331,12 +334,12 @@ func TestIntegration_ThreeChartsOneChanged(t *testing.T) {
 	// PR comment should only be for the changed chart (my-app)
 	prComment := reporter.FormatPRComment(changedResults)
 	goldenFile = filepath.Join(goldenDir, "pr-comment-three-charts.md")
-	compareOrUpdateGolden(t, goldenFile, prComment)
+	compareOrUpdateGolden(t, goldenFile, strings.Join(prComment, "\n"))
 
 	// Unified diff PR comment for the changed chart
 	prCommentUnified := reporter.FormatPRCommentUnified(changedResults)
 	goldenFile = filepath.Join(goldenDir, "pr-comment-three-charts-unified.md")
-	compareOrUpdateGolden(t, goldenFile, prCommentUnified)
+	compareOrUpdateGolden(t, goldenFile, strings.Join(prCommentUnified, "\n"))
 }
 
 // compareOrUpdateGolden either updates the golden file or compares against it.
@@ -2,9 +2,12 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +25,9 @@ const (
 	defaultEnvConcurrency = 4
 )
 
+// ChartPolicyDir is the chart subdirectory holding chart-specific policy files.
+const ChartPolicyDir = "policies"
+
 // DiffService implements ports.DiffUseCase by orchestrating the full
 // chart diff workflow: discover charts, fetch chart files, render, compute diffs, and report.
 type DiffService struct {
@@ -30,67 +36,133 @@ type DiffService struct {
 	argoEnvConfig ports.EnvironmentConfigPort // Optional: Argo CD apps (source of truth)
 	fsEnvConfig   ports.EnvironmentConfigPort // Fallback: discovers from chart's env/ folder
 	renderer      ports.RendererPort
+	depResolver   ports.DependencyResolverPort // Optional: fetches chart dependencies before rendering
 	reporter      ports.ReportingPort
-	semanticDiff  ports.DiffPort // Semantic YAML diff (e.g., dyff)
-	unifiedDiff   ports.DiffPort // Line-based diff (e.g., go-difflib)
+	semanticDiff  ports.DiffPort               // Semantic YAML diff (e.g., yaml_diff)
+	unifiedDiff   ports.DiffPort               // Line-based diff (e.g., go-difflib)
+	redactor      ports.RedactorPort           // Optional: masks secret values before diffing
+	validator     ports.ManifestValidatorPort  // Optional: checks head manifests against Kubernetes schemas
+	linter        ports.ChartLinterPort        // Optional: lints the head chart and its values per environment
+	deprecations  ports.DeprecationCheckerPort // Optional: finds deprecated and removed API versions
+	policies      ports.PolicyPort             // Optional: evaluates policy-as-code rules on head manifests
+	chartVersion  ports.ChartVersionPort       // Optional: checks the Chart.yaml version bump
+	valuesSurface ports.ValuesSurfacePort      // Optional: finds breaking values changes, stale and unused values
+	ignoreFilter  ports.ManifestFilterPort     // Optional: strips ignored fields before diffing
+	reports       ports.ReportStorePort        // Optional: keeps full diffs for reports that cut them
+	formatters    []ports.ReportFormatterPort  // Machine-readable outputs (e.g., JSON, SARIF, JUnit)
+	outputs       ports.OutputStorePort        // Optional: where the formatters' outputs are written
 	logger        *slog.Logger
 	tracer        trace.Tracer
-	chartDir      string // Top-level chart directory (e.g., "charts")
+	chartDir      string             // Top-level chart directory (e.g., "charts")
+	commentMode   domain.CommentMode // Per-chart PR comments or one summary comment
+	staleSeverity domain.Severity    // Severity of stale values overrides
 
 	maxEnvConcurrency int // Max concurrent per-environment diffs
 
 	// Pre-created metric instruments (created once, reused per call)
-	execCounter  metric.Int64Counter
-	execDuration metric.Float64Histogram
-	diffStatus   metric.Int64Counter
+	execCounter      metric.Int64Counter
+	execDuration     metric.Float64Histogram
+	diffStatus       metric.Int64Counter
+	resourceChanges  metric.Int64Counter
+	policyViolations metric.Int64Counter
+}
+
+// Deps are the driven ports and telemetry of a DiffService. Optional ports
+// may be left nil, which turns their feature off.
+type Deps struct {
+	SourceControl ports.SourceControlPort
+	ChangedCharts ports.ChangedChartsPort
+	ArgoEnvConfig ports.EnvironmentConfigPort // Optional: Argo CD apps, the source of truth when set
+	FSEnvConfig   ports.EnvironmentConfigPort // Fallback: discovers from the chart's env/ folder
+	Renderer      ports.RendererPort
+	DepResolver   ports.DependencyResolverPort // Optional: if nil, charts must vendor their dependencies
+	Reporter      ports.ReportingPort
+	SemanticDiff  ports.DiffPort
+	UnifiedDiff   ports.DiffPort
+	Redactor      ports.RedactorPort           // Optional: if nil, manifests are diffed and reported verbatim
+	Validator     ports.ManifestValidatorPort  // Optional: if nil, manifests are not schema-validated
+	Linter        ports.ChartLinterPort        // Optional: if nil, charts and their values are not linted
+	Deprecations  ports.DeprecationCheckerPort // Optional: if nil, API versions are not checked
+	Policies      ports.PolicyPort             // Optional: if nil, no policies are evaluated
+	ChartVersion  ports.ChartVersionPort       // Optional: if nil, chart versions are not checked
+	ValuesSurface ports.ValuesSurfacePort      // Optional: if nil, values changes are not checked
+	IgnoreFilter  ports.ManifestFilterPort     // Optional: if nil, every rendered field is diffed
+	Reports       ports.ReportStorePort        // Optional: if nil, cut diffs are not linked anywhere
+	Formatters    []ports.ReportFormatterPort  // Optional: machine-readable outputs (e.g., JSON, SARIF, JUnit)
+	Outputs       ports.OutputStorePort        // Optional: if nil, no outputs are written, whatever the formatters
+	Logger        *slog.Logger
+	Meter         metric.Meter
+	Tracer        trace.Tracer
 }
 
-// NewDiffService creates a new DiffService wired with all driven ports.
-// argoEnvConfig is optional (can be nil) - if provided, it's used as source of truth with filesystem as fallback.
-func NewDiffService(
-	sc ports.SourceControlPort,
-	cc ports.ChangedChartsPort,
-	argoEnvConfig ports.EnvironmentConfigPort,
-	fsEnvConfig ports.EnvironmentConfigPort,
-	rn ports.RendererPort,
-	rp ports.ReportingPort,
-	semanticDiff ports.DiffPort,
-	unifiedDiff ports.DiffPort,
-	logger *slog.Logger,
-	meter metric.Meter,
-	tracer trace.Tracer,
-	chartDir string,
-	metricPrefix string,
-) *DiffService {
-	execCounter, _ := meter.Int64Counter(metricPrefix+".executions",
+// Options configure a DiffService.
+type Options struct {
+	ChartDir     string             // Top-level chart directory (e.g., "charts")
+	CommentMode  domain.CommentMode // Per-chart PR comments or one summary comment
+	MetricPrefix string             // Prefix of the metric names (e.g., "chart_val")
+
+	FailOnStaleOverrides bool // Stale values overrides fail the check run instead of being warnings
+}
+
+// NewDiffService creates a new DiffService wired with the ports of deps.
+func NewDiffService(deps Deps, opts Options) *DiffService {
+	execCounter, _ := deps.Meter.Int64Counter(opts.MetricPrefix+".executions",
 		metric.WithUnit("{invocation}"),
 		metric.WithDescription("Number of Execute() invocations"),
 	)
-	execDuration, _ := meter.Float64Histogram(metricPrefix+".execution.duration",
+	execDuration, _ := deps.Meter.Float64Histogram(opts.MetricPrefix+".execution.duration",
 		metric.WithUnit("s"),
 		metric.WithDescription("Duration of Execute() calls"),
 	)
-	diffStatus, _ := meter.Int64Counter(metricPrefix+".diff.status",
+	diffStatus, _ := deps.Meter.Int64Counter(opts.MetricPrefix+".diff.status",
 		metric.WithUnit("{result}"),
-		metric.WithDescription("Diff results by status (success, changes, error)"),
+		metric.WithDescription("Diff results by status (success, changes, error, invalid)"),
+	)
+	resourceChanges, _ := deps.Meter.Int64Counter(opts.MetricPrefix+".diff.resources",
+		metric.WithUnit("{resource}"),
+		metric.WithDescription("Changed resources by kind and change type (added, removed, modified)"),
 	)
+	policyViolations, _ := deps.Meter.Int64Counter(opts.MetricPrefix+".policy.violations",
+		metric.WithUnit("{violation}"),
+		metric.WithDescription("Policy violations by policy and severity (error, warning)"),
+	)
+	staleSeverity := domain.SeverityWarning
+	if opts.FailOnStaleOverrides {
+		staleSeverity = domain.SeverityError
+	}
 
 	return &DiffService{
-		sourceControl:     sc,
-		changedCharts:     cc,
-		argoEnvConfig:     argoEnvConfig,
-		fsEnvConfig:       fsEnvConfig,
-		renderer:          rn,
-		reporter:          rp,
-		semanticDiff:      semanticDiff,
-		unifiedDiff:       unifiedDiff,
-		logger:            logger,
-		tracer:            tracer,
-		chartDir:          chartDir,
+		sourceControl:     deps.SourceControl,
+		changedCharts:     deps.ChangedCharts,
+		argoEnvConfig:     deps.ArgoEnvConfig,
+		fsEnvConfig:       deps.FSEnvConfig,
+		renderer:          deps.Renderer,
+		depResolver:       deps.DepResolver,
+		reporter:          deps.Reporter,
+		semanticDiff:      deps.SemanticDiff,
+		unifiedDiff:       deps.UnifiedDiff,
+		redactor:          deps.Redactor,
+		validator:         deps.Validator,
+		linter:            deps.Linter,
+		deprecations:      deps.Deprecations,
+		policies:          deps.Policies,
+		chartVersion:      deps.ChartVersion,
+		valuesSurface:     deps.ValuesSurface,
+		ignoreFilter:      deps.IgnoreFilter,
+		reports:           deps.Reports,
+		formatters:        deps.Formatters,
+		outputs:           deps.Outputs,
+		logger:            deps.Logger,
+		tracer:            deps.Tracer,
+		chartDir:          opts.ChartDir,
+		commentMode:       opts.CommentMode,
+		staleSeverity:     staleSeverity,
 		maxEnvConcurrency: defaultEnvConcurrency,
 		execCounter:       execCounter,
 		execDuration:      execDuration,
 		diffStatus:        diffStatus,
+		resourceChanges:   resourceChanges,
+		policyViolations:  policyViolations,
 	}
 }
 
@@ -157,15 +229,27 @@ func (s *DiffService) Execute(ctx context.Context, pr domain.PRContext) error {
 	if err := s.reporter.UpdateCheckWithResults(ctx, pr, checkRunID, allResults); err != nil {
 		s.logger.Error("failed to update check run", "checkRunID", checkRunID, "error", err)
 	}
+	s.writeOutputs(ctx, pr, allResults)
+
+	if s.commentMode == domain.CommentModeSummary {
+		if err := s.reporter.PostSummaryComment(ctx, pr, allResults); err != nil {
+			s.logger.Error("failed to post PR summary comment", "error", err)
+		}
+		return nil
+	}
 
-	// Post per-chart comment only for charts with changes
+	// Post per-chart comment only for charts with changes; a comment left by an
+	// earlier push for a chart that no longer changes is collapsed
 	for chartName, results := range chartResults {
-		if hasChanges(results) {
+		if slices.ContainsFunc(results, domain.DiffResult.HasFindings) {
 			if err := s.reporter.PostComment(ctx, pr, results); err != nil {
 				s.logger.Error("failed to post PR comment", "chart", chartName, "error", err)
 			}
-		} else {
-			s.logger.Info("no changes for chart, skipping comment", "chart", chartName)
+			continue
+		}
+		s.logger.Info("no changes for chart, skipping comment", "chart", chartName)
+		if err := s.reporter.CollapseComment(ctx, pr, chartName); err != nil {
+			s.logger.Error("failed to collapse PR comment", "chart", chartName, "error", err)
 		}
 	}
 
@@ -286,19 +370,7 @@ func (s *DiffService) processChart(
 			s.logger.Error("failed to fetch base chart", "chart", chartName, "error", err)
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "fetching base chart")
-			s.diffStatus.Add(ctx, 1, metric.WithAttributes(
-				attribute.String("chart", chartName),
-				attribute.String("environment", "all"),
-				attribute.String("status", domain.StatusError.String()),
-			))
-			return []domain.DiffResult{{
-				ChartName:   chartName,
-				Environment: "all",
-				BaseRef:     pr.BaseRef,
-				HeadRef:     pr.HeadRef,
-				Status:      domain.StatusError,
-				Summary:     fmt.Sprintf("❌ Error fetching base chart: %s", err),
-			}}
+			return s.chartErrorResult(ctx, pr, chartName, fmt.Sprintf("❌ Error fetching base chart: %s", err))
 		}
 	}
 	defer baseCleanup()
@@ -315,26 +387,49 @@ func (s *DiffService) processChart(
 		s.logger.Error("failed to fetch head chart", "chart", chartName, "error", err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "fetching head chart")
-		s.diffStatus.Add(ctx, 1, metric.WithAttributes(
-			attribute.String("chart", chartName),
-			attribute.String("environment", "all"),
-			attribute.String("status", domain.StatusError.String()),
-		))
-		return []domain.DiffResult{{
-			ChartName:   chartName,
-			Environment: "all",
-			BaseRef:     pr.BaseRef,
-			HeadRef:     pr.HeadRef,
-			Status:      domain.StatusError,
-			Summary:     fmt.Sprintf("❌ Error fetching head chart: %s", err),
-		}}
+		return s.chartErrorResult(ctx, pr, chartName, fmt.Sprintf("❌ Error fetching head chart: %s", err))
 	}
 	defer headCleanup()
 
+	// Check the version bump before dependencies are fetched into charts/
+	version := s.checkVersion(ctx, chartName, baseDir, headDir, baseExists)
+	valuesChanges := s.compareValues(ctx, chartName, baseDir, headDir, baseExists)
+
+	// Resolve dependencies once per checkout, before environments render concurrently
+	if err := s.resolveDependencies(ctx, chartPath, baseDir, headDir, baseExists); err != nil {
+		s.logger.Error("failed to resolve chart dependencies", "chart", chartName, "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "resolving dependencies")
+		return s.chartErrorResult(ctx, pr, chartName, fmt.Sprintf("❌ Error resolving chart dependencies: %s", err))
+	}
+
+	// Load policies once per chart. Chart policies come from the base ref, so a
+	// PR can't relax the checks on its own changes.
+	policies, err := s.loadPolicies(baseDir, baseExists)
+	if err != nil {
+		s.logger.Error("failed to load policies", "chart", chartName, "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "loading policies")
+		return s.chartErrorResult(ctx, pr, chartName, fmt.Sprintf("❌ Error loading policies: %s", err))
+	}
+
+	// Ignore rules come from the base ref too, so a PR can't hide its own changes
+	ignoreRules, err := s.loadIgnoreRules(chartPath, baseDir, baseExists)
+	if err != nil {
+		s.logger.Error("failed to load ignore rules", "chart", chartName, "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "loading ignore rules")
+		return s.chartErrorResult(ctx, pr, chartName, fmt.Sprintf("❌ Error loading ignore rules: %s", err))
+	}
+
 	// Use environments from config (not discovered)
 	envs := config.Environments
 	s.logger.Info("processing environments from config", "chart", chartName, "envCount", len(envs))
 
+	// Fetch repos referenced by $ref value files (Argo multi-source) once per chart
+	values, valuesCleanup := s.fetchValuesRepos(ctx, pr, chartPath, envs)
+	defer valuesCleanup()
+
 	// Diff each environment concurrently (bounded by maxEnvConcurrency)
 	results := make([]domain.DiffResult, len(envs))
 	sem := make(chan struct{}, s.maxEnvConcurrency)
@@ -373,7 +468,9 @@ func (s *DiffService) processChart(
 				"head", pr.HeadRef,
 			)
 
-			result, err := s.diffChartEnv(ctx, pr, chartName, baseDir, headDir, baseExists, env)
+			result, err := s.diffChartEnv(
+				ctx, pr, chartName, baseDir, headDir, baseExists, env, values, policies, ignoreRules, valuesChanges,
+			)
 			if err != nil {
 				s.logger.Error("diff failed",
 					"chart", chartName,
@@ -392,6 +489,7 @@ func (s *DiffService) processChart(
 					HeadRef:     pr.HeadRef,
 					Status:      domain.StatusError,
 					Summary:     err.Error(),
+					RenderError: result.RenderError,
 				}
 				return
 			}
@@ -409,15 +507,199 @@ func (s *DiffService) processChart(
 	}
 	wg.Wait()
 
+	for i := range results {
+		results[i].ChartPath = chartPath
+		results[i].ChartVersion = version
+		results[i].ValuesChanges = valuesChanges
+		s.storeReport(ctx, pr, &results[i])
+	}
 	return results
 }
 
+// storeReport stores the full diffs of a result with any, so reports that
+// cut them can link to them. A failure is logged rather than failing the
+// chart; the report then just has no link.
+func (s *DiffService) storeReport(ctx context.Context, pr domain.PRContext, result *domain.DiffResult) {
+	if s.reports == nil || (result.SemanticDiff == "" && result.UnifiedDiff == "") {
+		return
+	}
+	url, err := s.reports.StoreReport(ctx, pr, *result)
+	if err != nil {
+		s.logger.Warn("storing full diff report failed",
+			"chart", result.ChartName,
+			"env", result.Environment,
+			"error", err,
+		)
+		return
+	}
+	result.ReportURL = url
+}
+
+// writeOutputs writes the results of every chart in each machine-readable
+// format, as "results" with the format's extension. A failure is logged
+// rather than failing the PR; the GitHub reports don't depend on it.
+func (s *DiffService) writeOutputs(ctx context.Context, pr domain.PRContext, results []domain.DiffResult) {
+	if s.outputs == nil {
+		return
+	}
+	for _, f := range s.formatters {
+		content, err := f.FormatResults(pr, results)
+		if err == nil {
+			err = s.outputs.StoreOutput(ctx, pr, "results"+f.FileExtension(), content)
+		}
+		if err != nil {
+			s.logger.Warn("writing output failed", "format", f.Name(), "error", err)
+		}
+	}
+}
+
+// checkVersion checks the chart's version bump between the base and head
+// checkouts. Returns nil when no checker is configured, or when the check
+// can't run, which is logged rather than failing the chart.
+func (s *DiffService) checkVersion(
+	ctx context.Context,
+	chartName, baseDir, headDir string,
+	baseExists bool,
+) *domain.VersionCheck {
+	if s.chartVersion == nil {
+		return nil
+	}
+	if !baseExists {
+		baseDir = ""
+	}
+
+	check, err := s.chartVersion.CheckVersion(baseDir, headDir)
+	if err != nil {
+		s.logger.Warn("failed to check chart version", "chart", chartName, "error", err)
+		return nil
+	}
+	if check.Failed() {
+		s.logger.Info("chart version check failed", "chart", chartName, "problem", check.Problem)
+	}
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("chart.version_bump", check.Bump.String()),
+		attribute.Bool("chart.version_ok", !check.Failed()),
+	)
+	return &check
+}
+
+// compareValues returns the keys of the chart's values surface that head
+// removes, renames or changes the type of. Returns nil when no comparer is
+// configured, for a new chart, or when the comparison can't run, which is
+// logged rather than failing the chart.
+func (s *DiffService) compareValues(
+	ctx context.Context,
+	chartName, baseDir, headDir string,
+	baseExists bool,
+) []domain.ValuesChange {
+	if s.valuesSurface == nil || !baseExists {
+		return nil
+	}
+
+	changes, err := s.valuesSurface.CompareValues(baseDir, headDir)
+	if err != nil {
+		s.logger.Warn("failed to compare chart values", "chart", chartName, "error", err)
+		return nil
+	}
+	if len(changes) > 0 {
+		s.logger.Info("breaking values changes found", "chart", chartName, "count", len(changes))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("chart.values_changes", len(changes)))
+	return changes
+}
+
+// resolveDependencies fetches declared chart dependencies into the base and
+// head checkouts. No-op when no resolver is configured.
+func (s *DiffService) resolveDependencies(
+	ctx context.Context,
+	chartPath, baseDir, headDir string,
+	baseExists bool,
+) error {
+	if s.depResolver == nil {
+		return nil
+	}
+
+	ctx, span := s.tracer.Start(ctx, "resolveDependencies")
+	defer span.End()
+
+	if baseExists {
+		if err := s.depResolver.ResolveDependencies(ctx, baseDir, checkoutRoot(baseDir, chartPath)); err != nil {
+			return fmt.Errorf("base: %w", err)
+		}
+	}
+	if err := s.depResolver.ResolveDependencies(ctx, headDir, checkoutRoot(headDir, chartPath)); err != nil {
+		return fmt.Errorf("head: %w", err)
+	}
+	return nil
+}
+
+// checkoutRoot returns the root of the repository checkout holding the chart
+// at chartPath in chartDir.
+func checkoutRoot(chartDir, chartPath string) string {
+	root := strings.TrimSuffix(filepath.Clean(chartDir), filepath.Clean(filepath.FromSlash(chartPath)))
+	return filepath.Clean(root)
+}
+
+// loadPolicies returns the policies for a chart: those the policy port is
+// configured with plus the chart's own, from its base checkout. Returns no
+// policies when no policy port is configured.
+func (s *DiffService) loadPolicies(baseDir string, baseExists bool) ([]domain.Policy, error) {
+	if s.policies == nil {
+		return nil, nil
+	}
+	dir := ""
+	if baseExists {
+		dir = filepath.Join(baseDir, ChartPolicyDir)
+	}
+	return s.policies.LoadPolicies(dir)
+}
+
+// loadIgnoreRules returns the ignore rules for a chart: those the filter is
+// configured with plus the target repo's, from the base checkout. Returns no
+// rules when no filter is configured.
+func (s *DiffService) loadIgnoreRules(chartPath, baseDir string, baseExists bool) ([]domain.IgnoreRule, error) {
+	if s.ignoreFilter == nil {
+		return nil, nil
+	}
+	repoDir := ""
+	if baseExists {
+		repoDir = checkoutRoot(baseDir, chartPath)
+	}
+	return s.ignoreFilter.LoadRules(repoDir)
+}
+
+// chartErrorResult records and returns a single error result covering all
+// environments of a chart, for failures that happen before per-env diffing.
+func (s *DiffService) chartErrorResult(
+	ctx context.Context,
+	pr domain.PRContext,
+	chartName, summary string,
+) []domain.DiffResult {
+	s.diffStatus.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("chart", chartName),
+		attribute.String("environment", "all"),
+		attribute.String("status", domain.StatusError.String()),
+	))
+	return []domain.DiffResult{{
+		ChartName:   chartName,
+		Environment: "all",
+		BaseRef:     pr.BaseRef,
+		HeadRef:     pr.HeadRef,
+		Status:      domain.StatusError,
+		Summary:     summary,
+	}}
+}
+
 func (s *DiffService) diffChartEnv(
 	ctx context.Context,
 	pr domain.PRContext,
 	chartName, baseDir, headDir string,
 	baseExists bool,
 	env domain.EnvironmentConfig,
+	values *valuesRepos,
+	policies []domain.Policy,
+	ignoreRules []domain.IgnoreRule,
+	valuesChanges []domain.ValuesChange,
 ) (domain.DiffResult, error) {
 	ctx, span := s.tracer.Start(ctx, "diffChartEnv",
 		trace.WithAttributes(
@@ -431,6 +713,12 @@ func (s *DiffService) diffChartEnv(
 	var err error
 
 	if baseExists {
+		baseValueFiles, err := values.valueFiles(baseDir, env)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "resolving base value files")
+			return domain.DiffResult{}, fmt.Errorf("failed to resolve base value files: %w", err)
+		}
 		s.logger.Info(
 			"rendering base manifest",
 			"chart",
@@ -440,9 +728,9 @@ func (s *DiffService) diffChartEnv(
 			"baseDir",
 			baseDir,
 			"valueFiles",
-			env.ValueFiles,
+			baseValueFiles,
 		)
-		baseManifest, err = s.renderer.Render(ctx, baseDir, env.ValueFiles)
+		baseManifest, err = s.renderer.Render(ctx, baseDir, baseValueFiles, env.RenderOptions)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "rendering base")
@@ -461,6 +749,12 @@ func (s *DiffService) diffChartEnv(
 		s.logger.Info("skipping base render (chart not in base)", "chart", chartName, "env", env.Name)
 	}
 
+	headValueFiles, err := values.valueFiles(headDir, env)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "resolving head value files")
+		return domain.DiffResult{}, fmt.Errorf("failed to resolve PR value files: %w", err)
+	}
 	s.logger.Info(
 		"rendering head manifest",
 		"chart",
@@ -470,13 +764,28 @@ func (s *DiffService) diffChartEnv(
 		"headDir",
 		headDir,
 		"valueFiles",
-		env.ValueFiles,
+		headValueFiles,
 	)
-	headManifest, err := s.renderer.Render(ctx, headDir, env.ValueFiles)
+	// Lint before rendering: when values break the chart, the findings say
+	// which file and key is at fault, which a failed render can't
+	lintFindings := s.lint(chartName, headDir, headValueFiles, env)
+	lintErrors, lintWarnings := domain.CountLintFindings(lintFindings)
+	staleOverrides := s.findStaleOverrides(chartName, headDir, headValueFiles, env, valuesChanges)
+	unusedValues := s.findUnusedValues(chartName, headDir, headValueFiles, env)
+	headManifest, err := s.renderer.Render(ctx, headDir, headValueFiles, env.RenderOptions)
+	if err != nil && lintErrors > 0 {
+		s.logger.Info("head render failed with lint errors", "chart", chartName, "env", env.Name, "error", err)
+		result := s.lintFailedResult(ctx, span, pr, chartName, env.Name, lintFindings)
+		result.StaleOverrides = staleOverrides
+		result.UnusedValues = unusedValues
+		return result, nil
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "rendering head")
-		return domain.DiffResult{}, fmt.Errorf("failed to render PR changes: %w", err)
+		// The failed result points reporters at the template, if Helm named one
+		failed := domain.DiffResult{RenderError: renderErrorOf(err)}
+		return failed, fmt.Errorf("failed to render PR changes: %w", err)
 	}
 	s.logger.Info(
 		"head manifest rendered",
@@ -488,11 +797,53 @@ func (s *DiffService) diffChartEnv(
 		len(headManifest),
 	)
 
-	s.logger.Info("computing diffs", "chart", chartName, "env", env.Name)
+	// Mask secrets before anything is compared, so they can't leak into diffs or reports
+	if s.redactor != nil {
+		baseManifest = s.redactor.Redact(baseManifest)
+		headManifest = s.redactor.Redact(headManifest)
+	}
+
+	// Validate the head manifest before any fields are stripped. Diffs are still
+	// computed for invalid manifests, so reviewers see what caused the violations.
+	var violations []domain.SchemaViolation
+	if s.validator != nil {
+		violations = s.validator.Validate(headManifest, env.Name, env.RenderOptions.KubeVersion)
+		if len(violations) > 0 {
+			s.logger.Info("schema violations found",
+				"chart", chartName,
+				"env", env.Name,
+				"count", len(violations),
+			)
+		}
+	}
+
+	deprecations := s.checkDeprecations(baseManifest, headManifest, env)
+	if len(deprecations) > 0 {
+		s.logger.Info("deprecated API versions found",
+			"chart", chartName,
+			"env", env.Name,
+			"count", len(deprecations),
+		)
+	}
+
+	// Policies are checked against the same complete head manifest
+	var policyViolations []domain.PolicyViolation
+	if s.policies != nil && len(policies) > 0 {
+		policyViolations = s.policies.Evaluate(policies, headManifest, env.Name)
+		s.recordPolicyViolations(ctx, chartName, env.Name, policyViolations)
+	}
+
+	// Then strip ignored fields so that noise never reaches either diff
+	suppressed := 0
+	if s.ignoreFilter != nil && len(ignoreRules) > 0 {
+		baseManifest, headManifest, suppressed = s.ignoreFilter.Filter(ignoreRules, baseManifest, headManifest)
+	}
+
+	s.logger.Info("computing diffs", "chart", chartName, "env", env.Name, "suppressed", suppressed)
 	baseName := domain.DiffLabel(chartName, env.Name, pr.BaseRef)
 	headName := domain.DiffLabel(chartName, env.Name, pr.HeadRef)
 
-	// Compute semantic diff (dyff) - may be empty if dyff not available
+	// Compute semantic diff - may be empty if the manifests do not parse
 	semanticDiff := s.semanticDiff.ComputeDiff(baseName, headName, baseManifest, headManifest)
 	s.logger.Info(
 		"semantic diff computed",
@@ -501,7 +852,7 @@ func (s *DiffService) diffChartEnv(
 		"env",
 		env.Name,
 		"size",
-		len(semanticDiff),
+		len(semanticDiff.Text),
 	)
 
 	// Always compute unified diff as fallback
@@ -513,26 +864,87 @@ func (s *DiffService) diffChartEnv(
 		"env",
 		env.Name,
 		"size",
-		len(unifiedDiff),
+		len(unifiedDiff.Text),
 	)
 
-	var status domain.Status
-	var summary string
+	// Prefer the semantic diff's resources, which carry per-field changes
+	resources := unifiedDiff.Resources
+	if semanticDiff.Text != "" {
+		resources = semanticDiff.Resources
+	}
 
-	if unifiedDiff != "" || semanticDiff != "" {
-		status = domain.StatusChanges
-		summary = fmt.Sprintf("Changes detected in %s for environment %s.", chartName, env.Name)
-	} else {
-		status = domain.StatusSuccess
-		summary = noChangesMessage
+	// Risks always come from the semantic diff, unless it couldn't parse the
+	// manifests. The line diff's resources have no field changes, so risks
+	// that depend on fields are then reported as unassessed.
+	riskResources := semanticDiff.Resources
+	if semanticDiff.Unparsed {
+		riskResources = unifiedDiff.Resources
+	}
+	risks := domain.ClassifyRisks(riskResources)
+	if len(risks) > 0 {
+		s.logger.Info("risky changes found",
+			"chart", chartName,
+			"env", env.Name,
+			"count", len(risks),
+			"highest", domain.HighestRisk(risks).String(),
+		)
 	}
 
-	span.SetAttributes(attribute.String("diff.status", status.String()))
+	status, summary := summarize(chartName, env.Name, unifiedDiff.Text != "" || semanticDiff.Text != "", resources)
+	if len(violations) > 0 {
+		status = domain.StatusInvalid
+		summary = fmt.Sprintf("%d schema violation(s) in %s for environment %s.", len(violations), chartName, env.Name)
+	}
+	if lintErrors > 0 && status != domain.StatusInvalid {
+		status = domain.StatusInvalid
+		summary = fmt.Sprintf("Lint failed for %s in environment %s.", chartName, env.Name)
+	}
+	if suppressed > 0 {
+		summary += fmt.Sprintf(" %d change(s) suppressed by ignore rules.", suppressed)
+	}
+	if highest := domain.HighestRisk(risks); highest > domain.RiskLow {
+		summary += fmt.Sprintf(" Highest risk: %s.", highest)
+	}
+	if removed, deprecated := domain.CountDeprecations(deprecations); removed+deprecated > 0 {
+		summary += fmt.Sprintf(" API versions: %d removed, %d deprecated.", removed, deprecated)
+	}
+	if failed, warnings := domain.CountPolicyViolations(policyViolations); failed+warnings > 0 {
+		summary += fmt.Sprintf(" Policies: %d failed, %d warning(s).", failed, warnings)
+	}
+	if lintErrors+lintWarnings > 0 {
+		summary += fmt.Sprintf(" Lint: %d error(s), %d warning(s).", lintErrors, lintWarnings)
+	}
+	if len(staleOverrides) > 0 {
+		summary += fmt.Sprintf(" %d override(s) of removed values keys.", len(staleOverrides))
+	}
+	if len(unusedValues) > 0 {
+		summary += fmt.Sprintf(" %d unused values key(s).", len(unusedValues))
+	}
+
+	span.SetAttributes(
+		attribute.String("diff.status", status.String()),
+		attribute.Int("diff.suppressed", suppressed),
+		attribute.Int("diff.violations", len(violations)),
+		attribute.Int("diff.lint_findings", len(lintFindings)),
+		attribute.Int("diff.stale_overrides", len(staleOverrides)),
+		attribute.Int("diff.unused_values", len(unusedValues)),
+		attribute.Int("diff.deprecations", len(deprecations)),
+		attribute.String("diff.risk", domain.HighestRisk(risks).String()),
+		attribute.Int("policy.violations", len(policyViolations)),
+	)
 	s.diffStatus.Add(ctx, 1, metric.WithAttributes(
 		attribute.String("chart", chartName),
 		attribute.String("environment", env.Name),
 		attribute.String("status", status.String()),
 	))
+	for _, rc := range resources {
+		s.resourceChanges.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("chart", chartName),
+			attribute.String("environment", env.Name),
+			attribute.String("kind", rc.Kind),
+			attribute.String("change", string(rc.Type)),
+		))
+	}
 
 	return domain.DiffResult{
 		ChartName:    chartName,
@@ -540,20 +952,175 @@ func (s *DiffService) diffChartEnv(
 		BaseRef:      pr.BaseRef,
 		HeadRef:      pr.HeadRef,
 		Status:       status,
-		UnifiedDiff:  unifiedDiff,
-		SemanticDiff: semanticDiff,
+		UnifiedDiff:  unifiedDiff.Text,
+		SemanticDiff: semanticDiff.Text,
 		Summary:      summary,
+		Resources:    resources,
+		Suppressed:   suppressed,
+		Violations:   violations,
+
+		LintFindings:     lintFindings,
+		StaleOverrides:   staleOverrides,
+		UnusedValues:     unusedValues,
+		PolicyViolations: policyViolations,
+		Deprecations:     deprecations,
+		Risks:            risks,
 	}, nil
 }
 
-// hasChanges returns true if any result has changes or errors.
-func hasChanges(results []domain.DiffResult) bool {
-	for _, r := range results {
-		if r.Status == domain.StatusChanges || r.Status == domain.StatusError {
-			return true
-		}
+// lint returns the lint findings for the head chart with env's values.
+func (s *DiffService) lint(
+	chartName, headDir string,
+	headValueFiles []string,
+	env domain.EnvironmentConfig,
+) []domain.LintFinding {
+	if s.linter == nil {
+		return nil
+	}
+	findings := s.linter.Lint(headDir, headValueFiles, env.RenderOptions)
+	if len(findings) > 0 {
+		s.logger.Info("lint findings", "chart", chartName, "env", env.Name, "count", len(findings))
+	}
+	return findings
+}
+
+// findStaleOverrides returns the removed and renamed values keys that env's
+// head value files, inline values or parameters still set, as warnings unless
+// the service fails on them.
+func (s *DiffService) findStaleOverrides(
+	chartName, headDir string,
+	headValueFiles []string,
+	env domain.EnvironmentConfig,
+	valuesChanges []domain.ValuesChange,
+) []domain.StaleOverride {
+	if s.valuesSurface == nil || len(valuesChanges) == 0 {
+		return nil
+	}
+	stale := s.valuesSurface.FindStaleOverrides(headDir, headValueFiles, env.RenderOptions, valuesChanges)
+	for i := range stale {
+		stale[i].Severity = s.staleSeverity
+	}
+	if len(stale) > 0 {
+		s.logger.Info("stale values overrides found", "chart", chartName, "env", env.Name, "count", len(stale))
+	}
+	return stale
+}
+
+// findUnusedValues returns the keys env's head value files and inline values
+// set that the head chart neither declares nor reads.
+func (s *DiffService) findUnusedValues(
+	chartName, headDir string,
+	headValueFiles []string,
+	env domain.EnvironmentConfig,
+) []domain.UnusedValue {
+	if s.valuesSurface == nil {
+		return nil
+	}
+	unused := s.valuesSurface.FindUnusedValues(headDir, headValueFiles, env.RenderOptions)
+	if len(unused) > 0 {
+		s.logger.Info("unused values found", "chart", chartName, "env", env.Name, "count", len(unused))
+	}
+	return unused
+}
+
+// lintFailedResult reports a head chart that doesn't render by its lint
+// findings, which point at the files and keys to fix, instead of Helm's error.
+func (s *DiffService) lintFailedResult(
+	ctx context.Context,
+	span trace.Span,
+	pr domain.PRContext,
+	chartName, envName string,
+	findings []domain.LintFinding,
+) domain.DiffResult {
+	lintErrors, lintWarnings := domain.CountLintFindings(findings)
+	span.SetAttributes(
+		attribute.String("diff.status", domain.StatusInvalid.String()),
+		attribute.Int("diff.lint_findings", len(findings)),
+	)
+	s.diffStatus.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("chart", chartName),
+		attribute.String("environment", envName),
+		attribute.String("status", domain.StatusInvalid.String()),
+	))
+	return domain.DiffResult{
+		ChartName:   chartName,
+		Environment: envName,
+		BaseRef:     pr.BaseRef,
+		HeadRef:     pr.HeadRef,
+		Status:      domain.StatusInvalid,
+		Summary: fmt.Sprintf("Lint failed for %s in environment %s; the chart can't be rendered. "+
+			"Lint: %d error(s), %d warning(s).", chartName, envName, lintErrors, lintWarnings),
+		LintFindings: findings,
+	}
+}
+
+// checkDeprecations returns the deprecated API versions in the head manifest,
+// marking those the base manifest doesn't use as introduced by the PR.
+func (s *DiffService) checkDeprecations(
+	baseManifest, headManifest []byte,
+	env domain.EnvironmentConfig,
+) []domain.APIDeprecation {
+	if s.deprecations == nil {
+		return nil
+	}
+	head := s.deprecations.Check(headManifest, env.Name, env.RenderOptions.KubeVersion)
+	if len(head) == 0 {
+		return nil
+	}
+	var base []domain.APIDeprecation
+	if len(baseManifest) > 0 {
+		base = s.deprecations.Check(baseManifest, env.Name, env.RenderOptions.KubeVersion)
+	}
+	domain.MarkIntroduced(head, base)
+	return head
+}
+
+// recordPolicyViolations logs and counts the policy violations of a head render.
+func (s *DiffService) recordPolicyViolations(
+	ctx context.Context,
+	chartName, envName string,
+	violations []domain.PolicyViolation,
+) {
+	if len(violations) == 0 {
+		return
+	}
+	s.logger.Info("policy violations found", "chart", chartName, "env", envName, "count", len(violations))
+	for _, v := range violations {
+		s.policyViolations.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("chart", chartName),
+			attribute.String("environment", envName),
+			attribute.String("policy", v.Policy),
+			attribute.String("severity", string(v.Severity)),
+		))
+	}
+}
+
+// renderErrorOf returns where a failed render went wrong: the renderer's
+// *domain.RenderError, else the template and line parsed from Helm's output,
+// or nil if neither names one.
+func renderErrorOf(err error) *domain.RenderError {
+	var renderErr *domain.RenderError
+	if errors.As(err, &renderErr) {
+		return renderErr
+	}
+	return domain.ParseRenderError(err.Error())
+}
+
+// summarize returns the status and summary of a diff. Changes that were all
+// suppressed by ignore rules leave no diff, so they count as no changes.
+func summarize(
+	chartName, envName string,
+	hasDiff bool,
+	resources []domain.ResourceChange,
+) (domain.Status, string) {
+	if !hasDiff {
+		return domain.StatusSuccess, noChangesMessage
+	}
+	summary := fmt.Sprintf("Changes detected in %s for environment %s.", chartName, envName)
+	if counts := domain.SummarizeResourceChanges(resources); counts != "" {
+		summary = fmt.Sprintf("Changes detected in %s for environment %s: %s.", chartName, envName, counts)
 	}
-	return false
+	return domain.StatusChanges, summary
 }
 
 // extractChartNameFromPath extracts the chart name from a path.
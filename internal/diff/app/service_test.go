@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -13,6 +17,7 @@ import (
 	nooptrace "go.opentelemetry.io/otel/trace/noop"
 
 	"github.com/nathantilsley/chart-val/internal/diff/domain"
+	"github.com/nathantilsley/chart-val/internal/diff/ports"
 	"github.com/nathantilsley/chart-val/internal/platform/logger"
 )
 
@@ -79,9 +84,27 @@ func (m *mockEnvConfig) GetEnvironmentConfig(
 type mockRenderer struct {
 	manifests map[string]string // chartDir -> rendered manifest
 	errors    map[string]error  // chartDir -> error
+
+	mu    sync.Mutex
+	calls []renderCall // arguments received, in call order
+}
+
+type renderCall struct {
+	chartDir   string
+	valueFiles []string
+	opts       domain.RenderOptions
 }
 
-func (m *mockRenderer) Render(_ context.Context, chartDir string, _ []string) ([]byte, error) {
+func (m *mockRenderer) Render(
+	_ context.Context,
+	chartDir string,
+	valueFiles []string,
+	opts domain.RenderOptions,
+) ([]byte, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, renderCall{chartDir: chartDir, valueFiles: valueFiles, opts: opts})
+	m.mu.Unlock()
+
 	if m.errors != nil {
 		if err, ok := m.errors[chartDir]; ok {
 			return nil, err
@@ -95,10 +118,22 @@ func (m *mockRenderer) Render(_ context.Context, chartDir string, _ []string) ([
 	return []byte("dummy manifest"), nil
 }
 
+type mockDepResolver struct {
+	resolved []string         // chartDirs resolved, in call order
+	errors   map[string]error // chartDir -> error
+}
+
+func (m *mockDepResolver) ResolveDependencies(_ context.Context, chartDir, _ string) error {
+	m.resolved = append(m.resolved, chartDir)
+	return m.errors[chartDir]
+}
+
 type mockReporter struct {
 	results        []domain.DiffResult
 	checkRunID     int64
 	commentCount   int
+	collapsed      []string // charts whose comments were collapsed
+	summaries      [][]domain.DiffResult
 	createCheckErr error
 	updateCheckErr error
 	postCommentErr error
@@ -134,19 +169,34 @@ func (m *mockReporter) PostComment(
 	return nil
 }
 
-type mockDiff struct{}
+func (m *mockReporter) CollapseComment(_ context.Context, _ domain.PRContext, chartName string) error {
+	m.collapsed = append(m.collapsed, chartName)
+	return nil
+}
+
+func (m *mockReporter) PostSummaryComment(_ context.Context, _ domain.PRContext, results []domain.DiffResult) error {
+	m.summaries = append(m.summaries, results)
+	return nil
+}
+
+type mockDiff struct {
+	resources []domain.ResourceChange // returned when base and head differ
+}
 
-func (m *mockDiff) ComputeDiff(baseName, headName string, base, head []byte) string {
+func (m *mockDiff) ComputeDiff(baseName, headName string, base, head []byte) domain.ManifestDiff {
 	if string(base) != string(head) {
-		return fmt.Sprintf(
-			"--- %s\n+++ %s\n@@ -1 +1 @@\n-%s\n+%s",
-			baseName,
-			headName,
-			string(base),
-			string(head),
-		)
+		return domain.ManifestDiff{
+			Text: fmt.Sprintf(
+				"--- %s\n+++ %s\n@@ -1 +1 @@\n-%s\n+%s",
+				baseName,
+				headName,
+				string(base),
+				string(head),
+			),
+			Resources: m.resources,
+		}
 	}
-	return ""
+	return domain.ManifestDiff{}
 }
 
 func TestService_NoChartChanges(t *testing.T) {
@@ -159,13 +209,18 @@ func TestService_NoChartChanges(t *testing.T) {
 	unifiedDiff := &mockDiff{}
 	log := logger.New("error")
 
-	svc := NewDiffService(
-		srcCtrl, changedCharts, nil, envConfig, renderer, reporter,
-		semanticDiff, unifiedDiff, log,
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+	svc := NewDiffService(Deps{
+		SourceControl: srcCtrl,
+		ChangedCharts: changedCharts,
+		FSEnvConfig:   envConfig,
+		Renderer:      renderer,
+		Reporter:      reporter,
+		SemanticDiff:  semanticDiff,
+		UnifiedDiff:   unifiedDiff,
+		Logger:        log,
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner:    "test-owner",
@@ -218,13 +273,18 @@ func TestService_NewChartNotInBase(t *testing.T) {
 	unifiedDiff := &mockDiff{}
 	log := logger.New("error")
 
-	svc := NewDiffService(
-		srcCtrl, changedCharts, nil, envConfig, renderer, reporter,
-		semanticDiff, unifiedDiff, log,
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+	svc := NewDiffService(Deps{
+		SourceControl: srcCtrl,
+		ChangedCharts: changedCharts,
+		FSEnvConfig:   envConfig,
+		Renderer:      renderer,
+		Reporter:      reporter,
+		SemanticDiff:  semanticDiff,
+		UnifiedDiff:   unifiedDiff,
+		Logger:        log,
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner:    "test-owner",
@@ -307,13 +367,18 @@ func TestService_ThreeChartsOneChanged(t *testing.T) {
 	unifiedDiff := &mockDiff{}
 	log := logger.New("error")
 
-	svc := NewDiffService(
-		srcCtrl, changedCharts, nil, envConfig, renderer, reporter,
-		semanticDiff, unifiedDiff, log,
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+	svc := NewDiffService(Deps{
+		SourceControl: srcCtrl,
+		ChangedCharts: changedCharts,
+		FSEnvConfig:   envConfig,
+		Renderer:      renderer,
+		Reporter:      reporter,
+		SemanticDiff:  semanticDiff,
+		UnifiedDiff:   unifiedDiff,
+		Logger:        log,
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner:    "test-owner",
@@ -344,6 +409,11 @@ func TestService_ThreeChartsOneChanged(t *testing.T) {
 	if reporter.commentCount != 1 {
 		t.Errorf("expected 1 comment (only for changed chart), got %d", reporter.commentCount)
 	}
+	// Comments from earlier pushes for app-b and app-c are collapsed
+	slices.Sort(reporter.collapsed)
+	if !slices.Equal(reporter.collapsed, []string{"app-b", "app-c"}) {
+		t.Errorf("expected comments of app-b and app-c to be collapsed, got %v", reporter.collapsed)
+	}
 
 	// Verify which charts have changes
 	changesCount := 0
@@ -369,6 +439,24 @@ func TestService_ThreeChartsOneChanged(t *testing.T) {
 	t.Logf("✓ 3 charts, 1 changed: 1 check run, 1 comment, 2 silent")
 }
 
+func TestCheckoutRoot(t *testing.T) {
+	tests := []struct {
+		chartDir  string
+		chartPath string
+		want      string
+	}{
+		{chartDir: "/tmp/x/repo-abc/charts/my-app", chartPath: "charts/my-app", want: "/tmp/x/repo-abc"},
+		{chartDir: "/tmp/x/repo-abc/charts/my-app/", chartPath: "charts/my-app/", want: "/tmp/x/repo-abc"},
+		{chartDir: "/tmp/x/repo-abc/deploy/helm/api", chartPath: "deploy/helm/api", want: "/tmp/x/repo-abc"},
+	}
+
+	for _, tt := range tests {
+		if got := checkoutRoot(filepath.FromSlash(tt.chartDir), tt.chartPath); got != filepath.FromSlash(tt.want) {
+			t.Errorf("checkoutRoot(%q, %q) = %q, want %q", tt.chartDir, tt.chartPath, got, tt.want)
+		}
+	}
+}
+
 func TestExtractChartNames(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -471,14 +559,18 @@ func TestExtractChartNames(t *testing.T) {
 // --- Execute() error path tests ---
 
 func TestExecute_GetChangedChartsError(t *testing.T) {
-	svc := NewDiffService(
-		&mockSourceControl{}, &mockChangedCharts{err: errors.New("API failure")},
-		nil, &mockEnvConfig{}, &mockRenderer{}, &mockReporter{},
-		&mockDiff{}, &mockDiff{}, logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{},
+		ChangedCharts: &mockChangedCharts{err: errors.New("API failure")},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer:      &mockRenderer{},
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -495,23 +587,20 @@ func TestExecute_GetChangedChartsError(t *testing.T) {
 }
 
 func TestExecute_CreateInProgressCheckError(t *testing.T) {
-	svc := NewDiffService(
-		&mockSourceControl{},
-		&mockChangedCharts{
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{},
+		ChangedCharts: &mockChangedCharts{
 			charts: []domain.ChangedChart{{Name: "my-chart", Path: "charts/my-chart"}},
 		},
-		nil,
-		&mockEnvConfig{},
-		&mockRenderer{},
-		&mockReporter{createCheckErr: errors.New("GitHub 500")},
-		&mockDiff{},
-		&mockDiff{},
-		logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts",
-		"chart_val",
-	)
+		FSEnvConfig:  &mockEnvConfig{},
+		Renderer:     &mockRenderer{},
+		Reporter:     &mockReporter{createCheckErr: errors.New("GitHub 500")},
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -529,23 +618,20 @@ func TestExecute_CreateInProgressCheckError(t *testing.T) {
 
 func TestExecute_GetChartConfigError(t *testing.T) {
 	reporter := &mockReporter{}
-	svc := NewDiffService(
-		&mockSourceControl{},
-		&mockChangedCharts{
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{},
+		ChangedCharts: &mockChangedCharts{
 			charts: []domain.ChangedChart{{Name: "my-chart", Path: "charts/my-chart"}},
 		},
-		nil,
-		&mockEnvConfig{errors: map[string]error{"my-chart": errors.New("config fail")}},
-		&mockRenderer{},
-		reporter,
-		&mockDiff{},
-		&mockDiff{},
-		logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts",
-		"chart_val",
-	)
+		FSEnvConfig:  &mockEnvConfig{errors: map[string]error{"my-chart": errors.New("config fail")}},
+		Renderer:     &mockRenderer{},
+		Reporter:     reporter,
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -563,31 +649,28 @@ func TestExecute_GetChartConfigError(t *testing.T) {
 
 func TestExecute_UpdateCheckError(t *testing.T) {
 	reporter := &mockReporter{updateCheckErr: errors.New("GH error")}
-	svc := NewDiffService(
-		&mockSourceControl{charts: map[string]bool{
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
 			"main:charts/my-chart": true,
 			"feat:charts/my-chart": true,
 		}},
-		&mockChangedCharts{
+		ChangedCharts: &mockChangedCharts{
 			charts: []domain.ChangedChart{{Name: "my-chart", Path: "charts/my-chart"}},
 		},
-		nil,
-		&mockEnvConfig{config: domain.ChartConfig{
+		FSEnvConfig: &mockEnvConfig{config: domain.ChartConfig{
 			Path: "charts/my-chart",
 			Environments: []domain.EnvironmentConfig{
 				{Name: "prod", ValueFiles: []string{"env/prod.yaml"}},
 			},
 		}},
-		&mockRenderer{},
-		reporter,
-		&mockDiff{},
-		&mockDiff{},
-		logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts",
-		"chart_val",
-	)
+		Renderer:     &mockRenderer{},
+		Reporter:     reporter,
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -602,34 +685,31 @@ func TestExecute_UpdateCheckError(t *testing.T) {
 
 func TestExecute_PostCommentError(t *testing.T) {
 	reporter := &mockReporter{postCommentErr: errors.New("rate limited")}
-	svc := NewDiffService(
-		&mockSourceControl{charts: map[string]bool{
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
 			"main:charts/my-chart": true,
 			"feat:charts/my-chart": true,
 		}},
-		&mockChangedCharts{
+		ChangedCharts: &mockChangedCharts{
 			charts: []domain.ChangedChart{{Name: "my-chart", Path: "charts/my-chart"}},
 		},
-		nil,
-		&mockEnvConfig{config: domain.ChartConfig{
+		FSEnvConfig: &mockEnvConfig{config: domain.ChartConfig{
 			Path: "charts/my-chart",
 			Environments: []domain.EnvironmentConfig{
 				{Name: "prod", ValueFiles: []string{"env/prod.yaml"}},
 			},
 		}},
-		&mockRenderer{manifests: map[string]string{
+		Renderer: &mockRenderer{manifests: map[string]string{
 			"main:charts/my-chart": "replicas: 1",
 			"feat:charts/my-chart": "replicas: 3",
 		}},
-		reporter,
-		&mockDiff{},
-		&mockDiff{},
-		logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts",
-		"chart_val",
-	)
+		Reporter:     reporter,
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -645,6 +725,121 @@ func TestExecute_PostCommentError(t *testing.T) {
 	}
 }
 
+func TestExecute_SummaryComment(t *testing.T) {
+	reporter := &mockReporter{}
+	envs := []domain.EnvironmentConfig{{Name: "prod", ValueFiles: []string{"env/prod-values.yaml"}}}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/app-a": true,
+			"feat:charts/app-a": true,
+			"main:charts/app-b": true,
+			"feat:charts/app-b": true,
+		}},
+		ChangedCharts: &mockChangedCharts{charts: []domain.ChangedChart{
+			{Name: "app-a", Path: "charts/app-a"},
+			{Name: "app-b", Path: "charts/app-b"},
+		}},
+		FSEnvConfig: &mockEnvConfig{configs: map[string]domain.ChartConfig{
+			"app-a": {Path: "charts/app-a", Environments: envs},
+			"app-b": {Path: "charts/app-b", Environments: envs},
+		}},
+		Renderer: &mockRenderer{manifests: map[string]string{
+			"main:charts/app-a": "replicas: 1",
+			"feat:charts/app-a": "replicas: 3",
+		}},
+		Reporter:     reporter,
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModeSummary, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feat", HeadSHA: "abc",
+	}
+
+	if err := svc.Execute(context.Background(), pr); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// One summary comment with the results of both charts, and no per-chart comments
+	if len(reporter.summaries) != 1 || len(reporter.summaries[0]) != 2 {
+		t.Fatalf("expected 1 summary comment with 2 results, got %d summaries", len(reporter.summaries))
+	}
+	if reporter.commentCount != 0 || len(reporter.collapsed) != 0 {
+		t.Errorf("expected no per-chart comments, got %d posted and %v collapsed",
+			reporter.commentCount, reporter.collapsed)
+	}
+}
+
+type mockFormatter struct {
+	name string
+	err  error
+}
+
+func (m *mockFormatter) Name() string          { return m.name }
+func (m *mockFormatter) FileExtension() string { return "." + m.name }
+
+func (m *mockFormatter) FormatResults(_ domain.PRContext, results []domain.DiffResult) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return fmt.Appendf(nil, "%s: %d results", m.name, len(results)), nil
+}
+
+type mockOutputStore struct {
+	outputs map[string]string // Output name to content
+}
+
+func (m *mockOutputStore) StoreOutput(_ context.Context, _ domain.PRContext, name string, content []byte) error {
+	m.outputs[name] = string(content)
+	return nil
+}
+
+func TestExecute_WriteOutputs(t *testing.T) {
+	outputs := &mockOutputStore{outputs: make(map[string]string)}
+	formatters := []ports.ReportFormatterPort{
+		&mockFormatter{name: "broken", err: errors.New("unsupported")},
+		&mockFormatter{name: "json"},
+	}
+	envs := []domain.EnvironmentConfig{{Name: "dev"}, {Name: "prod"}}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{"main:charts/app": true, "feat:charts/app": true}},
+		ChangedCharts: &mockChangedCharts{charts: []domain.ChangedChart{{Name: "app", Path: "charts/app"}}},
+		FSEnvConfig: &mockEnvConfig{configs: map[string]domain.ChartConfig{
+			"app": {Path: "charts/app", Environments: envs},
+		}},
+		Renderer: &mockRenderer{manifests: map[string]string{
+			"main:charts/app": "replicas: 1",
+			"feat:charts/app": "replicas: 3",
+		}},
+		Reporter:     &mockReporter{},
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Formatters:   formatters,
+		Outputs:      outputs,
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feat", HeadSHA: "abc",
+	}
+	if err := svc.Execute(context.Background(), pr); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// A failing format is skipped; the others are still written
+	want := map[string]string{"results.json": "json: 2 results"}
+	if !reflect.DeepEqual(outputs.outputs, want) {
+		t.Errorf("outputs = %v, want %v", outputs.outputs, want)
+	}
+}
+
 // --- getChartConfig() path tests ---
 
 func TestGetChartConfig_ArgoSuccess(t *testing.T) {
@@ -655,16 +850,19 @@ func TestGetChartConfig_ArgoSuccess(t *testing.T) {
 			{Name: "prod", ValueFiles: []string{"env/prod.yaml"}},
 		},
 	}
-	svc := NewDiffService(
-		&mockSourceControl{}, &mockChangedCharts{},
-		&mockEnvConfig{config: argoConfig}, // argoEnvConfig
-		&mockEnvConfig{},                   // fsEnvConfig (should not be reached)
-		&mockRenderer{}, &mockReporter{},
-		&mockDiff{}, &mockDiff{}, logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{},
+		ChangedCharts: &mockChangedCharts{},
+		ArgoEnvConfig: &mockEnvConfig{config: argoConfig}, // argoEnvConfig
+		FSEnvConfig:   &mockEnvConfig{},                   // fsEnvConfig (should not be reached)
+		Renderer:      &mockRenderer{},
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -681,16 +879,18 @@ func TestGetChartConfig_ArgoSuccess(t *testing.T) {
 }
 
 func TestGetChartConfig_FilesystemError(t *testing.T) {
-	svc := NewDiffService(
-		&mockSourceControl{}, &mockChangedCharts{},
-		nil, // no argo
-		&mockEnvConfig{errors: map[string]error{"my-chart": errors.New("fs error")}},
-		&mockRenderer{}, &mockReporter{},
-		&mockDiff{}, &mockDiff{}, logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{errors: map[string]error{"my-chart": errors.New("fs error")}},
+		Renderer:      &mockRenderer{},
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -707,16 +907,18 @@ func TestGetChartConfig_FilesystemError(t *testing.T) {
 }
 
 func TestGetChartConfig_DefaultFallback(t *testing.T) {
-	svc := NewDiffService(
-		&mockSourceControl{}, &mockChangedCharts{},
-		nil, // no argo
-		&mockEnvConfig{config: domain.ChartConfig{Path: "charts/my-chart"}}, // empty envs
-		&mockRenderer{}, &mockReporter{},
-		&mockDiff{}, &mockDiff{}, logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{config: domain.ChartConfig{Path: "charts/my-chart"}}, // empty envs
+		Renderer:      &mockRenderer{},
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -744,18 +946,21 @@ func TestGetChartConfig_DefaultFallback(t *testing.T) {
 // --- processChart() path tests ---
 
 func TestProcessChart_HeadFetchError(t *testing.T) {
-	svc := NewDiffService(
-		&mockSourceControl{
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{
 			charts: map[string]bool{"main:charts/test-chart": true},
 			errors: map[string]error{"feature:charts/test-chart": errors.New("network error")},
 		},
-		&mockChangedCharts{}, nil, &mockEnvConfig{},
-		&mockRenderer{}, &mockReporter{},
-		&mockDiff{}, &mockDiff{}, logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer:      &mockRenderer{},
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -783,19 +988,24 @@ func TestProcessChart_HeadFetchError(t *testing.T) {
 	}
 }
 
-func TestProcessChart_MessageOnlyEnv(t *testing.T) {
-	svc := NewDiffService(
-		&mockSourceControl{charts: map[string]bool{
+func TestProcessChart_ResolvesDependenciesForBaseAndHead(t *testing.T) {
+	resolver := &mockDepResolver{}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
 			"main:charts/test-chart":    true,
 			"feature:charts/test-chart": true,
 		}},
-		&mockChangedCharts{}, nil, &mockEnvConfig{},
-		&mockRenderer{}, &mockReporter{},
-		&mockDiff{}, &mockDiff{}, logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer:      &mockRenderer{},
+		DepResolver:   resolver,
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -803,203 +1013,1602 @@ func TestProcessChart_MessageOnlyEnv(t *testing.T) {
 	}
 	config := domain.ChartConfig{
 		Path:         "charts/test-chart",
-		Environments: []domain.EnvironmentConfig{{Name: "disabled-env", Message: "Not deployed"}},
+		Environments: []domain.EnvironmentConfig{{Name: "prod"}},
 	}
 
 	results := svc.processChart(context.Background(), pr, config)
-	if len(results) != 1 {
-		t.Fatalf("expected 1 result, got %d", len(results))
+	if len(results) != 1 || results[0].Status == domain.StatusError {
+		t.Fatalf("expected 1 successful result, got %+v", results)
 	}
-	if results[0].Status != domain.StatusSuccess {
-		t.Errorf("expected StatusSuccess, got %v", results[0].Status)
+
+	want := []string{"main:charts/test-chart", "feature:charts/test-chart"}
+	if len(resolver.resolved) != len(want) {
+		t.Fatalf("expected resolver calls %v, got %v", want, resolver.resolved)
 	}
-	if results[0].Summary != "Not deployed" {
-		t.Errorf("expected summary 'Not deployed', got: %s", results[0].Summary)
+	for i := range want {
+		if resolver.resolved[i] != want[i] {
+			t.Errorf("resolver call %d = %q, want %q", i, resolver.resolved[i], want[i])
+		}
 	}
 }
 
-func TestProcessChart_DiffChartEnvError(t *testing.T) {
-	svc := NewDiffService(
-		&mockSourceControl{charts: map[string]bool{
+func TestProcessChart_ResourceChanges(t *testing.T) {
+	replicas := []domain.FieldChange{{Path: "spec.replicas", Type: domain.ChangeModified, Old: "1", New: "2"}}
+	semanticResources := []domain.ResourceChange{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "a", Type: domain.ChangeModified, Fields: replicas},
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "b", Type: domain.ChangeModified, Fields: replicas},
+		{APIVersion: "v1", Kind: "Service", Name: "a", Type: domain.ChangeAdded},
+	}
+	unifiedResources := []domain.ResourceChange{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "c", Type: domain.ChangeRemoved},
+	}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
 			"main:charts/test-chart":    true,
 			"feature:charts/test-chart": true,
 		}},
-		&mockChangedCharts{}, nil, &mockEnvConfig{},
-		&mockRenderer{errors: map[string]error{
-			"feature:charts/test-chart": errors.New("helm fail"),
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer: &mockRenderer{manifests: map[string]string{
+			"main:charts/test-chart":    "replicas: 1",
+			"feature:charts/test-chart": "replicas: 2",
 		}},
-		&mockReporter{},
-		&mockDiff{}, &mockDiff{}, logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+		Reporter:     &mockReporter{},
+		SemanticDiff: &mockDiff{resources: semanticResources},
+		UnifiedDiff:  &mockDiff{resources: unifiedResources},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
 		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
 	}
 	config := domain.ChartConfig{
-		Path: "charts/test-chart",
-		Environments: []domain.EnvironmentConfig{
-			{Name: "prod", ValueFiles: []string{"env/prod.yaml"}},
-		},
+		Path:         "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{Name: "prod"}},
 	}
 
 	results := svc.processChart(context.Background(), pr, config)
-	if len(results) != 1 {
-		t.Fatalf("expected 1 result, got %d", len(results))
+	if len(results) != 1 || results[0].Status != domain.StatusChanges {
+		t.Fatalf("expected 1 result with changes, got %+v", results)
 	}
-	if results[0].Status != domain.StatusError {
-		t.Errorf("expected StatusError, got %v", results[0].Status)
+
+	// The semantic diff's resources win over the unified diff's
+	r := results[0]
+	if len(r.Resources) != len(semanticResources) || r.Resources[2].Kind != "Service" {
+		t.Errorf("Resources = %+v, want the semantic diff's", r.Resources)
 	}
-	if !strings.Contains(results[0].Summary, "helm fail") {
-		t.Errorf("expected summary containing 'helm fail', got: %s", results[0].Summary)
+	want := "Changes detected in test-chart for environment prod: 2 Deployments modified, 1 Service added."
+	if r.Summary != want {
+		t.Errorf("Summary = %q, want %q", r.Summary, want)
 	}
 }
 
-// --- diffChartEnv() path tests ---
-
-func TestDiffChartEnv_HeadRenderError(t *testing.T) {
-	svc := NewDiffService(
-		&mockSourceControl{}, &mockChangedCharts{},
-		nil, &mockEnvConfig{},
-		&mockRenderer{errors: map[string]error{"headDir": errors.New("template error")}},
-		&mockReporter{},
-		&mockDiff{}, &mockDiff{}, logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+func TestProcessChart_Risks(t *testing.T) {
+	resources := []domain.ResourceChange{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Type: domain.ChangeRemoved},
+		{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Type: domain.ChangeModified,
+			Fields: []domain.FieldChange{
+				{Path: "spec.selector.matchLabels.app", Type: domain.ChangeModified, Old: "web", New: "frontend"},
+			},
+		},
+	}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/test-chart":    true,
+			"feature:charts/test-chart": true,
+		}},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer: &mockRenderer{manifests: map[string]string{
+			"main:charts/test-chart":    "app: web",
+			"feature:charts/test-chart": "app: frontend",
+		}},
+		Reporter:     &mockReporter{},
+		SemanticDiff: &mockDiff{resources: resources},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
 		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
 	}
-	env := domain.EnvironmentConfig{Name: "prod", ValueFiles: []string{"env/prod.yaml"}}
+	config := domain.ChartConfig{
+		Path:         "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{Name: "prod"}},
+	}
 
-	_, err := svc.diffChartEnv(
-		context.Background(),
-		pr,
-		"test-chart",
-		"baseDir",
-		"headDir",
-		true,
-		env,
-	)
-	if err == nil {
-		t.Fatal("expected error, got nil")
+	results := svc.processChart(context.Background(), pr, config)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
 	}
-	if !strings.Contains(err.Error(), "failed to render PR changes") {
-		t.Errorf("expected error wrapping 'failed to render PR changes', got: %v", err)
+	r := results[0]
+	if len(r.Risks) != 2 || domain.HighestRisk(r.Risks) != domain.RiskHigh {
+		t.Errorf("Risks = %+v, want a medium deletion and a high immutable field change", r.Risks)
+	}
+	if !strings.HasSuffix(r.Summary, " Highest risk: high.") {
+		t.Errorf("Summary = %q, want the highest risk", r.Summary)
 	}
 }
 
-// --- Concurrency test helpers ---
-
-// blockingRenderer blocks on gate and tracks concurrent active renders.
-type blockingRenderer struct {
-	gate   chan struct{}
-	active atomic.Int32
-	peak   atomic.Int32
+// emptySemanticDiff is a semantic differ that finds no changes, either
+// because the manifests only differ in formatting or because it can't parse
+// them.
+type emptySemanticDiff struct {
+	unparsed bool
 }
 
-func (b *blockingRenderer) Render(_ context.Context, _ string, _ []string) ([]byte, error) {
-	cur := b.active.Add(1)
-	// CAS-update peak
-	for {
-		old := b.peak.Load()
-		if cur <= old || b.peak.CompareAndSwap(old, cur) {
-			break
-		}
-	}
-	<-b.gate // block until released
-	b.active.Add(-1)
-	return []byte("manifest"), nil
+func (d emptySemanticDiff) ComputeDiff(_, _ string, _, _ []byte) domain.ManifestDiff {
+	return domain.ManifestDiff{Unparsed: d.unparsed}
 }
 
-// newTestService creates a DiffService wired for processChart tests.
-func newTestService(
-	renderer *blockingRenderer,
-	envs []domain.EnvironmentConfig,
-) *DiffService {
-	chartName := "test-chart"
-	charts := map[string]bool{
-		"main:charts/" + chartName:    true,
-		"feature:charts/" + chartName: true,
+func TestProcessChart_Risks_LineDiffOnly(t *testing.T) {
+	// The line differ knows which resources changed, but not which fields
+	resources := []domain.ResourceChange{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Type: domain.ChangeModified},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Type: domain.ChangeModified},
 	}
-	return NewDiffService(
-		&mockSourceControl{charts: charts},
-		&mockChangedCharts{},
-		nil,
-		&mockEnvConfig{
-			config: domain.ChartConfig{
-				Path:         "charts/" + chartName,
-				Environments: envs,
-			},
+	tests := []struct {
+		name        string
+		unparsed    bool
+		wantRisks   int
+		wantSummary string
+	}{
+		{
+			name:        "unparseable manifests leave field risks unassessed",
+			unparsed:    true,
+			wantRisks:   1,
+			wantSummary: " Highest risk: medium.",
 		},
-		renderer,
-		&mockReporter{},
-		&mockDiff{},
-		&mockDiff{},
-		logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
-}
+		{
+			name:        "formatting-only changes are not risky",
+			wantSummary: "1 Deployment modified, 1 ConfigMap modified.",
+		},
+	}
 
-func makeEnvs(n int) []domain.EnvironmentConfig {
-	envs := make([]domain.EnvironmentConfig, n)
-	for i := range envs {
-		envs[i] = domain.EnvironmentConfig{
-			Name:       fmt.Sprintf("env-%d", i),
-			ValueFiles: []string{fmt.Sprintf("env/env-%d-values.yaml", i)},
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewDiffService(Deps{
+				SourceControl: &mockSourceControl{charts: map[string]bool{
+					"main:charts/test-chart":    true,
+					"feature:charts/test-chart": true,
+				}},
+				ChangedCharts: &mockChangedCharts{},
+				FSEnvConfig:   &mockEnvConfig{},
+				Renderer: &mockRenderer{manifests: map[string]string{
+					"main:charts/test-chart":    "app: web",
+					"feature:charts/test-chart": "app: frontend",
+				}},
+				Reporter:     &mockReporter{},
+				SemanticDiff: emptySemanticDiff{unparsed: tt.unparsed},
+				UnifiedDiff:  &mockDiff{resources: resources},
+				Logger:       logger.New("error"),
+				Meter:        noopmetric.NewMeterProvider().Meter("test"),
+				Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+			}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+			pr := domain.PRContext{
+				Owner: "o", Repo: "r", PRNumber: 1,
+				BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+			}
+			config := domain.ChartConfig{
+				Path:         "charts/test-chart",
+				Environments: []domain.EnvironmentConfig{{Name: "prod"}},
+			}
+
+			results := svc.processChart(context.Background(), pr, config)
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			r := results[0]
+			if len(r.Risks) != tt.wantRisks {
+				t.Fatalf("Risks = %+v, want %d", r.Risks, tt.wantRisks)
+			}
+			if tt.wantRisks > 0 && (r.Risks[0].Reason != domain.RiskUnassessed || r.Risks[0].Level != domain.RiskMedium) {
+				t.Errorf("Risks = %+v, want the Deployment flagged as unassessed", r.Risks)
+			}
+			if !strings.HasSuffix(r.Summary, tt.wantSummary) {
+				t.Errorf("Summary = %q, want suffix %q", r.Summary, tt.wantSummary)
+			}
+		})
 	}
-	return envs
 }
 
-// waitForActive spins until br.active reaches the target value, or fails after timeout.
-func waitForActive(t *testing.T, br *blockingRenderer, target int32, msg string) {
-	t.Helper()
-	deadline := time.After(5 * time.Second)
-	for br.active.Load() != target {
-		select {
-		case <-deadline:
-			t.Fatalf("%s: timed out, active=%d want=%d", msg, br.active.Load(), target)
-		default:
-			time.Sleep(time.Millisecond)
-		}
-	}
+// mockChartVersion records the checkouts it's asked to compare.
+type mockChartVersion struct {
+	check   domain.VersionCheck
+	err     error
+	baseDir string
+	headDir string
 }
 
-func TestProcessChart_EnvsRunConcurrently(t *testing.T) {
-	envs := makeEnvs(4)
-	br := &blockingRenderer{gate: make(chan struct{})}
-	svc := newTestService(br, envs)
+func (m *mockChartVersion) CheckVersion(baseDir, headDir string) (domain.VersionCheck, error) {
+	m.baseDir, m.headDir = baseDir, headDir
+	return m.check, m.err
+}
 
+func TestProcessChart_ChartVersion(t *testing.T) {
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
 		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
 	}
 	config := domain.ChartConfig{
-		Path:         "charts/test-chart",
-		Environments: envs,
+		Path: "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{
+			{Name: "dev"},
+			{Name: "prod"},
+		},
 	}
+	newService := func(chartVersion *mockChartVersion, baseExists bool) *DiffService {
+		return NewDiffService(Deps{
+			SourceControl: &mockSourceControl{charts: map[string]bool{
+				"main:charts/test-chart":    baseExists,
+				"feature:charts/test-chart": true,
+			}},
+			ChangedCharts: &mockChangedCharts{},
+			FSEnvConfig:   &mockEnvConfig{},
+			Renderer: &mockRenderer{manifests: map[string]string{
+				"main:charts/test-chart":    "replicas: 1",
+				"feature:charts/test-chart": "replicas: 1",
+			}},
+			Reporter:     &mockReporter{},
+			SemanticDiff: &mockDiff{},
+			UnifiedDiff:  &mockDiff{},
+			ChartVersion: chartVersion,
+			Logger:       logger.New("error"),
+			Meter:        noopmetric.NewMeterProvider().Meter("test"),
+			Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+		}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+	}
+
+	t.Run("every environment gets the chart's check", func(t *testing.T) {
+		chartVersion := &mockChartVersion{check: domain.VersionCheck{
+			BaseVersion: "1.0.0", HeadVersion: "1.0.0", Required: domain.BumpPatch,
+			Problem: "chart content changed but version 1.0.0 was not bumped",
+		}}
+		results := newService(chartVersion, true).processChart(context.Background(), pr, config)
+		if chartVersion.baseDir != "main:charts/test-chart" || chartVersion.headDir != "feature:charts/test-chart" {
+			t.Errorf("CheckVersion(%q, %q), want the base and head checkouts",
+				chartVersion.baseDir, chartVersion.headDir)
+		}
+		if len(results) != 2 {
+			t.Fatalf("expected 2 results, got %d", len(results))
+		}
+		for _, r := range results {
+			if r.ChartVersion == nil || !r.ChartVersion.Failed() {
+				t.Errorf("%s: ChartVersion = %+v, want the failed check", r.Environment, r.ChartVersion)
+			}
+		}
+		if !slices.ContainsFunc(results, domain.DiffResult.HasFindings) {
+			t.Error("HasFindings() = false for all results, want true for a failed version check")
+		}
+	})
 
-	done := make(chan []domain.DiffResult, 1)
-	go func() {
-		done <- svc.processChart(context.Background(), pr, config)
-	}()
+	t.Run("new chart has no base checkout", func(t *testing.T) {
+		chartVersion := &mockChartVersion{check: domain.VersionCheck{HeadVersion: "0.1.0"}}
+		results := newService(chartVersion, false).processChart(context.Background(), pr, config)
+		if chartVersion.baseDir != "" {
+			t.Errorf("CheckVersion() baseDir = %q, want empty for a new chart", chartVersion.baseDir)
+		}
+		if results[0].ChartVersion == nil || results[0].ChartVersion.Failed() {
+			t.Errorf("ChartVersion = %+v, want a passing check", results[0].ChartVersion)
+		}
+	})
 
-	// Wait for all 4 base renders to be in-flight simultaneously
-	waitForActive(t, br, 4, "base renders")
+	t.Run("check that can't run is skipped", func(t *testing.T) {
+		chartVersion := &mockChartVersion{err: errors.New("parsing Chart.yaml")}
+		results := newService(chartVersion, true).processChart(context.Background(), pr, config)
+		if results[0].ChartVersion != nil {
+			t.Errorf("ChartVersion = %+v, want nil", results[0].ChartVersion)
+		}
+		if results[0].Status != domain.StatusSuccess {
+			t.Errorf("Status = %v, want Success", results[0].Status)
+		}
+	})
+}
 
-	// Release 4 base renders
-	for i := 0; i < 4; i++ {
-		br.gate <- struct{}{}
-	}
+// mockValuesSurface reports fixed values changes, and a stale override and an
+// unused value for each value file that starts with "env/prod".
+type mockValuesSurface struct {
+	changes []domain.ValuesChange
+	err     error
+	compare int
+}
+
+func (m *mockValuesSurface) CompareValues(_, _ string) ([]domain.ValuesChange, error) {
+	m.compare++
+	return m.changes, m.err
+}
+
+func (m *mockValuesSurface) FindStaleOverrides(
+	_ string,
+	valueFiles []string,
+	_ domain.RenderOptions,
+	changes []domain.ValuesChange,
+) []domain.StaleOverride {
+	var stale []domain.StaleOverride
+	for _, f := range valueFiles {
+		if strings.HasPrefix(f, "env/prod") {
+			stale = append(stale, domain.StaleOverride{File: f, Change: changes[0]})
+		}
+	}
+	return stale
+}
+
+func (m *mockValuesSurface) FindUnusedValues(
+	_ string,
+	valueFiles []string,
+	_ domain.RenderOptions,
+) []domain.UnusedValue {
+	var unused []domain.UnusedValue
+	for _, f := range valueFiles {
+		if strings.HasPrefix(f, "env/prod") {
+			unused = append(unused, domain.UnusedValue{File: f, Path: []string{"replica"}})
+		}
+	}
+	return unused
+}
+
+func TestProcessChart_ValuesSurface(t *testing.T) {
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path: "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{
+			{Name: "dev", ValueFiles: []string{"env/dev.yaml"}},
+			{Name: "prod", ValueFiles: []string{"env/prod.yaml"}},
+		},
+	}
+	removed := domain.ValuesChange{Path: []string{"metrics"}, Type: domain.ValuesKeyRemoved}
+	newService := func(valuesSurface *mockValuesSurface, baseExists, failOnStale bool) *DiffService {
+		return NewDiffService(Deps{
+			SourceControl: &mockSourceControl{charts: map[string]bool{
+				"main:charts/test-chart":    baseExists,
+				"feature:charts/test-chart": true,
+			}},
+			ChangedCharts: &mockChangedCharts{},
+			FSEnvConfig:   &mockEnvConfig{},
+			Renderer: &mockRenderer{manifests: map[string]string{
+				"main:charts/test-chart":    "replicas: 1",
+				"feature:charts/test-chart": "replicas: 1",
+			}},
+			Reporter:      &mockReporter{},
+			SemanticDiff:  &mockDiff{},
+			UnifiedDiff:   &mockDiff{},
+			ValuesSurface: valuesSurface,
+			Logger:        logger.New("error"),
+			Meter:         noopmetric.NewMeterProvider().Meter("test"),
+			Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+		}, Options{
+			ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val",
+			FailOnStaleOverrides: failOnStale,
+		})
+	}
+
+	t.Run("environments still setting a removed key are flagged", func(t *testing.T) {
+		valuesSurface := &mockValuesSurface{changes: []domain.ValuesChange{removed}}
+		results := newService(valuesSurface, true, false).processChart(context.Background(), pr, config)
+		if len(results) != 2 {
+			t.Fatalf("expected 2 results, got %d", len(results))
+		}
+		if valuesSurface.compare != 1 {
+			t.Errorf("CompareValues() called %d times, want once per chart", valuesSurface.compare)
+		}
+		for _, r := range results {
+			if len(r.ValuesChanges) != 1 {
+				t.Errorf("%s: ValuesChanges = %+v, want the chart's change", r.Environment, r.ValuesChanges)
+			}
+		}
+		dev, prod := results[0], results[1]
+		if len(dev.StaleOverrides) != 0 {
+			t.Errorf("dev: StaleOverrides = %+v, want none", dev.StaleOverrides)
+		}
+		if len(prod.StaleOverrides) != 1 || prod.StaleOverrides[0].File != "env/prod.yaml" ||
+			prod.StaleOverrides[0].Severity != domain.SeverityWarning {
+			t.Errorf("prod: StaleOverrides = %+v, want a warning for env/prod.yaml", prod.StaleOverrides)
+		}
+		if len(prod.UnusedValues) != 1 || len(dev.UnusedValues) != 0 {
+			t.Errorf("UnusedValues = %+v (dev), %+v (prod), want one in prod", dev.UnusedValues, prod.UnusedValues)
+		}
+		if !strings.HasSuffix(prod.Summary, " 1 override(s) of removed values keys. 1 unused values key(s).") {
+			t.Errorf("prod: Summary = %q, want the stale override and unused value counts", prod.Summary)
+		}
+		if !slices.ContainsFunc(results, domain.DiffResult.HasFindings) {
+			t.Error("HasFindings() = false for all results, want true for a breaking values change")
+		}
+	})
+
+	t.Run("stale overrides fail when configured", func(t *testing.T) {
+		valuesSurface := &mockValuesSurface{changes: []domain.ValuesChange{removed}}
+		results := newService(valuesSurface, true, true).processChart(context.Background(), pr, config)
+		if stale := results[1].StaleOverrides; len(stale) != 1 || stale[0].Severity != domain.SeverityError {
+			t.Errorf("prod: StaleOverrides = %+v, want an error", stale)
+		}
+	})
+
+	t.Run("new chart has nothing to compare", func(t *testing.T) {
+		valuesSurface := &mockValuesSurface{changes: []domain.ValuesChange{removed}}
+		results := newService(valuesSurface, false, false).processChart(context.Background(), pr, config)
+		if valuesSurface.compare != 0 {
+			t.Error("CompareValues() called for a chart without a base")
+		}
+		if len(results[1].StaleOverrides) != 0 {
+			t.Errorf("StaleOverrides = %+v, want none", results[1].StaleOverrides)
+		}
+	})
+
+	t.Run("comparison that can't run is skipped", func(t *testing.T) {
+		valuesSurface := &mockValuesSurface{err: errors.New("parsing values.yaml")}
+		results := newService(valuesSurface, true, false).processChart(context.Background(), pr, config)
+		for _, r := range results {
+			if len(r.ValuesChanges) != 0 || len(r.StaleOverrides) != 0 || r.Status != domain.StatusSuccess {
+				t.Errorf("%s: got %+v, want a plain success", r.Environment, r)
+			}
+		}
+	})
+}
+
+func TestProcessChart_RenderErrorLocation(t *testing.T) {
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path:         "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{Name: "prod"}},
+	}
+	helmErr := errors.New("helm template failed: Error: template: test-chart/templates/deployment.yaml:12:3: " +
+		`executing "test-chart/templates/deployment.yaml" at <.Values.image.tag>: nil pointer`)
+	tests := []struct {
+		name     string
+		errors   map[string]error
+		wantFile string
+	}{
+		{
+			name:     "head render failure carries the template and line",
+			errors:   map[string]error{"feature:charts/test-chart": helmErr},
+			wantFile: "test-chart/templates/deployment.yaml",
+		},
+		{
+			name: "renderer's own render error is kept",
+			errors: map[string]error{
+				"feature:charts/test-chart": &domain.RenderError{Template: "test-chart/a.yaml", Line: 3},
+			},
+			wantFile: "test-chart/a.yaml",
+		},
+		{
+			name:   "base render failure points nowhere in the head",
+			errors: map[string]error{"main:charts/test-chart": helmErr},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewDiffService(Deps{
+				SourceControl: &mockSourceControl{charts: map[string]bool{
+					"main:charts/test-chart":    true,
+					"feature:charts/test-chart": true,
+				}},
+				ChangedCharts: &mockChangedCharts{},
+				FSEnvConfig:   &mockEnvConfig{},
+				Renderer: &mockRenderer{manifests: map[string]string{
+					"main:charts/test-chart":    "replicas: 1",
+					"feature:charts/test-chart": "replicas: 2",
+				}, errors: tt.errors},
+				Reporter:     &mockReporter{},
+				SemanticDiff: &mockDiff{},
+				UnifiedDiff:  &mockDiff{},
+				Logger:       logger.New("error"),
+				Meter:        noopmetric.NewMeterProvider().Meter("test"),
+				Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+			}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+			results := svc.processChart(context.Background(), pr, config)
+			if len(results) != 1 || results[0].Status != domain.StatusError {
+				t.Fatalf("results = %+v, want one error", results)
+			}
+			r := results[0]
+			if r.ChartPath != "charts/test-chart" {
+				t.Errorf("ChartPath = %q, want charts/test-chart", r.ChartPath)
+			}
+			switch {
+			case tt.wantFile == "" && r.RenderError != nil:
+				t.Errorf("RenderError = %+v, want nil", r.RenderError)
+			case tt.wantFile != "" && (r.RenderError == nil || r.RenderError.Template != tt.wantFile):
+				t.Errorf("RenderError = %+v, want one in %s", r.RenderError, tt.wantFile)
+			}
+		})
+	}
+}
+
+// mockRedactor masks a fixed secret value.
+type mockRedactor struct{}
+
+func (m *mockRedactor) Redact(manifest []byte) []byte {
+	return []byte(strings.ReplaceAll(string(manifest), "hunter2", "<redacted>"))
+}
+
+func TestProcessChart_RedactsBeforeDiffing(t *testing.T) {
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/test-chart":    true,
+			"feature:charts/test-chart": true,
+		}},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer: &mockRenderer{manifests: map[string]string{
+			"main:charts/test-chart":    "replicas: 1\npassword: hunter2",
+			"feature:charts/test-chart": "replicas: 2\npassword: hunter2",
+		}},
+		Reporter:     &mockReporter{},
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Redactor:     &mockRedactor{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path:         "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{Name: "prod"}},
+	}
+
+	results := svc.processChart(context.Background(), pr, config)
+	if len(results) != 1 || results[0].Status != domain.StatusChanges {
+		t.Fatalf("expected 1 result with changes, got %+v", results)
+	}
+	for _, diff := range []string{results[0].SemanticDiff, results[0].UnifiedDiff} {
+		if strings.Contains(diff, "hunter2") || !strings.Contains(diff, "<redacted>") {
+			t.Errorf("diff was not redacted:\n%s", diff)
+		}
+	}
+}
+
+// mockValidator reports a violation for every manifest containing a
+// misspelled containers field, and records the manifests and target
+// environments it was given.
+type mockValidator struct {
+	mu           sync.Mutex
+	manifests    []string
+	envs         []string
+	kubeVersions []string
+}
+
+func (m *mockValidator) Validate(manifest []byte, envName, kubeVersion string) []domain.SchemaViolation {
+	m.mu.Lock()
+	m.manifests = append(m.manifests, string(manifest))
+	m.envs = append(m.envs, envName)
+	m.kubeVersions = append(m.kubeVersions, kubeVersion)
+	m.mu.Unlock()
+	if !strings.Contains(string(manifest), "contianers") {
+		return nil
+	}
+	return []domain.SchemaViolation{{
+		Resource: "apps/v1/Deployment/my-app",
+		Message:  ".spec.template.spec.contianers: field not declared in schema",
+	}}
+}
+
+func TestProcessChart_SchemaValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		head           string
+		wantStatus     domain.Status
+		wantViolations int
+	}{
+		{
+			name:       "valid head manifest",
+			head:       "replicas: 2\ncontainers: []",
+			wantStatus: domain.StatusChanges,
+		},
+		{
+			name:           "invalid head manifest",
+			head:           "replicas: 2\ncontianers: []",
+			wantStatus:     domain.StatusInvalid,
+			wantViolations: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &mockValidator{}
+			svc := NewDiffService(Deps{
+				SourceControl: &mockSourceControl{charts: map[string]bool{
+					"main:charts/test-chart":    true,
+					"feature:charts/test-chart": true,
+				}},
+				ChangedCharts: &mockChangedCharts{},
+				FSEnvConfig:   &mockEnvConfig{},
+				Renderer: &mockRenderer{manifests: map[string]string{
+					"main:charts/test-chart":    "replicas: 1\ncontainers: []",
+					"feature:charts/test-chart": tt.head,
+				}},
+				Reporter:     &mockReporter{},
+				SemanticDiff: &mockDiff{},
+				UnifiedDiff:  &mockDiff{},
+				Validator:    validator,
+				Logger:       logger.New("error"),
+				Meter:        noopmetric.NewMeterProvider().Meter("test"),
+				Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+			}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+			pr := domain.PRContext{
+				Owner: "o", Repo: "r", PRNumber: 1,
+				BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+			}
+			config := domain.ChartConfig{
+				Path: "charts/test-chart",
+				Environments: []domain.EnvironmentConfig{{
+					Name:          "prod",
+					RenderOptions: domain.RenderOptions{KubeVersion: "1.29.0"},
+				}},
+			}
+
+			results := svc.processChart(context.Background(), pr, config)
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			r := results[0]
+			if r.Status != tt.wantStatus || len(r.Violations) != tt.wantViolations {
+				t.Errorf("status = %v with %d violation(s), want %v with %d",
+					r.Status, len(r.Violations), tt.wantStatus, tt.wantViolations)
+			}
+			if r.UnifiedDiff == "" {
+				t.Error("expected diffs to be computed regardless of violations")
+			}
+			if len(validator.manifests) != 1 || validator.manifests[0] != tt.head {
+				t.Errorf("validator called with manifests %q, want only the head render", validator.manifests)
+			}
+			if validator.envs[0] != "prod" || validator.kubeVersions[0] != "1.29.0" {
+				t.Errorf("validator called for env %q with kube version %q, want the environment's",
+					validator.envs[0], validator.kubeVersions[0])
+			}
+		})
+	}
+}
+
+// mockLinter returns findings for the first value file it's given, and
+// records the charts it linted.
+type mockLinter struct {
+	findings map[string][]domain.LintFinding // first value file -> findings
+
+	mu        sync.Mutex
+	chartDirs []string
+}
+
+func (m *mockLinter) Lint(chartDir string, valueFiles []string, _ domain.RenderOptions) []domain.LintFinding {
+	m.mu.Lock()
+	m.chartDirs = append(m.chartDirs, chartDir)
+	m.mu.Unlock()
+	if len(valueFiles) == 0 {
+		return nil
+	}
+	return m.findings[valueFiles[0]]
+}
+
+func TestDiffChartEnv_Lint(t *testing.T) {
+	typo := domain.LintFinding{
+		File: "env/prod.yaml", Key: "replica", Message: "is not allowed by the schema", Severity: domain.SeverityError,
+	}
+	selector := domain.LintFinding{
+		File: "templates/", Message: "selector doesn't match labels", Severity: domain.SeverityWarning,
+	}
+	tests := []struct {
+		name        string
+		findings    []domain.LintFinding
+		renderErr   error
+		wantStatus  domain.Status
+		wantSummary string
+		wantErr     bool
+	}{
+		{
+			name:       "clean chart",
+			wantStatus: domain.StatusChanges,
+		},
+		{
+			name:        "warnings are reported alongside the diff",
+			findings:    []domain.LintFinding{selector},
+			wantStatus:  domain.StatusChanges,
+			wantSummary: "Lint: 0 error(s), 1 warning(s).",
+		},
+		{
+			name:        "errors make the result invalid",
+			findings:    []domain.LintFinding{typo, selector},
+			wantStatus:  domain.StatusInvalid,
+			wantSummary: "Lint failed for test-chart in environment prod. Lint: 1 error(s), 1 warning(s).",
+		},
+		{
+			name:        "errors replace a failed render",
+			findings:    []domain.LintFinding{typo},
+			renderErr:   errors.New("helm template failed: values don't meet the specifications of the schema"),
+			wantStatus:  domain.StatusInvalid,
+			wantSummary: "the chart can't be rendered",
+		},
+		{
+			name:      "warnings don't explain a failed render",
+			findings:  []domain.LintFinding{selector},
+			renderErr: errors.New("helm template failed"),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := &mockRenderer{manifests: map[string]string{"baseDir": "replicas: 1", "headDir": "replicas: 2"}}
+			if tt.renderErr != nil {
+				renderer.errors = map[string]error{"headDir": tt.renderErr}
+			}
+			linter := &mockLinter{findings: map[string][]domain.LintFinding{"env/prod.yaml": tt.findings}}
+			svc := NewDiffService(Deps{
+				SourceControl: &mockSourceControl{},
+				ChangedCharts: &mockChangedCharts{},
+				FSEnvConfig:   &mockEnvConfig{},
+				Renderer:      renderer,
+				Reporter:      &mockReporter{},
+				SemanticDiff:  &mockDiff{},
+				UnifiedDiff:   &mockDiff{},
+				Linter:        linter,
+				Logger:        logger.New("error"),
+				Meter:         noopmetric.NewMeterProvider().Meter("test"),
+				Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+			}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+			pr := domain.PRContext{Owner: "o", Repo: "r", PRNumber: 1, BaseRef: "main", HeadRef: "feature"}
+			env := domain.EnvironmentConfig{Name: "prod", ValueFiles: []string{"env/prod.yaml"}}
+			r, err := svc.diffChartEnv(context.Background(), pr, "test-chart", "baseDir", "headDir", true, env,
+				nil, nil, nil, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.Status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", r.Status, tt.wantStatus)
+			}
+			if !strings.Contains(r.Summary, tt.wantSummary) {
+				t.Errorf("summary = %q, want it to contain %q", r.Summary, tt.wantSummary)
+			}
+			if len(r.LintFindings) != len(tt.findings) {
+				t.Errorf("got %d lint finding(s), want %d", len(r.LintFindings), len(tt.findings))
+			}
+			if len(linter.chartDirs) != 1 || linter.chartDirs[0] != "headDir" {
+				t.Errorf("linter called with %v, want only the head chart", linter.chartDirs)
+			}
+		})
+	}
+}
+
+// mockDeprecations reports every line of a manifest that mentions a v1beta1
+// API version as deprecated, and records the kube versions it was given.
+type mockDeprecations struct {
+	mu           sync.Mutex
+	kubeVersions []string
+}
+
+func (m *mockDeprecations) Check(manifest []byte, _, kubeVersion string) []domain.APIDeprecation {
+	m.mu.Lock()
+	m.kubeVersions = append(m.kubeVersions, kubeVersion)
+	m.mu.Unlock()
+
+	var deprecations []domain.APIDeprecation
+	for line := range strings.SplitSeq(string(manifest), "\n") {
+		if strings.Contains(line, "v1beta1") {
+			deprecations = append(deprecations, domain.APIDeprecation{
+				Resource: line, APIVersion: "v1beta1", Severity: domain.SeverityWarning,
+			})
+		}
+	}
+	return deprecations
+}
+
+func TestProcessChart_Deprecations(t *testing.T) {
+	deprecations := &mockDeprecations{}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/test-chart":    true,
+			"feature:charts/test-chart": true,
+		}},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer: &mockRenderer{manifests: map[string]string{
+			"main:charts/test-chart":    "pdb: policy/v1beta1",
+			"feature:charts/test-chart": "pdb: policy/v1beta1\ncronjob: batch/v1beta1",
+		}},
+		Reporter:     &mockReporter{},
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Deprecations: deprecations,
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path: "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{
+			Name:          "prod",
+			RenderOptions: domain.RenderOptions{KubeVersion: "1.24.0"},
+		}},
+	}
+
+	results := svc.processChart(context.Background(), pr, config)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	got := results[0].Deprecations
+	if len(got) != 2 || got[0].Introduced || !got[1].Introduced {
+		t.Errorf("Deprecations = %+v, want the batch/v1beta1 one introduced by the PR", got)
+	}
+	if !strings.Contains(results[0].Summary, "API versions: 0 removed, 2 deprecated.") {
+		t.Errorf("Summary = %q, want deprecation counts", results[0].Summary)
+	}
+	if len(deprecations.kubeVersions) != 2 || deprecations.kubeVersions[0] != "1.24.0" {
+		t.Errorf("checker called with kube versions %q, want the environment's for base and head",
+			deprecations.kubeVersions)
+	}
+}
+
+// mockPolicies fails every manifest containing ":latest" against a single
+// policy, and records the chart policy directories it was asked to load.
+type mockPolicies struct {
+	severity domain.Severity
+	loadErr  error
+	dirs     []string
+}
+
+func (m *mockPolicies) LoadPolicies(chartPolicyDir string) ([]domain.Policy, error) {
+	m.dirs = append(m.dirs, chartPolicyDir)
+	if m.loadErr != nil {
+		return nil, m.loadErr
+	}
+	return []domain.Policy{{Name: "no-latest-tag", Expression: "true", Severity: m.severity}}, nil
+}
+
+func (m *mockPolicies) Evaluate(policies []domain.Policy, manifest []byte, envName string) []domain.PolicyViolation {
+	if !strings.Contains(string(manifest), ":latest") {
+		return nil
+	}
+	return []domain.PolicyViolation{{
+		Policy:   policies[0].Name,
+		Resource: "apps/v1/Deployment/my-app",
+		Message:  "images must be pinned in " + envName,
+		Severity: policies[0].SeverityFor(envName),
+	}}
+}
+
+func TestProcessChart_Policies(t *testing.T) {
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path:         "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{Name: "prod"}},
+	}
+	newService := func(policies *mockPolicies, baseExists bool) *DiffService {
+		return NewDiffService(Deps{
+			SourceControl: &mockSourceControl{charts: map[string]bool{
+				"main:charts/test-chart":    baseExists,
+				"feature:charts/test-chart": true,
+			}},
+			ChangedCharts: &mockChangedCharts{},
+			FSEnvConfig:   &mockEnvConfig{},
+			Renderer: &mockRenderer{manifests: map[string]string{
+				"main:charts/test-chart":    "image: app:1.0",
+				"feature:charts/test-chart": "image: app:latest",
+			}},
+			Reporter:     &mockReporter{},
+			SemanticDiff: &mockDiff{},
+			UnifiedDiff:  &mockDiff{},
+			Policies:     policies,
+			Logger:       logger.New("error"),
+			Meter:        noopmetric.NewMeterProvider().Meter("test"),
+			Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+		}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+	}
+
+	t.Run("violations are attached to the result", func(t *testing.T) {
+		policies := &mockPolicies{severity: domain.SeverityWarning}
+		results := newService(policies, true).processChart(context.Background(), pr, config)
+		if len(results) != 1 || results[0].Status != domain.StatusChanges {
+			t.Fatalf("expected 1 result with changes, got %+v", results)
+		}
+		r := results[0]
+		if len(r.PolicyViolations) != 1 || r.PolicyViolations[0].Severity != domain.SeverityWarning {
+			t.Errorf("PolicyViolations = %+v, want one warning", r.PolicyViolations)
+		}
+		if !strings.Contains(r.Summary, "Policies: 0 failed, 1 warning(s).") {
+			t.Errorf("Summary = %q, want policy counts", r.Summary)
+		}
+		if len(policies.dirs) != 1 || policies.dirs[0] != filepath.Join("main:charts/test-chart", ChartPolicyDir) {
+			t.Errorf("loaded chart policies from %q, want the base checkout's policies dir", policies.dirs)
+		}
+	})
+
+	t.Run("new charts only get configured policies", func(t *testing.T) {
+		policies := &mockPolicies{}
+		newService(policies, false).processChart(context.Background(), pr, config)
+		if len(policies.dirs) != 1 || policies.dirs[0] != "" {
+			t.Errorf("loaded chart policies from %q, want none", policies.dirs)
+		}
+	})
+
+	t.Run("invalid policies fail the chart", func(t *testing.T) {
+		policies := &mockPolicies{loadErr: errors.New("policy 1: name is required")}
+		results := newService(policies, true).processChart(context.Background(), pr, config)
+		if len(results) != 1 || results[0].Status != domain.StatusError ||
+			!strings.Contains(results[0].Summary, "name is required") {
+			t.Errorf("expected a chart error result, got %+v", results)
+		}
+	})
+}
+
+// mockFilter strips everything after the first line of each manifest,
+// standing in for ignore rules that match the rest.
+type mockFilter struct {
+	suppressed int
+	err        error    // Returned by LoadRules
+	repoDirs   []string // repoDirs LoadRules was called with
+}
+
+func (m *mockFilter) LoadRules(repoDir string) ([]domain.IgnoreRule, error) {
+	m.repoDirs = append(m.repoDirs, repoDir)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []domain.IgnoreRule{{Pattern: "^checksum$"}}, nil
+}
+
+func (m *mockFilter) Filter(
+	_ []domain.IgnoreRule,
+	base, head []byte,
+) (filteredBase, filteredHead []byte, suppressed int) {
+	firstLine := func(b []byte) []byte {
+		line, _, _ := strings.Cut(string(b), "\n")
+		return []byte(line)
+	}
+	return firstLine(base), firstLine(head), m.suppressed
+}
+
+func TestProcessChart_IgnoreRules(t *testing.T) {
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path:         "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{Name: "prod"}},
+	}
+	newService := func(filter *mockFilter, head string, baseExists bool) *DiffService {
+		return NewDiffService(Deps{
+			SourceControl: &mockSourceControl{charts: map[string]bool{
+				"main:charts/test-chart":    baseExists,
+				"feature:charts/test-chart": true,
+			}},
+			ChangedCharts: &mockChangedCharts{},
+			FSEnvConfig:   &mockEnvConfig{},
+			Renderer: &mockRenderer{manifests: map[string]string{
+				"main:charts/test-chart":    "replicas: 1\nchecksum: abc",
+				"feature:charts/test-chart": head,
+			}},
+			Reporter:     &mockReporter{},
+			SemanticDiff: &mockDiff{},
+			UnifiedDiff:  &mockDiff{},
+			IgnoreFilter: filter,
+			Logger:       logger.New("error"),
+			Meter:        noopmetric.NewMeterProvider().Meter("test"),
+			Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+		}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+	}
+
+	tests := []struct {
+		name        string
+		head        string
+		wantStatus  domain.Status
+		wantSummary string
+	}{
+		{
+			name:        "only suppressed changes",
+			head:        "replicas: 1\nchecksum: def",
+			wantStatus:  domain.StatusSuccess,
+			wantSummary: "No changes detected. 1 change(s) suppressed by ignore rules.",
+		},
+		{
+			name:        "real changes alongside suppressed ones",
+			head:        "replicas: 2\nchecksum: def",
+			wantStatus:  domain.StatusChanges,
+			wantSummary: "Changes detected in test-chart for environment prod. 1 change(s) suppressed by ignore rules.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(&mockFilter{suppressed: 1}, tt.head, true)
+			results := svc.processChart(context.Background(), pr, config)
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %+v", results)
+			}
+			r := results[0]
+			if r.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", r.Status, tt.wantStatus)
+			}
+			if r.Suppressed != 1 {
+				t.Errorf("Suppressed = %d, want 1", r.Suppressed)
+			}
+			if r.Summary != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", r.Summary, tt.wantSummary)
+			}
+		})
+	}
+
+	t.Run("repo rules are read from the base checkout", func(t *testing.T) {
+		for _, baseExists := range []bool{true, false} {
+			filter := &mockFilter{}
+			newService(filter, "replicas: 1", baseExists).processChart(context.Background(), pr, config)
+
+			want := "main:"
+			if !baseExists {
+				want = ""
+			}
+			if len(filter.repoDirs) != 1 || filter.repoDirs[0] != want {
+				t.Errorf("base exists %v: LoadRules called with %q, want [%q]", baseExists, filter.repoDirs, want)
+			}
+		}
+	})
+
+	t.Run("invalid repo rules fail the chart", func(t *testing.T) {
+		filter := &mockFilter{err: errors.New("ignore rule 1: path or pattern is required")}
+		results := newService(filter, "replicas: 1", true).processChart(context.Background(), pr, config)
+		if len(results) != 1 || results[0].Status != domain.StatusError ||
+			!strings.Contains(results[0].Summary, "path or pattern is required") {
+			t.Errorf("expected a chart error result, got %+v", results)
+		}
+	})
+}
+
+type mockReportStore struct {
+	err    error
+	stored []string // Environments whose reports were stored
+}
+
+func (m *mockReportStore) StoreReport(_ context.Context, _ domain.PRContext, result domain.DiffResult) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.stored = append(m.stored, result.Environment)
+	return "https://chart-val.example.com/reports/" + result.Environment, nil
+}
+
+func TestProcessChart_ReportStore(t *testing.T) {
+	tests := []struct {
+		name       string
+		head       string
+		err        error
+		wantURL    string
+		wantStored int
+	}{
+		{
+			name:       "stored",
+			head:       "replicas: 3",
+			wantURL:    "https://chart-val.example.com/reports/prod",
+			wantStored: 1,
+		},
+		{name: "store fails", head: "replicas: 3", err: errors.New("disk full")},
+		{name: "no diffs to store", head: "replicas: 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports := &mockReportStore{err: tt.err}
+			svc := NewDiffService(Deps{
+				SourceControl: &mockSourceControl{charts: map[string]bool{
+					"main:charts/test-chart":    true,
+					"feature:charts/test-chart": true,
+				}},
+				ChangedCharts: &mockChangedCharts{},
+				FSEnvConfig:   &mockEnvConfig{},
+				Renderer: &mockRenderer{manifests: map[string]string{
+					"main:charts/test-chart":    "replicas: 1",
+					"feature:charts/test-chart": tt.head,
+				}},
+				Reporter:     &mockReporter{},
+				SemanticDiff: &mockDiff{},
+				UnifiedDiff:  &mockDiff{},
+				Reports:      reports,
+				Logger:       logger.New("error"),
+				Meter:        noopmetric.NewMeterProvider().Meter("test"),
+				Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+			}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+			pr := domain.PRContext{
+				Owner: "o", Repo: "r", PRNumber: 1,
+				BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+			}
+			config := domain.ChartConfig{
+				Path:         "charts/test-chart",
+				Environments: []domain.EnvironmentConfig{{Name: "prod"}},
+			}
+
+			results := svc.processChart(context.Background(), pr, config)
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %+v", results)
+			}
+			if results[0].ReportURL != tt.wantURL {
+				t.Errorf("ReportURL = %q, want %q", results[0].ReportURL, tt.wantURL)
+			}
+			if len(reports.stored) != tt.wantStored {
+				t.Errorf("stored %d reports, want %d", len(reports.stored), tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestProcessChart_DependencyResolutionError(t *testing.T) {
+	resolver := &mockDepResolver{errors: map[string]error{
+		"feature:charts/test-chart": errors.New("no version matching \"^2.0.0\""),
+	}}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/test-chart":    true,
+			"feature:charts/test-chart": true,
+		}},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer:      &mockRenderer{},
+		DepResolver:   resolver,
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path: "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{
+			{Name: "dev"}, {Name: "prod"},
+		},
+	}
+
+	results := svc.processChart(context.Background(), pr, config)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != domain.StatusError {
+		t.Errorf("expected StatusError, got %v", results[0].Status)
+	}
+	if !strings.Contains(results[0].Summary, "Error resolving chart dependencies") {
+		t.Errorf(
+			"expected summary containing 'Error resolving chart dependencies', got: %s",
+			results[0].Summary,
+		)
+	}
+}
+
+func TestProcessChart_PassesRenderOptions(t *testing.T) {
+	renderer := &mockRenderer{}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/test-chart":    true,
+			"feature:charts/test-chart": true,
+		}},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer:      renderer,
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	opts := domain.RenderOptions{
+		ReleaseName: "my-app",
+		Namespace:   "my-app-prod",
+		KubeVersion: "1.29.0",
+		APIVersions: []string{"monitoring.coreos.com/v1"},
+	}
+	config := domain.ChartConfig{
+		Path:         "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{Name: "prod", RenderOptions: opts}},
+	}
+
+	svc.processChart(context.Background(), pr, config)
+
+	if len(renderer.calls) != 2 {
+		t.Fatalf("expected base and head renders, got %d", len(renderer.calls))
+	}
+	for i, call := range renderer.calls {
+		got := call.opts
+		if got.ReleaseName != opts.ReleaseName || got.Namespace != opts.Namespace ||
+			got.KubeVersion != opts.KubeVersion || len(got.APIVersions) != 1 {
+			t.Errorf("render %d got options %+v, want %+v", i, got, opts)
+		}
+	}
+}
+
+func TestProcessChart_ValuesRefs(t *testing.T) {
+	renderer := &mockRenderer{}
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/test-chart":    true,
+			"feature:charts/test-chart": true,
+			"release:":                  true,
+		}}, // values repo root at its target revision
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer:      renderer,
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path: "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{
+			Name: "prod",
+			ValueFiles: []string{
+				"values-prod.yaml",
+				"$gitops/envs/prod.yaml",
+				"$self/shared/prod.yaml",
+			},
+			ValuesRefs: []domain.ValuesRef{
+				{Name: "gitops", RepoURL: "https://github.com/o/gitops.git", Revision: "release"},
+				{Name: "self", RepoURL: "https://github.com/o/r", Revision: "main"},
+			},
+		}},
+	}
+
+	results := svc.processChart(context.Background(), pr, config)
+	if len(results) != 1 || results[0].Status == domain.StatusError {
+		t.Fatalf("expected 1 successful result, got %+v", results)
+	}
+
+	want := map[string][]string{
+		// The external repo is fetched once; the PR's own repo resolves within each checkout
+		"main:charts/test-chart": {
+			"values-prod.yaml",
+			filepath.Join("..", "..", "release:", "envs", "prod.yaml"),
+			filepath.Join("..", "..", "shared", "prod.yaml"),
+		},
+		"feature:charts/test-chart": {
+			"values-prod.yaml",
+			filepath.Join("..", "..", "release:", "envs", "prod.yaml"),
+			filepath.Join("..", "..", "shared", "prod.yaml"),
+		},
+	}
+	if len(renderer.calls) != len(want) {
+		t.Fatalf("expected %d renders, got %d", len(want), len(renderer.calls))
+	}
+	for _, call := range renderer.calls {
+		if !slices.Equal(call.valueFiles, want[call.chartDir]) {
+			t.Errorf("%s rendered with value files %v, want %v", call.chartDir, call.valueFiles, want[call.chartDir])
+		}
+	}
+}
+
+func TestProcessChart_ValuesRefErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		valueFile  string
+		wantSubstr string
+	}{
+		{name: "unknown ref", valueFile: "$missing/prod.yaml", wantSubstr: `no source with ref "missing"`},
+		{
+			name:       "values repo fetch fails",
+			valueFile:  "$gitops/prod.yaml",
+			wantSubstr: "fetching https://github.com/o/gitops",
+		},
+		{name: "path escapes repo", valueFile: "$gitops/../../etc/passwd", wantSubstr: "must stay inside"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewDiffService(Deps{
+				SourceControl: &mockSourceControl{charts: map[string]bool{
+					"main:charts/test-chart":    true,
+					"feature:charts/test-chart": true,
+				}},
+				ChangedCharts: &mockChangedCharts{},
+				FSEnvConfig:   &mockEnvConfig{},
+				Renderer:      &mockRenderer{},
+				Reporter:      &mockReporter{},
+				SemanticDiff:  &mockDiff{},
+				UnifiedDiff:   &mockDiff{},
+				Logger:        logger.New("error"),
+				Meter:         noopmetric.NewMeterProvider().Meter("test"),
+				Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+			}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+			pr := domain.PRContext{
+				Owner: "o", Repo: "r", PRNumber: 1,
+				BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+			}
+			config := domain.ChartConfig{
+				Path: "charts/test-chart",
+				Environments: []domain.EnvironmentConfig{{
+					Name:       "prod",
+					ValueFiles: []string{tt.valueFile},
+					ValuesRefs: []domain.ValuesRef{
+						{Name: "gitops", RepoURL: "https://github.com/o/gitops", Revision: "HEAD"},
+					},
+				}},
+			}
+
+			results := svc.processChart(context.Background(), pr, config)
+			if len(results) != 1 || results[0].Status != domain.StatusError {
+				t.Fatalf("expected 1 error result, got %+v", results)
+			}
+			if !strings.Contains(results[0].Summary, tt.wantSubstr) {
+				t.Errorf("summary %q should contain %q", results[0].Summary, tt.wantSubstr)
+			}
+		})
+	}
+}
+
+func TestProcessChart_MessageOnlyEnv(t *testing.T) {
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/test-chart":    true,
+			"feature:charts/test-chart": true,
+		}},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer:      &mockRenderer{},
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path:         "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{{Name: "disabled-env", Message: "Not deployed"}},
+	}
+
+	results := svc.processChart(context.Background(), pr, config)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != domain.StatusSuccess {
+		t.Errorf("expected StatusSuccess, got %v", results[0].Status)
+	}
+	if results[0].Summary != "Not deployed" {
+		t.Errorf("expected summary 'Not deployed', got: %s", results[0].Summary)
+	}
+}
+
+func TestProcessChart_DiffChartEnvError(t *testing.T) {
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
+			"main:charts/test-chart":    true,
+			"feature:charts/test-chart": true,
+		}},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer: &mockRenderer{errors: map[string]error{
+			"feature:charts/test-chart": errors.New("helm fail"),
+		}},
+		Reporter:     &mockReporter{},
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path: "charts/test-chart",
+		Environments: []domain.EnvironmentConfig{
+			{Name: "prod", ValueFiles: []string{"env/prod.yaml"}},
+		},
+	}
+
+	results := svc.processChart(context.Background(), pr, config)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != domain.StatusError {
+		t.Errorf("expected StatusError, got %v", results[0].Status)
+	}
+	if !strings.Contains(results[0].Summary, "helm fail") {
+		t.Errorf("expected summary containing 'helm fail', got: %s", results[0].Summary)
+	}
+}
+
+// --- diffChartEnv() path tests ---
+
+func TestDiffChartEnv_HeadRenderError(t *testing.T) {
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig:   &mockEnvConfig{},
+		Renderer:      &mockRenderer{errors: map[string]error{"headDir": errors.New("template error")}},
+		Reporter:      &mockReporter{},
+		SemanticDiff:  &mockDiff{},
+		UnifiedDiff:   &mockDiff{},
+		Logger:        logger.New("error"),
+		Meter:         noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:        nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	env := domain.EnvironmentConfig{Name: "prod", ValueFiles: []string{"env/prod.yaml"}}
+
+	_, err := svc.diffChartEnv(
+		context.Background(),
+		pr,
+		"test-chart",
+		"baseDir",
+		"headDir",
+		true,
+		env,
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "failed to render PR changes") {
+		t.Errorf("expected error wrapping 'failed to render PR changes', got: %v", err)
+	}
+}
+
+// --- Concurrency test helpers ---
+
+// blockingRenderer blocks on gate and tracks concurrent active renders.
+type blockingRenderer struct {
+	gate   chan struct{}
+	active atomic.Int32
+	peak   atomic.Int32
+}
+
+func (b *blockingRenderer) Render(_ context.Context, _ string, _ []string, _ domain.RenderOptions) ([]byte, error) {
+	cur := b.active.Add(1)
+	// CAS-update peak
+	for {
+		old := b.peak.Load()
+		if cur <= old || b.peak.CompareAndSwap(old, cur) {
+			break
+		}
+	}
+	<-b.gate // block until released
+	b.active.Add(-1)
+	return []byte("manifest"), nil
+}
+
+// newTestService creates a DiffService wired for processChart tests.
+func newTestService(
+	renderer *blockingRenderer,
+	envs []domain.EnvironmentConfig,
+) *DiffService {
+	chartName := "test-chart"
+	charts := map[string]bool{
+		"main:charts/" + chartName:    true,
+		"feature:charts/" + chartName: true,
+	}
+	return NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: charts},
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig: &mockEnvConfig{
+			config: domain.ChartConfig{
+				Path:         "charts/" + chartName,
+				Environments: envs,
+			},
+		},
+		Renderer:     renderer,
+		Reporter:     &mockReporter{},
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
+}
+
+func makeEnvs(n int) []domain.EnvironmentConfig {
+	envs := make([]domain.EnvironmentConfig, n)
+	for i := range envs {
+		envs[i] = domain.EnvironmentConfig{
+			Name:       fmt.Sprintf("env-%d", i),
+			ValueFiles: []string{fmt.Sprintf("env/env-%d-values.yaml", i)},
+		}
+	}
+	return envs
+}
+
+// waitForActive spins until br.active reaches the target value, or fails after timeout.
+func waitForActive(t *testing.T, br *blockingRenderer, target int32, msg string) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for br.active.Load() != target {
+		select {
+		case <-deadline:
+			t.Fatalf("%s: timed out, active=%d want=%d", msg, br.active.Load(), target)
+		default:
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestProcessChart_EnvsRunConcurrently(t *testing.T) {
+	envs := makeEnvs(4)
+	br := &blockingRenderer{gate: make(chan struct{})}
+	svc := newTestService(br, envs)
+
+	pr := domain.PRContext{
+		Owner: "o", Repo: "r", PRNumber: 1,
+		BaseRef: "main", HeadRef: "feature", HeadSHA: "abc",
+	}
+	config := domain.ChartConfig{
+		Path:         "charts/test-chart",
+		Environments: envs,
+	}
+
+	done := make(chan []domain.DiffResult, 1)
+	go func() {
+		done <- svc.processChart(context.Background(), pr, config)
+	}()
+
+	// Wait for all 4 base renders to be in-flight simultaneously
+	waitForActive(t, br, 4, "base renders")
+
+	// Release 4 base renders
+	for i := 0; i < 4; i++ {
+		br.gate <- struct{}{}
+	}
 
 	// Wait for all 4 head renders to be in-flight
 	waitForActive(t, br, 4, "head renders")
@@ -1088,26 +2697,24 @@ func TestProcessChart_MultipleEnvsCorrectResults(t *testing.T) {
 		},
 	}
 
-	svc := NewDiffService(
-		&mockSourceControl{charts: map[string]bool{
+	svc := NewDiffService(Deps{
+		SourceControl: &mockSourceControl{charts: map[string]bool{
 			"main:charts/test-chart":    true,
 			"feature:charts/test-chart": true,
 		}},
-		&mockChangedCharts{},
-		nil,
-		&mockEnvConfig{config: domain.ChartConfig{
+		ChangedCharts: &mockChangedCharts{},
+		FSEnvConfig: &mockEnvConfig{config: domain.ChartConfig{
 			Path:         "charts/test-chart",
 			Environments: envs,
 		}},
-		renderer,
-		&mockReporter{},
-		&mockDiff{},
-		&mockDiff{},
-		logger.New("error"),
-		noopmetric.NewMeterProvider().Meter("test"),
-		nooptrace.NewTracerProvider().Tracer("test"),
-		"charts", "chart_val",
-	)
+		Renderer:     renderer,
+		Reporter:     &mockReporter{},
+		SemanticDiff: &mockDiff{},
+		UnifiedDiff:  &mockDiff{},
+		Logger:       logger.New("error"),
+		Meter:        noopmetric.NewMeterProvider().Meter("test"),
+		Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+	}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 
 	pr := domain.PRContext{
 		Owner: "o", Repo: "r", PRNumber: 1,
@@ -1144,7 +2751,7 @@ func TestProcessChart_MultipleEnvsCorrectResults(t *testing.T) {
 // noopRenderer returns immediately — used for benchmarks.
 type noopRenderer struct{}
 
-func (n *noopRenderer) Render(_ context.Context, _ string, _ []string) ([]byte, error) {
+func (n *noopRenderer) Render(_ context.Context, _ string, _ []string, _ domain.RenderOptions) ([]byte, error) {
 	return []byte("manifest"), nil
 }
 
@@ -1158,23 +2765,21 @@ func BenchmarkProcessChart_EnvParallelism(b *testing.B) {
 					"main:charts/test-chart":    true,
 					"feature:charts/test-chart": true,
 				}
-				svc := NewDiffService(
-					&mockSourceControl{charts: charts},
-					&mockChangedCharts{},
-					nil,
-					&mockEnvConfig{config: domain.ChartConfig{
+				svc := NewDiffService(Deps{
+					SourceControl: &mockSourceControl{charts: charts},
+					ChangedCharts: &mockChangedCharts{},
+					FSEnvConfig: &mockEnvConfig{config: domain.ChartConfig{
 						Path:         "charts/test-chart",
 						Environments: envs,
 					}},
-					&noopRenderer{},
-					&mockReporter{},
-					&mockDiff{},
-					&mockDiff{},
-					logger.New("error"),
-					noopmetric.NewMeterProvider().Meter("test"),
-					nooptrace.NewTracerProvider().Tracer("test"),
-					"charts", "chart_val",
-				)
+					Renderer:     &noopRenderer{},
+					Reporter:     &mockReporter{},
+					SemanticDiff: &mockDiff{},
+					UnifiedDiff:  &mockDiff{},
+					Logger:       logger.New("error"),
+					Meter:        noopmetric.NewMeterProvider().Meter("test"),
+					Tracer:       nooptrace.NewTracerProvider().Tracer("test"),
+				}, Options{ChartDir: "charts", CommentMode: domain.CommentModePerChart, MetricPrefix: "chart_val"})
 				svc.maxEnvConcurrency = concurrency
 				pr := domain.PRContext{
 					Owner: "o", Repo: "r", PRNumber: 1,
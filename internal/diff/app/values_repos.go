@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
+)
+
+// valuesRepos resolves "$ref/path" value files from multi-source Argo
+// Applications. Refs pointing at the PR's own repository resolve inside the
+// base and head checkouts, so value changes in the PR show up in the diff.
+// Other repositories are fetched once per chart at their target revision.
+type valuesRepos struct {
+	pr        domain.PRContext
+	chartPath string            // Chart location within the PR repository
+	dirs      map[string]string // valuesRefKey -> local repo root
+	errs      map[string]error  // valuesRefKey -> fetch error
+}
+
+func valuesRefKey(ref domain.ValuesRef) string {
+	return ref.RepoURL + "@" + ref.Revision
+}
+
+// fetchValuesRepos fetches every external repository that the environments'
+// value files reference. Fetch failures are recorded and reported only for
+// the environments that need them. The returned cleanup removes the checkouts.
+func (s *DiffService) fetchValuesRepos(
+	ctx context.Context,
+	pr domain.PRContext,
+	chartPath string,
+	envs []domain.EnvironmentConfig,
+) (*valuesRepos, func()) {
+	repos := &valuesRepos{
+		pr:        pr,
+		chartPath: chartPath,
+		dirs:      make(map[string]string),
+		errs:      make(map[string]error),
+	}
+	var cleanups []func()
+
+	for _, env := range envs {
+		for _, ref := range referencedRefs(env) {
+			key := valuesRefKey(ref)
+			if repos.isPRRepo(ref) || repos.dirs[key] != "" || repos.errs[key] != nil {
+				continue
+			}
+
+			dir, cleanup, err := s.fetchValuesRepo(ctx, ref)
+			if err != nil {
+				s.logger.Error("failed to fetch values repo",
+					"repoURL", ref.RepoURL,
+					"revision", ref.Revision,
+					"error", err,
+				)
+				repos.errs[key] = err
+				continue
+			}
+			s.logger.Info("fetched values repo", "repoURL", ref.RepoURL, "revision", ref.Revision)
+			repos.dirs[key] = dir
+			cleanups = append(cleanups, cleanup)
+		}
+	}
+
+	return repos, func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+	}
+}
+
+func (s *DiffService) fetchValuesRepo(ctx context.Context, ref domain.ValuesRef) (string, func(), error) {
+	owner, repo, err := domain.ParseRepoURL(ref.RepoURL)
+	if err != nil {
+		return "", nil, err
+	}
+	revision := ref.Revision
+	if revision == "HEAD" {
+		revision = "" // Default branch
+	}
+	// An empty path returns the repository root
+	return s.sourceControl.FetchChartFiles(ctx, owner, repo, revision, "")
+}
+
+// referencedRefs returns the refs that env's value files actually use.
+func referencedRefs(env domain.EnvironmentConfig) []domain.ValuesRef {
+	var refs []domain.ValuesRef
+	for _, vf := range env.ValueFiles {
+		name, _, ok := domain.SplitValueFileRef(vf)
+		if !ok {
+			continue
+		}
+		if ref, found := findValuesRef(env.ValuesRefs, name); found {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func findValuesRef(refs []domain.ValuesRef, name string) (domain.ValuesRef, bool) {
+	for _, ref := range refs {
+		if ref.Name == name {
+			return ref, true
+		}
+	}
+	return domain.ValuesRef{}, false
+}
+
+func (r *valuesRepos) isPRRepo(ref domain.ValuesRef) bool {
+	owner, repo, err := domain.ParseRepoURL(ref.RepoURL)
+	return err == nil && strings.EqualFold(owner, r.pr.Owner) && strings.EqualFold(repo, r.pr.Repo)
+}
+
+// valueFiles returns env's value files for rendering the checkout at
+// chartDir, rewriting "$ref/path" entries into chartDir-relative paths.
+// A nil receiver returns the value files unchanged.
+func (r *valuesRepos) valueFiles(chartDir string, env domain.EnvironmentConfig) ([]string, error) {
+	if r == nil {
+		return env.ValueFiles, nil
+	}
+
+	files := make([]string, 0, len(env.ValueFiles))
+	for _, vf := range env.ValueFiles {
+		name, path, ok := domain.SplitValueFileRef(vf)
+		if !ok {
+			files = append(files, vf)
+			continue
+		}
+		resolved, err := r.resolve(chartDir, env, name, path)
+		if err != nil {
+			return nil, fmt.Errorf("value file %s: %w", vf, err)
+		}
+		files = append(files, resolved)
+	}
+	return files, nil
+}
+
+func (r *valuesRepos) resolve(chartDir string, env domain.EnvironmentConfig, name, path string) (string, error) {
+	ref, found := findValuesRef(env.ValuesRefs, name)
+	if !found {
+		return "", fmt.Errorf("no source with ref %q", name)
+	}
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("path %q must stay inside the referenced repository", path)
+	}
+
+	// chartDir is <checkout>/<chartPath>, so walk up from the chart to the repo root
+	if r.isPRRepo(ref) {
+		return filepath.Rel(r.chartPath, path)
+	}
+
+	key := valuesRefKey(ref)
+	if err := r.errs[key]; err != nil {
+		return "", fmt.Errorf("fetching %s: %w", ref.RepoURL, err)
+	}
+	return filepath.Rel(chartDir, filepath.Join(r.dirs[key], path))
+}
@@ -0,0 +1,75 @@
+package domain
+
+import "fmt"
+
+// APIDeprecation is a resource in a rendered manifest whose API version is
+// deprecated, or no longer served, in the environment's Kubernetes version.
+type APIDeprecation struct {
+	Resource     string // apiVersion/kind[/namespace]/name
+	APIVersion   string // e.g. "policy/v1beta1"
+	Kind         string
+	DeprecatedIn string // Kubernetes minor version, e.g. "1.21"
+	RemovedIn    string // Kubernetes minor version; empty if no removal is planned
+	Replacement  string // apiVersion to migrate to; empty if the kind has no replacement
+	Target       string // Kubernetes minor version checked against; empty for the newest release
+
+	// Removed is true if the target (or, without one, the newest release) no
+	// longer serves the API version
+	Removed bool
+
+	// SeverityError if the API version is removed in a known target, else
+	// SeverityWarning: without a target, the cluster may still serve it
+	Severity Severity
+
+	// Introduced is true if the base manifest doesn't use the API version for
+	// this resource, i.e. the PR adds it
+	Introduced bool
+}
+
+// Message describes the deprecation, e.g. "policy/v1beta1 PodDisruptionBudget
+// is not served by Kubernetes 1.29 (removed in 1.25); use policy/v1".
+func (d APIDeprecation) Message() string {
+	var msg string
+	switch {
+	case d.Removed && d.Target != "":
+		msg = fmt.Sprintf("%s %s is not served by Kubernetes %s (removed in %s)",
+			d.APIVersion, d.Kind, d.Target, d.RemovedIn)
+	case d.Removed:
+		msg = fmt.Sprintf("%s %s was removed in Kubernetes %s", d.APIVersion, d.Kind, d.RemovedIn)
+	case d.RemovedIn != "":
+		msg = fmt.Sprintf("%s %s is deprecated since Kubernetes %s and will be removed in %s",
+			d.APIVersion, d.Kind, d.DeprecatedIn, d.RemovedIn)
+	default:
+		msg = fmt.Sprintf("%s %s is deprecated since Kubernetes %s", d.APIVersion, d.Kind, d.DeprecatedIn)
+	}
+	if d.Replacement != "" {
+		msg += "; use " + d.Replacement
+	}
+	return msg
+}
+
+// CountDeprecations returns the number of removed API versions and the
+// number of deprecated ones; only removals from a known target fail the check
+// run, see Severity.
+func CountDeprecations(deprecations []APIDeprecation) (removed, deprecated int) {
+	for _, d := range deprecations {
+		if d.Removed {
+			removed++
+		} else {
+			deprecated++
+		}
+	}
+	return
+}
+
+// MarkIntroduced sets Introduced on the head deprecations that base doesn't
+// have for the same resource and API version.
+func MarkIntroduced(head, base []APIDeprecation) {
+	inBase := make(map[string]bool, len(base))
+	for _, d := range base {
+		inBase[d.Resource+" "+d.APIVersion] = true
+	}
+	for i := range head {
+		head[i].Introduced = !inBase[head[i].Resource+" "+head[i].APIVersion]
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import "testing"
+
+func TestAPIDeprecation_Message(t *testing.T) {
+	pdb := APIDeprecation{
+		APIVersion:   "policy/v1beta1",
+		Kind:         "PodDisruptionBudget",
+		DeprecatedIn: "1.21",
+		RemovedIn:    "1.25",
+		Replacement:  "policy/v1",
+	}
+	tests := []struct {
+		name string
+		d    func(d APIDeprecation) APIDeprecation
+		want string
+	}{
+		{
+			name: "deprecated",
+			d:    func(d APIDeprecation) APIDeprecation { d.Severity = SeverityWarning; return d },
+			want: "policy/v1beta1 PodDisruptionBudget is deprecated since Kubernetes 1.21 " +
+				"and will be removed in 1.25; use policy/v1",
+		},
+		{
+			name: "removed in the target version",
+			d: func(d APIDeprecation) APIDeprecation {
+				d.Removed, d.Severity, d.Target = true, SeverityError, "1.29"
+				return d
+			},
+			want: "policy/v1beta1 PodDisruptionBudget is not served by Kubernetes 1.29 (removed in 1.25); " +
+				"use policy/v1",
+		},
+		{
+			name: "removed without a target version",
+			d:    func(d APIDeprecation) APIDeprecation { d.Removed, d.Severity = true, SeverityWarning; return d },
+			want: "policy/v1beta1 PodDisruptionBudget was removed in Kubernetes 1.25; use policy/v1",
+		},
+		{
+			name: "deprecated without a planned removal or replacement",
+			d: func(APIDeprecation) APIDeprecation {
+				return APIDeprecation{
+					APIVersion: "v1", Kind: "ComponentStatus", DeprecatedIn: "1.19", Severity: SeverityWarning,
+				}
+			},
+			want: "v1 ComponentStatus is deprecated since Kubernetes 1.19",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d(pdb).Message(); got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkIntroduced(t *testing.T) {
+	base := []APIDeprecation{
+		{Resource: "policy/v1beta1/PodDisruptionBudget/web", APIVersion: "policy/v1beta1"},
+	}
+	head := []APIDeprecation{
+		{Resource: "policy/v1beta1/PodDisruptionBudget/web", APIVersion: "policy/v1beta1"},
+		{Resource: "batch/v1beta1/CronJob/cleanup", APIVersion: "batch/v1beta1", Removed: true},
+	}
+
+	MarkIntroduced(head, base)
+	if head[0].Introduced || !head[1].Introduced {
+		t.Errorf("Introduced = %v, %v, want false, true", head[0].Introduced, head[1].Introduced)
+	}
+
+	removed, deprecated := CountDeprecations(head)
+	if removed != 1 || deprecated != 1 {
+		t.Errorf("CountDeprecations() = %d, %d, want 1, 1", removed, deprecated)
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VersionBump is the part of a SemVer version that a release increments.
+type VersionBump int
+
+const (
+	// BumpNone leaves the version unchanged.
+	BumpNone VersionBump = iota
+	// BumpPrerelease only changes the pre-release label, e.g. 1.3.0-rc.1 to
+	// 1.3.0; the release it leads to carries the real bump.
+	BumpPrerelease
+	// BumpPatch increments the patch version, for backwards-compatible fixes.
+	BumpPatch
+	// BumpMinor increments the minor version, for backwards-compatible features.
+	BumpMinor
+	// BumpMajor increments the major version, for breaking changes.
+	BumpMajor
+)
+
+// String returns the string representation of the VersionBump.
+// Implements the Stringer interface.
+func (b VersionBump) String() string {
+	if b < 0 || int(b) >= len(versionBumpNames) {
+		return "unknown"
+	}
+	return versionBumpNames[b]
+}
+
+var versionBumpNames = [...]string{
+	BumpNone:       "none",
+	BumpPrerelease: "prerelease",
+	BumpPatch:      "patch",
+	BumpMinor:      "minor",
+	BumpMajor:      "major",
+}
+
+// VersionCheck is the outcome of comparing a chart's base and head Chart.yaml
+// versions against what changed in the chart between them.
+type VersionCheck struct {
+	BaseVersion string      // Version in base; "" for a new chart
+	HeadVersion string      // Version in head
+	Bump        VersionBump // Increment from BaseVersion to HeadVersion
+	Required    VersionBump // Smallest increment the changes warrant
+	Reasons     []string    // Why Required, e.g. "values.schema.json: image.tag removed"
+	Problem     string      // Why the version is wrong; "" when it's fine
+}
+
+// Failed reports whether the version doesn't follow SemVer or isn't bumped
+// enough for the changes.
+func (c VersionCheck) Failed() bool {
+	return c.Problem != ""
+}
+
+// Message returns a one-line description of the version change, e.g.
+// "1.2.3 → 1.2.4 (patch)", or "1.0.0 (new chart)".
+func (c VersionCheck) Message() string {
+	switch {
+	case c.BaseVersion == "":
+		return fmt.Sprintf("%s (new chart)", c.HeadVersion)
+	case c.BaseVersion == c.HeadVersion:
+		return fmt.Sprintf("%s (unchanged)", c.HeadVersion)
+	default:
+		return fmt.Sprintf("%s → %s (%s)", c.BaseVersion, c.HeadVersion, c.Bump)
+	}
+}
+
+// CheckVersionBump compares bump, the increment a chart's version makes, with
+// required, the smallest one its changes warrant, and returns why it falls
+// short, or "" if it doesn't. reasons explain required and are quoted in the
+// problem. Pre-release bumps always pass: the release they lead to is
+// checked when its pre-release series starts.
+func CheckVersionBump(baseVersion, headVersion string, bump, required VersionBump, reasons []string) string {
+	if bump >= required || bump == BumpPrerelease {
+		return ""
+	}
+	why := ""
+	if len(reasons) > 0 {
+		why = ": " + strings.Join(reasons, "; ")
+	}
+	if bump == BumpNone {
+		return fmt.Sprintf("chart content changed but version %s was not bumped; a %s bump is needed%s",
+			headVersion, required, why)
+	}
+	return fmt.Sprintf("%s → %s is a %s bump, but the changes need a %s bump%s",
+		baseVersion, headVersion, bump, required, why)
+}
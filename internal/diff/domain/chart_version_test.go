@@ -0,0 +1,103 @@
+package domain
+
+import "testing"
+
+func TestVersionCheck_Message(t *testing.T) {
+	tests := []struct {
+		name  string
+		check VersionCheck
+		want  string
+	}{
+		{
+			name:  "bumped",
+			check: VersionCheck{BaseVersion: "1.2.3", HeadVersion: "1.3.0", Bump: BumpMinor},
+			want:  "1.2.3 → 1.3.0 (minor)",
+		},
+		{
+			name:  "unchanged",
+			check: VersionCheck{BaseVersion: "1.2.3", HeadVersion: "1.2.3"},
+			want:  "1.2.3 (unchanged)",
+		},
+		{
+			name:  "new chart",
+			check: VersionCheck{HeadVersion: "0.1.0"},
+			want:  "0.1.0 (new chart)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check.Message(); got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckVersionBump(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     string
+		bump     VersionBump
+		required VersionBump
+		reasons  []string
+		want     string
+	}{
+		{
+			name:     "bump matches the changes",
+			bump:     BumpPatch,
+			required: BumpPatch,
+		},
+		{
+			name:     "bump bigger than needed",
+			bump:     BumpMajor,
+			required: BumpPatch,
+		},
+		{
+			name:     "pre-release bump",
+			bump:     BumpPrerelease,
+			required: BumpMajor,
+		},
+		{
+			name:     "nothing changed",
+			bump:     BumpNone,
+			required: BumpNone,
+		},
+		{
+			name:     "content changed without a bump",
+			head:     "1.2.3",
+			bump:     BumpNone,
+			required: BumpPatch,
+			reasons:  []string{"templates/deployment.yaml changed"},
+			want: "chart content changed but version 1.2.3 was not bumped; a patch bump is needed: " +
+				"templates/deployment.yaml changed",
+		},
+		{
+			name:     "bump too small",
+			head:     "1.2.4",
+			bump:     BumpPatch,
+			required: BumpMajor,
+			reasons:  []string{"values.schema.json: image.tag removed", "values.schema.json: port is now required"},
+			want: "1.2.3 → 1.2.4 is a patch bump, but the changes need a major bump: " +
+				"values.schema.json: image.tag removed; values.schema.json: port is now required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckVersionBump("1.2.3", tt.head, tt.bump, tt.required, tt.reasons)
+			if got != tt.want {
+				t.Errorf("CheckVersionBump() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionBump_String(t *testing.T) {
+	if got := BumpMajor.String(); got != "major" {
+		t.Errorf("BumpMajor.String() = %q, want %q", got, "major")
+	}
+	if got := VersionBump(42).String(); got != "unknown" {
+		t.Errorf("VersionBump(42).String() = %q, want %q", got, "unknown")
+	}
+}
@@ -0,0 +1,10 @@
+package domain
+
+// CommentMode is how diff results are posted as PR comments.
+type CommentMode string
+
+// Supported comment modes.
+const (
+	CommentModePerChart CommentMode = "per-chart" // A comment for each chart with changes
+	CommentModeSummary  CommentMode = "summary"   // One comment for the whole PR
+)
@@ -1,6 +1,11 @@
 // Package domain contains core business entities and types for diff operations.
 package domain
 
+import (
+	"path"
+	"strings"
+)
+
 // Status represents the outcome of a diff operation.
 type Status int
 
@@ -11,6 +16,9 @@ const (
 	StatusChanges
 	// StatusError indicates an error occurred during the diff operation.
 	StatusError
+	// StatusInvalid indicates the head manifests failed schema validation, or
+	// the head chart failed lint (and may not have rendered at all).
+	StatusInvalid
 )
 
 // String returns the string representation of the Status.
@@ -26,18 +34,62 @@ var statusNames = [...]string{
 	StatusSuccess: "Success",
 	StatusChanges: "Changes",
 	StatusError:   "Error",
+	StatusInvalid: "Invalid",
 }
 
 // DiffResult represents the diff output for a single chart + environment pair.
 type DiffResult struct {
 	ChartName    string
+	ChartPath    string // Chart location within the repository (e.g., "charts/my-app"); "" if unknown
 	Environment  string
 	BaseRef      string
 	HeadRef      string
 	Status       Status // Outcome of the diff operation
 	UnifiedDiff  string // Traditional line-based diff (go-difflib)
-	SemanticDiff string // Semantic YAML diff (dyff) - may be empty if dyff unavailable
+	SemanticDiff string // Semantic YAML diff - may be empty if manifests fail to parse
 	Summary      string // Human-readable summary (or error message if Status == StatusError)
+	ReportURL    string // Where the full diffs can be read when a report cuts them; "" if not stored
+
+	// Where the head chart failed to render, when Helm reported a template and line; only with StatusError
+	RenderError *RenderError
+
+	// Structured per-resource changes behind the diffs, for counts and filtering
+	Resources []ResourceChange
+
+	// Number of changes hidden by ignore rules; counted even when Status is StatusSuccess
+	Suppressed int
+
+	// Schema violations in the head manifests; set when Status is StatusInvalid
+	Violations []SchemaViolation
+
+	// Problems with the head chart and the environment's values, such as keys values.schema.json rejects;
+	// errors make Status StatusInvalid
+	LintFindings []LintFinding
+
+	// Policies the head manifests fail; errors fail the check run whatever the Status
+	PolicyViolations []PolicyViolation
+
+	// Deprecated or removed API versions in the head manifests, for the environment's kube version;
+	// removed ones fail the check run whatever the Status
+	Deprecations []APIDeprecation
+
+	// Risky resource changes (deletions, immutable field changes, ...); high ones need a reviewer's sign-off
+	Risks []ChangeRisk
+
+	// The chart's version bump, shared by all its environments; nil when not checked.
+	// A failed check fails the check run whatever the Status
+	ChartVersion *VersionCheck
+
+	// Keys of values.yaml and values.schema.json the PR removes, renames or changes the type of,
+	// shared by all the chart's environments
+	ValuesChanges []ValuesChange
+
+	// Removed or renamed keys the environment's values still set; warnings unless FAIL_ON_STALE_OVERRIDES
+	// is set, when they fail the check run whatever the Status
+	StaleOverrides []StaleOverride
+
+	// Keys the environment's values set that the chart neither declares nor reads; only warnings
+	UnusedValues []UnusedValue
 }
 
 // PreferredDiff returns the semantic diff if available, otherwise the unified diff.
@@ -49,8 +101,40 @@ func (r DiffResult) PreferredDiff() string {
 	return r.UnifiedDiff
 }
 
+// HasFindings reports whether the result is worth a PR comment: it has
+// changes, errors, schema violations, lint findings, stale or unused values,
+// policy violations, deprecated API versions, breaking values changes or a
+// failed chart version check.
+func (r DiffResult) HasFindings() bool {
+	return r.Status == StatusChanges || r.Status == StatusError || r.Status == StatusInvalid ||
+		len(r.LintFindings) > 0 || len(r.StaleOverrides) > 0 || len(r.UnusedValues) > 0 ||
+		len(r.PolicyViolations) > 0 || len(r.Deprecations) > 0 || len(r.ValuesChanges) > 0 ||
+		(r.ChartVersion != nil && r.ChartVersion.Failed())
+}
+
+// RepoPath returns the repository path of file, which is relative to the
+// chart. Inline values, parameters, directories, files outside the
+// repository (value files from other repositories) and subchart files
+// (dependencies may be fetched rather than committed) have none, nor do
+// files of a chart whose path is unknown.
+func (r DiffResult) RepoPath(file string) (string, bool) {
+	if r.ChartPath == "" || file == "" || file == "inline values" || strings.HasPrefix(file, "parameter ") ||
+		strings.HasSuffix(file, "/") {
+		return "", false
+	}
+	chartFile := path.Clean(file)
+	if path.IsAbs(chartFile) || chartFile == "charts" || strings.HasPrefix(chartFile, "charts/") {
+		return "", false
+	}
+	p := path.Join(r.ChartPath, chartFile)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", false
+	}
+	return p, true
+}
+
 // CountByStatus returns counts of results grouped by status.
-func CountByStatus(results []DiffResult) (success, changes, errors int) {
+func CountByStatus(results []DiffResult) (success, changes, errors, invalid int) {
 	for _, r := range results {
 		switch r.Status {
 		case StatusSuccess:
@@ -59,6 +143,8 @@ func CountByStatus(results []DiffResult) (success, changes, errors int) {
 			changes++
 		case StatusError:
 			errors++
+		case StatusInvalid:
+			invalid++
 		}
 	}
 	return
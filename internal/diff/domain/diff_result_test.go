@@ -10,7 +10,8 @@ func TestStatus_String(t *testing.T) {
 		{StatusSuccess, "Success"},
 		{StatusChanges, "Changes"},
 		{StatusError, "Error"},
-		{Status(99), "Unknown"}, // Invalid status
+		{StatusInvalid, "Invalid"},
+		{Status(99), "Unknown"}, // Out-of-range status
 		{Status(-1), "Unknown"}, // Negative status
 	}
 
@@ -69,6 +70,47 @@ func TestDiffResult_PreferredDiff(t *testing.T) {
 	}
 }
 
+func TestDiffResult_HasFindings(t *testing.T) {
+	tests := []struct {
+		name   string
+		result DiffResult
+		want   bool
+	}{
+		{name: "no changes", result: DiffResult{Status: StatusSuccess}, want: false},
+		{name: "changes", result: DiffResult{Status: StatusChanges}, want: true},
+		{name: "error", result: DiffResult{Status: StatusError}, want: true},
+		{name: "invalid", result: DiffResult{Status: StatusInvalid}, want: true},
+		{
+			name:   "lint finding without changes",
+			result: DiffResult{Status: StatusSuccess, LintFindings: []LintFinding{{Message: "icon is recommended"}}},
+			want:   true,
+		},
+		{
+			name:   "unused value without changes",
+			result: DiffResult{Status: StatusSuccess, UnusedValues: []UnusedValue{{Path: []string{"replicas"}}}},
+			want:   true,
+		},
+		{
+			name:   "passed version check",
+			result: DiffResult{Status: StatusSuccess, ChartVersion: &VersionCheck{BaseVersion: "1.0.0"}},
+			want:   false,
+		},
+		{
+			name:   "failed version check",
+			result: DiffResult{Status: StatusSuccess, ChartVersion: &VersionCheck{Problem: "version not bumped"}},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.HasFindings(); got != tt.want {
+				t.Errorf("HasFindings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCountByStatus(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -76,6 +118,7 @@ func TestCountByStatus(t *testing.T) {
 		wantSuccess int
 		wantChanges int
 		wantErrors  int
+		wantInvalid int
 	}{
 		{
 			name:        "empty results",
@@ -122,16 +165,18 @@ func TestCountByStatus(t *testing.T) {
 				{Status: StatusError},
 				{Status: StatusSuccess},
 				{Status: StatusChanges},
+				{Status: StatusInvalid},
 			},
 			wantSuccess: 2,
 			wantChanges: 2,
 			wantErrors:  1,
+			wantInvalid: 1,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotSuccess, gotChanges, gotErrors := CountByStatus(tt.results)
+			gotSuccess, gotChanges, gotErrors, gotInvalid := CountByStatus(tt.results)
 			if gotSuccess != tt.wantSuccess {
 				t.Errorf("CountByStatus() success = %v, want %v", gotSuccess, tt.wantSuccess)
 			}
@@ -141,6 +186,9 @@ func TestCountByStatus(t *testing.T) {
 			if gotErrors != tt.wantErrors {
 				t.Errorf("CountByStatus() errors = %v, want %v", gotErrors, tt.wantErrors)
 			}
+			if gotInvalid != tt.wantInvalid {
+				t.Errorf("CountByStatus() invalid = %v, want %v", gotInvalid, tt.wantInvalid)
+			}
 		})
 	}
 }
@@ -274,3 +322,31 @@ func TestGroupByChart(t *testing.T) {
 		})
 	}
 }
+
+func TestDiffResult_RepoPath(t *testing.T) {
+	tests := []struct {
+		chartPath string
+		file      string
+		want      string // "" for no path
+	}{
+		{"charts/my-app", "env/prod-values.yaml", "charts/my-app/env/prod-values.yaml"},
+		{"charts/my-app", "templates/../values.yaml", "charts/my-app/values.yaml"},
+		{"charts/my-app", "../other-app/values.yaml", "charts/other-app/values.yaml"},
+		{"", "values.yaml", ""},
+		{"charts/my-app", "", ""},
+		{"charts/my-app", "inline values", ""},
+		{"charts/my-app", "parameter image.tag", ""},
+		{"charts/my-app", "templates/", ""},
+		{"charts/my-app", "/etc/values.yaml", ""},
+		{"charts/my-app", "charts/redis/values.yaml", ""},
+		{"charts", "../../values.yaml", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chartPath+":"+tt.file, func(t *testing.T) {
+			got, ok := DiffResult{ChartPath: tt.chartPath}.RepoPath(tt.file)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("RepoPath(%q) = %q, %v, want %q", tt.file, got, ok, tt.want)
+			}
+		})
+	}
+}
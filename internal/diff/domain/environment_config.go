@@ -3,9 +3,25 @@ package domain
 // EnvironmentConfig holds the specific environment context and
 // the ordered list of values file paths (Helm applies left-to-right).
 type EnvironmentConfig struct {
-	Name       string
-	ValueFiles []string
-	Message    string // Optional message (e.g., for base charts not deployed)
+	Name          string
+	ValueFiles    []string
+	RenderOptions RenderOptions // How the environment is deployed (release, namespace, cluster)
+	ValuesRefs    []ValuesRef   // Repos that "$ref/..." value files point into (Argo multi-source)
+	Message       string        // Optional message (e.g., for base charts not deployed)
+}
+
+// RenderOptions mirrors the deploy-time settings that templates can observe
+// through .Release, .Capabilities and .Values. Zero values use renderer defaults.
+//
+// Values follow Argo CD's precedence, lowest to highest: value files, inline
+// Values, then Parameters.
+type RenderOptions struct {
+	ReleaseName string          // .Release.Name (default: "chart-val-render")
+	Namespace   string          // .Release.Namespace (default: "default")
+	KubeVersion string          // .Capabilities.KubeVersion (e.g., "1.29.0")
+	APIVersions []string        // Extra .Capabilities.APIVersions (e.g., "monitoring.coreos.com/v1")
+	Values      string          // Inline values YAML, applied after value files
+	Parameters  []HelmParameter // Individual overrides, applied last (--set, --set-string, --set-file)
 }
 
 // ChartConfig defines a chart to validate and its environments.
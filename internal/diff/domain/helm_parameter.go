@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// HelmParameter is a single value override, equivalent to a Helm --set flag.
+type HelmParameter struct {
+	Name        string // Dotted value path (e.g., "image.tag")
+	Value       string // Literal value, or a chart-relative file path when File is set
+	ForceString bool   // --set-string: never coerce Value to a number or bool
+	File        bool   // --set-file: Value names a file whose contents are used
+}
+
+// SetArg formats the parameter as a Helm "name=value" --set argument.
+// Commas are escaped so a single parameter cannot set several keys, matching
+// Argo CD; list literals like "{a,b}" are passed through unchanged.
+func (p HelmParameter) SetArg() string {
+	value := p.Value
+	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") {
+		value = escapeCommas(value)
+	}
+	return p.Name + "=" + value
+}
+
+// ResolveFile returns the path of a --set-file parameter within chartDir.
+// The path must stay inside the chart so a manifest cannot read arbitrary
+// files from the host running the render.
+func (p HelmParameter) ResolveFile(chartDir string) (string, error) {
+	if !filepath.IsLocal(p.Value) {
+		return "", fmt.Errorf("file parameter %s: path %q must be relative and inside the chart", p.Name, p.Value)
+	}
+	return filepath.Join(chartDir, p.Value), nil
+}
+
+func escapeCommas(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == ',' && (i == 0 || s[i-1] != '\\') {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHelmParameter_SetArg(t *testing.T) {
+	tests := []struct {
+		name  string
+		param HelmParameter
+		want  string
+	}{
+		{name: "plain value", param: HelmParameter{Name: "image.tag", Value: "1.2.3"}, want: "image.tag=1.2.3"},
+		{name: "comma escaped", param: HelmParameter{Name: "hosts", Value: "a,b"}, want: `hosts=a\,b`},
+		{name: "already escaped", param: HelmParameter{Name: "hosts", Value: `a\,b`}, want: `hosts=a\,b`},
+		{name: "list literal", param: HelmParameter{Name: "hosts", Value: "{a,b}"}, want: "hosts={a,b}"},
+		{name: "empty value", param: HelmParameter{Name: "suffix"}, want: "suffix="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.param.SetArg(); got != tt.want {
+				t.Errorf("SetArg() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelmParameter_ResolveFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "chart relative", value: "files/config.json", want: filepath.Join("/charts/app", "files/config.json")},
+		{name: "absolute path rejected", value: "/etc/passwd", wantErr: true},
+		{name: "escaping chart rejected", value: "../../secrets.yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := HelmParameter{Name: "config", Value: tt.value, File: true}
+			got, err := p.ResolveFile("/charts/app")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "path"
+
+// IgnoreRule suppresses changes to noisy fields (generated labels, checksums,
+// random values) so they don't show up as diffs. A rule applies to resources
+// matching its selector and to the fields matching Path or Pattern.
+type IgnoreRule struct {
+	// Resource selector; empty fields match any resource. Name supports
+	// glob patterns (e.g. "my-app-*").
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+
+	// Field selector; exactly one should be set.
+	Path    string // JSON path, e.g. `.metadata.labels["helm.sh/chart"]` or `.spec.containers[*].image`
+	Pattern string // Regex matched against dot-separated field paths (e.g. "spec.template.metadata.labels.app")
+}
+
+// Selects reports whether the rule applies to the given resource.
+func (r IgnoreRule) Selects(resource ResourceChange) bool {
+	if r.APIVersion != "" && r.APIVersion != resource.APIVersion {
+		return false
+	}
+	if r.Kind != "" && r.Kind != resource.Kind {
+		return false
+	}
+	if r.Namespace != "" && r.Namespace != resource.Namespace {
+		return false
+	}
+	if r.Name != "" {
+		if ok, err := path.Match(r.Name, resource.Name); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultIgnoreRules returns the rules applied to every repository: the
+// helm.sh/chart label (changes with every chart version bump) and checksum/*
+// annotations (change whenever the config they hash changes, which the diff
+// already shows).
+func DefaultIgnoreRules() []IgnoreRule {
+	return []IgnoreRule{
+		{Pattern: `(^|\.)labels\.helm\.sh/chart$`},
+		{Pattern: `(^|\.)annotations\.checksum/[^.]+$`},
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "testing"
+
+func TestIgnoreRule_Selects(t *testing.T) {
+	resource := ResourceChange{APIVersion: "v1", Kind: "Secret", Namespace: "prod", Name: "my-app-token"}
+
+	tests := []struct {
+		name string
+		rule IgnoreRule
+		want bool
+	}{
+		{"empty selector matches everything", IgnoreRule{}, true},
+		{"kind and namespace", IgnoreRule{Kind: "Secret", Namespace: "prod"}, true},
+		{"name glob", IgnoreRule{Name: "my-app-*"}, true},
+		{"other kind", IgnoreRule{Kind: "ConfigMap"}, false},
+		{"other apiVersion", IgnoreRule{APIVersion: "v2", Kind: "Secret"}, false},
+		{"name glob mismatch", IgnoreRule{Name: "other-*"}, false},
+		{"invalid name glob", IgnoreRule{Name: "[my-app"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Selects(resource); got != tt.want {
+				t.Errorf("Selects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
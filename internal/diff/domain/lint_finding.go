@@ -0,0 +1,49 @@
+package domain
+
+import "fmt"
+
+// LintFinding is a problem with a chart or with the values an environment
+// gives it, such as a template that doesn't render or a value that
+// values.schema.json rejects.
+type LintFinding struct {
+	// Where the problem is: a file relative to the chart (e.g. "env/prod-values.yaml"),
+	// "inline values" or "parameter <name>"; "" if it isn't in one place
+	File string
+
+	Line     int      // 1-based line in File the problem is at; 0 if unknown
+	Key      string   // Values key the problem is at, e.g. "image.tag" or "ports[0]"; "" if none
+	Message  string   // e.g. "got string, want integer"
+	Severity Severity // SeverityError or SeverityWarning
+}
+
+// Location returns where a finding points reviewers, e.g.
+// "env/prod-values.yaml:12: image.tag", or "" if it doesn't point anywhere.
+func (f LintFinding) Location() string {
+	file := f.File
+	if file != "" && f.Line > 0 {
+		file = fmt.Sprintf("%s:%d", file, f.Line)
+	}
+	switch {
+	case file != "" && f.Key != "":
+		return file + ": " + f.Key
+	case file != "":
+		return file
+	default:
+		return f.Key
+	}
+}
+
+// CountLintFindings returns the number of findings that fail the check run
+// and the number that are only warnings.
+func CountLintFindings(findings []LintFinding) (errors, warnings int) {
+	for _, f := range findings {
+		switch f.Severity {
+		case SeverityError:
+			errors++
+		case SeverityWarning:
+			warnings++
+		case SeverityOff:
+		}
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "testing"
+
+func TestLintFinding_Location(t *testing.T) {
+	tests := []struct {
+		name    string
+		finding LintFinding
+		want    string
+	}{
+		{"file and key", LintFinding{File: "env/prod.yaml", Key: "image.tag"}, "env/prod.yaml: image.tag"},
+		{
+			"file, line and key",
+			LintFinding{File: "env/prod.yaml", Line: 12, Key: "image.tag"},
+			"env/prod.yaml:12: image.tag",
+		},
+		{"file only", LintFinding{File: "templates/deployment.yaml"}, "templates/deployment.yaml"},
+		{"file and line", LintFinding{File: "templates/deployment.yaml", Line: 7}, "templates/deployment.yaml:7"},
+		{"line without a file", LintFinding{Line: 7, Key: "replicas"}, "replicas"},
+		{"key only", LintFinding{Key: "replicas"}, "replicas"},
+		{"neither", LintFinding{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.finding.Location(); got != tt.want {
+				t.Errorf("Location() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountLintFindings(t *testing.T) {
+	errors, warnings := CountLintFindings([]LintFinding{
+		{Key: "replicas", Severity: SeverityError},
+		{File: "templates/", Severity: SeverityWarning},
+		{Key: "image.tag", Severity: SeverityError},
+	})
+	if errors != 2 || warnings != 1 {
+		t.Errorf("CountLintFindings() = %d, %d, want 2, 1", errors, warnings)
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "slices"
+
+// Severity is how a failing policy affects the check run.
+type Severity string
+
+const (
+	// SeverityError fails the check run.
+	SeverityError Severity = "error"
+	// SeverityWarning is reported without failing the check run.
+	SeverityWarning Severity = "warning"
+	// SeverityOff skips the policy.
+	SeverityOff Severity = "off"
+)
+
+// Policy is an organization rule that every resource in a head render must
+// satisfy, such as "images are pinned" or "no hostNetwork in prod".
+type Policy struct {
+	Name       string
+	Message    string   // Shown for each failing resource, e.g. "images must not use the latest tag"
+	Kinds      []string // Kinds the policy applies to; empty for all
+	Expression string   // CEL, true when a resource passes (variables: object, environment)
+
+	Severity     Severity            // Default severity; SeverityError if empty
+	Environments map[string]Severity // Per-environment severity overrides, e.g. {"prod": SeverityError}
+}
+
+// SeverityFor returns the policy's severity in the named environment.
+func (p Policy) SeverityFor(envName string) Severity {
+	if s, ok := p.Environments[envName]; ok {
+		return s
+	}
+	if p.Severity == "" {
+		return SeverityError
+	}
+	return p.Severity
+}
+
+// AppliesTo reports whether the policy checks resources of the given kind.
+func (p Policy) AppliesTo(kind string) bool {
+	return len(p.Kinds) == 0 || slices.Contains(p.Kinds, kind)
+}
+
+// PolicyViolation is a resource in a head render that fails a policy.
+type PolicyViolation struct {
+	Policy   string // Policy name
+	Resource string // apiVersion/kind[/namespace]/name
+	Message  string
+	Severity Severity // SeverityError or SeverityWarning
+	Template string   // Template that rendered the resource, e.g. "my-app/templates/deployment.yaml"; "" if unknown
+}
+
+// CountPolicyViolations returns the number of violations that fail the check
+// run and the number that are only warnings.
+func CountPolicyViolations(violations []PolicyViolation) (errors, warnings int) {
+	for _, v := range violations {
+		switch v.Severity {
+		case SeverityError:
+			errors++
+		case SeverityWarning:
+			warnings++
+		case SeverityOff:
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "testing"
+
+func TestPolicy_SeverityFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		env    string
+		want   Severity
+	}{
+		{"defaults to error", Policy{}, "dev", SeverityError},
+		{"policy severity", Policy{Severity: SeverityWarning}, "dev", SeverityWarning},
+		{
+			"environment override",
+			Policy{Severity: SeverityOff, Environments: map[string]Severity{"prod": SeverityError}},
+			"prod",
+			SeverityError,
+		},
+		{
+			"other environments keep the default",
+			Policy{Severity: SeverityOff, Environments: map[string]Severity{"prod": SeverityError}},
+			"dev",
+			SeverityOff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.SeverityFor(tt.env); got != tt.want {
+				t.Errorf("SeverityFor(%q) = %q, want %q", tt.env, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_AppliesTo(t *testing.T) {
+	if !(Policy{}).AppliesTo("Deployment") {
+		t.Error("policy without kinds should apply to every kind")
+	}
+	p := Policy{Kinds: []string{"Deployment", "StatefulSet"}}
+	if !p.AppliesTo("StatefulSet") || p.AppliesTo("Service") {
+		t.Errorf("AppliesTo() mismatch for kinds %v", p.Kinds)
+	}
+}
+
+func TestCountPolicyViolations(t *testing.T) {
+	errors, warnings := CountPolicyViolations([]PolicyViolation{
+		{Policy: "no-latest", Severity: SeverityError},
+		{Policy: "limits", Severity: SeverityWarning},
+		{Policy: "no-latest", Severity: SeverityError},
+	})
+	if errors != 2 || warnings != 1 {
+		t.Errorf("CountPolicyViolations() = %d, %d, want 2, 1", errors, warnings)
+	}
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RenderError describes a Helm template failure that can be traced back to a
+// specific template file, so reporters can point reviewers at the offending line.
+type RenderError struct {
+	Template string // Template path as reported by Helm (e.g., "my-app/templates/deployment.yaml")
+	Line     int    // 1-based line number within the template
+	Message  string // Error detail without the location prefix
+}
+
+func (e *RenderError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Template, e.Line, e.Message)
+}
+
+// Helm surfaces template failures in a few shapes depending on whether the
+// failure happened while parsing, executing, or via the `fail`/`required` funcs:
+//
+//	template: my-app/templates/deployment.yaml:12:20: executing "..." at <.Values.x>: ...
+//	parse error at (my-app/templates/deployment.yaml:5): function "foo" not defined
+//	execution error at (my-app/templates/deployment.yaml:7:4): image.tag is required
+var renderErrorPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`template: ([^\s:]+):(\d+)(?::\d+)?: (.+)`),
+	regexp.MustCompile(`parse error at \(([^\s:]+):(\d+)\): (.+)`),
+	regexp.MustCompile(`execution error at \(([^\s:]+):(\d+)(?::\d+)?\): (.+)`),
+}
+
+// ParseRenderError extracts the template location from Helm error output.
+// Returns nil if the output does not reference a template file and line.
+func ParseRenderError(output string) *RenderError {
+	for _, line := range strings.Split(output, "\n") {
+		for _, re := range renderErrorPatterns {
+			m := re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			lineNum, err := strconv.Atoi(m[2])
+			if err != nil {
+				continue
+			}
+			return &RenderError{
+				Template: m[1],
+				Line:     lineNum,
+				Message:  strings.TrimSpace(m[3]),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package domain
+
+import "testing"
+
+func TestParseRenderError(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   *RenderError
+	}{
+		{
+			name: "execution failure",
+			output: `template: my-app/templates/deployment.yaml:12:20: executing "my-app/templates/deployment.yaml" ` +
+				`at <.Values.image.tag>: nil pointer evaluating interface {}.tag`,
+			want: &RenderError{
+				Template: "my-app/templates/deployment.yaml",
+				Line:     12,
+				Message: `executing "my-app/templates/deployment.yaml" at <.Values.image.tag>: ` +
+					`nil pointer evaluating interface {}.tag`,
+			},
+		},
+		{
+			name:   "parse failure",
+			output: `Error: parse error at (my-app/templates/service.yaml:5): function "foo" not defined`,
+			want: &RenderError{
+				Template: "my-app/templates/service.yaml",
+				Line:     5,
+				Message:  `function "foo" not defined`,
+			},
+		},
+		{
+			name:   "required value",
+			output: "Error: execution error at (my-app/templates/deployment.yaml:7:4): image.tag is required\n",
+			want: &RenderError{
+				Template: "my-app/templates/deployment.yaml",
+				Line:     7,
+				Message:  "image.tag is required",
+			},
+		},
+		{
+			name: "location on later line",
+			output: "walk.go:74: found symbolic link\n" +
+				"Error: parse error at (my-app/templates/cm.yaml:3): unexpected EOF",
+			want: &RenderError{
+				Template: "my-app/templates/cm.yaml",
+				Line:     3,
+				Message:  "unexpected EOF",
+			},
+		},
+		{
+			name:   "no location",
+			output: "Error: Chart.yaml file is missing",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseRenderError(tt.output)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("ParseRenderError() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("ParseRenderError() = nil, want %+v", tt.want)
+			}
+			if *got != *tt.want {
+				t.Errorf("ParseRenderError() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderError_Error(t *testing.T) {
+	err := &RenderError{Template: "my-app/templates/deployment.yaml", Line: 7, Message: "image.tag is required"}
+
+	want := "my-app/templates/deployment.yaml:7: image.tag is required"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeType classifies how a resource or field differs between base and head.
+type ChangeType string
+
+const (
+	// ChangeAdded indicates the resource or field only exists in head.
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved indicates the resource or field only exists in base.
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified indicates the resource or field exists in both but differs.
+	ChangeModified ChangeType = "modified"
+)
+
+// ResourceChange describes how a single Kubernetes resource differs between
+// the base and head manifests.
+type ResourceChange struct {
+	APIVersion string
+	Kind       string
+	Namespace  string // Empty for cluster-scoped or unset namespaces
+	Name       string
+	Type       ChangeType
+	Fields     []FieldChange // Per-field changes, set for modified resources
+}
+
+// FieldChange is a single change at a path within a resource.
+type FieldChange struct {
+	Path string // Dot-separated, list items by name (e.g. "spec.template.spec.containers.app.image")
+	Type ChangeType
+	Old  string // Base value as YAML, empty when added
+	New  string // Head value as YAML, empty when removed
+}
+
+// ID returns apiVersion/kind[/namespace]/name, e.g. "apps/v1/Deployment/my-app".
+// Empty parts are skipped.
+func (r ResourceChange) ID() string {
+	parts := make([]string, 0, 4)
+	for _, part := range []string{r.APIVersion, r.Kind, r.Namespace, r.Name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// ManifestDiff is the output of a DiffPort: the rendered diff text plus the
+// resource-level changes behind it.
+type ManifestDiff struct {
+	Text      string           // Empty when there are no differences
+	Resources []ResourceChange // Nil when the implementation cannot tell resources apart
+	Unparsed  bool             // Set when the manifests couldn't be parsed, so Text and Resources are empty
+}
+
+// FilterResourceChanges returns the changes of the given type, preserving order.
+func FilterResourceChanges(changes []ResourceChange, changeType ChangeType) []ResourceChange {
+	var filtered []ResourceChange
+	for _, c := range changes {
+		if c.Type == changeType {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// SummarizeResourceChanges describes changes by kind, e.g.
+// "2 Deployments modified, 1 Service added". Modified resources are listed
+// first, then added, then removed; kinds keep their first-seen order.
+// Returns an empty string when there are no changes.
+func SummarizeResourceChanges(changes []ResourceChange) string {
+	var parts []string
+	for _, changeType := range []ChangeType{ChangeModified, ChangeAdded, ChangeRemoved} {
+		counts := make(map[string]int)
+		var kinds []string
+		for _, c := range FilterResourceChanges(changes, changeType) {
+			if counts[c.Kind] == 0 {
+				kinds = append(kinds, c.Kind)
+			}
+			counts[c.Kind]++
+		}
+		for _, kind := range kinds {
+			parts = append(parts, fmt.Sprintf("%d %s %s", counts[kind], pluralKind(kind, counts[kind]), changeType))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// pluralKind returns the English plural of a Kubernetes kind when n != 1
+// (Deployment → Deployments, Ingress → Ingresses, NetworkPolicy → NetworkPolicies).
+func pluralKind(kind string, n int) string {
+	if n == 1 || kind == "" {
+		return kind
+	}
+	lower := strings.ToLower(kind)
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return kind + "es"
+	case len(lower) > 1 && strings.HasSuffix(lower, "y") && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return kind[:len(kind)-1] + "ies"
+	default:
+		return kind + "s"
+	}
+}
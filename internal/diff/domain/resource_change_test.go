@@ -0,0 +1,78 @@
+package domain
+
+import "testing"
+
+func TestResourceChange_ID(t *testing.T) {
+	tests := []struct {
+		change ResourceChange
+		want   string
+	}{
+		{ResourceChange{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}, "apps/v1/Deployment/web"},
+		{ResourceChange{APIVersion: "v1", Kind: "Service", Namespace: "prod", Name: "web"}, "v1/Service/prod/web"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.ID(); got != tt.want {
+			t.Errorf("ID() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeResourceChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []ResourceChange
+		want    string
+	}{
+		{
+			name: "no changes",
+			want: "",
+		},
+		{
+			name: "grouped by type then kind",
+			changes: []ResourceChange{
+				{Kind: "Service", Type: ChangeAdded},
+				{Kind: "Deployment", Type: ChangeModified},
+				{Kind: "ConfigMap", Type: ChangeRemoved},
+				{Kind: "Deployment", Type: ChangeModified},
+			},
+			want: "2 Deployments modified, 1 Service added, 1 ConfigMap removed",
+		},
+		{
+			name: "irregular plurals",
+			changes: []ResourceChange{
+				{Kind: "Ingress", Type: ChangeAdded},
+				{Kind: "Ingress", Type: ChangeAdded},
+				{Kind: "NetworkPolicy", Type: ChangeAdded},
+				{Kind: "NetworkPolicy", Type: ChangeAdded},
+				{Kind: "Gateway", Type: ChangeAdded},
+				{Kind: "Gateway", Type: ChangeAdded},
+			},
+			want: "2 Ingresses added, 2 NetworkPolicies added, 2 Gateways added",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SummarizeResourceChanges(tt.changes); got != tt.want {
+				t.Errorf("SummarizeResourceChanges() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterResourceChanges(t *testing.T) {
+	changes := []ResourceChange{
+		{Name: "a", Type: ChangeAdded},
+		{Name: "b", Type: ChangeRemoved},
+		{Name: "c", Type: ChangeRemoved},
+	}
+
+	removed := FilterResourceChanges(changes, ChangeRemoved)
+	if len(removed) != 2 || removed[0].Name != "b" || removed[1].Name != "c" {
+		t.Errorf("FilterResourceChanges(removed) = %+v", removed)
+	}
+	if got := FilterResourceChanges(changes, ChangeModified); got != nil {
+		t.Errorf("FilterResourceChanges(modified) = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,265 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RiskLevel ranks how disruptive applying a change may be.
+type RiskLevel int
+
+const (
+	// RiskLow is an ordinary change, such as a label or image update.
+	RiskLow RiskLevel = iota
+	// RiskMedium is a change worth a second look, such as deleting a stateless resource.
+	RiskMedium
+	// RiskHigh is a change that can lose data or take a workload down, and
+	// needs a reviewer's explicit sign-off.
+	RiskHigh
+)
+
+// String returns the string representation of the RiskLevel.
+// Implements the Stringer interface.
+func (l RiskLevel) String() string {
+	if l < 0 || int(l) >= len(riskLevelNames) {
+		return "unknown"
+	}
+	return riskLevelNames[l]
+}
+
+var riskLevelNames = [...]string{
+	RiskLow:    "low",
+	RiskMedium: "medium",
+	RiskHigh:   "high",
+}
+
+// RiskReason is why a resource change is risky.
+type RiskReason string
+
+const (
+	// RiskDeletion is a resource that only exists in base.
+	RiskDeletion RiskReason = "deletion"
+	// RiskImmutableField is a change to a field the API server won't update in
+	// place, so the resource has to be deleted and recreated.
+	RiskImmutableField RiskReason = "immutable-field"
+	// RiskScaleToZero is a workload whose replicas drop to zero.
+	RiskScaleToZero RiskReason = "scale-to-zero"
+	// RiskNamespaceChange is a resource that moves to another namespace, which
+	// deletes it from the old one.
+	RiskNamespaceChange RiskReason = "namespace-change"
+	// RiskAPIVersionChange is a resource moving to another apiVersion of its
+	// kind, which the API server converts in place.
+	RiskAPIVersionChange RiskReason = "api-version-change"
+	// RiskUnassessed is a modified resource of a kind with field-level risks
+	// whose field changes are unknown, so those risks couldn't be checked.
+	RiskUnassessed RiskReason = "unassessed"
+)
+
+// ChangeRisk is a resource change classified as risky.
+type ChangeRisk struct {
+	Resource string // apiVersion/kind[/namespace]/name
+	Level    RiskLevel
+	Reason   RiskReason
+	Detail   string // e.g. "spec.selector is immutable; the Deployment must be deleted and recreated"
+}
+
+// dataLossKinds are kinds whose deletion can lose data or take down
+// everything that depends on them.
+var dataLossKinds = []string{
+	"PersistentVolumeClaim",
+	"PersistentVolume",
+	"StatefulSet",
+	"Namespace",
+	"CustomResourceDefinition",
+}
+
+// immutableFields lists, by kind, the field paths the API server rejects
+// changes to (a change anywhere below a path counts).
+var immutableFields = map[string][]string{
+	"Deployment": {"spec.selector"},
+	"ReplicaSet": {"spec.selector"},
+	"DaemonSet":  {"spec.selector"},
+	"StatefulSet": {
+		"spec.selector", "spec.serviceName", "spec.volumeClaimTemplates", "spec.podManagementPolicy",
+	},
+	"Job":     {"spec.selector", "spec.template"},
+	"Service": {"spec.clusterIP", "spec.clusterIPs"},
+	"PersistentVolumeClaim": {
+		"spec.storageClassName", "spec.accessModes", "spec.volumeName", "spec.volumeMode", "spec.selector",
+	},
+	"StorageClass":       {"provisioner", "parameters", "reclaimPolicy", "volumeBindingMode"},
+	"RoleBinding":        {"roleRef"},
+	"ClusterRoleBinding": {"roleRef"},
+}
+
+// scalableKinds are workload kinds whose spec.replicas can drop to zero.
+var scalableKinds = []string{"Deployment", "StatefulSet", "ReplicaSet"}
+
+// ClassifyRisks returns the risky changes among changes: deletions, changes
+// to immutable fields, replicas dropping to zero, resources moving to
+// another namespace and apiVersion migrations. Ordinary changes are left out.
+// Field-level risks need the Fields of modified resources; a modified
+// resource without them is reported as unassessed rather than assumed safe.
+func ClassifyRisks(changes []ResourceChange) []ChangeRisk {
+	replaced := replacements(changes)
+
+	var risks []ChangeRisk
+	for i, c := range changes {
+		if to, ok := replaced[i]; ok {
+			risks = append(risks, replacementRisk(c, to))
+			continue
+		}
+
+		switch c.Type {
+		case ChangeRemoved:
+			risks = append(risks, deletionRisk(c))
+		case ChangeModified:
+			risks = append(risks, fieldRisks(c)...)
+		case ChangeAdded:
+		}
+	}
+	return risks
+}
+
+// DeletedResources returns the removed resources among changes that are
+// deleted from the cluster, leaving out those added back under another
+// apiVersion in the same namespace.
+func DeletedResources(changes []ResourceChange) []ResourceChange {
+	replaced := replacements(changes)
+	var deleted []ResourceChange
+	for i, c := range changes {
+		if c.Type != ChangeRemoved {
+			continue
+		}
+		if to, ok := replaced[i]; ok && to.Namespace == c.Namespace {
+			continue
+		}
+		deleted = append(deleted, c)
+	}
+	return deleted
+}
+
+// HighestRisk returns the highest level among risks, or RiskLow if there are none.
+func HighestRisk(risks []ChangeRisk) RiskLevel {
+	highest := RiskLow
+	for _, r := range risks {
+		highest = max(highest, r.Level)
+	}
+	return highest
+}
+
+// replacements matches removed resources with the added ones replacing
+// them: the same kind and name under another apiVersion, preferably in the
+// same namespace, or in another namespace. It returns the indexes of the
+// removed changes mapped to their replacements.
+func replacements(changes []ResourceChange) map[int]ResourceChange {
+	sameNamespace := make(map[string]ResourceChange)
+	byName := make(map[string]ResourceChange)
+	for _, c := range changes {
+		if c.Type == ChangeAdded {
+			sameNamespace[c.Kind+"/"+c.Namespace+"/"+c.Name] = c
+			byName[c.Kind+"/"+c.Name] = c
+		}
+	}
+
+	replaced := make(map[int]ResourceChange)
+	for i, c := range changes {
+		if c.Type != ChangeRemoved {
+			continue
+		}
+		if to, ok := sameNamespace[c.Kind+"/"+c.Namespace+"/"+c.Name]; ok {
+			replaced[i] = to
+		} else if to, ok := byName[c.Kind+"/"+c.Name]; ok {
+			replaced[i] = to
+		}
+	}
+	return replaced
+}
+
+// replacementRisk returns the risk of removed resource c being replaced by
+// to. Moving namespace deletes it from the old one; moving apiVersion
+// converts it in place, which only warrants a look for kinds holding data.
+func replacementRisk(c, to ResourceChange) ChangeRisk {
+	if to.Namespace != c.Namespace {
+		return ChangeRisk{
+			Resource: c.ID(),
+			Level:    RiskHigh,
+			Reason:   RiskNamespaceChange,
+			Detail: fmt.Sprintf("moves from namespace %q to %q, which deletes it from the old one",
+				c.Namespace, to.Namespace),
+		}
+	}
+	risk := ChangeRisk{
+		Resource: to.ID(),
+		Level:    RiskLow,
+		Reason:   RiskAPIVersionChange,
+		Detail: fmt.Sprintf("apiVersion changes from %s to %s; the %s is updated in place",
+			c.APIVersion, to.APIVersion, c.Kind),
+	}
+	if slices.Contains(dataLossKinds, c.Kind) {
+		risk.Level = RiskMedium
+		risk.Detail += ", check the new version keeps its fields"
+	}
+	return risk
+}
+
+func deletionRisk(c ResourceChange) ChangeRisk {
+	if slices.Contains(dataLossKinds, c.Kind) {
+		return ChangeRisk{
+			Resource: c.ID(),
+			Level:    RiskHigh,
+			Reason:   RiskDeletion,
+			Detail:   fmt.Sprintf("deleting a %s can lose data or take down what depends on it", c.Kind),
+		}
+	}
+	return ChangeRisk{Resource: c.ID(), Level: RiskMedium, Reason: RiskDeletion, Detail: c.Kind + " is deleted"}
+}
+
+// fieldRisks returns the risks of a modified resource's field changes, at
+// most one per immutable field.
+func fieldRisks(c ResourceChange) []ChangeRisk {
+	if len(c.Fields) == 0 {
+		if _, ok := immutableFields[c.Kind]; !ok && !slices.Contains(scalableKinds, c.Kind) {
+			return nil
+		}
+		return []ChangeRisk{{
+			Resource: c.ID(),
+			Level:    RiskMedium,
+			Reason:   RiskUnassessed,
+			Detail:   "field changes are unknown, so immutable fields and replicas weren't checked; review the diff",
+		}}
+	}
+
+	var risks []ChangeRisk
+	reported := make(map[string]bool)
+	for _, f := range c.Fields {
+		for _, field := range immutableFields[c.Kind] {
+			if reported[field] || (f.Path != field && !strings.HasPrefix(f.Path, field+".")) {
+				continue
+			}
+			reported[field] = true
+			risks = append(risks, ChangeRisk{
+				Resource: c.ID(),
+				Level:    RiskHigh,
+				Reason:   RiskImmutableField,
+				Detail:   fmt.Sprintf("%s is immutable; the %s must be deleted and recreated", field, c.Kind),
+			})
+		}
+
+		if f.Path == "spec.replicas" && slices.Contains(scalableKinds, c.Kind) &&
+			f.Type != ChangeRemoved && f.New == "0" && f.Old != "0" {
+			from := f.Old
+			if from == "" {
+				from = "the default"
+			}
+			risks = append(risks, ChangeRisk{
+				Resource: c.ID(),
+				Level:    RiskHigh,
+				Reason:   RiskScaleToZero,
+				Detail:   fmt.Sprintf("replicas drop from %s to 0", from),
+			})
+		}
+	}
+	return risks
+}
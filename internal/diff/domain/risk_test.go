@@ -0,0 +1,205 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyRisks(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []ResourceChange
+		want    []ChangeRisk
+	}{
+		{
+			name: "ordinary changes",
+			changes: []ResourceChange{
+				{
+					APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Type: ChangeModified,
+					Fields: []FieldChange{
+						{Path: "metadata.labels.version", Type: ChangeModified, Old: "1", New: "2"},
+						{Path: "spec.replicas", Type: ChangeModified, Old: "3", New: "2"},
+					},
+				},
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Type: ChangeAdded},
+			},
+		},
+		{
+			name: "deletions",
+			changes: []ResourceChange{
+				{APIVersion: "v1", Kind: "PersistentVolumeClaim", Namespace: "db", Name: "data", Type: ChangeRemoved},
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Type: ChangeRemoved},
+			},
+			want: []ChangeRisk{
+				{
+					Resource: "v1/PersistentVolumeClaim/db/data",
+					Level:    RiskHigh,
+					Reason:   RiskDeletion,
+					Detail:   "deleting a PersistentVolumeClaim can lose data or take down what depends on it",
+				},
+				{
+					Resource: "v1/ConfigMap/config",
+					Level:    RiskMedium,
+					Reason:   RiskDeletion,
+					Detail:   "ConfigMap is deleted",
+				},
+			},
+		},
+		{
+			name: "immutable fields are reported once each",
+			changes: []ResourceChange{{
+				APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db", Type: ChangeModified,
+				Fields: []FieldChange{
+					{Path: "spec.selector.matchLabels.app", Type: ChangeModified, Old: "db", New: "postgres"},
+					{Path: "spec.selector.matchLabels.tier", Type: ChangeAdded, New: "data"},
+					{Path: "spec.volumeClaimTemplates.data.spec.resources.requests.storage", Old: "1Gi", New: "2Gi"},
+					{Path: "spec.selectorLabels", Type: ChangeAdded, New: "x"},
+				},
+			}},
+			want: []ChangeRisk{
+				{
+					Resource: "apps/v1/StatefulSet/db",
+					Level:    RiskHigh,
+					Reason:   RiskImmutableField,
+					Detail:   "spec.selector is immutable; the StatefulSet must be deleted and recreated",
+				},
+				{
+					Resource: "apps/v1/StatefulSet/db",
+					Level:    RiskHigh,
+					Reason:   RiskImmutableField,
+					Detail:   "spec.volumeClaimTemplates is immutable; the StatefulSet must be deleted and recreated",
+				},
+			},
+		},
+		{
+			name: "replicas drop to zero",
+			changes: []ResourceChange{
+				{
+					APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Type: ChangeModified,
+					Fields: []FieldChange{{Path: "spec.replicas", Type: ChangeModified, Old: "3", New: "0"}},
+				},
+				{
+					APIVersion: "apps/v1", Kind: "Deployment", Name: "worker", Type: ChangeModified,
+					Fields: []FieldChange{{Path: "spec.replicas", Type: ChangeAdded, New: "0"}},
+				},
+			},
+			want: []ChangeRisk{
+				{
+					Resource: "apps/v1/Deployment/web",
+					Level:    RiskHigh,
+					Reason:   RiskScaleToZero,
+					Detail:   "replicas drop from 3 to 0",
+				},
+				{
+					Resource: "apps/v1/Deployment/worker",
+					Level:    RiskHigh,
+					Reason:   RiskScaleToZero,
+					Detail:   "replicas drop from the default to 0",
+				},
+			},
+		},
+		{
+			name: "modified without field changes",
+			changes: []ResourceChange{
+				{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db", Type: ChangeModified},
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Type: ChangeModified},
+			},
+			want: []ChangeRisk{{
+				Resource: "apps/v1/StatefulSet/db",
+				Level:    RiskMedium,
+				Reason:   RiskUnassessed,
+				Detail:   "field changes are unknown, so immutable fields and replicas weren't checked; review the diff",
+			}},
+		},
+		{
+			name: "namespace change",
+			changes: []ResourceChange{
+				{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "old", Name: "web", Type: ChangeRemoved},
+				{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "new", Name: "web", Type: ChangeAdded},
+			},
+			want: []ChangeRisk{{
+				Resource: "apps/v1/Deployment/old/web",
+				Level:    RiskHigh,
+				Reason:   RiskNamespaceChange,
+				Detail:   `moves from namespace "old" to "new", which deletes it from the old one`,
+			}},
+		},
+		{
+			name: "apiVersion migration",
+			changes: []ResourceChange{
+				{APIVersion: "apps/v1beta2", Kind: "StatefulSet", Namespace: "db", Name: "pg", Type: ChangeRemoved},
+				{APIVersion: "apps/v1", Kind: "StatefulSet", Namespace: "db", Name: "pg", Type: ChangeAdded},
+				{APIVersion: "extensions/v1beta1", Kind: "Ingress", Name: "web", Type: ChangeRemoved},
+				{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "web", Type: ChangeAdded},
+			},
+			want: []ChangeRisk{
+				{
+					Resource: "apps/v1/StatefulSet/db/pg",
+					Level:    RiskMedium,
+					Reason:   RiskAPIVersionChange,
+					Detail: "apiVersion changes from apps/v1beta2 to apps/v1; the StatefulSet is updated in place, " +
+						"check the new version keeps its fields",
+				},
+				{
+					Resource: "networking.k8s.io/v1/Ingress/web",
+					Level:    RiskLow,
+					Reason:   RiskAPIVersionChange,
+					Detail: "apiVersion changes from extensions/v1beta1 to networking.k8s.io/v1; " +
+						"the Ingress is updated in place",
+				},
+			},
+		},
+		{
+			name: "apiVersion migration to another namespace",
+			changes: []ResourceChange{
+				{APIVersion: "apps/v1beta2", Kind: "StatefulSet", Namespace: "old", Name: "db", Type: ChangeRemoved},
+				{APIVersion: "apps/v1", Kind: "StatefulSet", Namespace: "new", Name: "db", Type: ChangeAdded},
+			},
+			want: []ChangeRisk{{
+				Resource: "apps/v1beta2/StatefulSet/old/db",
+				Level:    RiskHigh,
+				Reason:   RiskNamespaceChange,
+				Detail:   `moves from namespace "old" to "new", which deletes it from the old one`,
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyRisks(tt.changes)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClassifyRisks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeletedResources(t *testing.T) {
+	migrated := ResourceChange{APIVersion: "apps/v1beta2", Kind: "StatefulSet", Name: "db", Type: ChangeRemoved}
+	moved := ResourceChange{
+		APIVersion: "apps/v1", Kind: "Deployment", Namespace: "old", Name: "web", Type: ChangeRemoved,
+	}
+	deleted := ResourceChange{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Type: ChangeRemoved}
+	changes := []ResourceChange{
+		migrated,
+		{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db", Type: ChangeAdded},
+		moved,
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "new", Name: "web", Type: ChangeAdded},
+		deleted,
+	}
+
+	want := []ResourceChange{moved, deleted}
+	if got := DeletedResources(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("DeletedResources() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHighestRisk(t *testing.T) {
+	if got := HighestRisk(nil); got != RiskLow {
+		t.Errorf("HighestRisk(nil) = %v, want %v", got, RiskLow)
+	}
+	risks := []ChangeRisk{{Level: RiskMedium}, {Level: RiskHigh}, {Level: RiskMedium}}
+	if got := HighestRisk(risks); got != RiskHigh || got.String() != "high" {
+		t.Errorf("HighestRisk() = %v, want %v", got, RiskHigh)
+	}
+}
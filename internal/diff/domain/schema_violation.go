@@ -0,0 +1,9 @@
+package domain
+
+// SchemaViolation is a rendered document that doesn't match its Kubernetes
+// schema, such as an unknown field or a value of the wrong type.
+type SchemaViolation struct {
+	Resource string // apiVersion/kind[/namespace]/name, or "document N" if unidentifiable
+	Message  string // e.g. ".spec.template.spec.contianers: field not declared in schema"
+	Template string // Template that rendered it, e.g. "my-app/templates/deployment.yaml"; "" if unknown
+}
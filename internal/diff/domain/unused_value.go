@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnusedValue is a key an environment's values set that the chart neither
+// declares, in values.yaml or values.schema.json, nor reads in its
+// templates: dead config that has no effect on the render.
+type UnusedValue struct {
+	File string   // Where the value is set: a value file or "inline values"
+	Line int      // 1-based line of the key in File; 0 if unknown
+	Path []string // e.g. ["image", "pullPolicy"]
+}
+
+// Key returns the key the value is set at, e.g. "image.pullPolicy".
+func (u UnusedValue) Key() string {
+	return strings.Join(u.Path, ".")
+}
+
+// Message describes the value, e.g. "env/prod-values.yaml sets replica,
+// which the chart doesn't use".
+func (u UnusedValue) Message() string {
+	return fmt.Sprintf("%s sets %s, which the chart doesn't use", u.File, u.Key())
+}
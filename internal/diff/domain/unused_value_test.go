@@ -0,0 +1,11 @@
+package domain
+
+import "testing"
+
+func TestUnusedValue_Message(t *testing.T) {
+	u := UnusedValue{File: "env/prod-values.yaml", Path: []string{"image", "pullPolicy"}}
+	want := "env/prod-values.yaml sets image.pullPolicy, which the chart doesn't use"
+	if got := u.Message(); got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValuesChangeType is how a chart's values surface changed for a key.
+type ValuesChangeType string
+
+const (
+	// ValuesKeyRemoved is a key head no longer declares.
+	ValuesKeyRemoved ValuesChangeType = "removed"
+	// ValuesKeyRenamed is a key head declares under another name or parent.
+	ValuesKeyRenamed ValuesChangeType = "renamed"
+	// ValuesKeyTypeChanged is a key whose value head expects to be of another type.
+	ValuesKeyTypeChanged ValuesChangeType = "type changed"
+)
+
+// ValuesChange is a key in a chart's values surface, the defaults in
+// values.yaml and the properties in values.schema.json, that a PR removes,
+// renames or changes the type of, breaking overrides that set it.
+type ValuesChange struct {
+	Path    []string // Key in base, e.g. ["image", "tag"]
+	Type    ValuesChangeType
+	NewPath []string // Key in head, for ValuesKeyRenamed
+	OldType string   // e.g. "integer", for ValuesKeyTypeChanged
+	NewType string   // e.g. "string", for ValuesKeyTypeChanged
+}
+
+// Key returns the key in base, e.g. "image.tag".
+func (c ValuesChange) Key() string {
+	return strings.Join(c.Path, ".")
+}
+
+// Message describes the change, e.g. "image.tag renamed to image.version".
+func (c ValuesChange) Message() string {
+	switch c.Type {
+	case ValuesKeyRenamed:
+		return fmt.Sprintf("%s renamed to %s", c.Key(), strings.Join(c.NewPath, "."))
+	case ValuesKeyTypeChanged:
+		return fmt.Sprintf("%s changed type from %s to %s", c.Key(), c.OldType, c.NewType)
+	default:
+		return fmt.Sprintf("%s %s", c.Key(), c.Type)
+	}
+}
+
+// StaleOverride is a value an environment still sets for a key the PR
+// removes or renames, which the chart will silently ignore.
+type StaleOverride struct {
+	File     string // Where the value is set: a value file, "inline values" or "parameter <name>"
+	Line     int    // 1-based line of the key in File; 0 if unknown
+	Change   ValuesChange
+	Severity Severity // SeverityError if stale overrides fail the check run, else SeverityWarning
+}
+
+// Message describes the override, e.g. "env/prod-values.yaml sets image.tag,
+// which was renamed to image.version".
+func (o StaleOverride) Message() string {
+	if o.Change.Type == ValuesKeyRenamed {
+		return fmt.Sprintf("%s sets %s, which was renamed to %s",
+			o.File, o.Change.Key(), strings.Join(o.Change.NewPath, "."))
+	}
+	return fmt.Sprintf("%s sets %s, which was removed", o.File, o.Change.Key())
+}
+
+// CountStaleOverrides returns the number of overrides that fail the check run
+// and the number that are only warnings.
+func CountStaleOverrides(overrides []StaleOverride) (errors, warnings int) {
+	for _, o := range overrides {
+		if o.Severity == SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	return
+}
+
+// FindStaleOverrides returns the removed and renamed keys among changes that
+// values, parsed from the source named file, still sets.
+func FindStaleOverrides(changes []ValuesChange, file string, values map[string]any) []StaleOverride {
+	var stale []StaleOverride
+	for _, c := range changes {
+		if c.Type == ValuesKeyTypeChanged {
+			continue
+		}
+		if setsKey(values, c.Path) {
+			stale = append(stale, StaleOverride{File: file, Change: c})
+		}
+	}
+	return stale
+}
+
+// setsKey reports whether values has a value at path. Lists aren't entered,
+// since keys below them aren't part of the values surface.
+func setsKey(values map[string]any, path []string) bool {
+	node := values
+	for i, key := range path {
+		v, ok := node[key]
+		if !ok {
+			return false
+		}
+		if i == len(path)-1 {
+			return true
+		}
+		if node, ok = v.(map[string]any); !ok {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValuesChange_Message(t *testing.T) {
+	tests := []struct {
+		change ValuesChange
+		want   string
+	}{
+		{ValuesChange{Path: []string{"image", "pullSecret"}, Type: ValuesKeyRemoved}, "image.pullSecret removed"},
+		{
+			ValuesChange{Path: []string{"image", "tag"}, Type: ValuesKeyRenamed, NewPath: []string{"image", "version"}},
+			"image.tag renamed to image.version",
+		},
+		{
+			ValuesChange{Path: []string{"replicas"}, Type: ValuesKeyTypeChanged, OldType: "integer", NewType: "string"},
+			"replicas changed type from integer to string",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.Message(); got != tt.want {
+			t.Errorf("Message() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestFindStaleOverrides(t *testing.T) {
+	removed := ValuesChange{Path: []string{"image", "pullSecret"}, Type: ValuesKeyRemoved}
+	renamed := ValuesChange{
+		Path: []string{"image", "tag"}, Type: ValuesKeyRenamed, NewPath: []string{"image", "version"},
+	}
+	retyped := ValuesChange{
+		Path: []string{"replicas"}, Type: ValuesKeyTypeChanged, OldType: "integer", NewType: "string",
+	}
+	dropped := ValuesChange{Path: []string{"metrics"}, Type: ValuesKeyRemoved}
+	changes := []ValuesChange{removed, renamed, retyped, dropped}
+
+	values := map[string]any{
+		"replicas": 3,
+		"image":    map[string]any{"tag": "1.2.3", "repository": "nginx"},
+		"metrics":  "enabled",
+	}
+	got := FindStaleOverrides(changes, "env/prod-values.yaml", values)
+	want := []StaleOverride{
+		{File: "env/prod-values.yaml", Change: renamed},
+		{File: "env/prod-values.yaml", Change: dropped},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindStaleOverrides() = %+v, want %+v", got, want)
+	}
+
+	if got := FindStaleOverrides(changes, "env/dev-values.yaml", map[string]any{"image": "nginx"}); len(got) != 0 {
+		t.Errorf("FindStaleOverrides() = %+v, want none when a parent isn't a map", got)
+	}
+}
+
+func TestStaleOverride_Message(t *testing.T) {
+	o := StaleOverride{
+		File: "env/prod-values.yaml",
+		Change: ValuesChange{
+			Path: []string{"image", "tag"}, Type: ValuesKeyRenamed, NewPath: []string{"image", "version"},
+		},
+	}
+	want := "env/prod-values.yaml sets image.tag, which was renamed to image.version"
+	if got := o.Message(); got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+	o.Change = ValuesChange{Path: []string{"metrics"}, Type: ValuesKeyRemoved}
+	want = "env/prod-values.yaml sets metrics, which was removed"
+	if got := o.Message(); got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+}
+
+func TestCountStaleOverrides(t *testing.T) {
+	errors, warnings := CountStaleOverrides([]StaleOverride{
+		{File: "env/prod-values.yaml", Severity: SeverityError},
+		{File: "env/dev-values.yaml", Severity: SeverityWarning},
+		{File: "inline values"},
+	})
+	if errors != 1 || warnings != 2 {
+		t.Errorf("CountStaleOverrides() = %d, %d, want 1, 2", errors, warnings)
+	}
+}
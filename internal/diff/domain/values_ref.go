@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValuesRef is a source of an Argo CD multi-source Application that other
+// sources reference by name, as in value files like "$values/envs/prod.yaml".
+type ValuesRef struct {
+	Name     string // Ref name without the "$" (e.g., "values")
+	RepoURL  string // Repository holding the referenced files
+	Revision string // targetRevision; empty or "HEAD" means the default branch
+}
+
+// SplitValueFileRef splits a "$ref/path" value file into the ref name and
+// the path within the referenced repository. ok is false for ordinary,
+// chart-relative value files.
+func SplitValueFileRef(valueFile string) (ref, path string, ok bool) {
+	if !strings.HasPrefix(valueFile, "$") {
+		return "", "", false
+	}
+	ref, path, found := strings.Cut(valueFile[1:], "/")
+	if !found || ref == "" {
+		return "", "", false
+	}
+	return ref, path, true
+}
+
+// ParseRepoURL extracts the owner and repository name from a Git URL in
+// https, ssh or scp-like (git@host:owner/repo.git) form.
+func ParseRepoURL(repoURL string) (owner, repo string, err error) {
+	rest := repoURL
+	if _, after, found := strings.Cut(rest, "://"); found {
+		rest = after
+	}
+	// Drop the host (and any user@), which ends at the first "/" or ":"
+	idx := strings.IndexAny(rest, "/:")
+	if idx < 0 {
+		return "", "", fmt.Errorf("parsing repo URL %q: missing owner/repo", repoURL)
+	}
+	rest = strings.TrimSuffix(strings.Trim(rest[idx+1:], "/"), ".git")
+
+	parts := strings.Split(rest, "/")
+	if len(parts) < 2 || parts[len(parts)-2] == "" || parts[len(parts)-1] == "" {
+		return "", "", fmt.Errorf("parsing repo URL %q: missing owner/repo", repoURL)
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "testing"
+
+func TestSplitValueFileRef(t *testing.T) {
+	tests := []struct {
+		valueFile string
+		wantRef   string
+		wantPath  string
+		wantOK    bool
+	}{
+		{
+			valueFile: "$values/envs/prod/values.yaml",
+			wantRef:   "values",
+			wantPath:  "envs/prod/values.yaml",
+			wantOK:    true,
+		},
+		{valueFile: "$shared/common.yaml", wantRef: "shared", wantPath: "common.yaml", wantOK: true},
+		{valueFile: "env/prod-values.yaml"},
+		{valueFile: "$values"},
+		{valueFile: "$/values.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.valueFile, func(t *testing.T) {
+			ref, path, ok := SplitValueFileRef(tt.valueFile)
+			if ok != tt.wantOK || ref != tt.wantRef || path != tt.wantPath {
+				t.Errorf(
+					"SplitValueFileRef(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.valueFile, ref, path, ok, tt.wantRef, tt.wantPath, tt.wantOK,
+				)
+			}
+		})
+	}
+}
+
+func TestParseRepoURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantOwner string
+		wantRepo  string
+		wantErr   bool
+	}{
+		{url: "https://github.com/org/gitops", wantOwner: "org", wantRepo: "gitops"},
+		{url: "https://github.com/org/gitops.git", wantOwner: "org", wantRepo: "gitops"},
+		{url: "https://github.com/org/gitops/", wantOwner: "org", wantRepo: "gitops"},
+		{url: "git@github.com:org/gitops.git", wantOwner: "org", wantRepo: "gitops"},
+		{url: "ssh://git@github.com/org/gitops.git", wantOwner: "org", wantRepo: "gitops"},
+		{url: "https://github.com/org", wantErr: true},
+		{url: "gitops", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			owner, repo, err := ParseRepoURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRepoURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			if owner != tt.wantOwner || repo != tt.wantRepo {
+				t.Errorf("ParseRepoURL(%q) = (%q, %q), want (%q, %q)", tt.url, owner, repo, tt.wantOwner, tt.wantRepo)
+			}
+		})
+	}
+}
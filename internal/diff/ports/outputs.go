@@ -14,7 +14,46 @@ type SourceControlPort interface {
 // RendererPort abstracts Helm template rendering, separated from source control
 // so the rendering strategy is independently swappable.
 type RendererPort interface {
-	Render(ctx context.Context, chartDir string, valueFiles []string) ([]byte, error)
+	Render(ctx context.Context, chartDir string, valueFiles []string, opts domain.RenderOptions) ([]byte, error)
+}
+
+// DependencyResolverPort abstracts fetching a chart's declared dependencies
+// into its charts/ directory so the chart can be rendered. repoDir is the
+// repository checkout holding chartDir, which local dependencies may not leave.
+type DependencyResolverPort interface {
+	ResolveDependencies(ctx context.Context, chartDir, repoDir string) error
+}
+
+// ChartVersionPort abstracts checking a chart's Chart.yaml version against
+// what changed in the chart, so a release isn't rejected for a missing bump.
+type ChartVersionPort interface {
+	// CheckVersion compares the chart versions in the base and head checkouts
+	// (baseDir is "" for a new chart) with the changes between them. Returns
+	// an error if a checkout can't be read.
+	CheckVersion(baseDir, headDir string) (domain.VersionCheck, error)
+}
+
+// ValuesSurfacePort abstracts comparing the values a chart accepts with the
+// values it is given, so overrides of keys a PR removes don't silently stop
+// working and dead config gets noticed.
+type ValuesSurfacePort interface {
+	// CompareValues returns the keys of the base chart's values.yaml and
+	// values.schema.json that the head chart removes, renames or changes the
+	// type of. Returns an error if a checkout can't be read.
+	CompareValues(baseDir, headDir string) ([]domain.ValuesChange, error)
+
+	// FindStaleOverrides returns the removed and renamed keys among changes
+	// that an environment's value files, inline values or parameters still set.
+	FindStaleOverrides(
+		chartDir string,
+		valueFiles []string,
+		opts domain.RenderOptions,
+		changes []domain.ValuesChange,
+	) []domain.StaleOverride
+
+	// FindUnusedValues returns the keys an environment's value files and
+	// inline values set that the chart in chartDir neither declares nor reads.
+	FindUnusedValues(chartDir string, valueFiles []string, opts domain.RenderOptions) []domain.UnusedValue
 }
 
 // ReportingPort abstracts posting diff results back to the pull request.
@@ -31,8 +70,50 @@ type ReportingPort interface {
 		results []domain.DiffResult,
 	) error
 
-	// PostComment posts a PR comment with diff results for a single chart.
+	// PostComment posts a PR comment with diff results for a single chart,
+	// updating the chart's comment from an earlier push if there is one.
 	PostComment(ctx context.Context, pr domain.PRContext, results []domain.DiffResult) error
+
+	// CollapseComment marks the chart's comment from an earlier push as no
+	// longer applicable, for a chart that no longer changes anything. It does
+	// nothing if the chart has no comment.
+	CollapseComment(ctx context.Context, pr domain.PRContext, chartName string) error
+
+	// PostSummaryComment posts one PR comment with the results of every
+	// chart, updating the summary comment from an earlier push if there is
+	// one. If no chart has changes, it collapses that comment instead of
+	// posting a new one.
+	PostSummaryComment(ctx context.Context, pr domain.PRContext, results []domain.DiffResult) error
+}
+
+// ReportStorePort abstracts keeping the full diffs of a result somewhere
+// reviewers can read them, for when they are too large for GitHub.
+type ReportStorePort interface {
+	// StoreReport stores the diffs of result and returns the URL they can be
+	// read at.
+	StoreReport(ctx context.Context, pr domain.PRContext, result domain.DiffResult) (url string, err error)
+}
+
+// ReportFormatterPort abstracts serialising results in a machine-readable
+// format, alongside the GitHub reports of ReportingPort, for tools such as
+// dashboards, code scanning and CI systems.
+type ReportFormatterPort interface {
+	// Name returns the name the format is configured by, e.g. "junit".
+	Name() string
+
+	// FileExtension returns the extension of files in the format, e.g. ".xml".
+	FileExtension() string
+
+	// FormatResults serialises the results of every chart of a PR.
+	FormatResults(pr domain.PRContext, results []domain.DiffResult) ([]byte, error)
+}
+
+// OutputStorePort abstracts keeping formatted results where other tools can
+// pick them up.
+type OutputStorePort interface {
+	// StoreOutput stores the results of pr, formatted into content, as the
+	// file name, replacing those of an earlier push.
+	StoreOutput(ctx context.Context, pr domain.PRContext, name string, content []byte) error
 }
 
 // ChangedChartsPort abstracts detecting which charts were modified in a PR.
@@ -46,9 +127,78 @@ type ChangedChartsPort interface {
 // Different implementations can provide different diff strategies
 // (e.g., semantic YAML diffing vs line-based text diffing).
 type DiffPort interface {
-	// ComputeDiff returns a diff between base and head manifests.
+	// ComputeDiff returns a diff between base and head manifests, along with
+	// the resources that were added, removed or modified. Semantic
+	// implementations also set the Fields of modified resources, and mark the
+	// diff Unparsed when they can't compare the manifests.
 	// baseName and headName are used for labeling (e.g., "my-app/prod (main)").
-	ComputeDiff(baseName, headName string, base, head []byte) string
+	ComputeDiff(baseName, headName string, base, head []byte) domain.ManifestDiff
+}
+
+// RedactorPort abstracts masking sensitive values (Secret data, credentials)
+// in rendered manifests, so they never reach diffs or pull request comments.
+type RedactorPort interface {
+	// Redact returns manifest with sensitive values replaced by placeholders.
+	// Equal values get equal placeholders, so changes remain visible.
+	Redact(manifest []byte) []byte
+}
+
+// ManifestValidatorPort abstracts checking rendered manifests against
+// Kubernetes schemas, so output the cluster would reject is caught in review.
+type ManifestValidatorPort interface {
+	// Validate returns the schema violations in manifest for the Kubernetes
+	// version envName targets: kubeVersion if set (from its render options),
+	// else the one configured for the environment. Documents whose kind has no
+	// known schema are skipped.
+	Validate(manifest []byte, envName, kubeVersion string) []domain.SchemaViolation
+}
+
+// ChartLinterPort abstracts `helm lint`-style checks of a chart and the
+// values an environment gives it, so problems are reported per file and key
+// rather than as a failed render.
+type ChartLinterPort interface {
+	// Lint returns the problems with the chart in chartDir when it is
+	// configured with valueFiles and opts, including values that fail the
+	// chart's values.schema.json.
+	Lint(chartDir string, valueFiles []string, opts domain.RenderOptions) []domain.LintFinding
+}
+
+// DeprecationCheckerPort abstracts finding resources that use deprecated or
+// removed Kubernetes API versions, so cluster upgrades don't break deploys.
+type DeprecationCheckerPort interface {
+	// Check returns the resources in manifest whose API version is deprecated
+	// in the environment's Kubernetes version: kubeVersion if set, else the
+	// implementation's version for envName, else its default.
+	Check(manifest []byte, envName, kubeVersion string) []domain.APIDeprecation
+}
+
+// PolicyPort abstracts loading and evaluating policy-as-code rules that
+// rendered manifests must satisfy.
+type PolicyPort interface {
+	// LoadPolicies returns the policies that apply to a chart: those the
+	// implementation is configured with, plus those in the policy files under
+	// chartPolicyDir ("" for none). Returns an error if a policy is invalid.
+	LoadPolicies(chartPolicyDir string) ([]domain.Policy, error)
+
+	// Evaluate returns the resources in manifest that fail policies, with
+	// each policy's severity for envName. Policies that are off are skipped.
+	Evaluate(policies []domain.Policy, manifest []byte, envName string) []domain.PolicyViolation
+}
+
+// ManifestFilterPort abstracts removing ignored fields from rendered manifests
+// before they are diffed, so noise such as generated labels never reaches a
+// DiffPort.
+type ManifestFilterPort interface {
+	// LoadRules returns the ignore rules that apply to a chart: those the
+	// implementation is configured with, plus those in the rules file of
+	// repoDir, a checkout of the target repo ("" for none). Returns an error
+	// if a rule is invalid.
+	LoadRules(repoDir string) ([]domain.IgnoreRule, error)
+
+	// Filter returns base and head without the fields rules ignore, and the
+	// number of changes between them that were suppressed. Manifests that
+	// fail to parse are returned unchanged.
+	Filter(rules []domain.IgnoreRule, base, head []byte) (filteredBase, filteredHead []byte, suppressed int)
 }
 
 // EnvironmentConfigPort abstracts discovering where a chart is deployed.
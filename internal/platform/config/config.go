@@ -5,10 +5,38 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Supported values for RENDERER.
+const (
+	RendererHelmCLI = "helm-cli" // Shell out to the helm binary
+	RendererHelmSDK = "helm-sdk" // Render in-process with the Helm Go libraries
+)
+
+// Supported values for COMMENT_MODE.
+const (
+	CommentModePerChart = "per-chart" // A comment for each chart with changes
+	CommentModeSummary  = "summary"   // One comment for the whole PR
+)
+
+// Supported values for NOTIFY_MIN_RISK.
+const (
+	NotifyRiskLow    = "low"    // Every manifest change
+	NotifyRiskMedium = "medium" // Changes with a medium or high risk
+	NotifyRiskHigh   = "high"   // Changes with a high risk
+)
+
+// Supported formats in OUTPUT_FORMATS.
+const (
+	OutputFormatJSON  = "json"  // Versioned JSON of every result
+	OutputFormatSARIF = "sarif" // SARIF 2.1.0 of the validation findings
+	OutputFormatJUnit = "junit" // JUnit XML, a test case per chart environment
+)
+
 // Config holds the application configuration loaded from environment variables.
 type Config struct {
 	Port                 int
@@ -33,7 +61,68 @@ type Config struct {
 	ChartDir         string // CHART_DIR (default: "charts"); top-level dir containing charts
 	EnvDir           string // ENV_DIR (default: "env"); subdirectory within chart for env overrides
 	ValuesFileSuffix string // VALUES_FILE_SUFFIX (default: "-values.yaml"); pattern for value files
+	RenderFileSuffix string // RENDER_FILE_SUFFIX (default: "-render.yaml"); per-env render options sidecar
+
+	// Rendering (optional)
+	Renderer          string // RENDERER (default: "helm-cli"); "helm-cli" or "helm-sdk"
+	ChartDepsCacheDir string // CHART_DEPS_CACHE_DIR (default: "/tmp/chart-val-deps"); dependency archive cache
+
+	// Secret redaction (optional); Secret data and well-known credential keys are always redacted
+	SensitiveKeyPatterns []string // SENSITIVE_KEY_PATTERNS (default: none); comma-separated regexes of extra keys
+	RedactHMACKey        string   // REDACT_HMAC_KEY (default: random per process); keys redaction placeholders
+
+	// Diff ignore rules (optional)
+	IgnoreRulesFile     string // IGNORE_RULES_FILE (default: ""); YAML file of additional ignore rules
+	IgnoreRulesRepoFile string // IGNORE_RULES_REPO_FILE (default: ""); rules file in each repo, read from base
+	IgnoreDefaultRules  bool   // IGNORE_DEFAULT_RULES (default: true); built-in rules for chart labels and checksums
+
+	// Schema validation of rendered manifests (optional)
+	SchemaValidation bool   // SCHEMA_VALIDATION (default: false); validate head manifests against Kubernetes schemas
+	CRDSchemaDir     string // CRD_SCHEMA_DIR (default: ""); directory of CustomResourceDefinition manifests
+	KubeSchemaDir    string // KUBE_SCHEMA_DIR (default: ""); OpenAPI documents of other Kubernetes versions
+
+	// Target Kubernetes versions for schema validation and deprecated API checks (optional); an environment's own kubeVersion wins
+	KubeVersion     string            // KUBE_VERSION (default: ""); target for other environments, "" for newest
+	EnvKubeVersions map[string]string // ENV_KUBE_VERSIONS (default: none); comma-separated env=version pairs
+
+	// Deprecated API checks (optional)
+	DeprecationCheck bool // DEPRECATION_CHECK (default: false); report deprecated and removed API versions
+
+	// Policy-as-code (optional); charts may also keep CEL policies in their own policies/ directory
+	PolicyDir string // POLICY_DIR (default: ""); directory of CEL policy files applied to every chart
+
+	// Chart version enforcement (optional)
+	VersionCheck bool // VERSION_CHECK (default: false); require a SemVer Chart.yaml version bump for chart changes
+
+	// Chart lint (optional)
+	ChartLint bool // CHART_LINT (default: false); lint charts and check each environment's values.schema.json
+
+	// Values surface checks (optional)
+	ValuesCheck          bool // VALUES_CHECK (default: false); report values set for removed keys, and unused ones
+	FailOnStaleOverrides bool // FAIL_ON_STALE_OVERRIDES (default: false); fail the check on stale overrides
+
+	// PR comments (optional); each chart's comment is edited in place on every push
+	CommentMode     string // COMMENT_MODE (default: "per-chart"); "per-chart" or "summary"
+	CommentCollapse bool   // COMMENT_COLLAPSE (default: true); note on a chart's comment once it no longer changes
+
+	// Full diff reports (optional); served by chart-val and linked when diffs are too large for GitHub
+	ReportURL       string        // REPORT_URL (default: ""); public base URL of this server, "" to not store reports
+	ReportDir       string        // REPORT_DIR (default: "/tmp/chart-val-reports"); where reports are stored
+	ReportRetention time.Duration // REPORT_RETENTION (default: 168h); how long reports are kept
 
+	// Report templates (optional); *.tmpl files overriding how check runs and PR comments are rendered
+	TemplateDir     string // TEMPLATE_DIR (default: ""); directory on this server, loaded at startup
+	TemplateRepoDir string // TEMPLATE_REPO_DIR (default: ""); directory in each target repo, read from the base branch
+
+	// Machine-readable outputs (optional); written per PR to OutputDir/<owner>/<repo>/<PR number>/
+	OutputFormats []string // OUTPUT_FORMATS (default: ""); comma-separated "json", "sarif" and "junit"
+	OutputDir     string   // OUTPUT_DIR (default: "/tmp/chart-val-outputs"); where outputs are written
+
+	// Webhook notifications (optional); Slack-compatible messages for the manifest changes someone watches
+	NotifyWebhookURL   string   // NOTIFY_WEBHOOK_URL (default: ""); "" to not notify
+	NotifyCharts       []string // NOTIFY_CHARTS (default: all); comma-separated chart name globs
+	NotifyEnvironments []string // NOTIFY_ENVIRONMENTS (default: all); comma-separated environment globs
+	NotifyMinRisk      string   // NOTIFY_MIN_RISK (default: "low"); "low", "medium" or "high"
 }
 
 // Load reads configuration from environment variables, validates required
@@ -52,6 +141,60 @@ func Load() (Config, error) {
 		return Config{}, err
 	}
 
+	if err := loadRendererConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	loadRedactionConfig(&cfg)
+
+	if err := loadIgnoreConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := loadValidationConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := loadKubeVersionConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := loadDeprecationConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	loadPolicyConfig(&cfg)
+
+	if err := loadVersionCheckConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := loadLintConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := loadValuesCheckConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := loadCommentConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := loadReportConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	loadTemplateConfig(&cfg)
+
+	if err := loadOutputConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := loadNotifyConfig(&cfg); err != nil {
+		return Config{}, err
+	}
+
 	loadOTelConfig(&cfg)
 	loadAppConfig(&cfg)
 
@@ -113,6 +256,196 @@ func loadArgoConfig(cfg *Config) error {
 	return nil
 }
 
+func loadRendererConfig(cfg *Config) error {
+	cfg.Renderer = getEnvOrDefault("RENDERER", RendererHelmCLI)
+	cfg.ChartDepsCacheDir = getEnvOrDefault("CHART_DEPS_CACHE_DIR", "/tmp/chart-val-deps")
+	switch cfg.Renderer {
+	case RendererHelmCLI, RendererHelmSDK:
+		return nil
+	default:
+		return fmt.Errorf("invalid RENDERER %q: must be %q or %q", cfg.Renderer, RendererHelmCLI, RendererHelmSDK)
+	}
+}
+
+func loadRedactionConfig(cfg *Config) {
+	cfg.SensitiveKeyPatterns = splitList(os.Getenv("SENSITIVE_KEY_PATTERNS"))
+	cfg.RedactHMACKey = os.Getenv("REDACT_HMAC_KEY")
+}
+
+func loadIgnoreConfig(cfg *Config) error {
+	cfg.IgnoreRulesFile = os.Getenv("IGNORE_RULES_FILE")
+	cfg.IgnoreRulesRepoFile = strings.TrimPrefix(os.Getenv("IGNORE_RULES_REPO_FILE"), "/")
+	enabled, err := parseBoolOrDefault("IGNORE_DEFAULT_RULES", true)
+	if err != nil {
+		return err
+	}
+	cfg.IgnoreDefaultRules = enabled
+	return nil
+}
+
+func loadValidationConfig(cfg *Config) error {
+	enabled, err := parseBoolOrDefault("SCHEMA_VALIDATION", false)
+	if err != nil {
+		return err
+	}
+	cfg.SchemaValidation = enabled
+	cfg.CRDSchemaDir = os.Getenv("CRD_SCHEMA_DIR")
+	cfg.KubeSchemaDir = os.Getenv("KUBE_SCHEMA_DIR")
+	return nil
+}
+
+func loadKubeVersionConfig(cfg *Config) error {
+	cfg.KubeVersion = os.Getenv("KUBE_VERSION")
+	for pair := range strings.SplitSeq(os.Getenv("ENV_KUBE_VERSIONS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		env, version, ok := strings.Cut(pair, "=")
+		env, version = strings.TrimSpace(env), strings.TrimSpace(version)
+		if !ok || env == "" || version == "" {
+			return fmt.Errorf("invalid ENV_KUBE_VERSIONS entry %q: must be env=version", pair)
+		}
+		if cfg.EnvKubeVersions == nil {
+			cfg.EnvKubeVersions = make(map[string]string)
+		}
+		cfg.EnvKubeVersions[env] = version
+	}
+	return nil
+}
+
+func loadDeprecationConfig(cfg *Config) error {
+	enabled, err := parseBoolOrDefault("DEPRECATION_CHECK", false)
+	if err != nil {
+		return err
+	}
+	cfg.DeprecationCheck = enabled
+	return nil
+}
+
+func loadPolicyConfig(cfg *Config) {
+	cfg.PolicyDir = os.Getenv("POLICY_DIR")
+}
+
+func loadVersionCheckConfig(cfg *Config) error {
+	enabled, err := parseBoolOrDefault("VERSION_CHECK", false)
+	if err != nil {
+		return err
+	}
+	cfg.VersionCheck = enabled
+	return nil
+}
+
+func loadLintConfig(cfg *Config) error {
+	enabled, err := parseBoolOrDefault("CHART_LINT", false)
+	if err != nil {
+		return err
+	}
+	cfg.ChartLint = enabled
+	return nil
+}
+
+func loadValuesCheckConfig(cfg *Config) error {
+	enabled, err := parseBoolOrDefault("VALUES_CHECK", false)
+	if err != nil {
+		return err
+	}
+	cfg.ValuesCheck = enabled
+	failOnStale, err := parseBoolOrDefault("FAIL_ON_STALE_OVERRIDES", false)
+	if err != nil {
+		return err
+	}
+	cfg.FailOnStaleOverrides = failOnStale
+	return nil
+}
+
+func loadCommentConfig(cfg *Config) error {
+	collapse, err := parseBoolOrDefault("COMMENT_COLLAPSE", true)
+	if err != nil {
+		return err
+	}
+	cfg.CommentCollapse = collapse
+
+	cfg.CommentMode = getEnvOrDefault("COMMENT_MODE", CommentModePerChart)
+	switch cfg.CommentMode {
+	case CommentModePerChart, CommentModeSummary:
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid COMMENT_MODE %q: must be %q or %q", cfg.CommentMode, CommentModePerChart, CommentModeSummary,
+		)
+	}
+}
+
+func loadReportConfig(cfg *Config) error {
+	cfg.ReportURL = strings.TrimSuffix(os.Getenv("REPORT_URL"), "/")
+	if cfg.ReportURL == "" {
+		return nil // Reports are optional
+	}
+
+	cfg.ReportDir = getEnvOrDefault("REPORT_DIR", "/tmp/chart-val-reports")
+	retention, err := parseDurationOrDefault("REPORT_RETENTION", 7*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	cfg.ReportRetention = retention
+	return nil
+}
+
+func loadTemplateConfig(cfg *Config) {
+	cfg.TemplateDir = os.Getenv("TEMPLATE_DIR")
+	cfg.TemplateRepoDir = strings.Trim(os.Getenv("TEMPLATE_REPO_DIR"), "/")
+}
+
+func loadOutputConfig(cfg *Config) error {
+	for format := range strings.SplitSeq(os.Getenv("OUTPUT_FORMATS"), ",") {
+		switch format = strings.ToLower(strings.TrimSpace(format)); format {
+		case "":
+			continue
+		case OutputFormatJSON, OutputFormatSARIF, OutputFormatJUnit:
+			if !slices.Contains(cfg.OutputFormats, format) {
+				cfg.OutputFormats = append(cfg.OutputFormats, format)
+			}
+		default:
+			return fmt.Errorf("invalid OUTPUT_FORMATS entry %q: must be %q, %q or %q",
+				format, OutputFormatJSON, OutputFormatSARIF, OutputFormatJUnit)
+		}
+	}
+	if len(cfg.OutputFormats) == 0 {
+		return nil // Outputs are optional
+	}
+	cfg.OutputDir = getEnvOrDefault("OUTPUT_DIR", "/tmp/chart-val-outputs")
+	return nil
+}
+
+func loadNotifyConfig(cfg *Config) error {
+	cfg.NotifyWebhookURL = os.Getenv("NOTIFY_WEBHOOK_URL")
+	if cfg.NotifyWebhookURL == "" {
+		return nil // Notifications are optional
+	}
+
+	cfg.NotifyCharts = splitList(os.Getenv("NOTIFY_CHARTS"))
+	cfg.NotifyEnvironments = splitList(os.Getenv("NOTIFY_ENVIRONMENTS"))
+	cfg.NotifyMinRisk = getEnvOrDefault("NOTIFY_MIN_RISK", NotifyRiskLow)
+	switch cfg.NotifyMinRisk {
+	case NotifyRiskLow, NotifyRiskMedium, NotifyRiskHigh:
+		return nil
+	default:
+		return fmt.Errorf("invalid NOTIFY_MIN_RISK %q: must be %q, %q or %q",
+			cfg.NotifyMinRisk, NotifyRiskLow, NotifyRiskMedium, NotifyRiskHigh)
+	}
+}
+
+// splitList returns the non-empty, trimmed entries of a comma-separated list.
+func splitList(list string) []string {
+	var entries []string
+	for entry := range strings.SplitSeq(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
 func parseRequiredInt64(envKey string) (int64, error) {
 	v := os.Getenv(envKey)
 	if v == "" {
@@ -142,6 +475,19 @@ func loadAppConfig(cfg *Config) {
 	cfg.ChartDir = getEnvOrDefault("CHART_DIR", "charts")
 	cfg.EnvDir = getEnvOrDefault("ENV_DIR", "env")
 	cfg.ValuesFileSuffix = getEnvOrDefault("VALUES_FILE_SUFFIX", "-values.yaml")
+	cfg.RenderFileSuffix = getEnvOrDefault("RENDER_FILE_SUFFIX", "-render.yaml")
+}
+
+func parseBoolOrDefault(envKey string, defaultValue bool) (bool, error) {
+	v := os.Getenv(envKey)
+	if v == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: %w", envKey, v, err)
+	}
+	return b, nil
 }
 
 func parseDurationOrDefault(envKey string, defaultValue time.Duration) (time.Duration, error) {
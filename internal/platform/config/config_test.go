@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -64,9 +66,58 @@ func TestLoad(t *testing.T) {
 				GitHubInstallationID: 789012,
 				GitHubPrivateKey:     "test-key",
 				LogLevel:             "info", // Default
+				Renderer:             RendererHelmCLI,
+				ChartDepsCacheDir:    "/tmp/chart-val-deps",
+				RenderFileSuffix:     "-render.yaml",
 			},
 			wantErr: false,
 		},
+		{
+			name: "helm sdk renderer",
+			setup: func() {
+				_ = os.Setenv("WEBHOOK_SECRET", "test-secret")
+				_ = os.Setenv("GITHUB_APP_ID", "123456")
+				_ = os.Setenv("GITHUB_INSTALLATION_ID", "789012")
+				_ = os.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+				_ = os.Setenv("RENDERER", "helm-sdk")
+			},
+			cleanup: func() {
+				_ = os.Unsetenv("WEBHOOK_SECRET")
+				_ = os.Unsetenv("GITHUB_APP_ID")
+				_ = os.Unsetenv("GITHUB_INSTALLATION_ID")
+				_ = os.Unsetenv("GITHUB_PRIVATE_KEY")
+				_ = os.Unsetenv("RENDERER")
+			},
+			want: Config{
+				Port:                 8080,
+				WebhookSecret:        "test-secret",
+				GitHubAppID:          123456,
+				GitHubInstallationID: 789012,
+				GitHubPrivateKey:     "test-key",
+				LogLevel:             "info",
+				Renderer:             RendererHelmSDK,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid RENDERER",
+			setup: func() {
+				_ = os.Setenv("WEBHOOK_SECRET", "test-secret")
+				_ = os.Setenv("GITHUB_APP_ID", "123456")
+				_ = os.Setenv("GITHUB_INSTALLATION_ID", "789012")
+				_ = os.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+				_ = os.Setenv("RENDERER", "kustomize")
+			},
+			cleanup: func() {
+				_ = os.Unsetenv("WEBHOOK_SECRET")
+				_ = os.Unsetenv("GITHUB_APP_ID")
+				_ = os.Unsetenv("GITHUB_INSTALLATION_ID")
+				_ = os.Unsetenv("GITHUB_PRIVATE_KEY")
+				_ = os.Unsetenv("RENDERER")
+			},
+			wantErr: true,
+			errMsg:  "RENDERER",
+		},
 		{
 			name: "missing WEBHOOK_SECRET",
 			setup: func() {
@@ -235,6 +286,15 @@ func TestLoad(t *testing.T) {
 			if got.LogLevel != tt.want.LogLevel {
 				t.Errorf("Load().LogLevel = %v, want %v", got.LogLevel, tt.want.LogLevel)
 			}
+			if tt.want.Renderer != "" && got.Renderer != tt.want.Renderer {
+				t.Errorf("Load().Renderer = %v, want %v", got.Renderer, tt.want.Renderer)
+			}
+			if tt.want.ChartDepsCacheDir != "" && got.ChartDepsCacheDir != tt.want.ChartDepsCacheDir {
+				t.Errorf("Load().ChartDepsCacheDir = %v, want %v", got.ChartDepsCacheDir, tt.want.ChartDepsCacheDir)
+			}
+			if tt.want.RenderFileSuffix != "" && got.RenderFileSuffix != tt.want.RenderFileSuffix {
+				t.Errorf("Load().RenderFileSuffix = %v, want %v", got.RenderFileSuffix, tt.want.RenderFileSuffix)
+			}
 		})
 	}
 }
@@ -252,3 +312,475 @@ func containsInner(s, substr string) bool {
 	}
 	return false
 }
+
+func TestLoad_IgnoreRules(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !cfg.IgnoreDefaultRules || cfg.IgnoreRulesFile != "" || cfg.IgnoreRulesRepoFile != "" {
+		t.Errorf("Load() ignore defaults = %v, file = %q, repo file = %q, want true and no files",
+			cfg.IgnoreDefaultRules, cfg.IgnoreRulesFile, cfg.IgnoreRulesRepoFile)
+	}
+
+	t.Setenv("IGNORE_RULES_FILE", "/etc/chart-val/ignore.yaml")
+	t.Setenv("IGNORE_RULES_REPO_FILE", "/.chart-val/ignore-rules.yaml")
+	t.Setenv("IGNORE_DEFAULT_RULES", "false")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.IgnoreDefaultRules || cfg.IgnoreRulesFile != "/etc/chart-val/ignore.yaml" {
+		t.Errorf("Load() ignore defaults = %v, file = %q", cfg.IgnoreDefaultRules, cfg.IgnoreRulesFile)
+	}
+	if cfg.IgnoreRulesRepoFile != ".chart-val/ignore-rules.yaml" {
+		t.Errorf("Load().IgnoreRulesRepoFile = %q, want the path without a leading slash", cfg.IgnoreRulesRepoFile)
+	}
+
+	t.Setenv("IGNORE_DEFAULT_RULES", "nope")
+	if _, err := Load(); err == nil || !contains(err.Error(), "IGNORE_DEFAULT_RULES") {
+		t.Errorf("Load() error = %v, want error containing IGNORE_DEFAULT_RULES", err)
+	}
+}
+
+func TestLoad_SensitiveKeyPatterns(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+	t.Setenv("SENSITIVE_KEY_PATTERNS", "(?i)dsn$, ^license,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	want := []string{"(?i)dsn$", "^license"}
+	if len(cfg.SensitiveKeyPatterns) != len(want) ||
+		cfg.SensitiveKeyPatterns[0] != want[0] || cfg.SensitiveKeyPatterns[1] != want[1] {
+		t.Errorf("Load().SensitiveKeyPatterns = %q, want %q", cfg.SensitiveKeyPatterns, want)
+	}
+	if cfg.RedactHMACKey != "" {
+		t.Errorf("Load().RedactHMACKey = %q, want empty by default", cfg.RedactHMACKey)
+	}
+
+	t.Setenv("REDACT_HMAC_KEY", "0123456789abcdef")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.RedactHMACKey != "0123456789abcdef" {
+		t.Errorf("Load().RedactHMACKey = %q, want the configured key", cfg.RedactHMACKey)
+	}
+}
+
+func TestLoad_SchemaValidation(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.SchemaValidation || cfg.KubeVersion != "" || cfg.CRDSchemaDir != "" || cfg.KubeSchemaDir != "" {
+		t.Errorf("Load() schema validation = %v, kube version = %q, CRD dir = %q, schema dir = %q, want false and empty",
+			cfg.SchemaValidation, cfg.KubeVersion, cfg.CRDSchemaDir, cfg.KubeSchemaDir)
+	}
+
+	t.Setenv("SCHEMA_VALIDATION", "true")
+	t.Setenv("KUBE_VERSION", "1.29.0")
+	t.Setenv("CRD_SCHEMA_DIR", "/etc/chart-val/crds")
+	t.Setenv("KUBE_SCHEMA_DIR", "/etc/chart-val/openapi")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !cfg.SchemaValidation || cfg.KubeVersion != "1.29.0" || cfg.CRDSchemaDir != "/etc/chart-val/crds" ||
+		cfg.KubeSchemaDir != "/etc/chart-val/openapi" {
+		t.Errorf("Load() schema validation = %v, kube version = %q, CRD dir = %q, schema dir = %q",
+			cfg.SchemaValidation, cfg.KubeVersion, cfg.CRDSchemaDir, cfg.KubeSchemaDir)
+	}
+
+	t.Setenv("SCHEMA_VALIDATION", "sometimes")
+	if _, err := Load(); err == nil || !contains(err.Error(), "SCHEMA_VALIDATION") {
+		t.Errorf("Load() error = %v, want error containing SCHEMA_VALIDATION", err)
+	}
+}
+
+func TestLoad_EnvKubeVersions(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+	t.Setenv("ENV_KUBE_VERSIONS", " dev=1.31, prod = 1.29.4 ,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	want := map[string]string{"dev": "1.31", "prod": "1.29.4"}
+	if !reflect.DeepEqual(cfg.EnvKubeVersions, want) {
+		t.Errorf("Load().EnvKubeVersions = %v, want %v", cfg.EnvKubeVersions, want)
+	}
+
+	t.Setenv("ENV_KUBE_VERSIONS", "dev=1.31,prod")
+	if _, err := Load(); err == nil || !contains(err.Error(), "ENV_KUBE_VERSIONS") {
+		t.Errorf("Load() error = %v, want error containing ENV_KUBE_VERSIONS", err)
+	}
+}
+
+func TestLoad_DeprecationCheck(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.DeprecationCheck {
+		t.Error("Load().DeprecationCheck = true, want false by default")
+	}
+
+	t.Setenv("DEPRECATION_CHECK", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !cfg.DeprecationCheck {
+		t.Error("Load().DeprecationCheck = false, want true")
+	}
+
+	t.Setenv("DEPRECATION_CHECK", "maybe")
+	if _, err := Load(); err == nil || !contains(err.Error(), "DEPRECATION_CHECK") {
+		t.Errorf("Load() error = %v, want error containing DEPRECATION_CHECK", err)
+	}
+}
+
+func TestLoad_PolicyDir(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+	t.Setenv("POLICY_DIR", "/tmp/chart-val-argocd/policies")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.PolicyDir != "/tmp/chart-val-argocd/policies" {
+		t.Errorf("Load().PolicyDir = %q, want %q", cfg.PolicyDir, "/tmp/chart-val-argocd/policies")
+	}
+}
+
+func TestLoad_VersionCheck(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.VersionCheck {
+		t.Error("Load().VersionCheck = true, want false by default")
+	}
+
+	t.Setenv("VERSION_CHECK", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !cfg.VersionCheck {
+		t.Error("Load().VersionCheck = false, want true")
+	}
+
+	t.Setenv("VERSION_CHECK", "maybe")
+	if _, err := Load(); err == nil || !contains(err.Error(), "VERSION_CHECK") {
+		t.Errorf("Load() error = %v, want error containing VERSION_CHECK", err)
+	}
+}
+
+func TestLoad_ChartLint(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.ChartLint {
+		t.Error("Load().ChartLint = true, want false by default")
+	}
+
+	t.Setenv("CHART_LINT", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !cfg.ChartLint {
+		t.Error("Load().ChartLint = false, want true")
+	}
+
+	t.Setenv("CHART_LINT", "maybe")
+	if _, err := Load(); err == nil || !contains(err.Error(), "CHART_LINT") {
+		t.Errorf("Load() error = %v, want error containing CHART_LINT", err)
+	}
+}
+
+func TestLoad_ValuesCheck(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.ValuesCheck {
+		t.Error("Load().ValuesCheck = true, want false by default")
+	}
+
+	t.Setenv("VALUES_CHECK", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !cfg.ValuesCheck {
+		t.Error("Load().ValuesCheck = false, want true")
+	}
+	if cfg.FailOnStaleOverrides {
+		t.Error("Load().FailOnStaleOverrides = true, want false by default")
+	}
+
+	t.Setenv("FAIL_ON_STALE_OVERRIDES", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !cfg.FailOnStaleOverrides {
+		t.Error("Load().FailOnStaleOverrides = false, want true")
+	}
+
+	t.Setenv("VALUES_CHECK", "maybe")
+	if _, err := Load(); err == nil || !contains(err.Error(), "VALUES_CHECK") {
+		t.Errorf("Load() error = %v, want error containing VALUES_CHECK", err)
+	}
+
+	t.Setenv("VALUES_CHECK", "true")
+	t.Setenv("FAIL_ON_STALE_OVERRIDES", "maybe")
+	if _, err := Load(); err == nil || !contains(err.Error(), "FAIL_ON_STALE_OVERRIDES") {
+		t.Errorf("Load() error = %v, want error containing FAIL_ON_STALE_OVERRIDES", err)
+	}
+}
+
+func TestLoad_CommentCollapse(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !cfg.CommentCollapse {
+		t.Error("Load().CommentCollapse = false, want true by default")
+	}
+
+	t.Setenv("COMMENT_COLLAPSE", "false")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.CommentCollapse {
+		t.Error("Load().CommentCollapse = true, want false")
+	}
+
+	t.Setenv("COMMENT_COLLAPSE", "maybe")
+	if _, err := Load(); err == nil || !contains(err.Error(), "COMMENT_COLLAPSE") {
+		t.Errorf("Load() error = %v, want error containing COMMENT_COLLAPSE", err)
+	}
+}
+
+func TestLoad_CommentMode(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.CommentMode != CommentModePerChart {
+		t.Errorf("Load().CommentMode = %q, want %q by default", cfg.CommentMode, CommentModePerChart)
+	}
+
+	t.Setenv("COMMENT_MODE", "summary")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.CommentMode != CommentModeSummary {
+		t.Errorf("Load().CommentMode = %q, want %q", cfg.CommentMode, CommentModeSummary)
+	}
+
+	t.Setenv("COMMENT_MODE", "per-env")
+	if _, err := Load(); err == nil || !contains(err.Error(), "COMMENT_MODE") {
+		t.Errorf("Load() error = %v, want error containing COMMENT_MODE", err)
+	}
+}
+
+func TestLoad_ReportConfig(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.ReportURL != "" {
+		t.Errorf("Load().ReportURL = %q, want empty by default", cfg.ReportURL)
+	}
+
+	t.Setenv("REPORT_URL", "https://chart-val.example.com/")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.ReportURL != "https://chart-val.example.com" {
+		t.Errorf("Load().ReportURL = %q, want the URL without its trailing slash", cfg.ReportURL)
+	}
+	if cfg.ReportDir != "/tmp/chart-val-reports" {
+		t.Errorf("Load().ReportDir = %q, want /tmp/chart-val-reports by default", cfg.ReportDir)
+	}
+	if cfg.ReportRetention != 7*24*time.Hour {
+		t.Errorf("Load().ReportRetention = %v, want 168h by default", cfg.ReportRetention)
+	}
+
+	t.Setenv("REPORT_RETENTION", "a week")
+	if _, err := Load(); err == nil || !contains(err.Error(), "REPORT_RETENTION") {
+		t.Errorf("Load() error = %v, want error containing REPORT_RETENTION", err)
+	}
+}
+
+func TestLoad_TemplateConfig(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.TemplateDir != "" || cfg.TemplateRepoDir != "" {
+		t.Errorf("Load() template dirs = %q, %q, want empty by default", cfg.TemplateDir, cfg.TemplateRepoDir)
+	}
+
+	t.Setenv("TEMPLATE_DIR", "/etc/chart-val/templates")
+	t.Setenv("TEMPLATE_REPO_DIR", "/.github/chart-val/")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.TemplateDir != "/etc/chart-val/templates" {
+		t.Errorf("Load().TemplateDir = %q, want /etc/chart-val/templates", cfg.TemplateDir)
+	}
+	if cfg.TemplateRepoDir != ".github/chart-val" {
+		t.Errorf("Load().TemplateRepoDir = %q, want the path without leading or trailing slashes", cfg.TemplateRepoDir)
+	}
+}
+
+func TestLoad_OutputConfig(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if len(cfg.OutputFormats) != 0 || cfg.OutputDir != "" {
+		t.Errorf("Load() outputs = %v in %q, want none by default", cfg.OutputFormats, cfg.OutputDir)
+	}
+
+	t.Setenv("OUTPUT_FORMATS", " JSON, junit,,json ")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.OutputFormats, []string{"json", "junit"}) {
+		t.Errorf("Load().OutputFormats = %v, want [json junit]", cfg.OutputFormats)
+	}
+	if cfg.OutputDir != "/tmp/chart-val-outputs" {
+		t.Errorf("Load().OutputDir = %q, want the default", cfg.OutputDir)
+	}
+
+	t.Setenv("OUTPUT_DIR", "/var/lib/chart-val/outputs")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.OutputDir != "/var/lib/chart-val/outputs" {
+		t.Errorf("Load().OutputDir = %q, want /var/lib/chart-val/outputs", cfg.OutputDir)
+	}
+
+	t.Setenv("OUTPUT_FORMATS", "json,html")
+	if _, err := Load(); err == nil {
+		t.Error("Load() error = nil, want an error for an unknown format")
+	}
+}
+
+func TestLoad_NotifyConfig(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "test-secret")
+	t.Setenv("GITHUB_APP_ID", "123456")
+	t.Setenv("GITHUB_INSTALLATION_ID", "789012")
+	t.Setenv("GITHUB_PRIVATE_KEY", "test-key")
+	t.Setenv("NOTIFY_CHARTS", "payments-*")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.NotifyWebhookURL != "" || cfg.NotifyCharts != nil || cfg.NotifyMinRisk != "" {
+		t.Errorf("Load() notifications = %+v, want none without NOTIFY_WEBHOOK_URL", cfg)
+	}
+
+	t.Setenv("NOTIFY_WEBHOOK_URL", "https://hooks.slack.com/services/T000/B000/XXXX")
+	t.Setenv("NOTIFY_CHARTS", " payments-*, ,ledger ")
+	t.Setenv("NOTIFY_ENVIRONMENTS", "prod*")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.NotifyCharts, []string{"payments-*", "ledger"}) {
+		t.Errorf("Load().NotifyCharts = %v, want [payments-* ledger]", cfg.NotifyCharts)
+	}
+	if !reflect.DeepEqual(cfg.NotifyEnvironments, []string{"prod*"}) {
+		t.Errorf("Load().NotifyEnvironments = %v, want [prod*]", cfg.NotifyEnvironments)
+	}
+	if cfg.NotifyMinRisk != NotifyRiskLow {
+		t.Errorf("Load().NotifyMinRisk = %q, want %q", cfg.NotifyMinRisk, NotifyRiskLow)
+	}
+
+	t.Setenv("NOTIFY_MIN_RISK", "critical")
+	if _, err := Load(); err == nil {
+		t.Error("Load() error = nil, want an error for an unknown risk")
+	}
+}
@@ -73,6 +73,11 @@ func (r *GitRepo) Path() string {
 	return r.localPath
 }
 
+// URL returns the URL the repository is cloned from.
+func (r *GitRepo) URL() string {
+	return r.repoURL
+}
+
 // Stop signals the background sync goroutine to exit.
 func (r *GitRepo) Stop() {
 	close(r.stopCh)
@@ -25,7 +25,6 @@ import (
 
 	"github.com/nathantilsley/chart-val/internal/platform/telemetry"
 
-	dyffdiff "github.com/nathantilsley/chart-val/internal/diff/adapters/dyff_diff"
 	fsenv "github.com/nathantilsley/chart-val/internal/diff/adapters/environment_config/filesystem"
 	githubin "github.com/nathantilsley/chart-val/internal/diff/adapters/github_in"
 	githubout "github.com/nathantilsley/chart-val/internal/diff/adapters/github_out"
@@ -33,7 +32,9 @@ import (
 	linediff "github.com/nathantilsley/chart-val/internal/diff/adapters/line_diff"
 	prfiles "github.com/nathantilsley/chart-val/internal/diff/adapters/pr_files"
 	sourcectrl "github.com/nathantilsley/chart-val/internal/diff/adapters/source_ctrl"
+	yamldiff "github.com/nathantilsley/chart-val/internal/diff/adapters/yaml_diff"
 	"github.com/nathantilsley/chart-val/internal/diff/app"
+	"github.com/nathantilsley/chart-val/internal/diff/domain"
 	ghclient "github.com/nathantilsley/chart-val/internal/platform/github"
 	"github.com/nathantilsley/chart-val/internal/platform/logger"
 )
@@ -924,13 +925,13 @@ func setupTestServer(
 	if err != nil {
 		t.Fatalf("creating helm adapter: %v", err)
 	}
-	reporter := githubout.New(githubClient, "chart-val", "")
+	reporter := githubout.New(githubClient, "chart-val", "", true, nil, "")
 	changedCharts := prfiles.New(githubClient, log, "charts")
-	semanticDiff := dyffdiff.New()
+	semanticDiff := yamldiff.New()
 	unifiedDiff := linediff.New()
 
 	// Environment config: filesystem discovery
-	filesystemEnvConfig := fsenv.New(sourceCtrl, "charts", "env", "-values.yaml")
+	filesystemEnvConfig := fsenv.New(sourceCtrl, "charts", "env", "-values.yaml", "-render.yaml")
 
 	// Use real OTel when OTEL_ENABLED=true (e.g., with local Jaeger),
 	// otherwise noop for zero overhead in normal test runs.
@@ -954,22 +955,25 @@ func setupTestServer(
 		t.Logf("OTel enabled — traces will be exported to OTLP endpoint")
 	}
 
-	// Create service (no Argo in E2E tests)
-	diffService := app.NewDiffService(
-		sourceCtrl,
-		changedCharts,
-		nil,                 // No Argo config in E2E
-		filesystemEnvConfig, // Use filesystem discovery
-		helmRenderer,
-		reporter,
-		semanticDiff,
-		unifiedDiff,
-		log,
-		meter,
-		tracer,
-		"charts",
-		"chart_val",
-	)
+	// Create service. E2E leaves out Argo config, dependency resolution,
+	// redaction, schema validation, lint, deprecation, policy, version, values
+	// and ignore rule checks, full diff reports and outputs.
+	diffService := app.NewDiffService(app.Deps{
+		SourceControl: sourceCtrl,
+		ChangedCharts: changedCharts,
+		FSEnvConfig:   filesystemEnvConfig, // Use filesystem discovery
+		Renderer:      helmRenderer,
+		Reporter:      reporter,
+		SemanticDiff:  semanticDiff,
+		UnifiedDiff:   unifiedDiff,
+		Logger:        log,
+		Meter:         meter,
+		Tracer:        tracer,
+	}, app.Options{
+		ChartDir:     "charts",
+		CommentMode:  domain.CommentModePerChart, // One comment per chart, as the tests expect
+		MetricPrefix: "chart_val",
+	})
 
 	// Create webhook handler
 	webhookHandler := githubin.NewWebhookHandler(diffService, webhookSecret, log)